@@ -36,6 +36,7 @@ import (
 	"knative.dev/pkg/websocket"
 	"github.com/knative/serving/cmd/util"
 	"github.com/knative/serving/pkg/activator"
+	"github.com/knative/serving/pkg/activator/async"
 	activatorconfig "github.com/knative/serving/pkg/activator/config"
 	activatorhandler "github.com/knative/serving/pkg/activator/handler"
 	"github.com/knative/serving/pkg/apis/networking"
@@ -72,24 +73,22 @@ const (
 	// Add enough buffer to not block request serving on stats collection
 	requestCountingQueueLength = 100
 
-	// The number of requests that are queued on the breaker before the 503s are sent.
-	// The value must be adjusted depending on the actual production requirements.
-	breakerQueueDepth = 10000
-
-	// The upper bound for concurrent requests sent to the revision.
-	// As new endpoints show up, the Breakers concurrency increases up to this value.
-	breakerMaxConcurrency = 1000
-
 	// The port on which autoscaler WebSocket server listens.
 	autoscalerPort = 8080
 
+	// The port on which the admin server listens on localhost.  It serves
+	// the debug endpoints, reachable through `kubectl port-forward`.
+	adminPort = 8009
+
 	defaultResyncInterval = 10 * time.Hour
 )
 
 var (
 	masterURL = flag.String("master", "", "The address of the Kubernetes API server. "+
 		"Overrides any value in kubeconfig. Only required if out-of-cluster.")
-	kubeconfig = flag.String("kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
+	kubeconfig    = flag.String("kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
+	asyncQueueDir = flag.String("async-queue-dir", "", "The directory persisting the asynchronous invocations, "+
+		"which may be shared by all the activators. They're kept in memory, and lost on restart, when empty.")
 )
 
 func statReporter(statSink *websocket.ManagedConnection, stopCh <-chan struct{},
@@ -181,8 +180,14 @@ func main() {
 		logger.Fatalw("Failed to start informers", zap.Error(err))
 	}
 
-	params := queue.BreakerParams{QueueDepth: breakerQueueDepth, MaxConcurrency: breakerMaxConcurrency, InitialCapacity: 0}
-	throttler := activator.NewThrottler(params, endpointInformer, sksInformer.Lister(), revisionInformer.Lister(), logger)
+	// The breaker parameters are updated from the config-activator ConfigMap below.
+	params := queue.BreakerParams{
+		QueueDepth:      activatorconfig.DefaultBreakerQueueDepth,
+		MaxConcurrency:  activatorconfig.DefaultBreakerMaxConcurrency,
+		InitialCapacity: 0,
+	}
+	throttler := activator.NewThrottler(params, endpointInformer, sksInformer.Lister(), revisionInformer.Lister(),
+		os.Getenv("POD_IP"), logger)
 
 	activatorL3 := fmt.Sprintf("%s:%d", activator.K8sServiceName, networking.ServiceHTTPPort)
 	zipkinEndpoint, err := zipkin.NewEndpoint("activator", activatorL3)
@@ -202,9 +207,41 @@ func main() {
 		}
 	})
 
+	// The asynchronous invocations are delivered through the handlers below,
+	// as if they had just been received.
+	var asyncDelivery http.Handler
+	asyncQueue := async.NewMemoryQueue()
+	if *asyncQueueDir != "" {
+		if asyncQueue, err = async.NewDiskQueue(*asyncQueueDir); err != nil {
+			logger.Fatalw("Failed to create the asynchronous invocation queue", zap.Error(err))
+		}
+	}
+	dispatcher := async.NewDispatcher(asyncQueue, func(inv *async.Invocation) (*async.Result, error) {
+		return async.HandlerDelivery(asyncDelivery)(inv)
+	}, logger)
+
+	activatorUpdater := configmap.TypeFilter(&activatorconfig.Activator{})(func(name string, value interface{}) {
+		cfg := value.(*activatorconfig.Activator)
+		throttler.UpdateBreakerParams(queue.BreakerParams{
+			QueueDepth:      cfg.BreakerQueueDepth,
+			MaxConcurrency:  cfg.BreakerMaxConcurrency,
+			InitialCapacity: 0,
+		})
+		throttler.UpdateBufferLimits(activator.BufferLimits{
+			Total:        cfg.MaxBufferedRequests,
+			PerNamespace: cfg.MaxBufferedRequestsPerNamespace,
+			PerRevision:  cfg.MaxBufferedRequestsPerRevision,
+		})
+		dispatcher.UpdateLimits(async.Limits{
+			Total:        cfg.MaxAsyncInvocations,
+			PerNamespace: cfg.MaxAsyncInvocationsPerNamespace,
+			Bytes:        cfg.MaxAsyncStorage,
+		})
+	})
+
 	// Set up our config store
 	configMapWatcher := configmap.NewInformedWatcher(kubeClient, system.Namespace())
-	configStore := activatorconfig.NewStore(createdLogger, tracerUpdater)
+	configStore := activatorconfig.NewStore(createdLogger, tracerUpdater, activatorUpdater)
 	configStore.WatchConfigs(configMapWatcher)
 
 	// Open a websocket connection to the autoscaler
@@ -234,6 +271,9 @@ func main() {
 	ah = activatorhandler.NewRequestEventHandler(reqChan, ah)
 	ah = tracing.HTTPSpanMiddleware(ah)
 	ah = configStore.HTTPMiddleware(ah)
+	asyncDelivery = ah
+	go dispatcher.Run(stopCh)
+	ah = &activatorhandler.AsyncHandler{Dispatcher: dispatcher, RevisionLister: revisionInformer.Lister(), NextHandler: ah, Logger: logger}
 	reqLogHandler, err := pkghttp.NewRequestLogHandler(ah, logging.NewSyncFileWriter(os.Stdout), "",
 		requestLogTemplateInputGetter(revisionInformer.Lister()))
 	if err != nil {
@@ -241,6 +281,7 @@ func main() {
 	}
 	ah = reqLogHandler
 	ah = &activatorhandler.ProbeHandler{NextHandler: ah}
+	ah = network.NewProbeHandler(ah)
 	ah = &activatorhandler.HealthHandler{HealthCheck: statSink.Status, NextHandler: ah}
 
 	// Watch the logging config map and dynamically update logging levels.
@@ -253,9 +294,13 @@ func main() {
 		logger.Fatalw("Failed to start configuration manager", zap.Error(err))
 	}
 
+	adminMux := http.NewServeMux()
+	adminMux.Handle("/debug/throttler", &activatorhandler.ThrottlerStateHandler{State: throttler.State})
+
 	servers := map[string]*http.Server{
 		"http1": network.NewServer(fmt.Sprintf(":%d", networking.BackendHTTPPort), ah),
 		"h2c":   network.NewServer(fmt.Sprintf(":%d", networking.BackendHTTP2Port), ah),
+		"admin": &http.Server{Addr: fmt.Sprintf("localhost:%d", adminPort), Handler: adminMux},
 	}
 
 	errCh := make(chan error, len(servers))
@@ -23,18 +23,34 @@ import (
 	"github.com/knative/serving/pkg/apis/serving"
 	servinglisters "github.com/knative/serving/pkg/client/listers/serving/v1alpha1"
 	pkghttp "github.com/knative/serving/pkg/http"
+	"github.com/knative/serving/pkg/metrics"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 )
 
 func updateRequestLogFromConfigMap(logger *zap.SugaredLogger, h *pkghttp.RequestLogHandler) func(configMap *corev1.ConfigMap) {
 	return func(configMap *corev1.ConfigMap) {
-		newTemplate := configMap.Data["logging.request-log-template"]
-		if err := h.SetTemplate(newTemplate); err != nil {
-			logger.Errorw("Failed to update the request log template.", zap.Error(err), "template", newTemplate)
-		} else {
-			logger.Infow("Updated the request log template.", "template", newTemplate)
+		oc, err := metrics.NewObservabilityConfigFromConfigMap(configMap)
+		if err != nil {
+			logger.Errorw("Failed to parse the request log configuration.", zap.Error(err))
+			return
 		}
+		filter, err := oc.RequestLogFilter()
+		if err != nil {
+			logger.Errorw("Failed to update the request log filter.", zap.Error(err))
+			return
+		}
+		if err := h.SetTemplate(oc.RequestLogTemplate); err != nil {
+			logger.Errorw("Failed to update the request log template.", zap.Error(err), "template", oc.RequestLogTemplate)
+			return
+		}
+		if err := h.SetFormat(pkghttp.RequestLogFormat(oc.RequestLogFormat)); err != nil {
+			logger.Errorw("Failed to update the request log format.", zap.Error(err), "format", oc.RequestLogFormat)
+			return
+		}
+		h.SetFilter(filter)
+		logger.Infow("Updated the request log configuration.", "template", oc.RequestLogTemplate,
+			"format", oc.RequestLogFormat, "sampleRate", oc.RequestLogSampleRate, "statusCodes", oc.RequestLogStatusCodes)
 	}
 }
 
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -113,6 +114,80 @@ func TestUpdateRequestLogFromConfigMap(t *testing.T) {
 	}
 }
 
+func TestUpdateRequestLogFormatFromConfigMap(t *testing.T) {
+	baseHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	buf := bytes.NewBufferString("")
+	handler, err := pkghttp.NewRequestLogHandler(baseHandler, buf, "",
+		requestLogTemplateInputGetter(getRevisionLister(true)))
+	if err != nil {
+		t.Fatalf("want: no error, got: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data map[string]string
+		want string
+	}{{
+		name: "json",
+		data: map[string]string{
+			"logging.request-log-format": "json",
+		},
+		want: `"revision":{"name":"testRevision","namespace":"testNs","service":"testSvc","configuration":"testConfig"}`,
+	}, {
+		name: "invalid filter keeps the previous configuration",
+		data: map[string]string{
+			"logging.request-log-format":       "json",
+			"logging.request-log-status-codes": "nope",
+		},
+		want: `"response":{"code":503,`,
+	}, {
+		name: "filtered out",
+		data: map[string]string{
+			"logging.request-log-format":       "json",
+			"logging.request-log-status-codes": "4xx",
+		},
+		want: "",
+	}, {
+		name: "sampled out",
+		data: map[string]string{
+			"logging.request-log-format":      "json",
+			"logging.request-log-sample-rate": "0",
+		},
+		want: "",
+	}, {
+		name: "template",
+		data: map[string]string{
+			"logging.request-log-template":     "{{.Response.Code}}",
+			"logging.request-log-status-codes": "5xx",
+		},
+		want: "503\n",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			cm := &corev1.ConfigMap{Data: test.data}
+			(updateRequestLogFromConfigMap(testing2.TestLogger(t), handler))(cm)
+			resp := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "http://example.com", bytes.NewBufferString("test"))
+			req.Header = map[string][]string{
+				activator.RevisionHeaderName:      {testRevisionName},
+				activator.RevisionHeaderNamespace: {testNamespaceName},
+			}
+			handler.ServeHTTP(resp, req)
+
+			got := buf.String()
+			if test.want == "" && got != "" {
+				t.Errorf("got '%v', want no request log", got)
+			} else if !strings.Contains(got, test.want) {
+				t.Errorf("got '%v', want it to contain '%v'", got, test.want)
+			}
+		})
+	}
+}
+
 func TestRequestLogTemplateInputGetter(t *testing.T) {
 	tests := []struct {
 		name     string
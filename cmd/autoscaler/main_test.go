@@ -135,3 +135,7 @@ type testMetricClient struct{}
 func (t *testMetricClient) StableAndPanicConcurrency(key string) (float64, float64, error) {
 	return 1.0, 1.0, nil
 }
+
+func (t *testMetricClient) ConcurrencyLimit(key string) (float64, error) {
+	return 0, autoscaler.ErrNoData
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/knative/serving/pkg/envoy/xds"
+	"github.com/knative/serving/pkg/reconciler/envoy"
+
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	// This defines the shared main for injected controllers.
+	"knative.dev/pkg/injection/sharedmain"
+	"knative.dev/pkg/logging"
+)
+
+// xdsPort is the port the Envoy pods connect to for their configuration.
+const xdsPort = 18000
+
+func main() {
+	xdsCache := xds.NewCache()
+	sharedmain.Main("networking-envoy",
+		func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+			go serveXDS(ctx, xdsCache)
+			return envoy.NewController(xdsCache)(ctx, cmw)
+		})
+}
+
+func serveXDS(ctx context.Context, xdsCache *xds.Cache) {
+	logger := logging.FromContext(ctx)
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", xdsPort))
+	if err != nil {
+		logger.Fatalw("Failed to listen for xDS connections", zap.Error(err))
+	}
+	gs := grpc.NewServer()
+	xds.NewServer(xdsCache, logger.Named("xds")).Register(gs)
+	go func() {
+		<-ctx.Done()
+		gs.GracefulStop()
+	}()
+
+	logger.Infof("Serving xDS on port %d", xdsPort)
+	if err := gs.Serve(lis); err != nil {
+		logger.Fatalw("xDS server failed", zap.Error(err))
+	}
+}
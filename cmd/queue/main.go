@@ -27,28 +27,34 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/knative/serving/cmd/util"
 	"github.com/knative/serving/pkg/activator"
 	activatorutil "github.com/knative/serving/pkg/activator/util"
+	"github.com/knative/serving/pkg/apis/autoscaling"
 	"github.com/knative/serving/pkg/apis/networking"
 	"github.com/knative/serving/pkg/autoscaler"
+	"github.com/knative/serving/pkg/deployment"
 	pkghttp "github.com/knative/serving/pkg/http"
 	"github.com/knative/serving/pkg/logging"
 	"github.com/knative/serving/pkg/network"
 	"github.com/knative/serving/pkg/queue"
 	"github.com/knative/serving/pkg/queue/health"
+	"github.com/knative/serving/pkg/queue/readiness"
 	queuestats "github.com/knative/serving/pkg/queue/stats"
 	"github.com/pkg/errors"
 
 	"go.opencensus.io/stats"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 
 	"knative.dev/pkg/logging/logkey"
 	"knative.dev/pkg/metrics"
 	"knative.dev/pkg/signals"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -61,12 +67,6 @@ const (
 	// Add enough buffer to not block request serving on stats collection
 	requestCountingQueueLength = 100
 
-	// Duration the /quitquitquit handler should wait before returning.
-	// This is to give Istio a little bit more time to remove the pod
-	// from its configuration and propagate that to all istio-proxies
-	// in the mesh.
-	quitSleepDuration = 20 * time.Second
-
 	// Set equal to the queue-proxy's ExecProbe timeout to take
 	// advantage of the full window
 	probeTimeout = 10 * time.Second
@@ -78,18 +78,29 @@ const (
 	responseTimeInMsecN    = "request_latencies"
 	appRequestCountN       = "app_request_count"
 	appResponseTimeInMsecN = "app_request_latencies"
+	requestTimeoutCountN   = "request_timeouts"
+	queueWaitLatenciesN    = "queue_wait_latencies"
 
 	// requestQueueHealthPath specifies the path for health checks for
 	// queue-proxy.
 	requestQueueHealthPath = "/health"
 
 	healthURLTemplate = "http://127.0.0.1:%d" + requestQueueHealthPath
+
+	// outlierCheckTimeout bounds the check of the peers of the pod before
+	// ejecting it.
+	outlierCheckTimeout = 5 * time.Second
 )
 
 var (
 	containerConcurrency   int
+	adaptiveConcurrency    bool
+	minConcurrency         int
 	queueServingPort       int
 	revisionTimeoutSeconds int
+	revisionMaxDuration    time.Duration
+	revisionIdleTimeout    time.Duration
+	drainDelay             time.Duration
 	servingConfig          string
 	servingNamespace       string
 	servingPodIP           string
@@ -99,6 +110,7 @@ var (
 	servingService         string
 	userTargetAddress      string
 	userTargetPort         int
+	userTargetSocket       string
 	userContainerName      string
 	enableVarLogCollection bool
 	varLogVolumeName       string
@@ -106,6 +118,12 @@ var (
 	reqChan                = make(chan queue.ReqEvent, requestCountingQueueLength)
 	logger                 *zap.SugaredLogger
 	breaker                *queue.Breaker
+	rateLimiter            *rate.Limiter
+	queuePolicy            queue.QueuePolicy
+	outlierParams          queue.OutlierDetectionParams
+	outlierGuard           *queue.OutlierEjectionGuard
+	outlierChecking        int32
+	readinessProbe         *readiness.Probe
 
 	httpProxy *httputil.ReverseProxy
 
@@ -131,12 +149,38 @@ var (
 		appResponseTimeInMsecN,
 		"The response time in millisecond",
 		stats.UnitMilliseconds)
+	requestTimeoutCountM = stats.Int64(
+		requestTimeoutCountN,
+		"The number of requests that timed out in queue-proxy",
+		stats.UnitDimensionless)
+	queueWaitLatenciesM = stats.Float64(
+		queueWaitLatenciesN,
+		"The time requests spend queued in millisecond",
+		stats.UnitMilliseconds)
 )
 
 func initEnv() {
 	containerConcurrency = util.MustParseIntEnvOrFatal("CONTAINER_CONCURRENCY", logger)
+	adaptiveConcurrency = os.Getenv("CONTAINER_CONCURRENCY_MODE") == autoscaling.ConcurrencyModeAdaptive
+	if v := os.Getenv("CONTAINER_MIN_CONCURRENCY"); v != "" { // Optional, default is 1
+		minConcurrency = util.MustParseIntEnvOrFatal("CONTAINER_MIN_CONCURRENCY", logger)
+	}
 	queueServingPort = util.MustParseIntEnvOrFatal("QUEUE_SERVING_PORT", logger)
 	revisionTimeoutSeconds = util.MustParseIntEnvOrFatal("REVISION_TIMEOUT_SECONDS", logger)
+	if v := os.Getenv("REVISION_MAX_DURATION_SECONDS"); v != "" { // Optional, default is no limit
+		revisionMaxDuration = time.Duration(util.MustParseIntEnvOrFatal("REVISION_MAX_DURATION_SECONDS", logger)) * time.Second
+	}
+	if v := os.Getenv("REVISION_IDLE_TIMEOUT_SECONDS"); v != "" { // Optional, default is no limit
+		revisionIdleTimeout = time.Duration(util.MustParseIntEnvOrFatal("REVISION_IDLE_TIMEOUT_SECONDS", logger)) * time.Second
+	}
+	drainDelay = deployment.DefaultQueueSidecarDrainDelay
+	if v := os.Getenv("QUEUE_DRAIN_DELAY"); v != "" { // Optional, default is deployment.DefaultQueueSidecarDrainDelay
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			logger.Fatalw("Failed to parse QUEUE_DRAIN_DELAY", zap.Error(err))
+		}
+		drainDelay = d
+	}
 	servingConfig = util.GetRequiredEnvOrFatal("SERVING_CONFIGURATION", logger)
 	servingNamespace = util.GetRequiredEnvOrFatal("SERVING_NAMESPACE", logger)
 	servingPodIP = util.GetRequiredEnvOrFatal("SERVING_POD_IP", logger)
@@ -145,6 +189,7 @@ func initEnv() {
 	servingService = os.Getenv("SERVING_SERVICE") // KService is optional
 	userTargetPort = util.MustParseIntEnvOrFatal("USER_PORT", logger)
 	userTargetAddress = fmt.Sprintf("127.0.0.1:%d", userTargetPort)
+	userTargetSocket = os.Getenv("USER_SOCKET") // Optional, the user-container is reached over TCP otherwise
 	userContainerName = util.GetRequiredEnvOrFatal("USER_CONTAINER_NAME", logger)
 
 	enableVarLogCollection, _ = strconv.ParseBool(os.Getenv("ENABLE_VAR_LOG_COLLECTION")) // Optional, default is false
@@ -157,6 +202,65 @@ func initEnv() {
 		logger.Fatal("INTERNAL_VOLUME_PATH must be specified when ENABLE_VAR_LOG_COLLECTION is true")
 	}
 
+	if v := os.Getenv("QUEUE_RATE_LIMIT"); v != "" { // Optional, default is no limit
+		limit, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			logger.Fatalw("Failed to parse QUEUE_RATE_LIMIT", zap.Error(err))
+		}
+		burst := 1
+		if v := os.Getenv("QUEUE_RATE_LIMIT_BURST"); v != "" { // Optional, default is 1
+			burst = util.MustParseIntEnvOrFatal("QUEUE_RATE_LIMIT_BURST", logger)
+		}
+		rateLimiter = rate.NewLimiter(rate.Limit(limit), burst)
+	}
+
+	queuePolicy = queue.QueuePolicy(os.Getenv("QUEUE_POLICY")) // Optional, default is FIFO
+	if err := queuePolicy.Validate(); err != nil {
+		logger.Fatalw("Failed to parse QUEUE_POLICY", zap.Error(err))
+	}
+
+	if v := os.Getenv("QUEUE_OUTLIER_CONSECUTIVE_FAILURES"); v != "" { // Optional, outlier detection is disabled otherwise
+		outlierParams.ConsecutiveFailures = util.MustParseIntEnvOrFatal("QUEUE_OUTLIER_CONSECUTIVE_FAILURES", logger)
+	}
+	if v := os.Getenv("QUEUE_OUTLIER_LATENCY_THRESHOLD"); v != "" { // Optional, latency isn't considered otherwise
+		threshold, err := time.ParseDuration(v)
+		if err != nil {
+			logger.Fatalw("Failed to parse QUEUE_OUTLIER_LATENCY_THRESHOLD", zap.Error(err))
+		}
+		outlierParams.LatencyThreshold = threshold
+	}
+	if host := os.Getenv("QUEUE_OUTLIER_PEERS_HOST"); host != "" { // Optional, the pod may only be ejected if it knows its peers
+		maxEjectionPercent := queue.DefaultMaxEjectionPercent
+		if v := os.Getenv("QUEUE_OUTLIER_MAX_EJECTION_PERCENT"); v != "" {
+			maxEjectionPercent = util.MustParseIntEnvOrFatal("QUEUE_OUTLIER_MAX_EJECTION_PERCENT", logger)
+		}
+		outlierGuard = queue.NewOutlierEjectionGuard(host, servingPodIP, maxEjectionPercent)
+	}
+
+	grpcHealthCheck, _ := strconv.ParseBool(os.Getenv("USER_GRPC_HEALTH_CHECK")) // Optional, default is false
+
+	if v := os.Getenv("SERVING_READINESS_PROBE"); v != "" || grpcHealthCheck { // Optional, the user-container is TCP probed otherwise
+		var p *corev1.Probe
+		if v != "" {
+			var err error
+			if p, err = readiness.DecodeProbe(v); err != nil {
+				logger.Fatalw("Failed to parse the readiness probe", zap.Error(err))
+			}
+		}
+		if grpcHealthCheck {
+			// The gRPC health check replaces the action of the probe, if any.
+			transport := network.AutoTransport
+			if userTargetSocket != "" {
+				transport = network.NewUnixAutoTransport(userTargetSocket)
+			}
+			readinessProbe = readiness.NewGRPCProbe(p, transport, "http://"+userTargetAddress, probeTimeout, logger)
+		} else if userTargetSocket != "" {
+			readinessProbe = readiness.NewUnixSocketProbe(p, userTargetSocket, probeTimeout, logger)
+		} else {
+			readinessProbe = readiness.NewProbe(p, probeTimeout, logger)
+		}
+	}
+
 	// TODO(mattmoor): Move this key to be in terms of the KPA.
 	servingRevisionKey = autoscaler.NewMetricKey(servingNamespace, servingRevision)
 	_psr, err := queue.NewPrometheusStatsReporter(servingNamespace, servingConfig, servingRevision, servingPodName)
@@ -168,6 +272,9 @@ func initEnv() {
 
 func reportStats(statChan chan *autoscaler.Stat) {
 	for s := range statChan {
+		if breaker != nil && adaptiveConcurrency {
+			s.ConcurrencyLimit = float64(breaker.Capacity())
+		}
 		if err := promStatReporter.Report(s); err != nil {
 			logger.Errorw("Error while sending stat", zap.Error(err))
 		}
@@ -183,10 +290,19 @@ func knativeProxyHeader(r *http.Request) string {
 }
 
 func probeUserContainer() bool {
+	if readinessProbe != nil {
+		return readinessProbe.ProbeContainer()
+	}
+
 	var err error
 	wait.PollImmediate(50*time.Millisecond, probeTimeout, func() (bool, error) {
-		logger.Debug("TCP probing the user-container.")
-		err = health.TCPProbe(userTargetAddress, 100*time.Millisecond)
+		if userTargetSocket != "" {
+			logger.Debug("Unix socket probing the user-container.")
+			err = health.UnixProbe(userTargetSocket, 100*time.Millisecond)
+		} else {
+			logger.Debug("TCP probing the user-container.")
+			err = health.TCPProbe(userTargetAddress, 100*time.Millisecond)
+		}
 		return err == nil, nil
 	})
 
@@ -200,7 +316,8 @@ func probeUserContainer() bool {
 }
 
 // Make handler a closure for testing.
-func handler(reqChan chan queue.ReqEvent, breaker *queue.Breaker, handler http.Handler) func(http.ResponseWriter, *http.Request) {
+func handler(reqChan chan queue.ReqEvent, breaker *queue.Breaker, limiter *rate.Limiter,
+	reportQueueTime func(int, time.Duration), handler http.Handler) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ph := knativeProbeHeader(r)
 		switch {
@@ -222,6 +339,14 @@ func handler(reqChan chan queue.ReqEvent, breaker *queue.Breaker, handler http.H
 			return
 		}
 
+		// Reject requests above the rate limit of this pod before they are
+		// counted for autoscaling.
+		if limiter != nil && !limiter.Allow() {
+			reqChan <- queue.ReqEvent{Time: time.Now(), EventType: queue.RateLimited}
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
 		// Metrics for autoscaling.
 		in, out := queue.ReqIn, queue.ReqOut
 		if activator.Name == knativeProxyHeader(r) {
@@ -235,10 +360,38 @@ func handler(reqChan chan queue.ReqEvent, breaker *queue.Breaker, handler http.H
 
 		// Enforce queuing and concurrency limits.
 		if breaker != nil {
-			if !breaker.Maybe(0 /* Infinite timeout */, func() {
+			// Requests are queued until their client gives up, or their
+			// propagated deadline passes.
+			ctx := r.Context()
+			if deadline, ok := network.RequestDeadline(r); ok {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, deadline)
+				defer cancel()
+			}
+			var rr *pkghttp.ResponseRecorder
+			if reportQueueTime != nil {
+				rr = pkghttp.NewResponseRecorder(w, http.StatusOK)
+				w = rr
+			}
+			start := time.Now()
+			var queueTime time.Duration
+			if !breaker.MaybeContext(ctx, func() {
+				queueTime = time.Since(start)
 				handler.ServeHTTP(w, r)
 			}) {
-				http.Error(w, "overload", http.StatusServiceUnavailable)
+				queueTime = time.Since(start)
+				switch ctx.Err() {
+				case context.DeadlineExceeded:
+					http.Error(w, "deadline exceeded", http.StatusGatewayTimeout)
+				case context.Canceled:
+					http.Error(w, "request cancelled", http.StatusServiceUnavailable)
+				default:
+					http.Error(w, "overload", http.StatusServiceUnavailable)
+				}
+			}
+			pkghttp.ReportQueueWait(r.Context(), queueTime)
+			if rr != nil {
+				reportQueueTime(rr.ResponseCode, queueTime)
 			}
 		} else {
 			handler.ServeHTTP(w, r)
@@ -246,10 +399,42 @@ func handler(reqChan chan queue.ReqEvent, breaker *queue.Breaker, handler http.H
 	}
 }
 
-// Sets up /health and /wait-for-drain endpoints.
+// ejectOutlier marks the pod not ready once its user-container is detected
+// as an outlier, unless too many of its peers are unavailable already.
+// Peers are checked in the background, and failures detected meanwhile are
+// ignored.
+func ejectOutlier() {
+	if outlierGuard == nil {
+		logger.Warnf("User-container failed %d requests in a row, but the pod can't check its peers. Not marking it not ready.",
+			outlierParams.ConsecutiveFailures)
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&outlierChecking, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&outlierChecking, 0)
+		ctx, cancel := context.WithTimeout(context.Background(), outlierCheckTimeout)
+		defer cancel()
+		if ok, err := outlierGuard.AllowEject(ctx); err != nil {
+			logger.Warnw(fmt.Sprintf("User-container failed %d requests in a row, but its peers couldn't be checked. Not marking the pod not ready.",
+				outlierParams.ConsecutiveFailures), zap.Error(err))
+		} else if !ok {
+			logger.Warnf("User-container failed %d requests in a row, but too many pods of the revision are unavailable. Not marking the pod not ready.",
+				outlierParams.ConsecutiveFailures)
+		} else {
+			logger.Warnf("User-container failed %d requests in a row. Marking the pod not ready for at least %v.",
+				outlierParams.ConsecutiveFailures, queue.OutlierEjectionTime)
+			healthState.Eject(queue.OutlierEjectionTime)
+		}
+	}()
+}
+
+// Sets up /health, /ready and /wait-for-drain endpoints.
 func createAdminHandlers() *http.ServeMux {
 	mux := http.NewServeMux()
-	mux.HandleFunc(requestQueueHealthPath, healthState.HealthHandler(probeUserContainer))
+	mux.HandleFunc(requestQueueHealthPath, healthState.HealthHandler(probeUserContainer, readinessProbe != nil))
+	mux.HandleFunc(queue.RequestQueueReadyPath, healthState.ReadyHandler())
 	mux.HandleFunc(queue.RequestQueueDrainPath, healthState.DrainHandler())
 
 	return mux
@@ -321,6 +506,17 @@ func main() {
 
 	httpProxy = httputil.NewSingleHostReverseProxy(target)
 	httpProxy.Transport = network.AutoTransport
+	if userTargetSocket != "" {
+		// The host of the target is irrelevant, requests go to the socket.
+		httpProxy.Transport = network.NewUnixAutoTransport(userTargetSocket)
+	}
+	var outlierDetector *queue.OutlierDetector
+	if outlierParams.ConsecutiveFailures > 0 {
+		// Mark the pod not ready if the user-container keeps failing
+		// requests, even though it may still pass its probes.
+		outlierDetector = queue.NewOutlierDetector(outlierParams, ejectOutlier)
+		httpProxy.Transport = outlierDetector.RoundTripper(httpProxy.Transport)
+	}
 	httpProxy.FlushInterval = -1
 
 	activatorutil.SetupHeaderPruning(httpProxy)
@@ -330,7 +526,15 @@ func main() {
 		// We set the queue depth to be equal to the container concurrency * 10 to
 		// allow the autoscaler to get a strong enough signal.
 		queueDepth := containerConcurrency * 10
-		params := queue.BreakerParams{QueueDepth: queueDepth, MaxConcurrency: containerConcurrency, InitialCapacity: containerConcurrency}
+		params := queue.BreakerParams{QueueDepth: queueDepth, MaxConcurrency: containerConcurrency, InitialCapacity: containerConcurrency, QueuePolicy: queuePolicy}
+		if adaptiveConcurrency {
+			// The limit adapts within [minConcurrency, containerConcurrency],
+			// starting from the top.
+			if minConcurrency > containerConcurrency {
+				minConcurrency = containerConcurrency
+			}
+			params.Adaptive = &queue.AdaptiveParams{MinConcurrency: minConcurrency}
+		}
 		breaker = queue.NewBreaker(params)
 		logger.Infof("Queue container is starting with %#v", params)
 	}
@@ -373,17 +577,39 @@ func main() {
 	// Create queue handler chain
 	// Note: innermost handlers are specified first, ie. the last handler in the chain will be executed first
 	var composedHandler http.Handler = httpProxy
+	var queueTimeReporter func(int, time.Duration)
 	if metricsSupported {
 		composedHandler = pushRequestMetricHandler(httpProxy, appRequestCountM, appResponseTimeInMsecM)
+		queueTimeReporter = queueTimeMetricReporter(queueWaitLatenciesM)
 	}
-	composedHandler = http.HandlerFunc(handler(reqChan, breaker, composedHandler))
+	composedHandler = http.HandlerFunc(handler(reqChan, breaker, rateLimiter, queueTimeReporter, composedHandler))
+	drainer := &queue.Drainer{}
+	composedHandler = drainer.Handler(composedHandler)
 	composedHandler = queue.ForwardedShimHandler(composedHandler)
-	composedHandler = queue.TimeToFirstByteTimeoutHandler(composedHandler,
-		time.Duration(revisionTimeoutSeconds)*time.Second, "request timeout")
+	timeoutParams := queue.TimeoutParams{
+		FirstByte:   time.Duration(revisionTimeoutSeconds) * time.Second,
+		MaxDuration: revisionMaxDuration,
+		Idle:        revisionIdleTimeout,
+		Message:     "request timeout",
+	}
+	var reportTimeout func(queue.TimeoutKind)
+	if metricsSupported {
+		reportTimeout = timeoutMetricReporter(requestTimeoutCountM)
+	}
+	timeoutParams.OnTimeout = func(kind queue.TimeoutKind) {
+		if reportTimeout != nil {
+			reportTimeout(kind)
+		}
+		if outlierDetector != nil {
+			outlierDetector.ReportFailure()
+		}
+	}
+	composedHandler = queue.TimeoutHandler(composedHandler, timeoutParams)
 	composedHandler = pushRequestLogHandler(composedHandler)
 	if metricsSupported {
 		composedHandler = pushRequestMetricHandler(composedHandler, requestCountM, responseTimeInMsecM)
 	}
+	composedHandler = network.NewProbeHandler(composedHandler)
 	logger.Infof("Queue-proxy will listen on port %d", queueServingPort)
 	server := network.NewServer(fmt.Sprintf(":%d", queueServingPort), composedHandler)
 
@@ -420,11 +646,22 @@ func main() {
 	case <-signals.SetupSignalHandler():
 		logger.Info("Received TERM signal, attempting to gracefully shutdown servers.")
 		healthState.Shutdown(func() {
-			// Give Istio time to sync our "not ready" state.
-			time.Sleep(quitSleepDuration)
+			// Give the networking layer time to sync our "not ready" state,
+			// then wait for the requests in flight to complete.
+			drainTimeout := time.Duration(revisionTimeoutSeconds) * time.Second
+			if revisionMaxDuration > drainTimeout {
+				drainTimeout = revisionMaxDuration
+			}
+			if !drainer.Drain(drainDelay, drainTimeout) {
+				logger.Warnf("%d requests still in flight after %v, closing.", drainer.InFlight(), drainTimeout)
+				if err := server.Close(); err != nil {
+					logger.Errorw("Failed to close proxy server", zap.Error(err))
+				}
+				return
+			}
 
-			// Calling server.Shutdown() allows pending requests to
-			// complete, while no new work is accepted.
+			// Calling server.Shutdown() stops accepting new work and
+			// closes the idle connections.
 			if err := server.Shutdown(context.Background()); err != nil {
 				logger.Errorw("Failed to shutdown proxy server", zap.Error(err))
 			}
@@ -450,7 +687,8 @@ func createVarLogLink(servingNamespace, servingPodName, userContainerName, varLo
 
 func pushRequestLogHandler(currentHandler http.Handler) http.Handler {
 	templ := os.Getenv("SERVING_REQUEST_LOG_TEMPLATE")
-	if templ == "" {
+	format := pkghttp.RequestLogFormat(os.Getenv("SERVING_REQUEST_LOG_FORMAT"))
+	if templ == "" && format != pkghttp.RequestLogFormatJSON {
 		return currentHandler
 	}
 
@@ -469,6 +707,25 @@ func pushRequestLogHandler(currentHandler http.Handler) http.Handler {
 		logger.Errorw("Error setting up request logger. Request logs will be unavailable.", zap.Error(err))
 		return currentHandler
 	}
+	if err := handler.SetFormat(format); err != nil {
+		logger.Errorw("Error setting up request logger. Request logs will be unavailable.", zap.Error(err))
+		return currentHandler
+	}
+
+	// Log all requests unless sampling or status filtering is configured.
+	sampleRate := 1.0
+	if v := os.Getenv("SERVING_REQUEST_LOG_SAMPLE_RATE"); v != "" {
+		if sampleRate, err = strconv.ParseFloat(v, 64); err != nil {
+			logger.Errorw("Error parsing SERVING_REQUEST_LOG_SAMPLE_RATE. Request logs will be unavailable.", zap.Error(err))
+			return currentHandler
+		}
+	}
+	filter, err := pkghttp.NewRequestLogFilter(sampleRate, os.Getenv("SERVING_REQUEST_LOG_STATUS_CODES"))
+	if err != nil {
+		logger.Errorw("Error setting up request log filter. Request logs will be unavailable.", zap.Error(err))
+		return currentHandler
+	}
+	handler.SetFilter(filter)
 	return handler
 }
 
@@ -487,6 +744,36 @@ func pushRequestMetricHandler(currentHandler http.Handler, countMetric *stats.In
 	return handler
 }
 
+// timeoutMetricReporter returns a callback reporting the requests timed
+// out by queue-proxy, or nil if the reporter cannot be set up.
+func timeoutMetricReporter(countMetric *stats.Int64Measure) func(queue.TimeoutKind) {
+	r, err := queuestats.NewTimeoutReporter(servingNamespace, servingService, servingConfig, servingRevision, countMetric)
+	if err != nil {
+		logger.Errorw("Error setting up timeout metrics reporter. Timeout metrics will be unavailable.", zap.Error(err))
+		return nil
+	}
+	return func(kind queue.TimeoutKind) {
+		if err := r.ReportTimeout(string(kind)); err != nil {
+			logger.Errorw("Error reporting timeout metric", zap.Error(err))
+		}
+	}
+}
+
+// queueTimeMetricReporter returns a callback reporting the time requests
+// spend queued, or nil if the reporter cannot be set up.
+func queueTimeMetricReporter(latencyMetric *stats.Float64Measure) func(int, time.Duration) {
+	r, err := queuestats.NewQueueTimeReporter(servingNamespace, servingService, servingConfig, servingRevision, latencyMetric)
+	if err != nil {
+		logger.Errorw("Error setting up queue time metrics reporter. Queue time metrics will be unavailable.", zap.Error(err))
+		return nil
+	}
+	return func(responseCode int, d time.Duration) {
+		if err := r.ReportQueueTime(responseCode, d); err != nil {
+			logger.Errorw("Error reporting queue time metric", zap.Error(err))
+		}
+	}
+}
+
 func setupMetricsExporter(backend string) error {
 	// Set up OpenCensus exporter.
 	// NOTE: We use revision as the component instead of queue because queue is
@@ -35,6 +35,9 @@ import (
 	"github.com/knative/serving/pkg/network"
 	"github.com/knative/serving/pkg/queue"
 	logtesting "knative.dev/pkg/logging/testing"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 const wantHost = "a-better-host.com"
@@ -70,7 +73,7 @@ func TestHandlerReqEvent(t *testing.T) {
 	params := queue.BreakerParams{QueueDepth: 10, MaxConcurrency: 10, InitialCapacity: 10}
 	breaker := queue.NewBreaker(params)
 	reqChan := make(chan queue.ReqEvent, 10)
-	h := handler(reqChan, breaker, proxy)
+	h := handler(reqChan, breaker, nil, nil, proxy)
 
 	writer := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
@@ -91,12 +94,126 @@ func TestHandlerReqEvent(t *testing.T) {
 	}
 }
 
+func TestHandlerRateLimited(t *testing.T) {
+	var httpHandler http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	// Allow a single request within the test.
+	limiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+	reqChan := make(chan queue.ReqEvent, 10)
+	h := handler(reqChan, nil, limiter, nil, httpHandler)
+
+	wantEvents := []queue.ReqEventType{queue.ReqIn, queue.ReqOut, queue.RateLimited}
+	wantCodes := []int{http.StatusOK, http.StatusTooManyRequests}
+	for _, want := range wantCodes {
+		writer := httptest.NewRecorder()
+		h(writer, httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+		if got := writer.Code; got != want {
+			t.Errorf("StatusCode = %d, want: %d", got, want)
+		}
+	}
+
+	for _, want := range wantEvents {
+		select {
+		case e := <-reqChan:
+			if e.EventType != want {
+				t.Errorf("EventType = %v, want: %v", e.EventType, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for an event to be intercepted")
+		}
+	}
+}
+
+func TestHandlerDeadlineExceeded(t *testing.T) {
+	var httpHandler http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected call of the handler")
+	}
+
+	params := queue.BreakerParams{QueueDepth: 10, MaxConcurrency: 10, InitialCapacity: 10}
+	breaker := queue.NewBreaker(params)
+	reqChan := make(chan queue.ReqEvent, 10)
+	var reportedCode int
+	reportQueueTime := func(code int, d time.Duration) {
+		reportedCode = code
+	}
+	h := handler(reqChan, breaker, nil, reportQueueTime, httpHandler)
+
+	writer := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set(network.RequestDeadlineHeaderName, time.Now().Add(-time.Second).Format(time.RFC3339Nano))
+	h(writer, req)
+
+	if got, want := writer.Code, http.StatusGatewayTimeout; got != want {
+		t.Errorf("StatusCode = %d, want: %d", got, want)
+	}
+	if got, want := reportedCode, http.StatusGatewayTimeout; got != want {
+		t.Errorf("Reported queue time for status code %d, want: %d", got, want)
+	}
+}
+
+func TestHandlerReportsQueueTime(t *testing.T) {
+	const queued = 50 * time.Millisecond
+
+	params := queue.BreakerParams{QueueDepth: 10, MaxConcurrency: 1, InitialCapacity: 1}
+	breaker := queue.NewBreaker(params)
+	reqChan := make(chan queue.ReqEvent, 10)
+
+	// The first request holds the only slot until released.
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var httpHandler http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hold" {
+			close(started)
+			<-release
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	queueTimes := make(chan time.Duration, 2)
+	h := handler(reqChan, breaker, nil, func(code int, d time.Duration) {
+		if code != http.StatusCreated {
+			t.Errorf("Reported queue time for status code %d, want: %d", code, http.StatusCreated)
+		}
+		queueTimes <- d
+	}, httpHandler)
+
+	go h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com/hold", nil))
+	<-started
+	go h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com/queued", nil))
+	if err := wait.PollImmediate(time.Millisecond, 5*time.Second, func() (bool, error) {
+		return breaker.Pending() == 1, nil
+	}); err != nil {
+		t.Fatal("Timed out waiting for the request to be queued")
+	}
+	time.Sleep(queued)
+	close(release)
+
+	// The held request was not queued, the other one was.
+	var got []time.Duration
+	for i := 0; i < 2; i++ {
+		select {
+		case d := <-queueTimes:
+			got = append(got, d)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for the queue time to be reported")
+		}
+	}
+	if got[0] > got[1] {
+		got[0], got[1] = got[1], got[0]
+	}
+	if got[0] >= queued || got[1] < queued {
+		t.Errorf("Queue times = %v, want one below and one above %v", got, queued)
+	}
+}
+
 func TestProberHandler(t *testing.T) {
 	defer logtesting.ClearAll()
 	logger = logtesting.TestLogger(t)
 
 	// All arguments are needed only for serving.
-	h := handler(nil, nil, nil)
+	h := handler(nil, nil, nil, nil, nil)
 
 	writer := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
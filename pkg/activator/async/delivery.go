@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package async
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// maxResultBytes is the maximal size of the response body kept as the
+// result of an invocation.
+const maxResultBytes = 1 << 20
+
+// HandlerDelivery returns a DeliverFunc serving the invocations with the
+// given handler, as if the requests had just been received.
+func HandlerDelivery(h http.Handler) DeliverFunc {
+	return func(inv *Invocation) (*Result, error) {
+		r, err := http.NewRequest(inv.Method, inv.URI, bytes.NewReader(inv.Body))
+		if err != nil {
+			return nil, err
+		}
+		r.Host = inv.Host
+		r.Header = cloneHeader(inv.Header)
+		if r.Header == nil {
+			r.Header = make(http.Header)
+		}
+
+		w := &resultWriter{header: make(http.Header)}
+		h.ServeHTTP(w, r)
+		return w.result(), nil
+	}
+}
+
+// resultWriter is an http.ResponseWriter recording the response as a Result.
+type resultWriter struct {
+	header    http.Header
+	code      int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *resultWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *resultWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *resultWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if room := maxResultBytes - w.body.Len(); len(b) > room {
+		w.body.Write(b[:room])
+		w.truncated = true
+	} else {
+		w.body.Write(b)
+	}
+	// The rest of the body is dropped, rather than failing the handler.
+	return len(b), nil
+}
+
+func (w *resultWriter) result() *Result {
+	code := w.code
+	if code == 0 {
+		code = http.StatusOK
+	}
+	return &Result{
+		StatusCode: code,
+		Header:     w.header,
+		Body:       w.body.Bytes(),
+		Truncated:  w.truncated,
+	}
+}
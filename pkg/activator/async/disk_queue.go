@@ -0,0 +1,289 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package async
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// pendingExt and doneExt are the extensions of the files of the pending
+	// and done invocations respectively.
+	pendingExt = ".json"
+	doneExt    = ".done.json"
+	// claimExt is the extension of the files claiming invocations.
+	claimExt = ".claim"
+
+	// claimTTL is how long a claim is honored, which must be longer than
+	// any delivery: a request may wait for capacity, then be served up to
+	// the maximal revision timeout.
+	claimTTL = 30 * time.Minute
+)
+
+// diskQueue is a Queue keeping every invocation in a JSON file of a
+// directory, so that they survive restarts of the activator.  The files are
+// laid out as <namespace>/<id>.json, or <id>.done.json once the invocation
+// is done, and their modification time is set to the time the invocation is
+// due, so that the queue is indexed from the directory listing alone.  The
+// directory may be on a volume shared by all the activators, which claim an
+// invocation by creating <namespace>/<id>.claim exclusively.
+type diskQueue struct {
+	// mux serializes the writers, so that a Delete can't be undone by a
+	// concurrent Put of the same invocation.
+	mux sync.Mutex
+	dir string
+}
+
+// NewDiskQueue returns a Queue keeping the invocations in the given
+// directory, which is created if needed.
+func NewDiskQueue(dir string) (Queue, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &diskQueue{dir: dir}, nil
+}
+
+// validKey returns whether the namespace and ID are safe to be used as
+// path elements, as they may come from a request.
+func validKey(namespace, id string) bool {
+	return len(validation.IsDNS1123Label(namespace)) == 0 && validID(id)
+}
+
+func (q *diskQueue) path(namespace, id string, done bool) string {
+	ext := pendingExt
+	if done {
+		ext = doneExt
+	}
+	return filepath.Join(q.dir, namespace, id+ext)
+}
+
+// Put implements Queue.
+func (q *diskQueue) Put(inv *Invocation) error {
+	namespace := inv.Revision.Namespace
+	if !validKey(namespace, inv.ID) {
+		return fmt.Errorf("invalid invocation %s/%s", namespace, inv.ID)
+	}
+	b, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(q.dir, namespace)
+
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that a crash can't leave a
+	// truncated invocation behind.
+	f, err := ioutil.TempFile(dir, inv.ID+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	due := inv.due()
+	if err := os.Chtimes(f.Name(), due, due); err != nil {
+		return err
+	}
+	done := inv.Done()
+	if err := os.Rename(f.Name(), q.path(namespace, inv.ID, done)); err != nil {
+		return err
+	}
+	if done {
+		// A crash before this leaves both files, of which the done one wins.
+		if err := os.Remove(q.path(namespace, inv.ID, false)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	// The rename is only durable once the directory is synced.
+	return syncDir(dir)
+}
+
+// syncDir flushes the entries of the given directory to the disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Get implements Queue.
+func (q *diskQueue) Get(namespace, id string) (*Invocation, error) {
+	if !validKey(namespace, id) {
+		return nil, ErrNotFound
+	}
+	inv, err := q.read(q.path(namespace, id, true))
+	if err == ErrNotFound {
+		return q.read(q.path(namespace, id, false))
+	}
+	return inv, err
+}
+
+func (q *diskQueue) read(path string) (*Invocation, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	inv := &Invocation{}
+	if err := json.Unmarshal(b, inv); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// Index implements Queue.
+func (q *diskQueue) Index() ([]Entry, error) {
+	namespaces, err := ioutil.ReadDir(q.dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, ns := range namespaces {
+		if !ns.IsDir() {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(q.dir, ns.Name()))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		// The done file of an invocation wins over its pending one.
+		byID := make(map[string]Entry, len(files))
+		for _, f := range files {
+			entry := Entry{
+				Namespace: ns.Name(),
+				Due:       f.ModTime(),
+				Size:      f.Size(),
+			}
+			switch name := f.Name(); {
+			case strings.HasSuffix(name, doneExt):
+				entry.ID, entry.Done = strings.TrimSuffix(name, doneExt), true
+			case strings.HasSuffix(name, pendingExt):
+				entry.ID = strings.TrimSuffix(name, pendingExt)
+				if byID[entry.ID].Done {
+					continue
+				}
+			default:
+				continue
+			}
+			if validKey(entry.Namespace, entry.ID) {
+				byID[entry.ID] = entry
+			}
+		}
+		for _, entry := range byID {
+			entries = append(entries, entry)
+		}
+	}
+	sortByDue(entries)
+	return entries, nil
+}
+
+// Claim implements Queue.
+func (q *diskQueue) Claim(namespace, id string) (bool, error) {
+	if !validKey(namespace, id) {
+		return false, ErrNotFound
+	}
+	path := filepath.Join(q.dir, namespace, id+claimExt)
+	claimed, err := createExclusive(path)
+	if err != nil || claimed {
+		return claimed, err
+	}
+	// The activator holding the claim may have died.
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return createExclusive(path)
+	} else if err != nil {
+		return false, err
+	}
+	if time.Since(info.ModTime()) < claimTTL {
+		return false, nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return createExclusive(path)
+}
+
+// createExclusive creates the file at the given path, and returns false
+// if it exists already.
+func createExclusive(path string) (bool, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if os.IsExist(err) {
+		return false, nil
+	} else if os.IsNotExist(err) {
+		// The namespace has no invocations anymore.
+		return false, ErrNotFound
+	} else if err != nil {
+		return false, err
+	}
+	return true, f.Close()
+}
+
+// Release implements Queue.
+func (q *diskQueue) Release(namespace, id string) error {
+	if !validKey(namespace, id) {
+		return nil
+	}
+	if err := os.Remove(filepath.Join(q.dir, namespace, id+claimExt)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Delete implements Queue.
+func (q *diskQueue) Delete(namespace, id string) error {
+	if !validKey(namespace, id) {
+		return nil
+	}
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	for _, path := range []string{
+		q.path(namespace, id, true),
+		q.path(namespace, id, false),
+		filepath.Join(q.dir, namespace, id+claimExt),
+	} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
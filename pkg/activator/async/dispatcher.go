@@ -0,0 +1,399 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package async
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"knative.dev/pkg/logging/logkey"
+	"github.com/knative/serving/pkg/activator"
+)
+
+const (
+	// DefaultMaxAttempts is the default number of times an invocation is
+	// delivered before it fails.
+	DefaultMaxAttempts = 5
+	// DefaultBackoff is the default wait after the first failed attempt,
+	// doubled after each of the following ones.
+	DefaultBackoff = time.Second
+	// DefaultRetention is how long the invocations are kept once done,
+	// for their results to be queried.
+	DefaultRetention = time.Hour
+	// DefaultConcurrencyPerRevision is the default number of invocations of
+	// a single revision delivered at once.
+	DefaultConcurrencyPerRevision = 10
+
+	// maxBackoff caps the wait between two attempts.
+	maxBackoff = time.Minute
+	// defaultSweepInterval is how often the queue is scanned for the
+	// invocations due for an attempt, and for the ones to expire.
+	defaultSweepInterval = time.Second
+)
+
+var (
+	// ErrQueueFull is returned by Submit when the queue holds as many
+	// invocations, or as many bytes, as allowed.
+	ErrQueueFull = errors.New("too many asynchronous invocations")
+	// ErrNamespaceFull is returned by Submit when the namespace of the
+	// invocation holds as many invocations as allowed.
+	ErrNamespaceFull = errors.New("too many asynchronous invocations in the namespace")
+)
+
+// Limits bound the invocations stored in the queue, whether pending or kept
+// for their results.  0 means no limit.
+type Limits struct {
+	// Total is the number of invocations of all the namespaces together.
+	Total int
+	// PerNamespace is the number of invocations of a single namespace.
+	PerNamespace int
+	// Bytes is the size of the invocations of all the namespaces together.
+	Bytes int64
+}
+
+// usage is the share of the Limits taken by the queue.
+type usage struct {
+	total      int
+	namespaces map[string]int
+	bytes      int64
+}
+
+// key identifies an invocation in the queue.
+type key struct {
+	namespace string
+	id        string
+}
+
+// DeliverFunc sends an invocation to its revision, and returns the response
+// of the revision.  An error means that the invocation couldn't be sent.
+type DeliverFunc func(inv *Invocation) (*Result, error)
+
+// Dispatcher accepts the asynchronous invocations into a Queue, and delivers
+// them to their revisions with retries.
+type Dispatcher struct {
+	queue   Queue
+	deliver DeliverFunc
+	logger  *zap.SugaredLogger
+
+	// MaxAttempts is the number of times an invocation is delivered
+	// before it fails.
+	MaxAttempts int
+	// Backoff is the wait after the first failed attempt, doubled after
+	// each of the following ones.
+	Backoff time.Duration
+	// Retention is how long the invocations are kept once done.
+	Retention time.Duration
+	// ConcurrencyPerRevision is the number of invocations of a single
+	// revision delivered at once, so that the revisions slow to respond,
+	// or to scale from zero, don't hold up the deliveries to the others.
+	ConcurrencyPerRevision int
+
+	sweepInterval time.Duration
+	// deliveries tracks the invocations being delivered, for Run to wait
+	// for them once stopped.
+	deliveries sync.WaitGroup
+	// mux guards running, scheduled, the invocations being delivered,
+	// inFlight, their count by revision, and revisions, as well as limits
+	// and usage.
+	mux       sync.Mutex
+	running   bool
+	scheduled map[key]bool
+	inFlight  map[activator.RevisionID]int
+	// revisions maps the pending invocations to their revisions, which
+	// aren't in the index of the queue.
+	revisions map[key]activator.RevisionID
+	limits    Limits
+	// usage is computed by every sweep, and updated by Submit in between.
+	usage usage
+}
+
+// NewDispatcher returns a Dispatcher of the invocations in the given queue,
+// with the default settings.  The invocations are delivered once Run is called.
+func NewDispatcher(queue Queue, deliver DeliverFunc, logger *zap.SugaredLogger) *Dispatcher {
+	return &Dispatcher{
+		queue:       queue,
+		deliver:     deliver,
+		logger:      logger,
+		MaxAttempts: DefaultMaxAttempts,
+		Backoff:     DefaultBackoff,
+		Retention:   DefaultRetention,
+
+		ConcurrencyPerRevision: DefaultConcurrencyPerRevision,
+
+		sweepInterval: defaultSweepInterval,
+		scheduled:     make(map[key]bool),
+		inFlight:      make(map[activator.RevisionID]int),
+		revisions:     make(map[key]activator.RevisionID),
+		usage:         usage{namespaces: make(map[string]int)},
+	}
+}
+
+// UpdateLimits sets the limits of the invocations stored in the queue,
+// which apply to the following submissions.
+func (d *Dispatcher) UpdateLimits(limits Limits) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.limits = limits
+}
+
+// Submit persists a new invocation, and schedules its delivery.  Once it
+// returns without error, the invocation will be attempted even if the
+// activator restarts, as long as the Queue is durable.  It returns
+// ErrQueueFull or ErrNamespaceFull when the invocation exceeds the Limits.
+func (d *Dispatcher) Submit(inv *Invocation) error {
+	if err := d.reserve(inv); err != nil {
+		return err
+	}
+	if err := d.queue.Put(inv); err != nil {
+		return err
+	}
+	k := key{namespace: inv.Revision.Namespace, id: inv.ID}
+	d.mux.Lock()
+	d.revisions[k] = inv.Revision
+	d.mux.Unlock()
+	d.schedule(k, inv.Revision)
+	return nil
+}
+
+// reserve accounts for the given invocation in the usage, unless that
+// exceeds the limits.
+func (d *Dispatcher) reserve(inv *Invocation) error {
+	namespace, size := inv.Revision.Namespace, inv.size()
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	switch {
+	case d.limits.PerNamespace > 0 && d.usage.namespaces[namespace] >= d.limits.PerNamespace:
+		return ErrNamespaceFull
+	case d.limits.Total > 0 && d.usage.total >= d.limits.Total,
+		d.limits.Bytes > 0 && d.usage.bytes+size > d.limits.Bytes:
+		return ErrQueueFull
+	}
+	d.usage.total++
+	d.usage.namespaces[namespace]++
+	d.usage.bytes += size
+	return nil
+}
+
+// Get returns the invocation with the given namespace and ID, or ErrNotFound.
+func (d *Dispatcher) Get(namespace, id string) (*Invocation, error) {
+	return d.queue.Get(namespace, id)
+}
+
+// Run delivers the invocations, including those left pending in the queue
+// before, until stopCh is closed.
+func (d *Dispatcher) Run(stopCh <-chan struct{}) {
+	d.mux.Lock()
+	d.running = true
+	d.mux.Unlock()
+
+	ticker := time.NewTicker(d.sweepInterval)
+	defer ticker.Stop()
+	for {
+		d.sweep()
+		select {
+		case <-stopCh:
+			d.mux.Lock()
+			d.running = false
+			d.mux.Unlock()
+			d.deliveries.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// schedule starts delivering the invocation of the given revision, unless
+// it already is, the Dispatcher isn't running, or the revision has as many
+// invocations delivered as allowed, in which case a later sweep picks it up.
+func (d *Dispatcher) schedule(k key, rev activator.RevisionID) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if !d.running || d.scheduled[k] ||
+		(d.ConcurrencyPerRevision > 0 && d.inFlight[rev] >= d.ConcurrencyPerRevision) {
+		return
+	}
+	d.scheduled[k] = true
+	d.inFlight[rev]++
+	d.deliveries.Add(1)
+	go func() {
+		defer d.deliveries.Done()
+		defer d.unschedule(k, rev)
+		d.attempt(k)
+	}()
+}
+
+func (d *Dispatcher) unschedule(k key, rev activator.RevisionID) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	delete(d.scheduled, k)
+	if d.inFlight[rev]--; d.inFlight[rev] <= 0 {
+		delete(d.inFlight, rev)
+	}
+}
+
+// revision returns the revision of the pending invocation with the given
+// key, reading it from the queue the first time.
+func (d *Dispatcher) revision(k key) (activator.RevisionID, error) {
+	d.mux.Lock()
+	rev, ok := d.revisions[k]
+	d.mux.Unlock()
+	if ok {
+		return rev, nil
+	}
+	inv, err := d.queue.Get(k.namespace, k.id)
+	if err != nil {
+		return activator.RevisionID{}, err
+	}
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.revisions[k] = inv.Revision
+	return inv.Revision, nil
+}
+
+// sweep schedules the pending invocations due for an attempt, deletes
+// the ones done for longer than the retention, and recomputes the usage.
+// It only goes through the index of the queue, which doesn't load the
+// invocations.
+func (d *Dispatcher) sweep() {
+	entries, err := d.queue.Index()
+	if err != nil {
+		d.logger.Errorw("Failed to index the asynchronous invocations", zap.Error(err))
+		return
+	}
+	now := time.Now()
+	current := usage{namespaces: make(map[string]int)}
+	pending := make(map[key]bool, len(entries))
+	for _, entry := range entries {
+		k := key{namespace: entry.Namespace, id: entry.ID}
+		switch {
+		case !entry.Done:
+			pending[k] = true
+			if entry.Due.After(now) {
+				break
+			}
+			rev, err := d.revision(k)
+			if err == nil {
+				d.schedule(k, rev)
+			} else if err != ErrNotFound {
+				d.logger.Errorw("Failed to get an asynchronous invocation", zap.String("id", entry.ID), zap.Error(err))
+			}
+		case entry.Done && now.Sub(entry.Due) > d.Retention:
+			if err := d.queue.Delete(entry.Namespace, entry.ID); err != nil {
+				d.logger.Errorw("Failed to delete an asynchronous invocation", zap.String("id", entry.ID), zap.Error(err))
+			} else {
+				continue
+			}
+		}
+		current.total++
+		current.namespaces[entry.Namespace]++
+		current.bytes += entry.Size
+	}
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.usage = current
+	for k := range d.revisions {
+		if !pending[k] {
+			delete(d.revisions, k)
+		}
+	}
+}
+
+// attempt delivers the invocation once, and records the outcome.
+func (d *Dispatcher) attempt(k key) {
+	// The queue may be shared with other activators, which mustn't deliver
+	// the invocation at the same time.
+	claimed, err := d.queue.Claim(k.namespace, k.id)
+	if err != nil {
+		if err != ErrNotFound {
+			d.logger.Errorw("Failed to claim an asynchronous invocation", zap.String("id", k.id), zap.Error(err))
+		}
+		return
+	} else if !claimed {
+		return
+	}
+	defer func() {
+		if err := d.queue.Release(k.namespace, k.id); err != nil {
+			d.logger.Errorw("Failed to release an asynchronous invocation", zap.String("id", k.id), zap.Error(err))
+		}
+	}()
+	// The invocation is read once claimed, as another activator may just
+	// have attempted it.
+	inv, err := d.queue.Get(k.namespace, k.id)
+	if err != nil {
+		if err != ErrNotFound {
+			d.logger.Errorw("Failed to get an asynchronous invocation", zap.String("id", k.id), zap.Error(err))
+		}
+		return
+	}
+	if inv.Done() || inv.NextAttempt.After(time.Now()) {
+		return
+	}
+	logger := d.logger.With(zap.String(logkey.Key, inv.Revision.String()), zap.String("id", k.id))
+
+	inv.Attempts++
+	result, err := d.deliver(inv)
+	inv.Updated = time.Now()
+	inv.Result = result
+	inv.Error = ""
+	switch {
+	case err == nil && !retryable(result.StatusCode):
+		inv.State = StateSucceeded
+	case inv.Attempts >= d.MaxAttempts:
+		inv.State = StateFailed
+		if err != nil {
+			inv.Error = err.Error()
+		}
+		logger.Infof("Asynchronous invocation failed after %d attempts", inv.Attempts)
+	default:
+		if err != nil {
+			inv.Error = err.Error()
+		}
+		inv.NextAttempt = inv.Updated.Add(d.backoff(inv.Attempts))
+	}
+	if err := d.queue.Put(inv); err != nil {
+		logger.Errorw("Failed to update an asynchronous invocation", zap.Error(err))
+	}
+}
+
+// backoff returns the wait after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	backoff := d.Backoff
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// retryable returns whether a response with the given status code means
+// the invocation may succeed when delivered again, i.e. that the revision
+// or the activator was overloaded or unavailable.
+func retryable(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,391 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package async
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	. "knative.dev/pkg/logging/testing"
+	"github.com/knative/serving/pkg/activator"
+)
+
+// scriptedDelivery returns the given outcomes in order, and the last one
+// once they're exhausted.
+type scriptedDelivery struct {
+	mux      sync.Mutex
+	outcomes []error
+	codes    []int
+	calls    int
+}
+
+func (s *scriptedDelivery) deliver(*Invocation) (*Result, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	i := s.calls
+	if i >= len(s.codes) {
+		i = len(s.codes) - 1
+	}
+	s.calls++
+	if s.outcomes[i] != nil {
+		return nil, s.outcomes[i]
+	}
+	return &Result{StatusCode: s.codes[i]}, nil
+}
+
+func waitForDone(t *testing.T, d *Dispatcher, id string) *Invocation {
+	t.Helper()
+	var inv *Invocation
+	if err := wait.PollImmediate(5*time.Millisecond, 5*time.Second, func() (bool, error) {
+		var err error
+		inv, err = d.Get("ns", id)
+		return err == nil && inv.Done(), err
+	}); err != nil {
+		t.Fatalf("The invocation never got done: %v", err)
+	}
+	return inv
+}
+
+func TestDispatcher(t *testing.T) {
+	errDeliver := errors.New("no capacity")
+	tests := []struct {
+		name         string
+		outcomes     []error
+		codes        []int
+		wantState    State
+		wantAttempts int
+		wantCode     int
+		wantError    string
+	}{{
+		name:         "delivered at once",
+		outcomes:     []error{nil},
+		codes:        []int{http.StatusOK},
+		wantState:    StateSucceeded,
+		wantAttempts: 1,
+		wantCode:     http.StatusOK,
+	}, {
+		name:         "client error isn't retried",
+		outcomes:     []error{nil},
+		codes:        []int{http.StatusBadRequest},
+		wantState:    StateSucceeded,
+		wantAttempts: 1,
+		wantCode:     http.StatusBadRequest,
+	}, {
+		name:         "delivered after retries",
+		outcomes:     []error{errDeliver, nil, nil},
+		codes:        []int{0, http.StatusServiceUnavailable, http.StatusCreated},
+		wantState:    StateSucceeded,
+		wantAttempts: 3,
+		wantCode:     http.StatusCreated,
+	}, {
+		name:         "attempts exhausted by errors",
+		outcomes:     []error{errDeliver},
+		codes:        []int{0},
+		wantState:    StateFailed,
+		wantAttempts: 3,
+		wantError:    errDeliver.Error(),
+	}, {
+		name:         "attempts exhausted by unavailability",
+		outcomes:     []error{nil},
+		codes:        []int{http.StatusServiceUnavailable},
+		wantState:    StateFailed,
+		wantAttempts: 3,
+		wantCode:     http.StatusServiceUnavailable,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			delivery := &scriptedDelivery{outcomes: test.outcomes, codes: test.codes}
+			d := NewDispatcher(NewMemoryQueue(), delivery.deliver, TestLogger(t))
+			d.MaxAttempts = 3
+			d.Backoff = time.Millisecond
+			d.sweepInterval = 10 * time.Millisecond
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			go d.Run(stopCh)
+
+			inv := testInvocation(t, time.Now())
+			if err := d.Submit(inv); err != nil {
+				t.Fatalf("Submit() = %v", err)
+			}
+			got := waitForDone(t, d, inv.ID)
+			if got.State != test.wantState {
+				t.Errorf("State = %v, want: %v", got.State, test.wantState)
+			}
+			if got.Attempts != test.wantAttempts {
+				t.Errorf("Attempts = %d, want: %d", got.Attempts, test.wantAttempts)
+			}
+			code := 0
+			if got.Result != nil {
+				code = got.Result.StatusCode
+			}
+			if code != test.wantCode {
+				t.Errorf("StatusCode = %d, want: %d", code, test.wantCode)
+			}
+			if got.Error != test.wantError {
+				t.Errorf("Error = %q, want: %q", got.Error, test.wantError)
+			}
+		})
+	}
+}
+
+func TestDispatcherRecoversPending(t *testing.T) {
+	q := NewMemoryQueue()
+	inv := testInvocation(t, time.Now())
+	// Persisted before an activator restart.
+	q.Put(inv)
+
+	delivery := &scriptedDelivery{outcomes: []error{nil}, codes: []int{http.StatusOK}}
+	d := NewDispatcher(q, delivery.deliver, TestLogger(t))
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go d.Run(stopCh)
+
+	if got := waitForDone(t, d, inv.ID); got.State != StateSucceeded {
+		t.Errorf("State = %v, want: %v", got.State, StateSucceeded)
+	}
+}
+
+func TestDispatchersSharingQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "async")
+	if err != nil {
+		t.Fatalf("TempDir() = %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Two activators share the volume of the queue.
+	delivery := &scriptedDelivery{outcomes: []error{nil}, codes: []int{http.StatusOK}}
+	var dispatchers []*Dispatcher
+	for i := 0; i < 2; i++ {
+		q, err := NewDiskQueue(dir)
+		if err != nil {
+			t.Fatalf("NewDiskQueue() = %v", err)
+		}
+		d := NewDispatcher(q, delivery.deliver, TestLogger(t))
+		d.sweepInterval = time.Millisecond
+		dispatchers = append(dispatchers, d)
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	for _, d := range dispatchers {
+		go d.Run(stopCh)
+	}
+
+	inv := testInvocation(t, time.Now())
+	if err := dispatchers[0].Submit(inv); err != nil {
+		t.Fatalf("Submit() = %v", err)
+	}
+	// Either activator reports the invocation.
+	if got := waitForDone(t, dispatchers[1], inv.ID); got.State != StateSucceeded || got.Attempts != 1 {
+		t.Errorf("State = %v after %d attempts, want: %v after 1", got.State, got.Attempts, StateSucceeded)
+	}
+	// Give the other activator a few sweeps to deliver it again.
+	time.Sleep(20 * time.Millisecond)
+	delivery.mux.Lock()
+	defer delivery.mux.Unlock()
+	if delivery.calls != 1 {
+		t.Errorf("Delivered %d times, want: 1", delivery.calls)
+	}
+}
+
+func TestDispatcherSlowRevision(t *testing.T) {
+	slow := activator.RevisionID{Namespace: "ns", Name: "slow"}
+	releaseCh := make(chan struct{})
+	deliver := func(inv *Invocation) (*Result, error) {
+		if inv.Revision == slow {
+			<-releaseCh
+		}
+		return &Result{StatusCode: http.StatusOK}, nil
+	}
+	d := NewDispatcher(NewMemoryQueue(), deliver, TestLogger(t))
+	d.ConcurrencyPerRevision = 2
+	d.sweepInterval = 10 * time.Millisecond
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go d.Run(stopCh)
+
+	// The slow revision has more invocations than it may be delivered at
+	// once, which hang.
+	var slowIDs []string
+	for i := 0; i < 3; i++ {
+		inv := testInvocation(t, time.Now())
+		inv.Revision = slow
+		if err := d.Submit(inv); err != nil {
+			t.Fatalf("Submit() = %v", err)
+		}
+		slowIDs = append(slowIDs, inv.ID)
+	}
+	inv := testInvocation(t, time.Now())
+	if err := d.Submit(inv); err != nil {
+		t.Fatalf("Submit() = %v", err)
+	}
+	if got := waitForDone(t, d, inv.ID); got.State != StateSucceeded {
+		t.Errorf("State = %v, want: %v", got.State, StateSucceeded)
+	}
+
+	d.mux.Lock()
+	if got := d.inFlight[slow]; got != 2 {
+		t.Errorf("Invocations of the slow revision delivered at once = %d, want: 2", got)
+	}
+	d.mux.Unlock()
+
+	close(releaseCh)
+	for _, id := range slowIDs {
+		if got := waitForDone(t, d, id); got.State != StateSucceeded {
+			t.Errorf("State = %v, want: %v", got.State, StateSucceeded)
+		}
+	}
+}
+
+func TestDispatcherSweepExpires(t *testing.T) {
+	q := NewMemoryQueue()
+	expired := testInvocation(t, time.Now().Add(-2*time.Hour))
+	expired.State = StateSucceeded
+	kept := testInvocation(t, time.Now())
+	kept.State = StateFailed
+	q.Put(expired)
+	q.Put(kept)
+
+	d := NewDispatcher(q, (&scriptedDelivery{}).deliver, TestLogger(t))
+	d.sweep()
+	if _, err := q.Get("ns", expired.ID); err != ErrNotFound {
+		t.Errorf("Get() = %v for an expired invocation, want: %v", err, ErrNotFound)
+	}
+	if _, err := q.Get("ns", kept.ID); err != nil {
+		t.Errorf("Get() = %v for a recent invocation", err)
+	}
+	// The expired invocation no longer counts against the limits.
+	if d.usage.total != 1 || d.usage.namespaces["ns"] != 1 || d.usage.bytes != kept.size() {
+		t.Errorf("usage = %+v, want the kept invocation only", d.usage)
+	}
+}
+
+func TestDispatcherLimits(t *testing.T) {
+	withNamespace := func(inv *Invocation, ns string) *Invocation {
+		inv.Revision.Namespace = ns
+		return inv
+	}
+	tests := []struct {
+		name   string
+		limits Limits
+		stored []*Invocation
+		want   error
+	}{{
+		name:   "no limits",
+		stored: []*Invocation{testInvocation(t, time.Now()), testInvocation(t, time.Now())},
+	}, {
+		name:   "under the limits",
+		limits: Limits{Total: 3, PerNamespace: 2, Bytes: 100},
+		stored: []*Invocation{testInvocation(t, time.Now()), withNamespace(testInvocation(t, time.Now()), "other")},
+	}, {
+		name:   "namespace full",
+		limits: Limits{PerNamespace: 2},
+		stored: []*Invocation{testInvocation(t, time.Now()), testInvocation(t, time.Now())},
+		want:   ErrNamespaceFull,
+	}, {
+		name:   "queue full",
+		limits: Limits{Total: 2, PerNamespace: 2},
+		stored: []*Invocation{testInvocation(t, time.Now()), withNamespace(testInvocation(t, time.Now()), "other")},
+		want:   ErrQueueFull,
+	}, {
+		name:   "storage full",
+		limits: Limits{Bytes: 10},
+		stored: []*Invocation{testInvocation(t, time.Now()), withNamespace(testInvocation(t, time.Now()), "other")},
+		want:   ErrQueueFull,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := NewMemoryQueue()
+			for _, inv := range test.stored {
+				q.Put(inv)
+			}
+			d := NewDispatcher(q, (&scriptedDelivery{}).deliver, TestLogger(t))
+			d.UpdateLimits(test.limits)
+			// The usage of the queue is known once swept.
+			d.sweep()
+
+			if err := d.Submit(testInvocation(t, time.Now())); err != test.want {
+				t.Errorf("Submit() = %v, want: %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestDispatcherLimitsBetweenSweeps(t *testing.T) {
+	d := NewDispatcher(NewMemoryQueue(), (&scriptedDelivery{}).deliver, TestLogger(t))
+	d.UpdateLimits(Limits{PerNamespace: 1})
+	if err := d.Submit(testInvocation(t, time.Now())); err != nil {
+		t.Fatalf("Submit() = %v", err)
+	}
+	if err := d.Submit(testInvocation(t, time.Now())); err != ErrNamespaceFull {
+		t.Errorf("Submit() = %v, want: %v", err, ErrNamespaceFull)
+	}
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	d := NewDispatcher(NewMemoryQueue(), nil, TestLogger(t))
+	for attempts, want := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		4:  8 * time.Second,
+		20: maxBackoff,
+	} {
+		if got := d.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want: %v", attempts, got, want)
+		}
+	}
+}
+
+func TestHandlerDelivery(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body = make([]byte, r.ContentLength)
+		r.Body.Read(body)
+		w.Header().Set("X-Result", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(make([]byte, maxResultBytes+1))
+	})
+
+	inv := testInvocation(t, time.Now())
+	inv.Host = "rev.example.com"
+	result, err := HandlerDelivery(h)(inv)
+	if err != nil {
+		t.Fatalf("HandlerDelivery() = %v", err)
+	}
+	if got.Method != http.MethodPost || got.URL.RequestURI() != "/path?q=1" || got.Host != "rev.example.com" {
+		t.Errorf("Request = %s %s %s, want: POST rev.example.com /path?q=1", got.Method, got.Host, got.URL.RequestURI())
+	}
+	if got.Header.Get("Content-Type") != "text/plain" || string(body) != "body" {
+		t.Errorf("Request header %v and body %q, want the invocation's", got.Header, body)
+	}
+	if result.StatusCode != http.StatusCreated || result.Header.Get("X-Result") != "yes" {
+		t.Errorf("Result = %d %v, want: %d with X-Result", result.StatusCode, result.Header, http.StatusCreated)
+	}
+	if len(result.Body) != maxResultBytes || !result.Truncated {
+		t.Errorf("Result body of %d bytes, truncated: %v, want %d, truncated", len(result.Body), result.Truncated, maxResultBytes)
+	}
+}
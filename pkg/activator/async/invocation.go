@@ -0,0 +1,199 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package async implements the asynchronous invocations of revisions through
+// the activator: the requests are persisted to a Queue, acknowledged right
+// away, and delivered in the background with retries by a Dispatcher.
+package async
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/knative/serving/pkg/activator"
+)
+
+// State is the state of an asynchronous invocation.
+type State string
+
+const (
+	// StatePending is the state of an invocation not delivered yet.
+	StatePending State = "Pending"
+	// StateSucceeded is the state of an invocation the revision responded to.
+	StateSucceeded State = "Succeeded"
+	// StateFailed is the state of an invocation which couldn't be delivered
+	// within the allowed attempts.
+	StateFailed State = "Failed"
+)
+
+// Invocation is a request to a revision, persisted to be delivered later.
+type Invocation struct {
+	ID       string               `json:"id"`
+	Revision activator.RevisionID `json:"revision"`
+
+	Method string      `json:"method"`
+	Host   string      `json:"host"`
+	URI    string      `json:"uri"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+
+	State    State `json:"state"`
+	Attempts int   `json:"attempts"`
+	// NextAttempt is the earliest time of the next delivery attempt.
+	NextAttempt time.Time `json:"nextAttempt"`
+	// Result is the last response of the revision, if any.
+	Result *Result `json:"result,omitempty"`
+	// Error describes why the last attempt failed.
+	Error string `json:"error,omitempty"`
+
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// Result is a response of a revision to an invocation.
+type Result struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+	// Truncated is whether the body was cut at the maximal size kept.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// NewInvocation returns a pending Invocation of the given revision, with a
+// new random ID, from a request whose body was read already.
+func NewInvocation(rev activator.RevisionID, r *http.Request, body []byte) (*Invocation, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &Invocation{
+		ID:          id,
+		Revision:    rev,
+		Method:      r.Method,
+		Host:        r.Host,
+		URI:         r.URL.RequestURI(),
+		Header:      cloneHeader(r.Header),
+		Body:        body,
+		State:       StatePending,
+		NextAttempt: now,
+		Created:     now,
+		Updated:     now,
+	}, nil
+}
+
+// Status is the state of an invocation as reported to its caller, which
+// leaves the request out since it may hold credentials.
+type Status struct {
+	ID          string               `json:"id"`
+	Revision    activator.RevisionID `json:"revision"`
+	State       State                `json:"state"`
+	Attempts    int                  `json:"attempts"`
+	NextAttempt *time.Time           `json:"nextAttempt,omitempty"`
+	Result      *Result              `json:"result,omitempty"`
+	Error       string               `json:"error,omitempty"`
+	Created     time.Time            `json:"created"`
+	Updated     time.Time            `json:"updated"`
+}
+
+// Status returns the Status of the invocation.
+func (inv *Invocation) Status() Status {
+	status := Status{
+		ID:       inv.ID,
+		Revision: inv.Revision,
+		State:    inv.State,
+		Attempts: inv.Attempts,
+		Result:   inv.Result,
+		Error:    inv.Error,
+		Created:  inv.Created,
+		Updated:  inv.Updated,
+	}
+	if !inv.Done() {
+		next := inv.NextAttempt
+		status.NextAttempt = &next
+	}
+	return status
+}
+
+// Done returns whether the invocation won't be attempted anymore.
+func (inv *Invocation) Done() bool {
+	return inv.State != StatePending
+}
+
+// due returns the time of the next attempt of a pending invocation, or the
+// time a done invocation was last updated.
+func (inv *Invocation) due() time.Time {
+	if inv.Done() {
+		return inv.Updated
+	}
+	return inv.NextAttempt
+}
+
+// size returns the approximate number of bytes the invocation takes, which
+// is dominated by the bodies of its request and result.
+func (inv *Invocation) size() int64 {
+	size := int64(len(inv.Body))
+	if inv.Result != nil {
+		size += int64(len(inv.Result.Body))
+	}
+	return size
+}
+
+// deepCopy returns a copy of the invocation sharing nothing with it.
+func (inv *Invocation) deepCopy() *Invocation {
+	out := *inv
+	out.Header = cloneHeader(inv.Header)
+	out.Body = append([]byte(nil), inv.Body...)
+	if inv.Result != nil {
+		result := *inv.Result
+		result.Header = cloneHeader(inv.Result.Header)
+		result.Body = append([]byte(nil), inv.Result.Body...)
+		out.Result = &result
+	}
+	return &out
+}
+
+func cloneHeader(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	clone := make(http.Header, len(h))
+	for k, v := range h {
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone
+}
+
+// idBytes is the number of random bytes of an invocation ID.
+const idBytes = 16
+
+// newID returns a random ID, which is hard to guess since it's the only
+// thing needed to get the result of an invocation.
+func newID() (string, error) {
+	b := make([]byte, idBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// validID returns whether id may have been returned by newID.
+func validID(id string) bool {
+	b, err := hex.DecodeString(id)
+	return err == nil && len(b) == idBytes
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package async
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by a Queue for an unknown invocation.
+var ErrNotFound = errors.New("invocation not found")
+
+// Queue persists the asynchronous invocations until they're done and their
+// results have been kept long enough.  The invocations are scoped by the
+// namespace of their revision.  A Queue may be shared by several activators,
+// which coordinate their deliveries through claims.  The Invocations passed
+// to and returned by a Queue are copies, which the caller is free to modify.
+type Queue interface {
+	// Put creates or replaces the given invocation.
+	Put(inv *Invocation) error
+	// Get returns the invocation with the given namespace and ID, or ErrNotFound.
+	Get(namespace, id string) (*Invocation, error)
+	// Index returns an entry per invocation, soonest due first, without
+	// loading their requests and results.
+	Index() ([]Entry, error)
+	// Delete removes the invocation with the given namespace and ID, if
+	// there's one.
+	Delete(namespace, id string) error
+	// Claim marks the invocation with the given namespace and ID as being
+	// delivered, and returns false if it already is, by this activator or
+	// by another one sharing the queue.  The claims of an activator which
+	// doesn't Release them eventually expire.
+	Claim(namespace, id string) (bool, error)
+	// Release removes the claim on the invocation with the given namespace
+	// and ID.
+	Release(namespace, id string) error
+}
+
+// Entry describes an invocation of a Queue.
+type Entry struct {
+	Namespace string
+	ID        string
+	// Done is whether the invocation succeeded or failed.
+	Done bool
+	// Due is the time of the next attempt of a pending invocation, or the
+	// time a done invocation was last updated.
+	Due time.Time
+	// Size is the number of bytes the invocation takes in the queue.
+	Size int64
+}
+
+// memoryQueue is a Queue keeping the invocations in memory, which are lost
+// when the activator restarts.
+type memoryQueue struct {
+	mux         sync.RWMutex
+	invocations map[string]*Invocation
+	claims      map[string]bool
+}
+
+// NewMemoryQueue returns an empty in-memory Queue.
+func NewMemoryQueue() Queue {
+	return &memoryQueue{
+		invocations: make(map[string]*Invocation),
+		claims:      make(map[string]bool),
+	}
+}
+
+func memoryKey(namespace, id string) string {
+	return namespace + "/" + id
+}
+
+// Put implements Queue.
+func (q *memoryQueue) Put(inv *Invocation) error {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	q.invocations[memoryKey(inv.Revision.Namespace, inv.ID)] = inv.deepCopy()
+	return nil
+}
+
+// Get implements Queue.
+func (q *memoryQueue) Get(namespace, id string) (*Invocation, error) {
+	q.mux.RLock()
+	defer q.mux.RUnlock()
+	inv, ok := q.invocations[memoryKey(namespace, id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return inv.deepCopy(), nil
+}
+
+// Index implements Queue.
+func (q *memoryQueue) Index() ([]Entry, error) {
+	q.mux.RLock()
+	defer q.mux.RUnlock()
+	entries := make([]Entry, 0, len(q.invocations))
+	for _, inv := range q.invocations {
+		entries = append(entries, Entry{
+			Namespace: inv.Revision.Namespace,
+			ID:        inv.ID,
+			Done:      inv.Done(),
+			Due:       inv.due(),
+			Size:      inv.size(),
+		})
+	}
+	sortByDue(entries)
+	return entries, nil
+}
+
+// Delete implements Queue.
+func (q *memoryQueue) Delete(namespace, id string) error {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	delete(q.invocations, memoryKey(namespace, id))
+	return nil
+}
+
+// Claim implements Queue.
+func (q *memoryQueue) Claim(namespace, id string) (bool, error) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	k := memoryKey(namespace, id)
+	if q.claims[k] {
+		return false, nil
+	}
+	q.claims[k] = true
+	return true, nil
+}
+
+// Release implements Queue.
+func (q *memoryQueue) Release(namespace, id string) error {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	delete(q.claims, memoryKey(namespace, id))
+	return nil
+}
+
+func sortByDue(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Due.Before(entries[j].Due)
+	})
+}
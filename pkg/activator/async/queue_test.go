@@ -0,0 +1,245 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package async
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/knative/serving/pkg/activator"
+)
+
+func testInvocation(t *testing.T, created time.Time) *Invocation {
+	t.Helper()
+	r, err := http.NewRequest(http.MethodPost, "http://example.com/path?q=1", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() = %v", err)
+	}
+	r.Header.Set("Content-Type", "text/plain")
+	inv, err := NewInvocation(activator.RevisionID{Namespace: "ns", Name: "rev"}, r, []byte("body"))
+	if err != nil {
+		t.Fatalf("NewInvocation() = %v", err)
+	}
+	// JSON drops the monotonic clock reading.
+	inv.Created = created.Round(0)
+	inv.Updated = inv.Created
+	inv.NextAttempt = inv.Created
+	return inv
+}
+
+func TestQueues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "async")
+	if err != nil {
+		t.Fatalf("TempDir() = %v", err)
+	}
+	defer os.RemoveAll(dir)
+	diskQueue, err := NewDiskQueue(dir)
+	if err != nil {
+		t.Fatalf("NewDiskQueue() = %v", err)
+	}
+
+	queues := map[string]Queue{
+		"memory": NewMemoryQueue(),
+		"disk":   diskQueue,
+	}
+	for name, q := range queues {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			older, newer := testInvocation(t, now.Add(-time.Minute)), testInvocation(t, now)
+			for _, inv := range []*Invocation{newer, older} {
+				if err := q.Put(inv); err != nil {
+					t.Fatalf("Put() = %v", err)
+				}
+			}
+
+			got, err := q.Get("ns", older.ID)
+			if err != nil {
+				t.Fatalf("Get() = %v", err)
+			}
+			if !cmp.Equal(older, got) {
+				t.Errorf("Get() (-want, +got): %s", cmp.Diff(older, got))
+			}
+			// The queue keeps a copy.
+			got.Header.Set("Content-Type", "changed")
+			if again, _ := q.Get("ns", older.ID); again.Header.Get("Content-Type") != "text/plain" {
+				t.Error("Modifying the invocation returned by Get() changed the queue")
+			}
+
+			older.State = StateSucceeded
+			older.Updated = now.Add(time.Second).Round(0)
+			older.Result = &Result{StatusCode: http.StatusOK, Body: []byte("done")}
+			if err := q.Put(older); err != nil {
+				t.Fatalf("Put() = %v", err)
+			}
+			if got, err := q.Get("ns", older.ID); err != nil {
+				t.Fatalf("Get() = %v", err)
+			} else if !cmp.Equal(older, got) {
+				t.Errorf("Get() (-want, +got): %s", cmp.Diff(older, got))
+			}
+			index, err := q.Index()
+			if err != nil {
+				t.Fatalf("Index() = %v", err)
+			}
+			// The done invocation is due as of its update, after the
+			// next attempt of the pending one.
+			if want := []string{newer.ID, older.ID}; len(index) != 2 || index[0].ID != want[0] || index[1].ID != want[1] {
+				t.Errorf("Index() = %v, want the IDs %v", index, want)
+			}
+			for _, entry := range index {
+				if entry.Namespace != "ns" {
+					t.Errorf("Namespace = %q, want: ns", entry.Namespace)
+				}
+				if entry.Done != (entry.ID == older.ID) {
+					t.Errorf("Done = %v for %s", entry.Done, entry.ID)
+				}
+				if entry.Size == 0 {
+					t.Errorf("Size = 0 for %s", entry.ID)
+				}
+			}
+
+			if _, err := q.Get("other", newer.ID); err != ErrNotFound {
+				t.Errorf("Get() = %v from another namespace, want: %v", err, ErrNotFound)
+			}
+			if err := q.Delete("ns", older.ID); err != nil {
+				t.Fatalf("Delete() = %v", err)
+			}
+			if _, err := q.Get("ns", older.ID); err != ErrNotFound {
+				t.Errorf("Get() = %v after Delete(), want: %v", err, ErrNotFound)
+			}
+			if err := q.Delete("ns", older.ID); err != nil {
+				t.Errorf("Delete() = %v for a deleted invocation", err)
+			}
+			if _, err := q.Get("ns", "../"+newer.ID); err != ErrNotFound {
+				t.Errorf("Get() = %v for an invalid ID, want: %v", err, ErrNotFound)
+			}
+			if _, err := q.Get("..", newer.ID); err != ErrNotFound {
+				t.Errorf("Get() = %v for an invalid namespace, want: %v", err, ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestDiskQueueSurvivesRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "async")
+	if err != nil {
+		t.Fatalf("TempDir() = %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	q, err := NewDiskQueue(dir)
+	if err != nil {
+		t.Fatalf("NewDiskQueue() = %v", err)
+	}
+	inv := testInvocation(t, time.Now())
+	if err := q.Put(inv); err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+
+	q, err = NewDiskQueue(dir)
+	if err != nil {
+		t.Fatalf("NewDiskQueue() = %v", err)
+	}
+	index, err := q.Index()
+	if err != nil {
+		t.Fatalf("Index() = %v", err)
+	}
+	if len(index) != 1 || index[0].ID != inv.ID || !index[0].Due.Equal(inv.NextAttempt) {
+		t.Errorf("Index() = %v, want the entry of %s due at %v", index, inv.ID, inv.NextAttempt)
+	}
+	got, err := q.Get("ns", inv.ID)
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	if !cmp.Equal(inv, got) {
+		t.Errorf("Get() (-want, +got): %s", cmp.Diff(inv, got))
+	}
+}
+
+func TestQueueClaims(t *testing.T) {
+	dir, err := ioutil.TempDir("", "async")
+	if err != nil {
+		t.Fatalf("TempDir() = %v", err)
+	}
+	defer os.RemoveAll(dir)
+	diskQueue, err := NewDiskQueue(dir)
+	if err != nil {
+		t.Fatalf("NewDiskQueue() = %v", err)
+	}
+
+	queues := map[string]Queue{
+		"memory": NewMemoryQueue(),
+		"disk":   diskQueue,
+	}
+	for name, q := range queues {
+		t.Run(name, func(t *testing.T) {
+			inv := testInvocation(t, time.Now())
+			if err := q.Put(inv); err != nil {
+				t.Fatalf("Put() = %v", err)
+			}
+			if claimed, err := q.Claim("ns", inv.ID); err != nil || !claimed {
+				t.Fatalf("Claim() = %v, %v, want: true", claimed, err)
+			}
+			if claimed, err := q.Claim("ns", inv.ID); err != nil || claimed {
+				t.Errorf("Claim() = %v, %v for a claimed invocation, want: false", claimed, err)
+			}
+			if err := q.Release("ns", inv.ID); err != nil {
+				t.Fatalf("Release() = %v", err)
+			}
+			if claimed, err := q.Claim("ns", inv.ID); err != nil || !claimed {
+				t.Errorf("Claim() = %v, %v for a released invocation, want: true", claimed, err)
+			}
+			// The claim isn't an invocation.
+			if index, err := q.Index(); err != nil || len(index) != 1 {
+				t.Errorf("Index() = %v, %v, want the invocation only", index, err)
+			}
+		})
+	}
+}
+
+func TestDiskQueueClaimExpires(t *testing.T) {
+	dir, err := ioutil.TempDir("", "async")
+	if err != nil {
+		t.Fatalf("TempDir() = %v", err)
+	}
+	defer os.RemoveAll(dir)
+	q, err := NewDiskQueue(dir)
+	if err != nil {
+		t.Fatalf("NewDiskQueue() = %v", err)
+	}
+
+	inv := testInvocation(t, time.Now())
+	if err := q.Put(inv); err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+	if claimed, err := q.Claim("ns", inv.ID); err != nil || !claimed {
+		t.Fatalf("Claim() = %v, %v, want: true", claimed, err)
+	}
+	// The activator holding the claim died long ago.
+	old := time.Now().Add(-2 * claimTTL)
+	if err := os.Chtimes(filepath.Join(dir, "ns", inv.ID+claimExt), old, old); err != nil {
+		t.Fatalf("Chtimes() = %v", err)
+	}
+	if claimed, err := q.Claim("ns", inv.ID); err != nil || !claimed {
+		t.Errorf("Claim() = %v, %v for an expired claim, want: true", claimed, err)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package activator
+
+import (
+	"errors"
+	"sync"
+)
+
+// fairShareReservePercent is the share of BufferLimits.Total reserved for
+// the namespaces holding less than their fair share of it.
+const fairShareReservePercent = 10
+
+// ErrBufferLimitExceeded indicates that the namespace or the revision of the request
+// already has as many requests buffered in the activator as it may.
+var ErrBufferLimitExceeded = errors.New("activator buffer limit exceeded")
+
+// BufferLimits bounds the number of requests buffered in the activator, waiting
+// for capacity of their revision.  A limit of 0 means no limit.
+//
+// The revisions don't share their capacity, so the buffer is the only
+// resource the namespaces compete for: it is shared fairly on admission,
+// and each revision serves its requests in the order of its breaker.
+type BufferLimits struct {
+	// Total is the number of requests of all the namespaces together, which
+	// is never exceeded.  The last fairShareReservePercent of it only admit
+	// the namespaces holding less than their fair share, counting one more
+	// namespace than those buffering, so that a namespace filling the buffer
+	// can't starve the others.
+	Total int
+	// PerNamespace is the number of requests of a single namespace.
+	PerNamespace int
+	// PerRevision is the number of requests of a single revision.
+	PerRevision int
+}
+
+// requestBuffer accounts for the requests buffered in the activator, per
+// namespace and per revision.
+type requestBuffer struct {
+	mux        sync.Mutex
+	limits     BufferLimits
+	total      int
+	namespaces map[string]int
+	revisions  map[RevisionID]int
+}
+
+func newRequestBuffer() *requestBuffer {
+	return &requestBuffer{
+		namespaces: make(map[string]int),
+		revisions:  make(map[RevisionID]int),
+	}
+}
+
+// add buffers a request of the given revision, and returns false if that
+// exceeds any of the limits.
+func (b *requestBuffer) add(rev RevisionID) bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if b.limits.PerRevision > 0 && b.revisions[rev] >= b.limits.PerRevision {
+		return false
+	}
+	inNamespace := b.namespaces[rev.Namespace]
+	if b.limits.PerNamespace > 0 && inNamespace >= b.limits.PerNamespace {
+		return false
+	}
+	if b.limits.Total > 0 {
+		if b.total >= b.limits.Total {
+			return false
+		}
+		reserve := b.limits.Total * fairShareReservePercent / 100
+		if b.total >= b.limits.Total-reserve {
+			// Leave room for a namespace not buffering yet.
+			namespaces := len(b.namespaces) + 1
+			if inNamespace == 0 {
+				namespaces++
+			}
+			if inNamespace >= b.limits.Total/namespaces {
+				return false
+			}
+		}
+	}
+
+	b.total++
+	b.namespaces[rev.Namespace]++
+	b.revisions[rev]++
+	return true
+}
+
+// remove releases a request of the given revision previously added.
+func (b *requestBuffer) remove(rev RevisionID) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.total--
+	if b.namespaces[rev.Namespace]--; b.namespaces[rev.Namespace] <= 0 {
+		delete(b.namespaces, rev.Namespace)
+	}
+	if b.revisions[rev]--; b.revisions[rev] <= 0 {
+		delete(b.revisions, rev)
+	}
+}
+
+func (b *requestBuffer) updateLimits(limits BufferLimits) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.limits = limits
+}
+
+// buffered returns the number of requests of the given revision in the buffer.
+func (b *requestBuffer) buffered(rev RevisionID) int {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.revisions[rev]
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package activator
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	. "knative.dev/pkg/logging/testing"
+)
+
+func TestRequestBuffer(t *testing.T) {
+	var (
+		a1 = RevisionID{Namespace: "a", Name: "1"}
+		a2 = RevisionID{Namespace: "a", Name: "2"}
+		b1 = RevisionID{Namespace: "b", Name: "1"}
+		c1 = RevisionID{Namespace: "c", Name: "1"}
+	)
+	tests := []struct {
+		name   string
+		limits BufferLimits
+		// held are added before want is checked.
+		held []RevisionID
+		rev  RevisionID
+		want bool
+	}{{
+		name: "no limits",
+		held: []RevisionID{a1, a1, a1},
+		rev:  a1,
+		want: true,
+	}, {
+		name:   "revision limit",
+		limits: BufferLimits{PerRevision: 2},
+		held:   []RevisionID{a1, a1},
+		rev:    a1,
+	}, {
+		name:   "other revision below its limit",
+		limits: BufferLimits{PerRevision: 2},
+		held:   []RevisionID{a1, a1},
+		rev:    a2,
+		want:   true,
+	}, {
+		name:   "namespace limit",
+		limits: BufferLimits{PerNamespace: 2},
+		held:   []RevisionID{a1, a2},
+		rev:    a1,
+	}, {
+		name:   "other namespace below its limit",
+		limits: BufferLimits{PerNamespace: 2},
+		held:   []RevisionID{a1, a2},
+		rev:    b1,
+		want:   true,
+	}, {
+		name:   "total limit",
+		limits: BufferLimits{Total: 2},
+		held:   []RevisionID{a1, a2},
+		rev:    a1,
+	}, {
+		name:   "total limit, new namespace below its fair share",
+		limits: BufferLimits{Total: 4},
+		held:   []RevisionID{a1, a1, a2, a2},
+		rev:    b1,
+	}, {
+		name:   "below the reserve",
+		limits: BufferLimits{Total: 10},
+		held:   []RevisionID{a1, a1, a1, a1, a1, a1, a1, a2},
+		rev:    a1,
+		want:   true,
+	}, {
+		name:   "reserve, new namespace below its fair share",
+		limits: BufferLimits{Total: 10},
+		held:   []RevisionID{a1, a1, a1, a1, a1, a1, a1, a2, a2},
+		rev:    b1,
+		want:   true,
+	}, {
+		name:   "reserve, namespace alone beyond its fair share",
+		limits: BufferLimits{Total: 10},
+		held:   []RevisionID{a1, a1, a1, a1, a1, a1, a1, a2, a2},
+		rev:    a1,
+	}, {
+		name:   "reserve, namespace below its fair share",
+		limits: BufferLimits{Total: 10},
+		held:   []RevisionID{a1, a1, a1, a1, a1, a1, a1, b1, c1},
+		rev:    c1,
+		want:   true,
+	}, {
+		name:   "reserve, namespace at its fair share",
+		limits: BufferLimits{Total: 10},
+		held:   []RevisionID{a1, a1, a1, a1, a1, a1, b1, b1, c1},
+		rev:    b1,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := newRequestBuffer()
+			for _, rev := range test.held {
+				if !b.add(rev) {
+					t.Fatalf("add(%v) = false while setting up", rev)
+				}
+			}
+			b.updateLimits(test.limits)
+			if got := b.add(test.rev); got != test.want {
+				t.Errorf("add(%v) = %v, want: %v", test.rev, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRequestBufferRemove(t *testing.T) {
+	rev := RevisionID{Namespace: "a", Name: "1"}
+	b := newRequestBuffer()
+	b.updateLimits(BufferLimits{Total: 1, PerNamespace: 1, PerRevision: 1})
+	if !b.add(rev) {
+		t.Fatal("add() = false, want: true")
+	}
+	if b.add(rev) {
+		t.Fatal("add() = true beyond the limits")
+	}
+	b.remove(rev)
+	if len(b.namespaces) != 0 || len(b.revisions) != 0 || b.total != 0 {
+		t.Errorf("Buffer not empty after remove(): %d, %v, %v", b.total, b.namespaces, b.revisions)
+	}
+	if !b.add(rev) {
+		t.Error("add() = false after remove(), want: true")
+	}
+}
+
+func TestThrottlerTryBufferLimit(t *testing.T) {
+	throttler := getThrottler(
+		defaultMaxConcurrency,
+		revisionLister(testNamespace, testRevision, 1),
+		endpointsInformer(testNamespace, testRevision, 0),
+		sksLister(testNamespace, testRevision),
+		TestLogger(t),
+		initCapacity)
+	throttler.UpdateBufferLimits(BufferLimits{PerRevision: 1})
+
+	// Without capacity, the first request stays buffered until it times out.
+	errCh := make(chan error)
+	go func() {
+		errCh <- throttler.Try(500*time.Millisecond, revID, func(string, TryStats) {})
+	}()
+	if err := wait.PollImmediate(10*time.Millisecond, time.Second, func() (bool, error) {
+		throttler.buffer.mux.Lock()
+		defer throttler.buffer.mux.Unlock()
+		return throttler.buffer.revisions[revID] == 1, nil
+	}); err != nil {
+		t.Fatal("The first request was never buffered")
+	}
+
+	if err := throttler.Try(0, revID, func(string, TryStats) {
+		t.Error("Function called beyond the buffer limit")
+	}); err != ErrBufferLimitExceeded {
+		t.Errorf("Try() = %v, want: %v", err, ErrBufferLimitExceeded)
+	}
+	if err := <-errCh; err != ErrActivatorOverload {
+		t.Errorf("Try() = %v, want: %v", err, ErrActivatorOverload)
+	}
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2019 The Knative Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// ActivatorConfigName is the name of the configmap containing the
+	// configuration of the activator.
+	ActivatorConfigName = "config-activator"
+
+	// DefaultProbeTimeout is the default time the activator probes
+	// a revision for activation.
+	DefaultProbeTimeout = 2 * time.Minute
+
+	// DefaultEndpointTimeout is the default time a request waits for
+	// capacity of its revision.
+	DefaultEndpointTimeout = 2 * time.Minute
+
+	// DefaultBreakerQueueDepth is the default number of requests that are
+	// queued on the breaker of a revision before 503s are sent.
+	DefaultBreakerQueueDepth = 10000
+
+	// DefaultBreakerMaxConcurrency is the default upper bound for concurrent
+	// requests sent to a revision.
+	DefaultBreakerMaxConcurrency = 1000
+
+	// DefaultMaxBufferedRequests is the default number of requests of all
+	// the namespaces together buffered in the activator.
+	DefaultMaxBufferedRequests = 10000
+
+	// DefaultBufferRetryAfter is the default time clients are asked to wait
+	// before retrying requests rejected for exceeding the buffer limits.
+	DefaultBufferRetryAfter = time.Second
+
+	// DefaultMaxRetries is the default number of times a request that is
+	// safe to be sent again is retried.
+	DefaultMaxRetries = 2
+
+	// DefaultMaxAsyncInvocations is the default number of asynchronous
+	// invocations of all the namespaces together stored by the activators.
+	DefaultMaxAsyncInvocations = 10000
+
+	// DefaultMaxAsyncInvocationsPerNamespace is the default number of
+	// asynchronous invocations of a single namespace stored by the activators.
+	DefaultMaxAsyncInvocationsPerNamespace = 1000
+
+	// DefaultMaxAsyncStorage is the default number of bytes of the
+	// asynchronous invocations stored by the activators.
+	DefaultMaxAsyncStorage = 1 << 30
+)
+
+// DefaultRetryStatusCodes are the default status codes on which idempotent
+// requests are retried.
+var DefaultRetryStatusCodes = []int{502, 503}
+
+// Activator contains the configuration of the request handling
+// in the activator.
+type Activator struct {
+	// ProbeTimeout is how long a revision is probed for activation
+	// before the request fails.
+	ProbeTimeout time.Duration
+	// EndpointTimeout is how long a request waits for capacity of its
+	// revision before the request fails.
+	EndpointTimeout time.Duration
+
+	// BreakerQueueDepth is the number of requests that are queued on the
+	// breaker of a revision before 503s are sent.
+	BreakerQueueDepth int
+	// BreakerMaxConcurrency is the upper bound for concurrent requests sent
+	// to a revision. As new endpoints show up, the breaker's concurrency
+	// increases up to this value.
+	BreakerMaxConcurrency int
+
+	// MaxBufferedRequests is the number of requests of all the namespaces
+	// together waiting for capacity in the activator, shared fairly between
+	// the namespaces.  0 means no limit.
+	MaxBufferedRequests int
+	// MaxBufferedRequestsPerNamespace is the number of requests of a single
+	// namespace waiting for capacity in the activator.  0 means no limit.
+	MaxBufferedRequestsPerNamespace int
+	// MaxBufferedRequestsPerRevision is the number of requests of a single
+	// revision waiting for capacity in the activator.  0 means no limit.
+	MaxBufferedRequestsPerRevision int
+	// BufferRetryAfter is how long clients are asked to wait before
+	// retrying requests rejected for exceeding the buffer limits.
+	BufferRetryAfter time.Duration
+
+	// MaxRetries is the number of times a single request is retried, when its
+	// connection to the upstream fails, or when it's idempotent and gets one
+	// of the RetryStatusCodes.
+	MaxRetries int
+	// RetryStatusCodes are the status codes on which idempotent requests are retried.
+	RetryStatusCodes []int
+
+	// MaxAsyncInvocations is the number of asynchronous invocations of all
+	// the namespaces together stored, pending or done.  0 means no limit.
+	MaxAsyncInvocations int
+	// MaxAsyncInvocationsPerNamespace is the number of asynchronous
+	// invocations of a single namespace stored.  0 means no limit.
+	MaxAsyncInvocationsPerNamespace int
+	// MaxAsyncStorage is the number of bytes of the asynchronous invocations
+	// stored.  0 means no limit.
+	MaxAsyncStorage int64
+}
+
+// NewActivatorConfigFromConfigMap creates an Activator from the supplied ConfigMap.
+func NewActivatorConfigFromConfigMap(configMap *corev1.ConfigMap) (*Activator, error) {
+	c := &Activator{}
+
+	for _, dur := range []struct {
+		key          string
+		field        *time.Duration
+		defaultValue time.Duration
+	}{{
+		key:          "probe-timeout",
+		field:        &c.ProbeTimeout,
+		defaultValue: DefaultProbeTimeout,
+	}, {
+		key:          "endpoint-timeout",
+		field:        &c.EndpointTimeout,
+		defaultValue: DefaultEndpointTimeout,
+	}, {
+		key:          "buffer-retry-after",
+		field:        &c.BufferRetryAfter,
+		defaultValue: DefaultBufferRetryAfter,
+	}} {
+		if raw, ok := configMap.Data[dur.key]; !ok {
+			*dur.field = dur.defaultValue
+		} else if val, err := time.ParseDuration(raw); err != nil {
+			return nil, err
+		} else if val <= 0 {
+			return nil, fmt.Errorf("%s must be positive, was: %v", dur.key, val)
+		} else {
+			*dur.field = val
+		}
+	}
+
+	for _, i := range []struct {
+		key          string
+		field        *int
+		defaultValue int
+	}{{
+		key:          "breaker-queue-depth",
+		field:        &c.BreakerQueueDepth,
+		defaultValue: DefaultBreakerQueueDepth,
+	}, {
+		key:          "breaker-max-concurrency",
+		field:        &c.BreakerMaxConcurrency,
+		defaultValue: DefaultBreakerMaxConcurrency,
+	}} {
+		if raw, ok := configMap.Data[i.key]; !ok {
+			*i.field = i.defaultValue
+		} else if val, err := strconv.Atoi(raw); err != nil {
+			return nil, err
+		} else if val <= 0 {
+			return nil, fmt.Errorf("%s must be positive, was: %d", i.key, val)
+		} else {
+			*i.field = val
+		}
+	}
+
+	for _, i := range []struct {
+		key          string
+		field        *int
+		defaultValue int
+	}{{
+		key:          "max-buffered-requests",
+		field:        &c.MaxBufferedRequests,
+		defaultValue: DefaultMaxBufferedRequests,
+	}, {
+		key:   "max-buffered-requests-per-namespace",
+		field: &c.MaxBufferedRequestsPerNamespace,
+	}, {
+		key:   "max-buffered-requests-per-revision",
+		field: &c.MaxBufferedRequestsPerRevision,
+	}, {
+		key:          "max-retries",
+		field:        &c.MaxRetries,
+		defaultValue: DefaultMaxRetries,
+	}, {
+		key:          "max-async-invocations",
+		field:        &c.MaxAsyncInvocations,
+		defaultValue: DefaultMaxAsyncInvocations,
+	}, {
+		key:          "max-async-invocations-per-namespace",
+		field:        &c.MaxAsyncInvocationsPerNamespace,
+		defaultValue: DefaultMaxAsyncInvocationsPerNamespace,
+	}} {
+		if raw, ok := configMap.Data[i.key]; !ok {
+			*i.field = i.defaultValue
+		} else if val, err := strconv.Atoi(raw); err != nil {
+			return nil, err
+		} else if val < 0 {
+			return nil, fmt.Errorf("%s must be zero or greater, was: %d", i.key, val)
+		} else {
+			*i.field = val
+		}
+	}
+
+	c.MaxAsyncStorage = DefaultMaxAsyncStorage
+	if raw, ok := configMap.Data["max-async-storage"]; ok {
+		val, err := resource.ParseQuantity(raw)
+		if err != nil {
+			return nil, err
+		}
+		if val.Sign() < 0 {
+			return nil, fmt.Errorf("max-async-storage must be zero or greater, was: %s", raw)
+		}
+		c.MaxAsyncStorage = val.Value()
+	}
+
+	c.RetryStatusCodes = DefaultRetryStatusCodes
+	if raw, ok := configMap.Data["retry-status-codes"]; ok {
+		c.RetryStatusCodes = []int{}
+		for _, code := range strings.Split(raw, ",") {
+			code = strings.TrimSpace(code)
+			if code == "" {
+				continue
+			}
+			val, err := strconv.Atoi(code)
+			if err != nil {
+				return nil, err
+			}
+			if val < 100 || val > 599 {
+				return nil, fmt.Errorf("retry-status-codes must be HTTP status codes, was: %d", val)
+			}
+			c.RetryStatusCodes = append(c.RetryStatusCodes, val)
+		}
+	}
+
+	return c, nil
+}
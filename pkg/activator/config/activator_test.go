@@ -0,0 +1,181 @@
+/*
+Copyright 2019 The Knative Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+
+	. "knative.dev/pkg/configmap/testing"
+)
+
+var defaultActivator = &Activator{
+	ProbeTimeout:          DefaultProbeTimeout,
+	EndpointTimeout:       DefaultEndpointTimeout,
+	BreakerQueueDepth:     DefaultBreakerQueueDepth,
+	BreakerMaxConcurrency: DefaultBreakerMaxConcurrency,
+	MaxBufferedRequests:   DefaultMaxBufferedRequests,
+	BufferRetryAfter:      DefaultBufferRetryAfter,
+	MaxRetries:            DefaultMaxRetries,
+	RetryStatusCodes:      DefaultRetryStatusCodes,
+
+	MaxAsyncInvocations:             DefaultMaxAsyncInvocations,
+	MaxAsyncInvocationsPerNamespace: DefaultMaxAsyncInvocationsPerNamespace,
+	MaxAsyncStorage:                 DefaultMaxAsyncStorage,
+}
+
+func TestActivatorConfig(t *testing.T) {
+	actual, example := ConfigMapsFromTestFile(t, ActivatorConfigName)
+	for _, tt := range []struct {
+		name string
+		fail bool
+		want *Activator
+		data *corev1.ConfigMap
+	}{{
+		name: "actual config",
+		want: defaultActivator,
+		data: actual,
+	}, {
+		name: "example config",
+		want: defaultActivator,
+		data: example,
+	}, {
+		name: "with value overrides",
+		want: &Activator{
+			ProbeTimeout:                    30 * time.Second,
+			EndpointTimeout:                 DefaultEndpointTimeout,
+			BreakerQueueDepth:               100,
+			BreakerMaxConcurrency:           DefaultBreakerMaxConcurrency,
+			MaxBufferedRequests:             0,
+			MaxBufferedRequestsPerNamespace: 50,
+			MaxBufferedRequestsPerRevision:  20,
+			BufferRetryAfter:                1500 * time.Millisecond,
+			MaxRetries:                      0,
+			RetryStatusCodes:                []int{500, 504},
+			MaxAsyncInvocations:             0,
+			MaxAsyncInvocationsPerNamespace: 10,
+			MaxAsyncStorage:                 100 << 20,
+		},
+		data: &corev1.ConfigMap{
+			Data: map[string]string{
+				"probe-timeout":                       "30s",
+				"breaker-queue-depth":                 "100",
+				"max-buffered-requests":               "0",
+				"max-buffered-requests-per-namespace": "50",
+				"max-buffered-requests-per-revision":  "20",
+				"buffer-retry-after":                  "1.5s",
+				"max-retries":                         "0",
+				"retry-status-codes":                  "500, 504",
+				"max-async-invocations":               "0",
+				"max-async-invocations-per-namespace": "10",
+				"max-async-storage":                   "100Mi",
+			},
+		},
+	}, {
+		name: "invalid duration",
+		fail: true,
+		data: &corev1.ConfigMap{
+			Data: map[string]string{
+				"endpoint-timeout": "invalid",
+			},
+		},
+	}, {
+		name: "negative duration",
+		fail: true,
+		data: &corev1.ConfigMap{
+			Data: map[string]string{
+				"probe-timeout": "-1s",
+			},
+		},
+	}, {
+		name: "invalid max concurrency",
+		fail: true,
+		data: &corev1.ConfigMap{
+			Data: map[string]string{
+				"breaker-max-concurrency": "invalid",
+			},
+		},
+	}, {
+		name: "negative buffer limit",
+		fail: true,
+		data: &corev1.ConfigMap{
+			Data: map[string]string{
+				"max-buffered-requests-per-namespace": "-1",
+			},
+		},
+	}, {
+		name: "invalid async storage",
+		fail: true,
+		data: &corev1.ConfigMap{
+			Data: map[string]string{
+				"max-async-storage": "lots",
+			},
+		},
+	}, {
+		name: "negative async storage",
+		fail: true,
+		data: &corev1.ConfigMap{
+			Data: map[string]string{
+				"max-async-storage": "-1Gi",
+			},
+		},
+	}, {
+		name: "invalid retry status code",
+		fail: true,
+		data: &corev1.ConfigMap{
+			Data: map[string]string{
+				"retry-status-codes": "502,abc",
+			},
+		},
+	}, {
+		name: "out of range retry status code",
+		fail: true,
+		data: &corev1.ConfigMap{
+			Data: map[string]string{
+				"retry-status-codes": "1000",
+			},
+		},
+	}, {
+		name: "zero queue depth",
+		fail: true,
+		data: &corev1.ConfigMap{
+			Data: map[string]string{
+				"breaker-queue-depth": "0",
+			},
+		},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewActivatorConfigFromConfigMap(tt.data)
+			if tt.fail != (err != nil) {
+				t.Errorf("Unexpected error value: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Unexpected activator config (-want, +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestFromContextWithoutConfig(t *testing.T) {
+	if got := FromContext(context.Background()); got != nil {
+		t.Errorf("FromContext() = %v, want: nil", got)
+	}
+}
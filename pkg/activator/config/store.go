@@ -28,12 +28,15 @@ type cfgKey struct{}
 
 // Config is a configuration for the activator
 type Config struct {
-	Tracing *tracingconfig.Config
+	Tracing   *tracingconfig.Config
+	Activator *Activator
 }
 
-// FromContext obtains a Config injected into the passed context
+// FromContext obtains a Config injected into the passed context,
+// or nil if there is none.
 func FromContext(ctx context.Context) *Config {
-	return ctx.Value(cfgKey{}).(*Config)
+	c, _ := ctx.Value(cfgKey{}).(*Config)
+	return c
 }
 
 func toContext(ctx context.Context, c *Config) context.Context {
@@ -54,6 +57,7 @@ func NewStore(logger configmap.Logger, onAfterStore ...func(name string, value i
 			logger,
 			configmap.Constructors{
 				tracingconfig.ConfigName: tracingconfig.NewTracingConfigFromConfigMap,
+				ActivatorConfigName:      NewActivatorConfigFromConfigMap,
 			},
 			onAfterStore...,
 		),
@@ -68,7 +72,8 @@ func (s *Store) ToContext(ctx context.Context) context.Context {
 // Load creates a Config for this store
 func (s *Store) Load() *Config {
 	return &Config{
-		Tracing: s.UntypedLoad(tracingconfig.ConfigName).(*tracingconfig.Config).DeepCopy(),
+		Tracing:   s.UntypedLoad(tracingconfig.ConfigName).(*tracingconfig.Config).DeepCopy(),
+		Activator: s.UntypedLoad(ActivatorConfigName).(*Activator).DeepCopy(),
 	}
 }
 
@@ -0,0 +1,176 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/knative/serving/pkg/activator"
+	"github.com/knative/serving/pkg/activator/async"
+	"github.com/knative/serving/pkg/apis/serving"
+	servinglisters "github.com/knative/serving/pkg/client/listers/serving/v1alpha1"
+)
+
+const (
+	// AsyncStatusPath is the path under which the activator serves the
+	// status of the asynchronous invocations, by ID.
+	AsyncStatusPath = "/.knative/async/"
+
+	// preferRespondAsync is the preference of the callers asking for an
+	// asynchronous invocation, per RFC 7240.
+	preferRespondAsync = "respond-async"
+	// maxAsyncBodyBytes is the maximal size of the body of an asynchronous
+	// invocation, which is persisted.
+	maxAsyncBodyBytes = 10 << 20
+	// asyncLimitRetryAfter is the number of seconds clients are asked to
+	// wait before submitting again invocations rejected for exceeding the
+	// limits of their namespace.
+	asyncLimitRetryAfter = "10"
+)
+
+// AsyncHandler accepts the requests preferring to be responded to
+// asynchronously: it persists them to be delivered later, and responds with
+// a 202 and the location of their status.  It serves these statuses too.
+// Only the revisions opting into asynchronous invocations are concerned,
+// as they are the ones keeping the activator in their request path.
+type AsyncHandler struct {
+	Dispatcher     *async.Dispatcher
+	RevisionLister servinglisters.RevisionLister
+	NextHandler    http.Handler
+	Logger         *zap.SugaredLogger
+}
+
+func (h *AsyncHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rev := activator.RevisionID{
+		Namespace: r.Header.Get(activator.RevisionHeaderNamespace),
+		Name:      r.Header.Get(activator.RevisionHeaderName),
+	}
+	if !h.acceptsAsync(rev) {
+		h.NextHandler.ServeHTTP(w, r)
+		return
+	}
+	if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, AsyncStatusPath) {
+		// The invocations are only visible from the namespace they were
+		// submitted to.
+		h.serveStatus(w, rev.Namespace, strings.TrimPrefix(r.URL.Path, AsyncStatusPath))
+		return
+	}
+	prefer, ok := removePreference(r.Header["Prefer"], preferRespondAsync)
+	if !ok {
+		h.NextHandler.ServeHTTP(w, r)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxAsyncBodyBytes))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	// The preference was honored here, and must not reach the revision.
+	if len(prefer) > 0 {
+		r.Header["Prefer"] = prefer
+	} else {
+		r.Header.Del("Prefer")
+	}
+	inv, err := async.NewInvocation(rev, r, body)
+	if err == nil {
+		err = h.Dispatcher.Submit(inv)
+	}
+	switch err {
+	case nil:
+	case async.ErrNamespaceFull:
+		w.Header().Set("Retry-After", asyncLimitRetryAfter)
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	case async.ErrQueueFull:
+		http.Error(w, err.Error(), http.StatusInsufficientStorage)
+		return
+	default:
+		h.Logger.Errorw("Failed to persist an asynchronous invocation", zap.Error(err))
+		http.Error(w, "failed to persist the request", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", AsyncStatusPath+inv.ID)
+	w.Header().Set("Preference-Applied", preferRespondAsync)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// acceptsAsync returns whether the revision opted into asynchronous
+// invocations. The activation handler rejects the unknown revisions.
+func (h *AsyncHandler) acceptsAsync(rev activator.RevisionID) bool {
+	if rev.Namespace == "" || rev.Name == "" {
+		return false
+	}
+	revision, err := h.RevisionLister.Revisions(rev.Namespace).Get(rev.Name)
+	if err != nil {
+		return false
+	}
+	enabled, _ := strconv.ParseBool(revision.Annotations[serving.AsyncInvocationsAnnotation])
+	return enabled
+}
+
+func (h *AsyncHandler) serveStatus(w http.ResponseWriter, namespace, id string) {
+	inv, err := h.Dispatcher.Get(namespace, id)
+	if err == async.ErrNotFound {
+		http.NotFound(w, nil)
+		return
+	} else if err != nil {
+		h.Logger.Errorw("Failed to get an asynchronous invocation", zap.String("id", id), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	b, err := json.Marshal(inv.Status())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// removePreference returns the values of a Prefer header without the given
+// preference, and whether it was there.
+func removePreference(values []string, preference string) ([]string, bool) {
+	found := false
+	var rest []string
+	for _, value := range values {
+		var kept []string
+		for _, pref := range strings.Split(value, ",") {
+			token := strings.TrimSpace(pref)
+			if i := strings.IndexAny(token, ";="); i >= 0 {
+				token = strings.TrimSpace(token[:i])
+			}
+			if strings.EqualFold(token, preference) {
+				found = true
+				continue
+			}
+			if strings.TrimSpace(pref) != "" {
+				kept = append(kept, strings.TrimSpace(pref))
+			}
+		}
+		if len(kept) > 0 {
+			rest = append(rest, strings.Join(kept, ", "))
+		}
+	}
+	return rest, found
+}
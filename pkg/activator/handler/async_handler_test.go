@@ -0,0 +1,252 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	. "knative.dev/pkg/logging/testing"
+	"github.com/knative/serving/pkg/activator"
+	"github.com/knative/serving/pkg/activator/async"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+func TestAsyncHandler(t *testing.T) {
+	defer ClearAll()
+	queue := async.NewMemoryQueue()
+	dispatcher := async.NewDispatcher(queue, nil, TestLogger(t))
+	passed := false
+	handler := &AsyncHandler{
+		Dispatcher:     dispatcher,
+		RevisionLister: revisionLister(asyncRevision(testNamespace, testRevName), revision("other", testRevName)),
+		NextHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			passed = true
+			http.NotFound(w, r)
+		}),
+		Logger: TestLogger(t),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/path", strings.NewReader("payload"))
+	req.Header.Set("Prefer", "respond-async, wait=10")
+	req.Header.Set(activator.RevisionHeaderNamespace, testNamespace)
+	req.Header.Set(activator.RevisionHeaderName, testRevName)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	if passed {
+		t.Error("The asynchronous request was passed to the next handler")
+	}
+	if resp.Code != http.StatusAccepted {
+		t.Fatalf("Unexpected response status. Want %d, got %d", http.StatusAccepted, resp.Code)
+	}
+	if got := resp.Header().Get("Preference-Applied"); got != "respond-async" {
+		t.Errorf("Preference-Applied = %q, want: respond-async", got)
+	}
+	location := resp.Header().Get("Location")
+	if !strings.HasPrefix(location, AsyncStatusPath) {
+		t.Fatalf("Location = %q, want a path under %s", location, AsyncStatusPath)
+	}
+	inv, err := queue.Get(testNamespace, strings.TrimPrefix(location, AsyncStatusPath))
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	if got, want := inv.Revision, (activator.RevisionID{Namespace: testNamespace, Name: testRevName}); got != want {
+		t.Errorf("Revision = %v, want: %v", got, want)
+	}
+	if string(inv.Body) != "payload" {
+		t.Errorf("Body = %q, want: payload", inv.Body)
+	}
+	if got := inv.Header.Get("Prefer"); got != "wait=10" {
+		t.Errorf("Prefer = %q, want: wait=10", got)
+	}
+
+	// The status of the invocation is served at its location, within its
+	// namespace only.
+	req = httptest.NewRequest(http.MethodGet, "http://example.com"+location, nil)
+	req.Header.Set(activator.RevisionHeaderNamespace, "other")
+	req.Header.Set(activator.RevisionHeaderName, testRevName)
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("Unexpected response status from another namespace. Want %d, got %d", http.StatusNotFound, resp.Code)
+	}
+	req.Header.Set(activator.RevisionHeaderNamespace, testNamespace)
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Unexpected response status. Want %d, got %d", http.StatusOK, resp.Code)
+	}
+	var status async.Status
+	if err := json.Unmarshal(resp.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Failed to decode the status: %v", err)
+	}
+	if status.ID != inv.ID || status.State != async.StatePending {
+		t.Errorf("Status = %#v, want the pending invocation %s", status, inv.ID)
+	}
+
+	passed = false
+	req = httptest.NewRequest(http.MethodGet, "http://example.com"+AsyncStatusPath+"unknown", nil)
+	req.Header.Set(activator.RevisionHeaderNamespace, testNamespace)
+	req.Header.Set(activator.RevisionHeaderName, testRevName)
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("Unexpected response status. Want %d, got %d", http.StatusNotFound, resp.Code)
+	}
+	if passed {
+		t.Error("The status request was passed to the next handler")
+	}
+
+	// Synchronous requests are passed on.
+	req = httptest.NewRequest(http.MethodPost, "http://example.com/path", nil)
+	req.Header.Set("Prefer", "wait=10")
+	req.Header.Set(activator.RevisionHeaderNamespace, testNamespace)
+	req.Header.Set(activator.RevisionHeaderName, testRevName)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if !passed {
+		t.Error("The synchronous request wasn't passed to the next handler")
+	}
+
+	// So are the requests to the revisions not opting into asynchronous
+	// invocations, with their preference.
+	passed = false
+	req = httptest.NewRequest(http.MethodPost, "http://example.com/path", nil)
+	req.Header.Set("Prefer", "respond-async")
+	req.Header.Set(activator.RevisionHeaderNamespace, "other")
+	req.Header.Set(activator.RevisionHeaderName, testRevName)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if !passed {
+		t.Error("The request to a synchronous revision wasn't passed to the next handler")
+	}
+	if got := req.Header.Get("Prefer"); got != "respond-async" {
+		t.Errorf("Prefer = %q, want: respond-async", got)
+	}
+}
+
+func TestAsyncHandlerLimits(t *testing.T) {
+	defer ClearAll()
+	tests := []struct {
+		name           string
+		limits         async.Limits
+		wantCode       int
+		wantRetryAfter string
+	}{{
+		name:     "under the limits",
+		limits:   async.Limits{Total: 2, PerNamespace: 2},
+		wantCode: http.StatusAccepted,
+	}, {
+		name:           "namespace full",
+		limits:         async.Limits{PerNamespace: 1},
+		wantCode:       http.StatusTooManyRequests,
+		wantRetryAfter: asyncLimitRetryAfter,
+	}, {
+		name:     "queue full",
+		limits:   async.Limits{Total: 1},
+		wantCode: http.StatusInsufficientStorage,
+	}, {
+		name:     "storage full",
+		limits:   async.Limits{Bytes: 10},
+		wantCode: http.StatusInsufficientStorage,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dispatcher := async.NewDispatcher(async.NewMemoryQueue(), nil, TestLogger(t))
+			dispatcher.UpdateLimits(test.limits)
+			handler := &AsyncHandler{
+				Dispatcher:     dispatcher,
+				RevisionLister: revisionLister(asyncRevision(testNamespace, testRevName)),
+				NextHandler:    http.NotFoundHandler(),
+				Logger:         TestLogger(t),
+			}
+
+			var resp *httptest.ResponseRecorder
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest(http.MethodPost, "http://example.com/path", strings.NewReader("payload"))
+				req.Header.Set("Prefer", "respond-async")
+				req.Header.Set(activator.RevisionHeaderNamespace, testNamespace)
+				req.Header.Set(activator.RevisionHeaderName, testRevName)
+				resp = httptest.NewRecorder()
+				handler.ServeHTTP(resp, req)
+			}
+			if resp.Code != test.wantCode {
+				t.Errorf("Unexpected response status. Want %d, got %d", test.wantCode, resp.Code)
+			}
+			if got := resp.Header().Get("Retry-After"); got != test.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want: %q", got, test.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func asyncRevision(namespace, name string) *v1alpha1.Revision {
+	rev := revision(namespace, name)
+	rev.Annotations = map[string]string{serving.AsyncInvocationsAnnotation: "true"}
+	return rev
+}
+
+func TestRemovePreference(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []string
+		want      []string
+		wantFound bool
+	}{{
+		name: "no header",
+	}, {
+		name:   "other preferences",
+		values: []string{"return=minimal", "wait=5"},
+		want:   []string{"return=minimal", "wait=5"},
+	}, {
+		name:      "only preference",
+		values:    []string{"respond-async"},
+		wantFound: true,
+	}, {
+		name:      "among others",
+		values:    []string{"wait=5, Respond-Async ,return=minimal"},
+		want:      []string{"wait=5, return=minimal"},
+		wantFound: true,
+	}, {
+		name:      "with parameters",
+		values:    []string{"respond-async; foo=bar", "wait=5"},
+		want:      []string{"wait=5"},
+		wantFound: true,
+	}, {
+		name:   "similar token",
+		values: []string{"respond-asynchronously"},
+		want:   []string{"respond-asynchronously"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, found := removePreference(test.values, preferRespondAsync)
+			if found != test.wantFound {
+				t.Errorf("found = %v, want: %v", found, test.wantFound)
+			}
+			if !cmp.Equal(test.want, got) {
+				t.Errorf("Preferences (-want, +got): %s", cmp.Diff(test.want, got))
+			}
+		})
+	}
+}
@@ -16,11 +16,14 @@ limitations under the License.
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"time"
 
 	"go.opencensus.io/plugin/ochttp"
@@ -29,6 +32,7 @@ import (
 
 	"knative.dev/pkg/logging/logkey"
 	"github.com/knative/serving/pkg/activator"
+	activatorconfig "github.com/knative/serving/pkg/activator/config"
 	"github.com/knative/serving/pkg/activator/util"
 	"github.com/knative/serving/pkg/apis/networking"
 	"github.com/knative/serving/pkg/apis/serving"
@@ -53,6 +57,8 @@ type activationHandler struct {
 	reporter  activator.StatsReporter
 	throttler *activator.Throttler
 
+	// probeTimeout and endpointTimeout are used when the request
+	// context carries no activator configuration.
 	probeTimeout          time.Duration
 	probeTransportFactory prober.TransportFactory
 	endpointTimeout       time.Duration
@@ -62,8 +68,11 @@ type activationHandler struct {
 	sksLister      netlisters.ServerlessServiceLister
 }
 
-// The default time we'll try to probe the revision for activation.
-const defaulTimeout = 2 * time.Minute
+const (
+	// coldAttribute is the span attribute telling whether the revision had
+	// no capacity when the request arrived.
+	coldAttribute = "activator.cold"
+)
 
 // New constructs a new http.Handler that deals with revision activation.
 func New(l *zap.SugaredLogger, r activator.StatsReporter, t *activator.Throttler,
@@ -78,7 +87,7 @@ func New(l *zap.SugaredLogger, r activator.StatsReporter, t *activator.Throttler
 		revisionLister: rl,
 		sksLister:      sksL,
 		serviceLister:  sl,
-		probeTimeout:   defaulTimeout,
+		probeTimeout:   activatorconfig.DefaultProbeTimeout,
 		// In activator we collect metrics, so we're wrapping
 		// the Roundtripper the prober would use inside annotating transport.
 		probeTransportFactory: func() http.RoundTripper {
@@ -86,8 +95,37 @@ func New(l *zap.SugaredLogger, r activator.StatsReporter, t *activator.Throttler
 				Base: network.NewAutoTransport(),
 			}
 		},
-		endpointTimeout: defaulTimeout,
+		endpointTimeout: activatorconfig.DefaultEndpointTimeout,
+	}
+}
+
+// timeouts returns the probe and endpoint timeouts of the activator
+// configuration in the given context.
+func (a *activationHandler) timeouts(ctx context.Context) (time.Duration, time.Duration) {
+	if cfg := activatorconfig.FromContext(ctx); cfg != nil && cfg.Activator != nil {
+		return cfg.Activator.ProbeTimeout, cfg.Activator.EndpointTimeout
+	}
+	return a.probeTimeout, a.endpointTimeout
+}
+
+// retryPolicy returns the number of retries and the retryable status codes
+// of the activator configuration in the given context.
+func retryPolicy(ctx context.Context) (int, []int) {
+	if cfg := activatorconfig.FromContext(ctx); cfg != nil && cfg.Activator != nil {
+		return cfg.Activator.MaxRetries, cfg.Activator.RetryStatusCodes
+	}
+	return activatorconfig.DefaultMaxRetries, activatorconfig.DefaultRetryStatusCodes
+}
+
+// bufferRetryAfter returns the value of the Retry-After header of the
+// requests rejected for exceeding the buffer limits, in whole seconds, from
+// the activator configuration in the given context.
+func bufferRetryAfter(ctx context.Context) string {
+	retryAfter := activatorconfig.DefaultBufferRetryAfter
+	if cfg := activatorconfig.FromContext(ctx); cfg != nil && cfg.Activator != nil {
+		retryAfter = cfg.Activator.BufferRetryAfter
 	}
+	return strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
 }
 
 func withOrigProto(or *http.Request) prober.Preparer {
@@ -99,19 +137,22 @@ func withOrigProto(or *http.Request) prober.Preparer {
 	}
 }
 
-func (a *activationHandler) probeEndpoint(logger *zap.SugaredLogger, r *http.Request, target *url.URL) (bool, int) {
+func (a *activationHandler) probeEndpoint(logger *zap.SugaredLogger, r *http.Request, target *url.URL, cold bool) (bool, int) {
 	var (
 		attempts int
 		st       = time.Now()
 	)
 
 	reqCtx, probeSpan := trace.StartSpan(r.Context(), "probe")
+	probeSpan.AddAttributes(trace.BoolAttribute(coldAttribute, cold))
 	defer func() {
+		probeSpan.AddAttributes(trace.Int64Attribute("activator.probe.attempts", int64(attempts)))
 		probeSpan.End()
 		a.logger.Debugf("Probing %s took %d attempts and %v time", target.String(), attempts, time.Since(st))
 	}()
 
-	err := wait.PollImmediate(100*time.Millisecond, a.probeTimeout, func() (bool, error) {
+	probeTimeout, _ := a.timeouts(r.Context())
+	err := wait.PollImmediate(100*time.Millisecond, probeTimeout, func() (bool, error) {
 		attempts++
 		ret, err := prober.Do(
 			reqCtx,
@@ -169,21 +210,60 @@ func (a *activationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	_, ttSpan := trace.StartSpan(r.Context(), "throttler_try")
 	ttStart := time.Now()
-	err = a.throttler.Try(a.endpointTimeout, revID, func() {
+	_, endpointTimeout := a.timeouts(r.Context())
+	var configurationName string
+	var serviceName string
+	if revision.Labels != nil {
+		configurationName = revision.Labels[serving.ConfigurationLabelKey]
+		serviceName = revision.Labels[serving.ServiceLabelKey]
+	}
+	reportPhase := func(phase string, cold bool, d time.Duration) {
+		a.reporter.ReportPhaseTime(namespace, serviceName, configurationName, name, phase, cold, d)
+	}
+
+	err = a.throttler.Try(endpointTimeout, revID, func(dest string, stats activator.TryStats) {
 		var (
 			httpStatus int
 		)
 
+		ttSpan.AddAttributes(
+			trace.BoolAttribute(coldAttribute, stats.Cold),
+			trace.Int64Attribute("activator.endpoints_wait_ms", int64(stats.EndpointsWait/time.Millisecond)),
+			trace.Int64Attribute("activator.throttler_wait_ms", int64(stats.ThrottlerWait/time.Millisecond)))
 		ttSpan.End()
 		a.logger.Debugf("Waiting for throttler took %v time", time.Since(ttStart))
+		pkghttp.ReportQueueWait(r.Context(), time.Since(ttStart))
+		if stats.Cold {
+			reportPhase(activator.PhaseEndpointsWait, stats.Cold, stats.EndpointsWait)
+		}
+		reportPhase(activator.PhaseThrottlerWait, stats.Cold, stats.ThrottlerWait)
+
+		target := target
+		if dest != "" {
+			// Send the request straight to the pod the throttler picked,
+			// rather than to a random one behind the private Service.
+			target = &url.URL{
+				Scheme: "http",
+				Host:   dest,
+			}
+		}
 
-		success, attempts := a.probeEndpoint(logger, r, target)
+		probeStart := time.Now()
+		success, attempts := a.probeEndpoint(logger, r, target, stats.Cold)
+		reportPhase(activator.PhaseProbe, stats.Cold, time.Since(probeStart))
 		if success {
 			// Once we see a successful probe, send traffic.
 			attempts++
+			proxyStart := time.Now()
 			reqCtx, proxySpan := trace.StartSpan(r.Context(), "proxy")
-			httpStatus = a.proxyRequest(w, r.WithContext(reqCtx), target)
+			proxySpan.AddAttributes(trace.BoolAttribute(coldAttribute, stats.Cold))
+			// Retries go to the private Service, since the pod may be gone.
+			httpStatus = a.proxyRequest(w, r.WithContext(reqCtx), target, host, func(reason string) {
+				attempts++
+				a.reporter.ReportRetry(namespace, serviceName, configurationName, name, reason)
+			})
 			proxySpan.End()
+			reportPhase(activator.PhaseProxy, stats.Cold, time.Since(proxyStart))
 		} else {
 			httpStatus = http.StatusInternalServerError
 			w.WriteHeader(httpStatus)
@@ -192,13 +272,6 @@ func (a *activationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		// Report the metrics
 		duration := time.Since(start)
 
-		var configurationName string
-		var serviceName string
-		if revision.Labels != nil {
-			configurationName = revision.Labels[serving.ConfigurationLabelKey]
-			serviceName = revision.Labels[serving.ServiceLabelKey]
-		}
-
 		a.reporter.ReportRequestCount(namespace, serviceName, configurationName, name, httpStatus, attempts, 1.0)
 		a.reporter.ReportResponseTime(namespace, serviceName, configurationName, name, httpStatus, duration)
 	})
@@ -211,6 +284,9 @@ func (a *activationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		if err == activator.ErrActivatorOverload {
 			http.Error(w, activator.ErrActivatorOverload.Error(), http.StatusServiceUnavailable)
+		} else if err == activator.ErrBufferLimitExceeded {
+			w.Header().Set("Retry-After", bufferRetryAfter(r.Context()))
+			http.Error(w, activator.ErrBufferLimitExceeded.Error(), http.StatusTooManyRequests)
 		} else {
 			w.WriteHeader(http.StatusInternalServerError)
 			logger.Errorw("Error processing request in the activator", zap.Error(err))
@@ -218,12 +294,23 @@ func (a *activationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (a *activationHandler) proxyRequest(w http.ResponseWriter, r *http.Request, target *url.URL) int {
+// proxyRequest sends the request to the target.  The requests which are safe to
+// be sent again are retried against the fallbackHost, and onRetry is called
+// with the reason of every retry.
+func (a *activationHandler) proxyRequest(w http.ResponseWriter, r *http.Request, target *url.URL,
+	fallbackHost string, onRetry func(string)) int {
 	network.RewriteHostIn(r)
 	recorder := pkghttp.NewResponseRecorder(w, http.StatusOK)
 	proxy := httputil.NewSingleHostReverseProxy(target)
-	proxy.Transport = &ochttp.Transport{
-		Base: a.transport,
+	retries, statusCodes := retryPolicy(r.Context())
+	proxy.Transport = &retryTransport{
+		base: &ochttp.Transport{
+			Base: a.transport,
+		},
+		retries:      retries,
+		statusCodes:  statusCodes,
+		fallbackHost: fallbackHost,
+		onRetry:      onRetry,
 	}
 	proxy.FlushInterval = -1
 
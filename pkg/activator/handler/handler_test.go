@@ -15,6 +15,7 @@ limitations under the License.
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -36,6 +37,7 @@ import (
 	. "knative.dev/pkg/logging/testing"
 	_ "knative.dev/pkg/system/testing"
 	"github.com/knative/serving/pkg/activator"
+	activatorconfig "github.com/knative/serving/pkg/activator/config"
 	activatortest "github.com/knative/serving/pkg/activator/testing"
 	nv1a1 "github.com/knative/serving/pkg/apis/networking/v1alpha1"
 	"github.com/knative/serving/pkg/apis/serving"
@@ -311,6 +313,7 @@ func TestActivationHandler(t *testing.T) {
 				test.endpointsInformer,
 				sksLister(sks(testNamespace, testRevName)),
 				revisionLister(revision(testNamespace, testRevName)),
+				"", /*selfIP*/
 				TestLogger(t))
 
 			handler := (New(TestLogger(t), reporter, throttler,
@@ -386,6 +389,7 @@ func TestActivationHandlerOverflow(t *testing.T) {
 		endpointsInformer(endpoints(namespace, revName, breakerParams.InitialCapacity)),
 		sksLister(sks(namespace, revName)),
 		revisionLister(revision(namespace, revName)),
+		"", /*selfIP*/
 		TestLogger(t))
 
 	handler := (New(TestLogger(t), reporter, throttler,
@@ -424,7 +428,7 @@ func TestActivationHandlerOverflowSeveralRevisions(t *testing.T) {
 	respCh := make(chan *httptest.ResponseRecorder, overallRequests)
 	lockerCh := make(chan struct{})
 
-	throttler := activator.NewThrottler(breakerParams, epClient, sksClient, revClient, TestLogger(t))
+	throttler := activator.NewThrottler(breakerParams, epClient, sksClient, revClient, "" /*selfIP*/, TestLogger(t))
 
 	fakeRT := activatortest.FakeRoundTripper{
 		LockerCh: lockerCh,
@@ -464,6 +468,7 @@ func TestActivationHandlerProxyHeader(t *testing.T) {
 		endpointsInformer(endpoints(namespace, revName, breakerParams.InitialCapacity)),
 		sksLister(sks(namespace, revName)),
 		revisionLister(revision(namespace, revName)),
+		"", /*selfIP*/
 		TestLogger(t))
 
 	fakeRT := activatortest.FakeRoundTripper{
@@ -502,6 +507,100 @@ func TestActivationHandlerProxyHeader(t *testing.T) {
 	}
 }
 
+// Make sure we return 429 with Retry-After when a revision exceeds its buffer limit
+func TestActivationHandlerBufferLimit(t *testing.T) {
+	defer ClearAll()
+	const requests = 2
+	respCh := make(chan *httptest.ResponseRecorder, requests)
+	namespace := testNamespace
+	revName := testRevName
+
+	// Without endpoints, the requests are buffered until they time out.
+	throttler := activator.NewThrottler(
+		queue.BreakerParams{QueueDepth: 10, MaxConcurrency: 10, InitialCapacity: 0},
+		endpointsInformer(endpoints(namespace, revName, 0)),
+		sksLister(sks(namespace, revName)),
+		revisionLister(revision(namespace, revName)),
+		"", /*selfIP*/
+		TestLogger(t))
+	throttler.UpdateBufferLimits(activator.BufferLimits{PerRevision: 1})
+
+	handler := (New(TestLogger(t), &fakeReporter{}, throttler,
+		revisionLister(revision(namespace, revName)),
+		serviceLister(service(namespace, revName, "http")),
+		sksLister(sks(namespace, revName)),
+	)).(*activationHandler)
+	handler.endpointTimeout = 200 * time.Millisecond
+
+	sendRequests(requests, namespace, revName, respCh, *handler)
+	codes := make(map[int]int)
+	for i := 0; i < requests; i++ {
+		select {
+		case resp := <-respCh:
+			codes[resp.Code]++
+			if resp.Code == http.StatusTooManyRequests {
+				if got, want := resp.Header().Get("Retry-After"), "1"; got != want {
+					t.Errorf("Retry-After = %q, want: %q", got, want)
+				}
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("Timed out waiting for a response")
+		}
+	}
+	want := map[int]int{http.StatusTooManyRequests: 1, http.StatusServiceUnavailable: 1}
+	if diff := cmp.Diff(want, codes); diff != "" {
+		t.Errorf("Response codes (-want, +got): %s", diff)
+	}
+}
+
+func TestActivationHandlerTimeoutsFromConfig(t *testing.T) {
+	defer ClearAll()
+	handler := (New(TestLogger(t), &fakeReporter{}, nil, nil, nil, nil)).(*activationHandler)
+
+	probeTimeout, endpointTimeout := handler.timeouts(context.Background())
+	if probeTimeout != activatorconfig.DefaultProbeTimeout || endpointTimeout != activatorconfig.DefaultEndpointTimeout {
+		t.Errorf("timeouts() = %v, %v, want: %v, %v", probeTimeout, endpointTimeout,
+			activatorconfig.DefaultProbeTimeout, activatorconfig.DefaultEndpointTimeout)
+	}
+
+	store := activatorconfig.NewStore(TestLogger(t))
+	store.OnConfigChanged(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: tracingconfig.ConfigName},
+	})
+	store.OnConfigChanged(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: activatorconfig.ActivatorConfigName},
+		Data: map[string]string{
+			"probe-timeout":    "5s",
+			"endpoint-timeout": "7s",
+		},
+	})
+	probeTimeout, endpointTimeout = handler.timeouts(store.ToContext(context.Background()))
+	if probeTimeout != 5*time.Second || endpointTimeout != 7*time.Second {
+		t.Errorf("timeouts() = %v, %v, want: 5s, 7s", probeTimeout, endpointTimeout)
+	}
+}
+
+func TestBufferRetryAfter(t *testing.T) {
+	if got, want := bufferRetryAfter(context.Background()), "1"; got != want {
+		t.Errorf("bufferRetryAfter() = %q, want: %q", got, want)
+	}
+
+	store := activatorconfig.NewStore(TestLogger(t))
+	store.OnConfigChanged(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: tracingconfig.ConfigName},
+	})
+	store.OnConfigChanged(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: activatorconfig.ActivatorConfigName},
+		Data: map[string]string{
+			"buffer-retry-after": "2500ms",
+		},
+	})
+	// Retry-After is rounded up to whole seconds.
+	if got, want := bufferRetryAfter(store.ToContext(context.Background())), "3"; got != want {
+		t.Errorf("bufferRetryAfter() = %q, want: %q", got, want)
+	}
+}
+
 func TestActivationHandlerTraceSpans(t *testing.T) {
 	// Setup transport
 	fakeRt := activatortest.FakeRoundTripper{
@@ -539,13 +638,15 @@ func TestActivationHandlerTraceSpans(t *testing.T) {
 		endpointsInformer(endpoints(namespace, revName, breakerParams.InitialCapacity)),
 		sksLister(sks(namespace, revName)),
 		revisionLister(revision(namespace, revName)),
+		"", /*selfIP*/
 		TestLogger(t))
 
+	statsReporter := &fakeReporter{}
 	handler := activationHandler{
 		transport:             rt,
 		probeTransportFactory: rtFact(rt),
 		logger:                TestLogger(t),
-		reporter:              &fakeReporter{},
+		reporter:              statsReporter,
 		throttler:             throttler,
 		revisionLister:        revisionLister(revision(testNamespace, testRevName)),
 		serviceLister:         serviceLister(service(testNamespace, testRevName, "http")),
@@ -566,6 +667,29 @@ func TestActivationHandlerTraceSpans(t *testing.T) {
 			t.Errorf("Got span %d named %q, expected %q", i, gotSpans[i].Name, spanName)
 		}
 	}
+	for _, i := range []int{0, 1, 3} {
+		if got, want := gotSpans[i].Tags[coldAttribute], "false"; got != want {
+			t.Errorf("Span %q has %s = %q, want: %q", gotSpans[i].Name, coldAttribute, got, want)
+		}
+	}
+	if got, want := gotSpans[1].Tags["activator.probe.attempts"], "1"; got != want {
+		t.Errorf("Probe attempts = %q, want: %q", got, want)
+	}
+
+	// The revision has capacity, so there's no wait for endpoints.
+	wantPhases := []phaseCall{{
+		Revision: revName,
+		Phase:    activator.PhaseThrottlerWait,
+	}, {
+		Revision: revName,
+		Phase:    activator.PhaseProbe,
+	}, {
+		Revision: revName,
+		Phase:    activator.PhaseProxy,
+	}}
+	if diff := cmp.Diff(wantPhases, statsReporter.phases); diff != "" {
+		t.Errorf("Reported phases (-want, +got): %s", diff)
+	}
 }
 
 func sendRequest(namespace, revName string, handler activationHandler) *httptest.ResponseRecorder {
@@ -665,9 +789,17 @@ type reporterCall struct {
 	Duration   time.Duration
 }
 
+type phaseCall struct {
+	Revision string
+	Phase    string
+	Cold     bool
+}
+
 type fakeReporter struct {
-	calls []reporterCall
-	mux   sync.Mutex
+	calls   []reporterCall
+	phases  []phaseCall
+	retries []string
+	mux     sync.Mutex
 }
 
 func (f *fakeReporter) ReportRequestCount(ns, service, config, rev string, responseCode, numTries int, v int64) error {
@@ -703,6 +835,26 @@ func (f *fakeReporter) ReportResponseTime(ns, service, config, rev string, respo
 	return nil
 }
 
+func (f *fakeReporter) ReportPhaseTime(ns, service, config, rev, phase string, cold bool, d time.Duration) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.phases = append(f.phases, phaseCall{
+		Revision: rev,
+		Phase:    phase,
+		Cold:     cold,
+	})
+
+	return nil
+}
+
+func (f *fakeReporter) ReportRetry(ns, service, config, rev, reason string) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.retries = append(f.retries, reason)
+
+	return nil
+}
+
 func revision(namespace, name string) *v1alpha1.Revision {
 	return &v1alpha1.Revision{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,163 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+const (
+	// retryReasonDial is the reason of retrying a request whose connection
+	// to the upstream failed.
+	retryReasonDial = "dial_error"
+	// retryReasonStatus is the reason of retrying an idempotent request
+	// which got one of the retryable status codes.
+	retryReasonStatus = "status_code"
+
+	// maxDrainBytes is how much of the body of a response that is retried
+	// is read, so that its connection can be reused.
+	maxDrainBytes = 4 << 10
+)
+
+// retryTransport is an http.RoundTripper which re-dispatches the requests
+// that are safe to be sent again: any request whose connection to the
+// upstream failed, since nothing of it reached the upstream, and idempotent
+// requests without a body which got one of the status codes.
+type retryTransport struct {
+	base http.RoundTripper
+	// retries is the number of times a single request may be re-dispatched.
+	retries     int
+	statusCodes []int
+	// fallbackHost is the host the retries are sent to, e.g. a Service
+	// rather than the pod which may have gone away.  Empty means the
+	// original host.
+	fallbackHost string
+	// onRetry is called with the reason of every retry.
+	onRetry func(reason string)
+}
+
+// replayableBody tracks whether a request body was read, and keeps it from
+// being closed by the transport, so that it can be sent again if it wasn't.
+type replayableBody struct {
+	io.ReadCloser
+	read int32
+}
+
+func (b *replayableBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		atomic.StoreInt32(&b.read, 1)
+	}
+	return n, err
+}
+
+// Close is a no-op; the body is closed by the server once the request is done.
+func (b *replayableBody) Close() error {
+	return nil
+}
+
+func (b *replayableBody) wasRead() bool {
+	return atomic.LoadInt32(&b.read) == 1
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	var body *replayableBody
+	if r.Body != nil && r.Body != http.NoBody {
+		body = &replayableBody{ReadCloser: r.Body}
+	}
+
+	req := r
+	for attempt := 0; ; attempt++ {
+		if body != nil {
+			req = withBody(req, body)
+		}
+		resp, err := t.base.RoundTrip(req)
+
+		if attempt >= t.retries {
+			return resp, err
+		}
+		var reason string
+		if err != nil {
+			if !isDialError(err) || (body != nil && body.wasRead()) {
+				return resp, err
+			}
+			reason = retryReasonDial
+		} else {
+			if body != nil || !isIdempotent(r.Method) || !t.retryable(resp.StatusCode) {
+				return resp, err
+			}
+			io.CopyN(ioutil.Discard, resp.Body, maxDrainBytes)
+			resp.Body.Close()
+			reason = retryReasonStatus
+		}
+
+		if t.onRetry != nil {
+			t.onRetry(reason)
+		}
+		if t.fallbackHost != "" && req.URL.Host != t.fallbackHost {
+			req = withHost(req, t.fallbackHost)
+		}
+	}
+}
+
+func (t *retryTransport) retryable(statusCode int) bool {
+	for _, code := range t.statusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// withBody returns a shallow copy of r sending body.
+func withBody(r *http.Request, body io.ReadCloser) *http.Request {
+	req := r.WithContext(r.Context())
+	req.Body = body
+	return req
+}
+
+// withHost returns a shallow copy of r sent to host.
+func withHost(r *http.Request, host string) *http.Request {
+	req := r.WithContext(r.Context())
+	u := *r.URL
+	u.Host = host
+	req.URL = &u
+	return req
+}
+
+// isDialError returns whether err is a failure to connect to the upstream,
+// before any part of the request was sent.
+func isDialError(err error) bool {
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
+}
+
+// isIdempotent returns whether requests with the given method may be sent
+// more than once, per RFC 7231.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/knative/serving/pkg/network"
+)
+
+// attempt is the outcome of a single round trip of the fake transport.
+type attempt struct {
+	code int
+	err  error
+	// readBody makes the transport consume the request body.
+	readBody bool
+}
+
+type scriptedTransport struct {
+	attempts []attempt
+	hosts    []string
+	bodies   []string
+}
+
+func (t *scriptedTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	a := t.attempts[len(t.hosts)]
+	t.hosts = append(t.hosts, r.URL.Host)
+	if r.Body != http.NoBody && (a.err == nil || a.readBody) {
+		b, _ := ioutil.ReadAll(r.Body)
+		t.bodies = append(t.bodies, string(b))
+	}
+	r.Body.Close()
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &http.Response{
+		StatusCode: a.code,
+		Body:       ioutil.NopCloser(strings.NewReader("body")),
+	}, nil
+}
+
+func TestRetryTransport(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	resetErr := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+
+	tests := []struct {
+		name        string
+		method      string
+		body        string
+		attempts    []attempt
+		wantCode    int
+		wantErr     bool
+		wantHosts   []string
+		wantRetries []string
+		wantBodies  []string
+	}{{
+		name:        "dial error is retried on the fallback host",
+		method:      http.MethodPost,
+		attempts:    []attempt{{err: dialErr}, {code: http.StatusOK}},
+		wantCode:    http.StatusOK,
+		wantHosts:   []string{"pod", "service"},
+		wantRetries: []string{retryReasonDial},
+	}, {
+		name:        "dial error with an unread body",
+		method:      http.MethodPost,
+		body:        "payload",
+		attempts:    []attempt{{err: dialErr}, {code: http.StatusOK}},
+		wantCode:    http.StatusOK,
+		wantHosts:   []string{"pod", "service"},
+		wantRetries: []string{retryReasonDial},
+		wantBodies:  []string{"payload"},
+	}, {
+		name:       "error after the body was read",
+		method:     http.MethodPost,
+		body:       "payload",
+		attempts:   []attempt{{err: dialErr, readBody: true}},
+		wantErr:    true,
+		wantHosts:  []string{"pod"},
+		wantBodies: []string{"payload"},
+	}, {
+		name:      "connection reset is not retried",
+		method:    http.MethodGet,
+		attempts:  []attempt{{err: resetErr}},
+		wantErr:   true,
+		wantHosts: []string{"pod"},
+	}, {
+		name:        "idempotent request on a retryable status",
+		method:      http.MethodGet,
+		attempts:    []attempt{{code: http.StatusServiceUnavailable}, {code: http.StatusOK}},
+		wantCode:    http.StatusOK,
+		wantHosts:   []string{"pod", "service"},
+		wantRetries: []string{retryReasonStatus},
+	}, {
+		name:      "idempotent request on another status",
+		method:    http.MethodGet,
+		attempts:  []attempt{{code: http.StatusGatewayTimeout}},
+		wantCode:  http.StatusGatewayTimeout,
+		wantHosts: []string{"pod"},
+	}, {
+		name:      "non-idempotent request on a retryable status",
+		method:    http.MethodPost,
+		attempts:  []attempt{{code: http.StatusServiceUnavailable}},
+		wantCode:  http.StatusServiceUnavailable,
+		wantHosts: []string{"pod"},
+	}, {
+		name:       "idempotent request with a body on a retryable status",
+		method:     http.MethodPut,
+		body:       "payload",
+		attempts:   []attempt{{code: http.StatusServiceUnavailable}},
+		wantCode:   http.StatusServiceUnavailable,
+		wantHosts:  []string{"pod"},
+		wantBodies: []string{"payload"},
+	}, {
+		name:        "retry budget is exhausted",
+		method:      http.MethodGet,
+		attempts:    []attempt{{err: dialErr}, {err: dialErr}, {err: dialErr}},
+		wantErr:     true,
+		wantHosts:   []string{"pod", "service", "service"},
+		wantRetries: []string{retryReasonDial, retryReasonDial},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			base := &scriptedTransport{attempts: test.attempts}
+			var retries []string
+			rt := &retryTransport{
+				base:         base,
+				retries:      2,
+				statusCodes:  []int{http.StatusBadGateway, http.StatusServiceUnavailable},
+				fallbackHost: "service",
+				onRetry: func(reason string) {
+					retries = append(retries, reason)
+				},
+			}
+
+			var body io.Reader
+			if test.body != "" {
+				body = strings.NewReader(test.body)
+			}
+			req := httptest.NewRequest(test.method, "http://pod/", body)
+			req.URL.Host = "pod"
+			resp, err := rt.RoundTrip(req)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("RoundTrip() = %v, wantErr: %v", err, test.wantErr)
+			}
+			if err == nil && resp.StatusCode != test.wantCode {
+				t.Errorf("StatusCode = %d, want: %d", resp.StatusCode, test.wantCode)
+			}
+			if diff := cmp.Diff(test.wantHosts, base.hosts); diff != "" {
+				t.Errorf("Hosts (-want, +got): %s", diff)
+			}
+			if diff := cmp.Diff(test.wantRetries, retries); diff != "" {
+				t.Errorf("Retries (-want, +got): %s", diff)
+			}
+			if diff := cmp.Diff(test.wantBodies, base.bodies); diff != "" {
+				t.Errorf("Bodies (-want, +got): %s", diff)
+			}
+			if req.URL.Host != "pod" {
+				t.Errorf("The original request was modified: %s", req.URL.Host)
+			}
+		})
+	}
+}
+
+func TestIsDialError(t *testing.T) {
+	if !isDialError(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}) {
+		t.Error("isDialError(dial) = false, want: true")
+	}
+	if isDialError(errors.New("dial tcp: connection refused")) {
+		t.Error("isDialError(plain error) = true, want: false")
+	}
+}
+
+func TestRetryTransportBlackholedPod(t *testing.T) {
+	// A pod which went away silently drops the connection attempts, so
+	// dialing it only ever times out.  Use a special testing IP address.
+	const blackhole = "198.18.0.254:8888"
+	if c, err := net.DialTimeout("tcp", blackhole, 100*time.Millisecond); err == nil {
+		c.Close()
+		t.Skipf("%s is reachable from this environment", blackhole)
+	}
+
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer service.Close()
+
+	var retries []string
+	rt := &retryTransport{
+		base:         network.NewAutoTransport(),
+		retries:      1,
+		fallbackHost: strings.TrimPrefix(service.URL, "http://"),
+		onRetry: func(reason string) {
+			retries = append(retries, reason)
+		},
+	}
+	req := httptest.NewRequest(http.MethodPost, "http://"+blackhole, strings.NewReader("body"))
+	req.RequestURI = ""
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() = %v", err)
+	}
+	resp.Body.Close()
+	if diff := cmp.Diff([]string{retryReasonDial}, retries); diff != "" {
+		t.Errorf("Retries (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2019 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/knative/serving/pkg/activator"
+)
+
+func TestThrottlerStateHandler(t *testing.T) {
+	state := activator.ThrottlerState{
+		ActivatorCount: 2,
+		Revisions: []activator.RevisionState{{
+			Revision: "ns/rev",
+			Capacity: 10,
+			InFlight: 3,
+			Queued:   1,
+			Buffered: 4,
+			Pods: []activator.PodState{{
+				Dest:     "10.0.0.1:8012",
+				Capacity: 10,
+				InFlight: 3,
+			}},
+		}},
+	}
+	handler := &ThrottlerStateHandler{State: func() activator.ThrottlerState { return state }}
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://example.com/debug/throttler", nil))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Unexpected response status. Want %d, got %d", http.StatusOK, resp.Code)
+	}
+	if got, want := resp.Header().Get("Content-Type"), "application/json"; got != want {
+		t.Errorf("Content-Type = %q, want: %q", got, want)
+	}
+	var got activator.ThrottlerState
+	if err := json.Unmarshal(resp.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to decode the response: %v", err)
+	}
+	if !cmp.Equal(state, got) {
+		t.Errorf("ThrottlerState (-want, +got): %s", cmp.Diff(state, got))
+	}
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "http://example.com/debug/throttler", nil))
+	if resp.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Unexpected response status. Want %d, got %d", http.StatusMethodNotAllowed, resp.Code)
+	}
+}
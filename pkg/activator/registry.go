@@ -0,0 +1,147 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package activator
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+)
+
+// registryShards is the number of shards of a throttlerRegistry.  With
+// thousands of revisions, a write copies a few dozens of them.
+const registryShards = 256
+
+// registryShard holds a copy-on-write map of revisionThrottlers, which is
+// read without locking and replaced as a whole by the writers.
+type registryShard struct {
+	// mux serializes the writers of throttlers.
+	mux sync.Mutex
+	// throttlers holds a map[RevisionID]*revisionThrottler, which must not
+	// be modified once stored.
+	throttlers atomic.Value
+}
+
+func (s *registryShard) load() map[RevisionID]*revisionThrottler {
+	return s.throttlers.Load().(map[RevisionID]*revisionThrottler)
+}
+
+// store replaces the map of the shard with a copy of it modified by mutate.
+// mux must be held to call it.
+func (s *registryShard) store(mutate func(map[RevisionID]*revisionThrottler)) {
+	old := s.load()
+	throttlers := make(map[RevisionID]*revisionThrottler, len(old)+1)
+	for rev, rt := range old {
+		throttlers[rev] = rt
+	}
+	mutate(throttlers)
+	s.throttlers.Store(throttlers)
+}
+
+// throttlerRegistry maps the revisions to their revisionThrottlers.  The
+// revisions are spread over shards, so that adding or removing one copies
+// only a fraction of them, and looking one up never blocks.
+type throttlerRegistry struct {
+	shards [registryShards]registryShard
+}
+
+func newThrottlerRegistry() *throttlerRegistry {
+	r := &throttlerRegistry{}
+	for i := range r.shards {
+		r.shards[i].throttlers.Store(map[RevisionID]*revisionThrottler{})
+	}
+	return r
+}
+
+func (r *throttlerRegistry) shard(rev RevisionID) *registryShard {
+	h := fnv.New32a()
+	h.Write([]byte(rev.Namespace))
+	h.Write([]byte{'/'})
+	h.Write([]byte(rev.Name))
+	return &r.shards[h.Sum32()%registryShards]
+}
+
+// get returns the revisionThrottler of the given revision, if there's one.
+func (r *throttlerRegistry) get(rev RevisionID) (*revisionThrottler, bool) {
+	rt, ok := r.shard(rev).load()[rev]
+	return rt, ok
+}
+
+// getOrCreate returns the revisionThrottler of the given revision, storing
+// the one returned by create if there's none, and whether it existed.
+func (r *throttlerRegistry) getOrCreate(rev RevisionID, create func() *revisionThrottler) (*revisionThrottler, bool) {
+	s := r.shard(rev)
+	if rt, ok := s.load()[rev]; ok {
+		return rt, true
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	// Another writer may have created it in the meantime.
+	if rt, ok := s.load()[rev]; ok {
+		return rt, true
+	}
+	rt := create()
+	s.store(func(throttlers map[RevisionID]*revisionThrottler) {
+		throttlers[rev] = rt
+	})
+	return rt, false
+}
+
+// remove deletes the revisionThrottler of the given revision.
+func (r *throttlerRegistry) remove(rev RevisionID) {
+	s := r.shard(rev)
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if _, ok := s.load()[rev]; !ok {
+		return
+	}
+	s.store(func(throttlers map[RevisionID]*revisionThrottler) {
+		delete(throttlers, rev)
+	})
+}
+
+// clear deletes all the revisionThrottlers.
+func (r *throttlerRegistry) clear() {
+	for i := range r.shards {
+		s := &r.shards[i]
+		s.mux.Lock()
+		s.throttlers.Store(map[RevisionID]*revisionThrottler{})
+		s.mux.Unlock()
+	}
+}
+
+// snapshot returns all the revisionThrottlers.  It reflects the registry
+// of every shard at some point during the call.
+func (r *throttlerRegistry) snapshot() map[RevisionID]*revisionThrottler {
+	all := make(map[RevisionID]*revisionThrottler)
+	for i := range r.shards {
+		for rev, rt := range r.shards[i].load() {
+			all[rev] = rt
+		}
+	}
+	return all
+}
+
+// len returns the number of revisionThrottlers.
+func (r *throttlerRegistry) len() int {
+	n := 0
+	for i := range r.shards {
+		n += len(r.shards[i].load())
+	}
+	return n
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package activator
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/knative/serving/pkg/apis/networking"
+	nv1a1 "github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving/v1beta1"
+	servingfake "github.com/knative/serving/pkg/client/clientset/versioned/fake"
+	servinginformers "github.com/knative/serving/pkg/client/informers/externalversions"
+	"github.com/knative/serving/pkg/queue"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+var benchmarkSizes = []int{100, 1000, 10000}
+
+func TestThrottlerRegistry(t *testing.T) {
+	r := newThrottlerRegistry()
+	a := RevisionID{Namespace: "a", Name: "1"}
+	b := RevisionID{Namespace: "b", Name: "1"}
+	newRT := func() *revisionThrottler { return &revisionThrottler{} }
+
+	if _, ok := r.get(a); ok {
+		t.Error("get() = true for an empty registry")
+	}
+	rt, existed := r.getOrCreate(a, newRT)
+	if existed {
+		t.Error("getOrCreate() = true for a new revision")
+	}
+	if got, existed := r.getOrCreate(a, newRT); !existed || got != rt {
+		t.Errorf("getOrCreate() = %p, %v, want: %p, true", got, existed, rt)
+	}
+	if got, ok := r.get(a); !ok || got != rt {
+		t.Errorf("get() = %p, %v, want: %p, true", got, ok, rt)
+	}
+	r.getOrCreate(b, newRT)
+	if got, want := r.len(), 2; got != want {
+		t.Errorf("len() = %d, want: %d", got, want)
+	}
+	if got := r.snapshot(); len(got) != 2 || got[a] != rt {
+		t.Errorf("snapshot() = %v, want both revisions", got)
+	}
+
+	r.remove(a)
+	if _, ok := r.get(a); ok {
+		t.Error("get() = true after remove()")
+	}
+	// Removing an unknown revision is a no-op.
+	r.remove(a)
+	if got, want := r.len(), 1; got != want {
+		t.Errorf("len() = %d, want: %d", got, want)
+	}
+
+	r.clear()
+	if got := r.len(); got != 0 {
+		t.Errorf("len() = %d after clear(), want: 0", got)
+	}
+}
+
+func TestThrottlerRegistryConcurrentCreate(t *testing.T) {
+	r := newThrottlerRegistry()
+	rev := RevisionID{Namespace: "a", Name: "1"}
+	const writers = 10
+	rts := make(chan *revisionThrottler, writers)
+	var wg sync.WaitGroup
+	wg.Add(writers)
+	for i := 0; i < writers; i++ {
+		go func() {
+			defer wg.Done()
+			rt, _ := r.getOrCreate(rev, func() *revisionThrottler { return &revisionThrottler{} })
+			rts <- rt
+		}()
+	}
+	wg.Wait()
+	close(rts)
+	first := <-rts
+	for rt := range rts {
+		if rt != first {
+			t.Fatal("getOrCreate() created more than one revisionThrottler")
+		}
+	}
+}
+
+func revisionIDs(n int) []RevisionID {
+	revs := make([]RevisionID, n)
+	for i := range revs {
+		revs[i] = RevisionID{Namespace: fmt.Sprintf("ns-%d", i%100), Name: fmt.Sprintf("rev-%d", i)}
+	}
+	return revs
+}
+
+func BenchmarkThrottlerRegistryGet(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("%d-revisions", n), func(b *testing.B) {
+			r := newThrottlerRegistry()
+			revs := revisionIDs(n)
+			for _, rev := range revs {
+				r.getOrCreate(rev, func() *revisionThrottler { return &revisionThrottler{} })
+			}
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					r.get(revs[i%n])
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkThrottlerRegistryCreate(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("%d-revisions", n), func(b *testing.B) {
+			revs := revisionIDs(n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r := newThrottlerRegistry()
+				for _, rev := range revs {
+					r.getOrCreate(rev, func() *revisionThrottler { return &revisionThrottler{} })
+				}
+			}
+		})
+	}
+}
+
+// benchmarkThrottler returns a Throttler knowing n revisions, each of which
+// has a single ready pod.
+func benchmarkThrottler(b *testing.B, n int) (*Throttler, []RevisionID) {
+	revs := revisionIDs(n)
+	servingInformer := servinginformers.NewSharedInformerFactory(servingfake.NewSimpleClientset(), 0)
+	revisions := servingInformer.Serving().V1alpha1().Revisions()
+	skss := servingInformer.Networking().V1alpha1().ServerlessServices()
+	endpoints := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 0).Core().V1().Endpoints()
+
+	for i, rev := range revs {
+		meta := metav1.ObjectMeta{Namespace: rev.Namespace, Name: rev.Name}
+		revisions.Informer().GetIndexer().Add(&v1alpha1.Revision{
+			ObjectMeta: meta,
+			Spec: v1alpha1.RevisionSpec{
+				RevisionSpec: v1beta1.RevisionSpec{ContainerConcurrency: 10},
+			},
+		})
+		skss.Informer().GetIndexer().Add(&nv1a1.ServerlessService{
+			ObjectMeta: meta,
+			Status:     nv1a1.ServerlessServiceStatus{PrivateServiceName: rev.Name},
+		})
+		endpoints.Informer().GetIndexer().Add(&corev1.Endpoints{
+			ObjectMeta: meta,
+			Subsets: []corev1.EndpointSubset{{
+				Addresses: []corev1.EndpointAddress{{IP: fmt.Sprintf("10.%d.%d.%d", i>>16&0xff, i>>8&0xff, i&0xff)}},
+				Ports:     []corev1.EndpointPort{{Name: networking.ServicePortNameHTTP1, Port: 8012}},
+			}},
+		})
+	}
+
+	throttler := NewThrottler(queue.BreakerParams{
+		QueueDepth:     defaultMaxConcurrency,
+		MaxConcurrency: defaultMaxConcurrency,
+	}, endpoints, skss.Lister(), revisions.Lister(), "", zap.NewNop().Sugar())
+	for _, rev := range revs {
+		if err := throttler.Try(0, rev, func(string, TryStats) {}); err != nil {
+			b.Fatalf("Try(%v) = %v", rev, err)
+		}
+	}
+	return throttler, revs
+}
+
+func BenchmarkThrottlerTry(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("%d-revisions", n), func(b *testing.B) {
+			throttler, revs := benchmarkThrottler(b, n)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					throttler.Try(0, revs[i%n], func(string, TryStats) {})
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkThrottlerTryActivatorChurn measures the requests while the
+// activators keep changing, each change recomputing the capacity of every
+// revision.
+func BenchmarkThrottlerTryActivatorChurn(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("%d-revisions", n), func(b *testing.B) {
+			throttler, revs := benchmarkThrottler(b, n)
+			stopCh := make(chan struct{})
+			doneCh := make(chan struct{})
+			go func() {
+				defer close(doneCh)
+				for i := 1; ; i++ {
+					select {
+					case <-stopCh:
+						return
+					default:
+					}
+					throttler.activatorEndpointsUpdated(&corev1.Endpoints{
+						Subsets: []corev1.EndpointSubset{{
+							Addresses: make([]corev1.EndpointAddress, 1+i%3),
+						}},
+					})
+				}
+			}()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					throttler.Try(0, revs[i%n], func(string, TryStats) {})
+				}
+			})
+			b.StopTimer()
+			close(stopCh)
+			<-doneCh
+		})
+	}
+}
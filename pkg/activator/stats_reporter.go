@@ -38,14 +38,38 @@ var (
 		"request_latencies",
 		"The response time in millisecond",
 		stats.UnitMilliseconds)
+	retryCountM = stats.Int64(
+		"request_retries",
+		"The number of times requests were retried by the Activator",
+		stats.UnitDimensionless)
+	phaseTimeInMsecM = stats.Float64(
+		"request_phase_latencies",
+		"The time spent in each phase of a request in millisecond",
+		stats.UnitMilliseconds)
 
 	defaultLatencyDistribution = view.Distribution(0, 5, 10, 20, 40, 60, 80, 100, 150, 200, 250, 300, 350, 400, 450, 500, 600, 700, 800, 900, 1000, 2000, 5000, 10000, 20000, 50000, 100000)
 )
 
+// The phases of a request in the activator, reported by ReportPhaseTime.
+const (
+	// PhaseEndpointsWait is the time a request to a revision without
+	// capacity waits for its pods to become ready.
+	PhaseEndpointsWait = "endpoints_wait"
+	// PhaseThrottlerWait is the time a request waits for a free slot
+	// in the Throttler otherwise.
+	PhaseThrottlerWait = "throttler_wait"
+	// PhaseProbe is the time spent probing the pod the request is sent to.
+	PhaseProbe = "probe"
+	// PhaseProxy is the time spent proxying the request to the pod.
+	PhaseProxy = "proxy"
+)
+
 // StatsReporter defines the interface for sending activator metrics
 type StatsReporter interface {
 	ReportRequestCount(ns, service, config, rev string, responseCode, numTries int, v int64) error
 	ReportResponseTime(ns, service, config, rev string, responseCode int, d time.Duration) error
+	ReportPhaseTime(ns, service, config, rev, phase string, cold bool, d time.Duration) error
+	ReportRetry(ns, service, config, rev, reason string) error
 }
 
 // Reporter holds cached metric objects to report autoscaler metrics
@@ -58,6 +82,9 @@ type Reporter struct {
 	responseCodeKey      tag.Key
 	responseCodeClassKey tag.Key
 	numTriesKey          tag.Key
+	phaseKey             tag.Key
+	startKey             tag.Key
+	reasonKey            tag.Key
 }
 
 // NewStatsReporter creates a reporter that collects and reports activator metrics
@@ -100,6 +127,21 @@ func NewStatsReporter() (*Reporter, error) {
 		return nil, err
 	}
 	r.numTriesKey = numTriesTag
+	phaseTag, err := tag.NewKey("phase")
+	if err != nil {
+		return nil, err
+	}
+	r.phaseKey = phaseTag
+	startTag, err := tag.NewKey("start")
+	if err != nil {
+		return nil, err
+	}
+	r.startKey = startTag
+	reasonTag, err := tag.NewKey("reason")
+	if err != nil {
+		return nil, err
+	}
+	r.reasonKey = reasonTag
 	// Create view to see our measurements.
 	err = view.Register(
 		&view.View{
@@ -114,6 +156,18 @@ func NewStatsReporter() (*Reporter, error) {
 			Aggregation: defaultLatencyDistribution,
 			TagKeys:     []tag.Key{r.namespaceTagKey, r.serviceTagKey, r.configTagKey, r.revisionTagKey, r.responseCodeClassKey, r.responseCodeKey},
 		},
+		&view.View{
+			Description: "The time spent in each phase of a request in millisecond",
+			Measure:     phaseTimeInMsecM,
+			Aggregation: defaultLatencyDistribution,
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.serviceTagKey, r.configTagKey, r.revisionTagKey, r.phaseKey, r.startKey},
+		},
+		&view.View{
+			Description: "The number of times requests were retried by the Activator",
+			Measure:     retryCountM,
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.serviceTagKey, r.configTagKey, r.revisionTagKey, r.reasonKey},
+		},
 	)
 	if err != nil {
 		return nil, err
@@ -178,6 +232,61 @@ func (r *Reporter) ReportResponseTime(ns, service, config, rev string, responseC
 	return nil
 }
 
+// ReportPhaseTime captures the time spent in a phase of a request, which is
+// tagged with whether the revision had to be started for the request.
+func (r *Reporter) ReportPhaseTime(ns, service, config, rev, phase string, cold bool, d time.Duration) error {
+	if !r.initialized {
+		return errors.New("StatsReporter is not initialized yet")
+	}
+
+	// Note that service names can be an empty string, so it needs a special treatment.
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(r.namespaceTagKey, ns),
+		tag.Insert(r.serviceTagKey, valueOrUnknown(service)),
+		tag.Insert(r.configTagKey, config),
+		tag.Insert(r.revisionTagKey, rev),
+		tag.Insert(r.phaseKey, phase),
+		tag.Insert(r.startKey, startType(cold)))
+	if err != nil {
+		return err
+	}
+
+	// convert time.Duration in nanoseconds to milliseconds
+	metrics.Record(ctx, phaseTimeInMsecM.M(float64(d/time.Millisecond)))
+	return nil
+}
+
+// ReportRetry captures a retry of a request for the given reason.
+func (r *Reporter) ReportRetry(ns, service, config, rev, reason string) error {
+	if !r.initialized {
+		return errors.New("StatsReporter is not initialized yet")
+	}
+
+	// Note that service names can be an empty string, so it needs a special treatment.
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(r.namespaceTagKey, ns),
+		tag.Insert(r.serviceTagKey, valueOrUnknown(service)),
+		tag.Insert(r.configTagKey, config),
+		tag.Insert(r.revisionTagKey, rev),
+		tag.Insert(r.reasonKey, reason))
+	if err != nil {
+		return err
+	}
+
+	metrics.Record(ctx, retryCountM.M(1))
+	return nil
+}
+
+// startType returns the value of the start tag.
+func startType(cold bool) string {
+	if cold {
+		return "cold"
+	}
+	return "warm"
+}
+
 // responseCodeClass converts response code to a string of response code class.
 // e.g. The response code class is "5xx" for response code 503.
 func responseCodeClass(responseCode int) string {
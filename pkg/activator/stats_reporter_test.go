@@ -33,6 +33,8 @@ func unregister() {
 	for _, s := range []string{
 		"request_count",
 		"request_latencies",
+		"request_phase_latencies",
+		"request_retries",
 	} {
 		if v := view.Find(s); v != nil {
 			view.Unregister(v)
@@ -127,6 +129,43 @@ func TestReportResponseTimeEmptyServiceName(t *testing.T) {
 	checkDistributionData(t, "request_latencies", wantTags, 2, 5100.0, 7100.0)
 }
 
+func TestReportPhaseTime(t *testing.T) {
+	r, _ := NewStatsReporter()
+	defer unregister()
+
+	wantTags := map[string]string{
+		metricskey.LabelNamespaceName:     "testns",
+		metricskey.LabelServiceName:       "testsvc",
+		metricskey.LabelConfigurationName: "testconfig",
+		metricskey.LabelRevisionName:      "testrev",
+		"phase":                           PhaseEndpointsWait,
+		"start":                           "cold",
+	}
+	expectSuccess(t, func() error {
+		return r.ReportPhaseTime("testns", "testsvc", "testconfig", "testrev", PhaseEndpointsWait, true, 3100*time.Millisecond)
+	})
+	expectSuccess(t, func() error {
+		return r.ReportPhaseTime("testns", "testsvc", "testconfig", "testrev", PhaseEndpointsWait, true, 4100*time.Millisecond)
+	})
+	checkDistributionData(t, "request_phase_latencies", wantTags, 2, 3100.0, 4100.0)
+}
+
+func TestReportRetry(t *testing.T) {
+	r, _ := NewStatsReporter()
+	defer unregister()
+
+	wantTags := map[string]string{
+		metricskey.LabelNamespaceName:     "testns",
+		metricskey.LabelServiceName:       "testsvc",
+		metricskey.LabelConfigurationName: "testconfig",
+		metricskey.LabelRevisionName:      "testrev",
+		"reason":                          "dial_error",
+	}
+	expectSuccess(t, func() error { return r.ReportRetry("testns", "testsvc", "testconfig", "testrev", "dial_error") })
+	expectSuccess(t, func() error { return r.ReportRetry("testns", "testsvc", "testconfig", "testrev", "dial_error") })
+	checkSumData(t, "request_retries", wantTags, 2)
+}
+
 func expectSuccess(t *testing.T, f func() error) {
 	t.Helper()
 	if err := f(); err != nil {
@@ -17,7 +17,12 @@ limitations under the License.
 package activator
 
 import (
+	"context"
 	"errors"
+	"hash/fnv"
+	"net"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -28,6 +33,7 @@ import (
 	"knative.dev/pkg/system"
 	"github.com/knative/serving/pkg/apis/networking"
 	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	netlisters "github.com/knative/serving/pkg/client/listers/networking/v1alpha1"
 	servinglisters "github.com/knative/serving/pkg/client/listers/serving/v1alpha1"
 	"github.com/knative/serving/pkg/queue"
@@ -43,41 +49,351 @@ import (
 // ErrActivatorOverload indicates that throttler has no free slots to buffer the request.
 var ErrActivatorOverload = errors.New("activator overload")
 
+// podTracker tracks the requests this activator has in flight to a single
+// pod of a revision.  Its breaker enforces the container concurrency of the
+// revision on that pod.
+type podTracker struct {
+	dest    string
+	breaker *queue.Breaker
+	// inFlight is guarded by the revisionThrottler's mux.
+	inFlight int
+}
+
+// free returns the capacity of the pod left for the requests of this
+// activator.  It must be called with the revisionThrottler's mux held.
+func (pt *podTracker) free() int {
+	return pt.breaker.Capacity() - pt.inFlight
+}
+
+// revisionThrottler holds the Breaker queueing the requests of a revision
+// in excess of its capacity, and the pods these requests are balanced across.
+type revisionThrottler struct {
+	breaker *queue.Breaker
+
+	// updateMux serializes the updates of the pods and capacity, so that
+	// an update from stale endpoints can't overwrite a newer one.
+	updateMux sync.Mutex
+
+	// mux guards podTrackers and the inFlight counts of its elements,
+	// capacityTime and endpointsTime.
+	mux         sync.Mutex
+	podTrackers []*podTracker
+	// capacityTime is when the breaker last got capacity after it had none.
+	capacityTime time.Time
+	// endpointsTime is when the pods of the revision were last updated.
+	endpointsTime time.Time
+}
+
+// PodState is the state of the requests this activator sends to a pod.
+type PodState struct {
+	Dest     string `json:"dest"`
+	Capacity int    `json:"capacity"`
+	InFlight int    `json:"inFlight"`
+}
+
+// RevisionState is the state of the Breaker of a revision and of its pods.
+type RevisionState struct {
+	Revision string `json:"revision"`
+	Capacity int    `json:"capacity"`
+	InFlight int    `json:"inFlight"`
+	// Queued is the number of requests waiting for capacity in the Breaker.
+	Queued int `json:"queued"`
+	// Buffered is the number of requests counting against the BufferLimits.
+	Buffered int        `json:"buffered"`
+	Pods     []PodState `json:"pods"`
+	// EndpointsUpdateTime is when the pods were last updated, zero if never.
+	EndpointsUpdateTime time.Time `json:"endpointsUpdateTime"`
+}
+
+// ThrottlerState is a snapshot of the Throttler, for debugging.
+type ThrottlerState struct {
+	// ActivatorCount is the number of activators the capacity of the
+	// revisions is split across.
+	ActivatorCount int             `json:"activatorCount"`
+	Revisions      []RevisionState `json:"revisions"`
+}
+
+// TryStats breaks down the time a request waited in the Throttler.
+type TryStats struct {
+	// Cold is whether the revision had no capacity when the request arrived.
+	Cold bool
+	// EndpointsWait is how long a cold request waited for the revision to get
+	// capacity, i.e. for its pods to become ready.
+	EndpointsWait time.Duration
+	// ThrottlerWait is how long the request waited for a free slot otherwise.
+	ThrottlerWait time.Duration
+}
+
+// updateCapacity sets the capacity of the revision's breaker, noting when it
+// gets capacity after it had none.
+func (rt *revisionThrottler) updateCapacity(size int) error {
+	rt.mux.Lock()
+	defer rt.mux.Unlock()
+	had := rt.breaker.Capacity()
+	err := rt.breaker.UpdateConcurrency(size)
+	if had == 0 && rt.breaker.Capacity() > 0 {
+		rt.capacityTime = time.Now()
+	}
+	return err
+}
+
+// tryStats returns the TryStats of a request which arrived at start and got
+// a free slot now.
+func (rt *revisionThrottler) tryStats(start time.Time, cold bool) TryStats {
+	stats := TryStats{
+		Cold:          cold,
+		ThrottlerWait: time.Since(start),
+	}
+	if !cold {
+		return stats
+	}
+	rt.mux.Lock()
+	capacityTime := rt.capacityTime
+	rt.mux.Unlock()
+	if capacityTime.After(start) {
+		stats.EndpointsWait = capacityTime.Sub(start)
+		if stats.EndpointsWait > stats.ThrottlerWait {
+			stats.EndpointsWait = stats.ThrottlerWait
+		}
+		stats.ThrottlerWait -= stats.EndpointsWait
+	}
+	return stats
+}
+
+// acquireDest picks the pod with the most free capacity for the requests
+// of this activator, the one with the fewest requests in flight among
+// equals, as the pods may have unequal capacities.  It returns nil when no
+// pod is known.
+func (rt *revisionThrottler) acquireDest() *podTracker {
+	rt.mux.Lock()
+	defer rt.mux.Unlock()
+	var best *podTracker
+	for _, pt := range rt.podTrackers {
+		if best == nil || pt.free() > best.free() ||
+			(pt.free() == best.free() && pt.inFlight < best.inFlight) {
+			best = pt
+		}
+	}
+	if best != nil {
+		best.inFlight++
+	}
+	return best
+}
+
+func (rt *revisionThrottler) releaseDest(pt *podTracker) {
+	rt.mux.Lock()
+	defer rt.mux.Unlock()
+	pt.inFlight--
+}
+
+// try sends a request, which already got a slot in the revision's breaker,
+// to the least loaded pod, waiting for its capacity until ctx is done.
+func (rt *revisionThrottler) try(ctx context.Context, function func(string)) error {
+	pt := rt.acquireDest()
+	if pt == nil {
+		// No pod address is known yet, so let the private Service pick one.
+		function("")
+		return nil
+	}
+	defer rt.releaseDest(pt)
+	if !pt.breaker.MaybeContext(ctx, func() { function(pt.dest) }) {
+		return ErrActivatorOverload
+	}
+	return nil
+}
+
+// updatePods replaces the tracked pods with the given destinations, keeping
+// the in-flight counts of the pods which remain, and sets the capacity of
+// every pod to the one it's mapped to.
+func (rt *revisionThrottler) updatePods(params queue.BreakerParams, capacities map[string]int) error {
+	rt.mux.Lock()
+	defer rt.mux.Unlock()
+
+	existing := make(map[string]*podTracker, len(rt.podTrackers))
+	for _, pt := range rt.podTrackers {
+		existing[pt.dest] = pt
+	}
+	dests := make([]string, 0, len(capacities))
+	for dest := range capacities {
+		dests = append(dests, dest)
+	}
+	sort.Strings(dests)
+
+	trackers := make([]*podTracker, 0, len(dests))
+	for _, dest := range dests {
+		pt, ok := existing[dest]
+		if !ok {
+			pt = &podTracker{
+				dest:    dest,
+				breaker: queue.NewBreaker(params),
+			}
+		}
+		if err := pt.breaker.UpdateConcurrency(capacities[dest]); err != nil {
+			return err
+		}
+		trackers = append(trackers, pt)
+	}
+	rt.podTrackers = trackers
+	rt.endpointsTime = time.Now()
+	return nil
+}
+
+// state returns the RevisionState of the revision, but for its name and
+// the requests buffered.
+func (rt *revisionThrottler) state() RevisionState {
+	rt.mux.Lock()
+	defer rt.mux.Unlock()
+	state := RevisionState{
+		Capacity:            rt.breaker.Capacity(),
+		InFlight:            rt.breaker.InFlight(),
+		Queued:              rt.breaker.Pending(),
+		Pods:                make([]PodState, 0, len(rt.podTrackers)),
+		EndpointsUpdateTime: rt.endpointsTime,
+	}
+	for _, pt := range rt.podTrackers {
+		state.Pods = append(state.Pods, PodState{
+			Dest:     pt.dest,
+			Capacity: pt.breaker.Capacity(),
+			InFlight: pt.inFlight,
+		})
+	}
+	return state
+}
+
+// activatorSet describes the activator replicas the capacity of the
+// revisions is split across.
+type activatorSet struct {
+	// count is the number of ready activators.
+	count int
+	// ips are the sorted addresses of the ready activators.
+	ips []string
+}
+
+// weight is the rendezvous hashing weight of the given pod for the given activator.
+func weight(activatorIP, dest string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(activatorIP))
+	h.Write([]byte{'/'})
+	h.Write([]byte(dest))
+	return h.Sum64()
+}
+
+// subsetPods assigns the pods of a revision to the activators by rendezvous
+// hashing, which every activator computes alike, and which only reassigns
+// the pods of an activator that comes or goes.  Every pod is owned by the
+// activator with the highest weight for it, and an activator owning no pod
+// borrows the pod it has the highest weight for.  subsetPods returns the pods
+// assigned to self, mapped to the number of activators sharing each of them,
+// or nil when self isn't one of the activators.
+func subsetPods(self string, activatorIPs, dests []string) map[string]int {
+	if i := sort.SearchStrings(activatorIPs, self); len(dests) == 0 || i == len(activatorIPs) || activatorIPs[i] != self {
+		return nil
+	}
+
+	owners := make(map[string]string, len(dests))
+	owned := make(map[string]int, len(activatorIPs))
+	sharers := make(map[string]int, len(dests))
+	for _, dest := range dests {
+		var owner string
+		var best uint64
+		for i, ip := range activatorIPs {
+			if w := weight(ip, dest); i == 0 || w > best {
+				owner, best = ip, w
+			}
+		}
+		owners[dest] = owner
+		owned[owner]++
+		sharers[dest] = 1
+	}
+
+	borrowed := ""
+	for _, ip := range activatorIPs {
+		if owned[ip] > 0 {
+			continue
+		}
+		var dest string
+		var best uint64
+		for i, d := range dests {
+			if w := weight(ip, d); i == 0 || w > best {
+				dest, best = d, w
+			}
+		}
+		sharers[dest]++
+		if ip == self {
+			borrowed = dest
+		}
+	}
+
+	subset := make(map[string]int)
+	for _, dest := range dests {
+		if owners[dest] == self || dest == borrowed {
+			subset[dest] = sharers[dest]
+		}
+	}
+	return subset
+}
+
 // Throttler keeps the mapping of Revisions to Breakers
 // and allows updating max concurrency dynamically of respective Breakers.
 // Max concurrency is essentially the number of semaphore tokens the Breaker has in rotation.
 // The manipulation of the parameter is done via `UpdateCapacity()` method.
 // It enables the use case to start with max concurrency set to 0 (no requests are sent because no endpoints are available)
 // and gradually increase its value depending on the external condition (e.g. new endpoints become available)
+//
+// Within the capacity of a revision, the requests are balanced across its
+// ready pods, each of which has a Breaker of its own.
+//
+// The Breakers are looked up without locking, and a change of the activators
+// recomputes their capacity in the background.
 type Throttler struct {
-	breakersMux sync.Mutex
-	breakers    map[RevisionID]*queue.Breaker
+	revisionThrottlers *throttlerRegistry
+
+	breakerParamsMux sync.RWMutex
+	breakerParams    queue.BreakerParams
+
+	// buffer accounts for the requests waiting for capacity of their revision.
+	buffer *requestBuffer
 
-	breakerParams   queue.BreakerParams
 	logger          *zap.SugaredLogger
 	endpointsLister corev1listers.EndpointsLister
 	revisionLister  servinglisters.RevisionLister
 	sksLister       netlisters.ServerlessServiceLister
 
-	numActivatorsMux sync.RWMutex
-	numActivators    int
+	// selfIP is the address of this activator, which selects its subset
+	// of the pods of every revision.
+	selfIP string
+
+	activatorsMux sync.RWMutex
+	activators    activatorSet
+
+	// recomputeMux guards recomputing and recomputePending, which coalesce
+	// the recomputations of the capacity of all the revisions.
+	recomputeMux     sync.Mutex
+	recomputing      bool
+	recomputePending bool
 }
 
-// NewThrottler creates a new Throttler.
+// NewThrottler creates a new Throttler.  The selfIP is the address of this
+// activator in the activator Endpoints; when it's empty, every activator
+// balances across all the pods of a revision, with an equal share of its capacity.
+// The requests buffered are not limited until UpdateBufferLimits is called.
 func NewThrottler(
 	params queue.BreakerParams,
 	endpointsInformer corev1informers.EndpointsInformer,
 	sksLister netlisters.ServerlessServiceLister,
 	revisionLister servinglisters.RevisionLister,
+	selfIP string,
 	logger *zap.SugaredLogger) *Throttler {
 
 	throttler := &Throttler{
-		breakers:        make(map[RevisionID]*queue.Breaker),
-		breakerParams:   params,
-		logger:          logger,
-		endpointsLister: endpointsInformer.Lister(),
-		revisionLister:  revisionLister,
-		sksLister:       sksLister,
+		revisionThrottlers: newThrottlerRegistry(),
+		breakerParams:      params,
+		buffer:             newRequestBuffer(),
+		logger:             logger,
+		endpointsLister:    endpointsInformer.Lister(),
+		revisionLister:     revisionLister,
+		sksLister:          sksLister,
+		selfIP:             selfIP,
 	}
 
 	// Update/create the breaker in the throttler when the number of endpoints changes.
@@ -115,9 +431,7 @@ func NewThrottler(
 
 // Remove deletes the breaker from the bookkeeping.
 func (t *Throttler) Remove(rev RevisionID) {
-	t.breakersMux.Lock()
-	defer t.breakersMux.Unlock()
-	delete(t.breakers, rev)
+	t.revisionThrottlers.remove(rev)
 }
 
 // UpdateCapacity updates the max concurrency of the Breaker corresponding to a revision.
@@ -126,8 +440,10 @@ func (t *Throttler) UpdateCapacity(rev RevisionID, size int) error {
 	if err != nil {
 		return err
 	}
-	breaker, _ := t.getOrCreateBreaker(rev)
-	return t.updateCapacity(breaker, int(revision.Spec.ContainerConcurrency), size, t.activatorCount())
+	rt, _ := t.getOrCreateRevisionThrottler(rev)
+	rt.updateMux.Lock()
+	defer rt.updateMux.Unlock()
+	return t.updateCapacity(rt, int(revision.Spec.ContainerConcurrency), size, t.activatorCount())
 }
 
 // Try potentially registers a new breaker in our bookkeeping
@@ -136,33 +452,158 @@ func (t *Throttler) UpdateCapacity(rev RevisionID, size int) error {
 // or breaker's registration didn't succeed, e.g. getting endpoints or update capacity failed.
 // timeout is the time before this function returns ErrActivatorOverload. A 0 value for
 // timeout is infinite.
-func (t *Throttler) Try(timeout time.Duration, rev RevisionID, function func()) error {
-	breaker, existed := t.getOrCreateBreaker(rev)
+//
+// The `function` is passed the `ip:port` of the pod to send the request to,
+// which is empty when no ready pod of the revision is known yet, and how
+// long the request waited for it.
+//
+// Until the `function` is called the request counts against the BufferLimits;
+// Try returns ErrBufferLimitExceeded right away if it would exceed them.
+func (t *Throttler) Try(timeout time.Duration, rev RevisionID, function func(string, TryStats)) error {
+	start := time.Now()
+	if !t.buffer.add(rev) {
+		return ErrBufferLimitExceeded
+	}
+	var once sync.Once
+	unbuffer := func() {
+		once.Do(func() { t.buffer.remove(rev) })
+	}
+	defer unbuffer()
+
+	rt, existed := t.getOrCreateRevisionThrottler(rev)
 	if !existed {
 		// Need to fetch the latest endpoints state, in case we missed the update.
-		if err := t.forceUpdateCapacity(rev, breaker, t.activatorCount()); err != nil {
+		if err := t.forceUpdateCapacity(rev, rt, t.activatorSet()); err != nil {
 			return err
 		}
 	}
-	if !breaker.Maybe(timeout, function) {
+	cold := rt.breaker.Capacity() == 0
+	// The timeout bounds the whole wait, for the revision and then the pod.
+	ctx := context.Background()
+	if timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, start.Add(timeout))
+		defer cancel()
+	}
+	var err error
+	if !rt.breaker.MaybeContext(ctx, func() {
+		err = rt.try(ctx, func(dest string) {
+			unbuffer()
+			function(dest, rt.tryStats(start, cold))
+		})
+	}) {
 		return ErrActivatorOverload
 	}
-	return nil
+	return err
+}
+
+// UpdateBreakerParams changes the parameters of the breakers of the revisions.
+// The breakers are recreated with the new parameters on their next use;
+// the requests in flight finish on the old ones.
+func (t *Throttler) UpdateBreakerParams(params queue.BreakerParams) {
+	t.breakerParamsMux.Lock()
+	changed := t.breakerParams != params
+	t.breakerParams = params
+	t.breakerParamsMux.Unlock()
+	if !changed {
+		return
+	}
+	t.revisionThrottlers.clear()
+}
+
+// UpdateBufferLimits changes the limits on the requests buffered in the activator.
+func (t *Throttler) UpdateBufferLimits(limits BufferLimits) {
+	t.buffer.updateLimits(limits)
+}
+
+// State returns a snapshot of the revisions known to the Throttler, sorted
+// by namespace and name.
+func (t *Throttler) State() ThrottlerState {
+	rts := t.revisionThrottlers.snapshot()
+	revs := make([]RevisionID, 0, len(rts))
+	for rev := range rts {
+		revs = append(revs, rev)
+	}
+	sort.Slice(revs, func(i, j int) bool {
+		return revs[i].String() < revs[j].String()
+	})
+
+	state := ThrottlerState{
+		ActivatorCount: t.activatorCount(),
+		Revisions:      make([]RevisionState, 0, len(revs)),
+	}
+	for _, rev := range revs {
+		rs := rts[rev].state()
+		rs.Revision = rev.String()
+		rs.Buffered = t.buffer.buffered(rev)
+		state.Revisions = append(state.Revisions, rs)
+	}
+	return state
+}
+
+func (t *Throttler) params() queue.BreakerParams {
+	t.breakerParamsMux.RLock()
+	defer t.breakerParamsMux.RUnlock()
+	return t.breakerParams
 }
 
 func (t *Throttler) activatorCount() int {
-	t.numActivatorsMux.RLock()
-	defer t.numActivatorsMux.RUnlock()
-	return t.numActivators
+	return t.activatorSet().count
+}
+
+func (t *Throttler) activatorSet() activatorSet {
+	t.activatorsMux.RLock()
+	defer t.activatorsMux.RUnlock()
+	return t.activators
 }
 
 func (t *Throttler) activatorEndpointsUpdated(newObj interface{}) {
 	endpoints := newObj.(*corev1.Endpoints)
 
-	t.numActivatorsMux.Lock()
-	defer t.numActivatorsMux.Unlock()
-	t.numActivators = resources.ReadyAddressCount(endpoints)
-	t.updateAllBreakerCapacity(t.numActivators)
+	var ips []string
+	for _, subset := range endpoints.Subsets {
+		for _, addr := range subset.Addresses {
+			if addr.IP != "" {
+				ips = append(ips, addr.IP)
+			}
+		}
+	}
+	sort.Strings(ips)
+
+	t.activatorsMux.Lock()
+	t.activators = activatorSet{
+		count: resources.ReadyAddressCount(endpoints),
+		ips:   ips,
+	}
+	t.activatorsMux.Unlock()
+	t.recomputeAllCapacity()
+}
+
+// recomputeAllCapacity updates the capacity of all the revisions in the
+// background, for the latest activators.  The calls made while an update is
+// running are coalesced into a single one following it.
+func (t *Throttler) recomputeAllCapacity() {
+	t.recomputeMux.Lock()
+	defer t.recomputeMux.Unlock()
+	if t.recomputing {
+		t.recomputePending = true
+		return
+	}
+	t.recomputing = true
+	go func() {
+		for {
+			t.updateAllBreakerCapacity(t.activatorSet())
+
+			t.recomputeMux.Lock()
+			if !t.recomputePending {
+				t.recomputing = false
+				t.recomputeMux.Unlock()
+				return
+			}
+			t.recomputePending = false
+			t.recomputeMux.Unlock()
+		}
+	}()
 }
 
 // minOneOrValue function returns num if its greater than 1
@@ -175,36 +616,118 @@ func minOneOrValue(num int) int {
 }
 
 // This method updates Breaker's concurrency.
-func (t *Throttler) updateCapacity(breaker *queue.Breaker, cc, size, activatorCount int) (err error) {
+func (t *Throttler) updateCapacity(rt *revisionThrottler, cc, size, activatorCount int) (err error) {
 	targetCapacity := cc * size
+	maxConcurrency := t.params().MaxConcurrency
 
-	if size > 0 && (cc == 0 || targetCapacity > t.breakerParams.MaxConcurrency) {
+	if size > 0 && (cc == 0 || targetCapacity > maxConcurrency) {
 		// The concurrency is unlimited, thus hand out as many tokens as we can in this breaker.
-		targetCapacity = t.breakerParams.MaxConcurrency
+		targetCapacity = maxConcurrency
 	} else if targetCapacity > 0 {
 		targetCapacity = minOneOrValue(targetCapacity / minOneOrValue(activatorCount))
 	}
-	return breaker.UpdateConcurrency(targetCapacity)
+	return rt.updateCapacity(targetCapacity)
 }
 
-// getOrCreateBreaker retrieves existing breaker or creates a new one.
+// podCapacity returns the number of requests a single pod of a revision
+// with the given container concurrency may have in flight from this activator.
+func (t *Throttler) podCapacity(cc int) int {
+	maxConcurrency := t.params().MaxConcurrency
+	if cc == 0 || cc > maxConcurrency {
+		return maxConcurrency
+	}
+	return cc
+}
+
+// updateRevision updates the pods the requests of the revision are balanced
+// across from its private Endpoints, and the capacity of its breaker.
+// The updateMux of rt must be held to call it.
+//
+// When the pods and this activator are known, the capacity is that of the
+// subset of the pods assigned to this activator, with the capacity of a pod
+// split between the activators sharing it.  Otherwise, the capacity of the
+// revision is split equally between the activators.
+func (t *Throttler) updateRevision(rt *revisionThrottler, revision *v1alpha1.Revision, endpoints *corev1.Endpoints, as activatorSet) error {
+	cc := int(revision.Spec.ContainerConcurrency)
+	dests := podDests(endpoints, networking.ServicePortName(revision.GetProtocol()))
+	podParams := t.params()
+	podParams.InitialCapacity = 0
+
+	subset := subsetPods(t.selfIP, as.ips, dests)
+	if subset == nil {
+		capacities := make(map[string]int, len(dests))
+		for _, dest := range dests {
+			capacities[dest] = t.podCapacity(cc)
+		}
+		if err := rt.updatePods(podParams, capacities); err != nil {
+			return err
+		}
+		return t.updateCapacity(rt, cc, resources.ReadyAddressCount(endpoints), as.count)
+	}
+
+	capacities := make(map[string]int, len(subset))
+	total := 0
+	for dest, sharers := range subset {
+		capacity := t.podCapacity(cc)
+		if cc > 0 {
+			capacity = t.podCapacity(minOneOrValue(cc / sharers))
+		}
+		capacities[dest] = capacity
+		total += capacity
+	}
+	if err := rt.updatePods(podParams, capacities); err != nil {
+		return err
+	}
+	if total > podParams.MaxConcurrency {
+		total = podParams.MaxConcurrency
+	}
+	return rt.updateCapacity(total)
+}
+
+// podDests returns the `ip:port` destinations of the ready pods in the given
+// Endpoints, using the port with the given name.
+func podDests(endpoints *corev1.Endpoints, portName string) []string {
+	var dests []string
+	for _, subset := range endpoints.Subsets {
+		port := int32(-1)
+		for _, p := range subset.Ports {
+			if p.Name == portName {
+				port = p.Port
+				break
+			}
+		}
+		if port == -1 {
+			continue
+		}
+		for _, addr := range subset.Addresses {
+			if addr.IP != "" {
+				dests = append(dests, net.JoinHostPort(addr.IP, strconv.Itoa(int(port))))
+			}
+		}
+	}
+	return dests
+}
+
+// getOrCreateRevisionThrottler retrieves existing revisionThrottler or creates a new one.
 // This is important for not loosing the update signals
 // that came before the requests reached the Activator's Handler.
-func (t *Throttler) getOrCreateBreaker(rev RevisionID) (*queue.Breaker, bool) {
-	t.breakersMux.Lock()
-	defer t.breakersMux.Unlock()
-	breaker, ok := t.breakers[rev]
-	if !ok {
-		breaker = queue.NewBreaker(t.breakerParams)
-		t.breakers[rev] = breaker
-	}
-	return breaker, ok
+func (t *Throttler) getOrCreateRevisionThrottler(rev RevisionID) (*revisionThrottler, bool) {
+	return t.revisionThrottlers.getOrCreate(rev, func() *revisionThrottler {
+		return &revisionThrottler{
+			breaker: queue.NewBreaker(t.params()),
+		}
+	})
 }
 
 // forceUpdateCapacity fetches the endpoints and updates the capacity of the newly created breaker.
 // This avoids a potential deadlock in case if we missed the updates from the Endpoints informer.
 // This could happen because of a restart of the Activator or when a new one is added as part of scale out.
-func (t *Throttler) forceUpdateCapacity(rev RevisionID, breaker *queue.Breaker, activatorCount int) (err error) {
+func (t *Throttler) forceUpdateCapacity(rev RevisionID, rt *revisionThrottler, as activatorSet) (err error) {
+	// Read the endpoints under the lock, so that they're at least as recent
+	// as those of any update made before.
+	rt.updateMux.Lock()
+	defer rt.updateMux.Unlock()
+
 	revision, err := t.revisionLister.Revisions(rev.Namespace).Get(rev.Name)
 	if err != nil {
 		return err
@@ -219,21 +742,18 @@ func (t *Throttler) forceUpdateCapacity(rev RevisionID, breaker *queue.Breaker,
 	// We have to read the private service endpoints in activator
 	// in order to count the serving pod count, since the public one
 	// may point at ourselves.
-	podCounter := resources.NewScopedEndpointsCounter(t.endpointsLister, sks.Namespace, sks.Status.PrivateServiceName)
-	size, err := podCounter.ReadyCount()
+	endpoints, err := t.endpointsLister.Endpoints(sks.Namespace).Get(sks.Status.PrivateServiceName)
 	if err != nil {
 		return err
 	}
-
-	return t.updateCapacity(breaker, int(revision.Spec.ContainerConcurrency), size, activatorCount)
+	return t.updateRevision(rt, revision, endpoints, as)
 }
 
-// updateAllBreakerCapacity updates the capacity of all breakers.
-func (t *Throttler) updateAllBreakerCapacity(activatorCount int) {
-	t.breakersMux.Lock()
-	defer t.breakersMux.Unlock()
-	for revID, breaker := range t.breakers {
-		if err := t.forceUpdateCapacity(revID, breaker, activatorCount); err != nil {
+// updateAllBreakerCapacity updates the capacity of all breakers.  It doesn't
+// block the requests, nor the creation of new breakers.
+func (t *Throttler) updateAllBreakerCapacity(as activatorSet) {
+	for revID, rt := range t.revisionThrottlers.snapshot() {
+		if err := t.forceUpdateCapacity(revID, rt, as); err != nil {
 			t.logger.With(zap.String(logkey.Key, revID.String())).Errorw("updating capacity failed", zap.Error(err))
 		}
 	}
@@ -247,9 +767,15 @@ func (t *Throttler) updateAllBreakerCapacity(activatorCount int) {
 // This function must not be called in parallel to not induce a wrong order of events.
 func (t *Throttler) endpointsUpdated(newObj interface{}) {
 	endpoints := newObj.(*corev1.Endpoints)
-	addresses := resources.ReadyAddressCount(endpoints)
 	revID := RevisionID{endpoints.Namespace, resources.ParentResourceFromService(endpoints.Name)}
-	if err := t.UpdateCapacity(revID, addresses); err != nil {
+	revision, err := t.revisionLister.Revisions(revID.Namespace).Get(revID.Name)
+	if err == nil {
+		rt, _ := t.getOrCreateRevisionThrottler(revID)
+		rt.updateMux.Lock()
+		err = t.updateRevision(rt, revision, endpoints, t.activatorSet())
+		rt.updateMux.Unlock()
+	}
+	if err != nil {
 		t.logger.With(zap.String(logkey.Key, revID.String())).Errorw("updating capacity failed", zap.Error(err))
 	}
 }
@@ -17,9 +17,12 @@ limitations under the License.
 package activator
 
 import (
+	"fmt"
+	"sort"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"go.uber.org/zap"
@@ -122,7 +125,7 @@ func TestThrottlerUpdateCapacity(t *testing.T) {
 				t.Errorf("UpdateCapacity() = %v, wanted no error", err)
 			}
 			if s.want > 0 {
-				if got := throttler.breakers[revID].Capacity(); got != s.want {
+				if got := getRevisionThrottler(throttler, revID).breaker.Capacity(); got != s.want {
 					t.Errorf("breakers[revID].Capacity() = %d, want %d", got, s.want)
 				}
 			}
@@ -192,7 +195,7 @@ func TestThrottlerActivatorEndpoints(t *testing.T) {
 			fake.CoreV1().Endpoints(activatorEp.Namespace).Create(activatorEp)
 			endpoints.Informer().GetIndexer().Add(activatorEp)
 
-			breaker := throttler.breakers[RevisionID{Name: testRevision, Namespace: testNamespace}]
+			breaker := getRevisionThrottler(throttler, revID).breaker
 
 			if err := wait.PollImmediate(updatePollInterval, updatePollTimeout, func() (bool, error) {
 				return breaker.Capacity() == s.wantCapacity, nil
@@ -257,7 +260,7 @@ func TestThrottlerTry(t *testing.T) {
 			if s.addCapacity {
 				throttler.UpdateCapacity(revID, 1)
 			}
-			err := throttler.Try(0, revID, func() {
+			err := throttler.Try(0, revID, func(string, TryStats) {
 				called++
 			})
 			if err == nil && s.wantError {
@@ -272,6 +275,51 @@ func TestThrottlerTry(t *testing.T) {
 	}
 }
 
+func TestThrottlerTryStats(t *testing.T) {
+	const endpointsDelay = 50 * time.Millisecond
+	throttler := getThrottler(
+		defaultMaxConcurrency,
+		revisionLister(testNamespace, testRevision, 1),
+		endpointsInformer(testNamespace, testRevision, 0),
+		sksLister(testNamespace, testRevision),
+		TestLogger(t),
+		initCapacity)
+
+	// The revision has no capacity until its pod becomes ready.
+	statsCh := make(chan TryStats)
+	go func() {
+		if err := throttler.Try(time.Second, revID, func(_ string, stats TryStats) {
+			statsCh <- stats
+		}); err != nil {
+			t.Errorf("Try() = %v", err)
+		}
+	}()
+	if err := wait.PollImmediate(time.Millisecond, time.Second, func() (bool, error) {
+		return throttler.buffer.buffered(revID) == 1, nil
+	}); err != nil {
+		t.Fatal("The request was never buffered")
+	}
+	time.Sleep(endpointsDelay)
+	if err := throttler.UpdateCapacity(revID, 1); err != nil {
+		t.Fatalf("UpdateCapacity() = %v", err)
+	}
+	stats := <-statsCh
+	if !stats.Cold {
+		t.Error("Cold = false for a request waiting for endpoints")
+	}
+	if stats.EndpointsWait < endpointsDelay {
+		t.Errorf("EndpointsWait = %v, want at least %v", stats.EndpointsWait, endpointsDelay)
+	}
+
+	if err := throttler.Try(0, revID, func(_ string, stats TryStats) {
+		if stats.Cold || stats.EndpointsWait != 0 {
+			t.Errorf("Stats = %#v, want a warm request", stats)
+		}
+	}); err != nil {
+		t.Errorf("Try() = %v", err)
+	}
+}
+
 func TestThrottlerTryOverload(t *testing.T) {
 	maxConcurrency := 1
 	initialCapacity := 1
@@ -291,7 +339,7 @@ func TestThrottlerTryOverload(t *testing.T) {
 	allowedRequests := initialCapacity + queueLength
 	for i := 0; i < allowedRequests+1; i++ {
 		go func() {
-			err := th.Try(0, revID, func() {
+			err := th.Try(0, revID, func(string, TryStats) {
 				doneCh <- struct{}{} // Blocks forever
 			})
 			if err != nil {
@@ -319,6 +367,326 @@ func TestThrottlerTryOverload(t *testing.T) {
 	}
 }
 
+func TestThrottlerTryBalancesPods(t *testing.T) {
+	ep := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testRevision,
+			Namespace: testNamespace,
+		},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}},
+			Ports: []corev1.EndpointPort{{
+				Name: networking.ServicePortNameHTTP1,
+				Port: 8012,
+			}},
+		}},
+	}
+	fake := kubefake.NewSimpleClientset(ep)
+	informer := kubeinformers.NewSharedInformerFactory(fake, 0)
+	endpoints := informer.Core().V1().Endpoints()
+	endpoints.Informer().GetIndexer().Add(ep)
+
+	throttler := getThrottler(
+		defaultMaxConcurrency,
+		revisionLister(testNamespace, testRevision, 1),
+		endpoints,
+		sksLister(testNamespace, testRevision),
+		TestLogger(t),
+		initCapacity)
+
+	// With a container concurrency of 1, concurrent requests must not
+	// share a pod while another one is idle.
+	destCh := make(chan string)
+	releaseCh := make(chan struct{})
+	errCh := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			errCh <- throttler.Try(0, revID, func(dest string, _ TryStats) {
+				destCh <- dest
+				<-releaseCh
+			})
+		}()
+	}
+	got := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case dest := <-destCh:
+			got[dest] = true
+		case <-time.After(3 * time.Second):
+			t.Fatal("Timed out waiting for the requests to be sent")
+		}
+	}
+	close(releaseCh)
+	for i := 0; i < 2; i++ {
+		if err := <-errCh; err != nil {
+			t.Errorf("Try() = %v", err)
+		}
+	}
+
+	want := map[string]bool{"10.0.0.1:8012": true, "10.0.0.2:8012": true}
+	if !cmp.Equal(want, got) {
+		t.Errorf("Destinations (-want, +got): %s", cmp.Diff(want, got))
+	}
+}
+
+func TestRevisionThrottlerAcquireDest(t *testing.T) {
+	params := queue.BreakerParams{QueueDepth: 1, MaxConcurrency: 10}
+	tracker := func(dest string, capacity, inFlight int) *podTracker {
+		pt := &podTracker{dest: dest, breaker: queue.NewBreaker(params), inFlight: inFlight}
+		if err := pt.breaker.UpdateConcurrency(capacity); err != nil {
+			t.Fatalf("UpdateConcurrency() = %v", err)
+		}
+		return pt
+	}
+
+	tests := []struct {
+		name string
+		pods []*podTracker
+		want string
+	}{{
+		name: "no pods",
+	}, {
+		name: "most free capacity",
+		// The first pod has fewer requests in flight, but it's full.
+		pods: []*podTracker{tracker("a", 1, 1), tracker("b", 3, 2)},
+		want: "b",
+	}, {
+		name: "fewest in flight among equals",
+		pods: []*podTracker{tracker("a", 3, 2), tracker("b", 2, 1)},
+		want: "b",
+	}, {
+		name: "all full",
+		pods: []*podTracker{tracker("a", 1, 2), tracker("b", 2, 2)},
+		want: "b",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rt := &revisionThrottler{podTrackers: test.pods}
+			pt := rt.acquireDest()
+			got := ""
+			if pt != nil {
+				got = pt.dest
+			}
+			if got != test.want {
+				t.Errorf("acquireDest() = %q, want: %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestThrottlerState(t *testing.T) {
+	ep := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testRevision,
+			Namespace: testNamespace,
+		},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}},
+			Ports: []corev1.EndpointPort{{
+				Name: networking.ServicePortNameHTTP1,
+				Port: 8012,
+			}},
+		}},
+	}
+	fake := kubefake.NewSimpleClientset(ep)
+	informer := kubeinformers.NewSharedInformerFactory(fake, 0)
+	endpoints := informer.Core().V1().Endpoints()
+	endpoints.Informer().GetIndexer().Add(ep)
+
+	throttler := getThrottler(
+		defaultMaxConcurrency,
+		revisionLister(testNamespace, testRevision, 1),
+		endpoints,
+		sksLister(testNamespace, testRevision),
+		TestLogger(t),
+		initCapacity)
+
+	if got := throttler.State(); len(got.Revisions) != 0 {
+		t.Errorf("State().Revisions = %v, want none", got.Revisions)
+	}
+
+	var got ThrottlerState
+	if err := throttler.Try(0, revID, func(string, TryStats) {
+		got = throttler.State()
+	}); err != nil {
+		t.Fatalf("Try() = %v", err)
+	}
+
+	if len(got.Revisions) != 1 {
+		t.Fatalf("State().Revisions = %v, want one", got.Revisions)
+	}
+	rs := got.Revisions[0]
+	if rs.EndpointsUpdateTime.IsZero() {
+		t.Error("EndpointsUpdateTime is zero")
+	}
+	rs.EndpointsUpdateTime = time.Time{}
+	// Either pod may have gotten the request.
+	inFlight := 0
+	for i := range rs.Pods {
+		inFlight += rs.Pods[i].InFlight
+		rs.Pods[i].InFlight = 0
+	}
+	if inFlight != 1 {
+		t.Errorf("Requests in flight to the pods = %d, want: 1", inFlight)
+	}
+	want := RevisionState{
+		Revision: revID.String(),
+		Capacity: 2,
+		InFlight: 1,
+		Pods: []PodState{
+			{Dest: "10.0.0.1:8012", Capacity: 1},
+			{Dest: "10.0.0.2:8012", Capacity: 1},
+		},
+	}
+	if !cmp.Equal(want, rs) {
+		t.Errorf("RevisionState (-want, +got): %s", cmp.Diff(want, rs))
+	}
+}
+
+func TestPodDests(t *testing.T) {
+	ep := &corev1.Endpoints{
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}, {}},
+			Ports: []corev1.EndpointPort{{
+				Name: networking.ServicePortNameH2C,
+				Port: 8013,
+			}, {
+				Name: networking.ServicePortNameHTTP1,
+				Port: 8012,
+			}},
+		}, {
+			Addresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}},
+			Ports: []corev1.EndpointPort{{
+				Name: networking.ServicePortNameH2C,
+				Port: 8013,
+			}},
+		}},
+	}
+	got := podDests(ep, networking.ServicePortNameHTTP1)
+	if want := []string{"10.0.0.1:8012"}; !cmp.Equal(want, got) {
+		t.Errorf("podDests (-want, +got): %s", cmp.Diff(want, got))
+	}
+}
+
+func TestSubsetPods(t *testing.T) {
+	activators := []string{"10.1.0.1", "10.1.0.2", "10.1.0.3"}
+	dests := make([]string, 0, 20)
+	for i := 1; i <= 20; i++ {
+		dests = append(dests, fmt.Sprintf("10.0.0.%d:8012", i))
+	}
+
+	if got := subsetPods("10.1.0.4", activators, dests); got != nil {
+		t.Errorf("subsetPods() = %v for an unknown activator, want: nil", got)
+	}
+	if got := subsetPods("10.1.0.1", activators, nil); got != nil {
+		t.Errorf("subsetPods() = %v without pods, want: nil", got)
+	}
+
+	// Every pod is assigned to as many activators as share it, and every
+	// activator gets at least one pod.
+	subsets := assignAll(activators, dests)
+	for _, dest := range dests {
+		assigned, sharers := 0, 0
+		for _, subset := range subsets {
+			if n, ok := subset[dest]; ok {
+				assigned++
+				sharers = n
+			}
+		}
+		if assigned == 0 || assigned != sharers {
+			t.Errorf("Pod %s is assigned to %d activators, shared by %d", dest, assigned, sharers)
+		}
+	}
+	for ip, subset := range subsets {
+		if len(subset) == 0 {
+			t.Errorf("Activator %s got no pods", ip)
+		}
+	}
+
+	// A new activator only takes pods over, the others keep theirs.
+	grown := assignAll(append(activators, "10.1.0.4"), dests)
+	for _, ip := range activators {
+		for dest, sharers := range grown[ip] {
+			if _, ok := subsets[ip][dest]; !ok && sharers == 1 {
+				t.Errorf("Pod %s moved to activator %s, which already existed", dest, ip)
+			}
+		}
+	}
+
+	// With fewer pods than activators, the pods are shared.
+	few := assignAll(activators, dests[:1])
+	for _, ip := range activators {
+		if got, want := few[ip], map[string]int{dests[0]: 3}; !cmp.Equal(got, want) {
+			t.Errorf("subsetPods(%s) = %v, want: %v", ip, got, want)
+		}
+	}
+}
+
+func assignAll(activators, dests []string) map[string]map[string]int {
+	sorted := append([]string(nil), activators...)
+	sort.Strings(sorted)
+	subsets := make(map[string]map[string]int, len(sorted))
+	for _, ip := range sorted {
+		subsets[ip] = subsetPods(ip, sorted, dests)
+	}
+	return subsets
+}
+
+func TestThrottlerSubsetCapacity(t *testing.T) {
+	const self = "10.1.0.1"
+	ep := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testRevision,
+			Namespace: testNamespace,
+		},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{
+				{IP: "10.0.0.1"}, {IP: "10.0.0.2"}, {IP: "10.0.0.3"}, {IP: "10.0.0.4"},
+			},
+			Ports: []corev1.EndpointPort{{
+				Name: networking.ServicePortNameHTTP1,
+				Port: 8012,
+			}},
+		}},
+	}
+	fake := kubefake.NewSimpleClientset(ep)
+	informer := kubeinformers.NewSharedInformerFactory(fake, 0)
+	endpoints := informer.Core().V1().Endpoints()
+	endpoints.Informer().GetIndexer().Add(ep)
+
+	throttler := NewThrottler(queue.BreakerParams{
+		QueueDepth:     1,
+		MaxConcurrency: defaultMaxConcurrency,
+	}, endpoints, sksLister(testNamespace, testRevision),
+		revisionLister(testNamespace, testRevision, 10), self, TestLogger(t))
+	throttler.activatorEndpointsUpdated(&corev1.Endpoints{
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "10.1.0.2"}, {IP: self}},
+		}},
+	})
+
+	if err := throttler.Try(0, revID, func(string, TryStats) {}); err != nil {
+		t.Fatalf("Try() = %v", err)
+	}
+	subset := subsetPods(self, []string{self, "10.1.0.2"},
+		[]string{"10.0.0.1:8012", "10.0.0.2:8012", "10.0.0.3:8012", "10.0.0.4:8012"})
+	rt := getRevisionThrottler(throttler, revID)
+	if got, want := rt.breaker.Capacity(), 10*len(subset); got != want {
+		t.Errorf("Capacity() = %d, want: %d", got, want)
+	}
+	rt.mux.Lock()
+	defer rt.mux.Unlock()
+	for _, pt := range rt.podTrackers {
+		if _, ok := subset[pt.dest]; !ok {
+			t.Errorf("Tracking pod %s, which isn't in the subset %v", pt.dest, subset)
+		}
+	}
+	if got, want := len(rt.podTrackers), len(subset); got != want {
+		t.Errorf("len(podTrackers) = %d, want: %d", got, want)
+	}
+}
+
 func TestThrottlerRemove(t *testing.T) {
 	throttler := getThrottler(
 		defaultMaxConcurrency,
@@ -328,17 +696,59 @@ func TestThrottlerRemove(t *testing.T) {
 		TestLogger(t),
 		initCapacity)
 
-	throttler.breakers[revID] = queue.NewBreaker(throttler.breakerParams)
-	if got := len(throttler.breakers); got != 1 {
+	throttler.revisionThrottlers.getOrCreate(revID, func() *revisionThrottler {
+		return &revisionThrottler{
+			breaker: queue.NewBreaker(throttler.breakerParams),
+		}
+	})
+	if got := breakerCount(throttler); got != 1 {
 		t.Errorf("Number of Breakers created = %d, want: 1", got)
 	}
 
 	throttler.Remove(revID)
-	if got := len(throttler.breakers); got != 0 {
+	if got := breakerCount(throttler); got != 0 {
 		t.Errorf("Number of Breakers created = %d, want: %d", got, 0)
 	}
 }
 
+func TestThrottlerUpdateBreakerParams(t *testing.T) {
+	throttler := getThrottler(
+		defaultMaxConcurrency,
+		revisionLister(testNamespace, testRevision, 0),
+		endpointsInformer(testNamespace, testRevision, 1),
+		sksLister(testNamespace, testRevision),
+		TestLogger(t),
+		initCapacity)
+	capacity := func() int {
+		var got int
+		if err := throttler.Try(0, revID, func(string, TryStats) {
+			got = getRevisionThrottler(throttler, revID).breaker.Capacity()
+		}); err != nil {
+			t.Fatalf("Try() = %v", err)
+		}
+		return got
+	}
+
+	if got, want := capacity(), defaultMaxConcurrency; got != want {
+		t.Errorf("Capacity() = %d, want: %d", got, want)
+	}
+
+	params := queue.BreakerParams{QueueDepth: 1, MaxConcurrency: 3}
+	throttler.UpdateBreakerParams(params)
+	if got := breakerCount(throttler); got != 0 {
+		t.Errorf("breakerCount() = %d, want: 0", got)
+	}
+	if got, want := capacity(), 3; got != want {
+		t.Errorf("Capacity() = %d, want: %d", got, want)
+	}
+
+	// The same parameters keep the breakers.
+	throttler.UpdateBreakerParams(params)
+	if got := breakerCount(throttler); got != 1 {
+		t.Errorf("breakerCount() = %d, want: 1", got)
+	}
+}
+
 func TestHelper_ReactToEndpoints(t *testing.T) {
 	const updatePollInterval = 10 * time.Millisecond
 	const updatePollTimeout = 3 * time.Second
@@ -379,7 +789,7 @@ func TestHelper_ReactToEndpoints(t *testing.T) {
 	if got := breakerCount(throttler); got != 1 {
 		t.Errorf("breakerCount() = %d, want 1", got)
 	}
-	breaker := throttler.breakers[RevisionID{Name: testRevision, Namespace: testNamespace}]
+	breaker := getRevisionThrottler(throttler, revID).breaker
 	if got := breaker.Capacity(); got != 0 {
 		t.Errorf("Capacity() = %d, want 0", got)
 	}
@@ -475,13 +885,16 @@ func getThrottler(
 		MaxConcurrency:  maxConcurrency,
 		InitialCapacity: initCapacity,
 	}
-	return NewThrottler(params, endpointsInformer, sksLister, revisionLister, logger)
+	return NewThrottler(params, endpointsInformer, sksLister, revisionLister, "" /*selfIP*/, logger)
 }
 
 func breakerCount(t *Throttler) int {
-	t.breakersMux.Lock()
-	defer t.breakersMux.Unlock()
-	return len(t.breakers)
+	return t.revisionThrottlers.len()
+}
+
+func getRevisionThrottler(t *Throttler, rev RevisionID) *revisionThrottler {
+	rt, _ := t.revisionThrottlers.get(rev)
+	return rt
 }
 
 func endpointsSubset(hostsPerSubset, subsets int) []v1.EndpointSubset {
@@ -59,5 +59,13 @@ func ValidateAnnotations(annotations map[string]string) *apis.FieldError {
 		}
 	}
 
+	if mode, ok := annotations[ConcurrencyModeAnnotationKey]; ok &&
+		mode != ConcurrencyModeFixed && mode != ConcurrencyModeAdaptive {
+		return apis.ErrInvalidValue(mode, ConcurrencyModeAnnotationKey)
+	}
+	if _, err := getIntGE0(annotations, MinConcurrencyAnnotationKey); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -99,6 +99,24 @@ func TestValidateScaleBoundAnnotations(t *testing.T) {
 			MaxScaleAnnotationKey: "0",
 		},
 		expectErr: nil,
+	}, {
+		name: "adaptive concurrency",
+		annotations: map[string]string{
+			ConcurrencyModeAnnotationKey: ConcurrencyModeAdaptive,
+			MinConcurrencyAnnotationKey:  "2",
+		},
+		expectErr: nil,
+	}, {
+		name:        "concurrencyMode is foo",
+		annotations: map[string]string{ConcurrencyModeAnnotationKey: "foo"},
+		expectErr:   apis.ErrInvalidValue("foo", ConcurrencyModeAnnotationKey),
+	}, {
+		name:        "minConcurrency is -1",
+		annotations: map[string]string{MinConcurrencyAnnotationKey: "-1"},
+		expectErr: &apis.FieldError{
+			Message: fmt.Sprintf("Invalid %s annotation value: must be an integer equal or greater than 0", MinConcurrencyAnnotationKey),
+			Paths:   []string{MinConcurrencyAnnotationKey},
+		},
 	}}
 
 	for _, c := range cases {
@@ -129,6 +129,28 @@ const (
 	// smallest useful value.
 	PanicThresholdPercentageMin = 110.0
 
+	// ConcurrencyModeAnnotationKey is the annotation to specify whether the
+	// queue-proxy enforces the container concurrency as a fixed limit, or
+	// adapts the limit to the latency of the requests, never exceeding the
+	// container concurrency. For example,
+	//   autoscaling.knative.dev/concurrencyMode: adaptive
+	// The adaptive limit is reported to the autoscaler in place of the
+	// container concurrency. It has no effect if the container concurrency
+	// is unlimited.
+	ConcurrencyModeAnnotationKey = GroupName + "/concurrencyMode"
+	// ConcurrencyModeFixed enforces the container concurrency as is. This
+	// is the default.
+	ConcurrencyModeFixed = "fixed"
+	// ConcurrencyModeAdaptive adapts the concurrency limit to the latency.
+	ConcurrencyModeAdaptive = "adaptive"
+
+	// MinConcurrencyAnnotationKey is the annotation to specify the lowest
+	// the adaptive concurrency limit is reduced to. For example,
+	//   autoscaling.knative.dev/concurrencyMode: adaptive
+	//   autoscaling.knative.dev/minConcurrency: "2"
+	// Defaults to 1.
+	MinConcurrencyAnnotationKey = GroupName + "/minConcurrency"
+
 	// KPALabelKey is the label key attached to a K8s Service to hint to the KPA
 	// which services/endpoints should trigger reconciles.
 	KPALabelKey = GroupName + "/kpa"
@@ -35,10 +35,34 @@ const (
 	// Istio-based ClusterIngress will reconcile into a VirtualService).
 	IngressClassAnnotationKey = "networking.knative.dev/ingress.class"
 
+	// GatewaysAnnotationKey is the annotation which narrows down the
+	// gateways a public ingress is exposed through to the listed ones,
+	// e.g.
+	//
+	//    networking.knative.dev/gateways: team-a-gateway,team-b-gateway
+	//
+	// It is set on a Route, which passes it on to its ingress, or on a
+	// Namespace, for every Route in that namespace.  The names must be
+	// among the gateways the ingress implementation is configured with.
+	GatewaysAnnotationKey = "networking.knative.dev/gateways"
+
+	// LocalGatewaysAnnotationKey is the counterpart of GatewaysAnnotationKey
+	// for the gateways a cluster-local ingress is exposed through.
+	LocalGatewaysAnnotationKey = "networking.knative.dev/local-gateways"
+
 	// ClusterIngressLabelKey is the label key attached to underlying network programming
 	// resources to indicate which ClusterIngress triggered their creation.
 	ClusterIngressLabelKey = GroupName + "/clusteringress"
 
+	// IngressLabelKey is the label key attached to underlying network programming
+	// resources to indicate which Ingress triggered their creation.
+	IngressLabelKey = GroupName + "/ingress"
+
+	// IngressNamespaceLabelKey is the label key attached to underlying network
+	// programming resources living outside of the namespace of the Ingress
+	// which triggered their creation, to indicate the namespace of that Ingress.
+	IngressNamespaceLabelKey = GroupName + "/ingressNamespace"
+
 	// SKSLabelKey is the label key that SKS Controller attaches to the
 	// underlying resources it controls.
 	SKSLabelKey = GroupName + "/serverlessservice"
@@ -20,6 +20,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// GetGroupVersionKind returns SchemeGroupVersion of a ClusterIngress
 func (ci *ClusterIngress) GetGroupVersionKind() schema.GroupVersionKind {
 	return SchemeGroupVersion.WithKind("ClusterIngress")
 }
@@ -28,3 +29,18 @@ func (ci *ClusterIngress) GetGroupVersionKind() schema.GroupVersionKind {
 func (ci *ClusterIngress) IsPublic() bool {
 	return ci.Spec.Visibility == "" || ci.Spec.Visibility == IngressVisibilityExternalIP
 }
+
+// GetSpec returns the spec of the ClusterIngress.
+func (ci *ClusterIngress) GetSpec() *IngressSpec {
+	return &ci.Spec
+}
+
+// GetStatus returns the status of the ClusterIngress.
+func (ci *ClusterIngress) GetStatus() *IngressStatus {
+	return &ci.Status
+}
+
+// SetStatus replaces the status of the ClusterIngress.
+func (ci *ClusterIngress) SetStatus(status IngressStatus) {
+	ci.Status = status
+}
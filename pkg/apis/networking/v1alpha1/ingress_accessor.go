@@ -0,0 +1,45 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/kmeta"
+)
+
+// IngressAccessor is an interface for accessing the ingress resources,
+// ClusterIngress and Ingress, which share the same spec and status.
+type IngressAccessor interface {
+	kmeta.Accessor
+	kmeta.OwnerRefable
+	apis.Defaultable
+
+	// GetSpec returns the spec of the ingress.
+	GetSpec() *IngressSpec
+	// GetStatus returns the status of the ingress.
+	GetStatus() *IngressStatus
+	// SetStatus replaces the status of the ingress.
+	SetStatus(IngressStatus)
+	// IsPublic returns whether the ingress should be exposed publicly.
+	IsPublic() bool
+}
+
+// Check that both ingress types implement IngressAccessor.
+var (
+	_ IngressAccessor = (*ClusterIngress)(nil)
+	_ IngressAccessor = (*Ingress)(nil)
+)
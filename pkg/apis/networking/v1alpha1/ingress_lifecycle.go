@@ -39,6 +39,21 @@ func (i *Ingress) IsPublic() bool {
 	return i.Spec.Visibility == "" || i.Spec.Visibility == IngressVisibilityExternalIP
 }
 
+// GetSpec returns the spec of the Ingress.
+func (i *Ingress) GetSpec() *IngressSpec {
+	return &i.Spec
+}
+
+// GetStatus returns the status of the Ingress.
+func (i *Ingress) GetStatus() *IngressStatus {
+	return &i.Status
+}
+
+// SetStatus replaces the status of the Ingress.
+func (i *Ingress) SetStatus(status IngressStatus) {
+	i.Status = status
+}
+
 // GetCondition returns the current condition of a given condition type
 func (is *IngressStatus) GetCondition(t apis.ConditionType) *apis.Condition {
 	return ingressCondSet.Manage(is).GetCondition(t)
@@ -61,6 +76,20 @@ func (is *IngressStatus) MarkResourceNotOwned(kind, name string) {
 		fmt.Sprintf("There is an existing %s %q that we do not own.", kind, name))
 }
 
+// MarkInvalidGateways changes the "NetworkConfigured" condition to false to reflect that the
+// ingress selects gateways which aren't configured.
+func (is *IngressStatus) MarkInvalidGateways(message string) {
+	ingressCondSet.Manage(is).MarkFalse(IngressConditionNetworkConfigured, "InvalidGateways",
+		"Invalid gateway selection: %s", message)
+}
+
+// MarkInvalidBackends changes the "NetworkConfigured" condition to false to reflect that the
+// ingress routes traffic to backends which cannot be programmed.
+func (is *IngressStatus) MarkInvalidBackends(message string) {
+	ingressCondSet.Manage(is).MarkFalse(IngressConditionNetworkConfigured, "InvalidBackends",
+		"Invalid backends: %s", message)
+}
+
 // MarkLoadBalancerReady marks the Ingress with IngressConditionLoadBalancerReady,
 // and also populate the address of the load balancer.
 func (is *IngressStatus) MarkLoadBalancerReady(lbs []LoadBalancerIngressStatus) {
@@ -71,6 +100,13 @@ func (is *IngressStatus) MarkLoadBalancerReady(lbs []LoadBalancerIngressStatus)
 	ingressCondSet.Manage(is).MarkTrue(IngressConditionLoadBalancerReady)
 }
 
+// MarkLoadBalancerNotReady marks the "IngressConditionLoadBalancerReady" condition to unknown to
+// reflect that the load balancer is not ready yet.
+func (is *IngressStatus) MarkLoadBalancerNotReady() {
+	ingressCondSet.Manage(is).MarkUnknown(IngressConditionLoadBalancerReady, "Uninitialized",
+		"Waiting for VirtualService to be ready")
+}
+
 // IsReady looks at the conditions and if the Status has a condition
 // IngressConditionReady returns true if ConditionStatus is True
 func (is *IngressStatus) IsReady() bool {
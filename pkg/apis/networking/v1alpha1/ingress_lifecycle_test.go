@@ -69,6 +69,22 @@ func TestIngressIsPublic(t *testing.T) {
 	}
 }
 
+func TestIngressAccessor(t *testing.T) {
+	for _, ia := range []IngressAccessor{&Ingress{}, &ClusterIngress{}} {
+		ia.GetSpec().Visibility = IngressVisibilityClusterLocal
+		if ia.IsPublic() {
+			t.Errorf("%T: IsPublic() = true after changing the spec through GetSpec()", ia)
+		}
+
+		status := IngressStatus{}
+		status.MarkNetworkConfigured()
+		ia.SetStatus(status)
+		if diff := cmp.Diff(status, *ia.GetStatus()); diff != "" {
+			t.Errorf("%T: GetStatus() (-want, +got) = %v", ia, diff)
+		}
+	}
+}
+
 func TestIngressTypicalFlow(t *testing.T) {
 	r := &IngressStatus{}
 	r.InitializeConditions()
@@ -80,6 +96,11 @@ func TestIngressTypicalFlow(t *testing.T) {
 	apitest.CheckConditionSucceeded(r.duck(), IngressConditionNetworkConfigured, t)
 	apitest.CheckConditionOngoing(r.duck(), IngressConditionReady, t)
 
+	// Then the gateways are still being probed.
+	r.MarkLoadBalancerNotReady()
+	apitest.CheckConditionOngoing(r.duck(), IngressConditionLoadBalancerReady, t)
+	apitest.CheckConditionOngoing(r.duck(), IngressConditionReady, t)
+
 	// Then ingress has address.
 	r.MarkLoadBalancerReady([]LoadBalancerIngressStatus{{DomainInternal: "gateway.default.svc"}})
 	apitest.CheckConditionSucceeded(r.duck(), IngressConditionLoadBalancerReady, t)
@@ -92,3 +113,27 @@ func TestIngressTypicalFlow(t *testing.T) {
 	r.MarkResourceNotOwned("i own", "you")
 	apitest.CheckConditionFailed(r.duck(), IngressConditionReady, t)
 }
+
+func TestIngressInvalidGateways(t *testing.T) {
+	r := &IngressStatus{}
+	r.InitializeConditions()
+	r.MarkInvalidGateways(`unknown gateway "foo"`)
+
+	apitest.CheckConditionFailed(r.duck(), IngressConditionNetworkConfigured, t)
+	apitest.CheckConditionFailed(r.duck(), IngressConditionReady, t)
+	if got, want := r.GetCondition(IngressConditionNetworkConfigured).Reason, "InvalidGateways"; got != want {
+		t.Errorf("Reason = %s, want: %s", got, want)
+	}
+}
+
+func TestIngressInvalidBackends(t *testing.T) {
+	r := &IngressStatus{}
+	r.InitializeConditions()
+	r.MarkInvalidBackends(`unknown service port name "grpc"`)
+
+	apitest.CheckConditionFailed(r.duck(), IngressConditionNetworkConfigured, t)
+	apitest.CheckConditionFailed(r.duck(), IngressConditionReady, t)
+	if got, want := r.GetCondition(IngressConditionNetworkConfigured).Reason, "InvalidBackends"; got != want {
+		t.Errorf("Reason = %s, want: %s", got, want)
+	}
+}
@@ -225,6 +225,14 @@ type HTTPIngressPath struct {
 	// NOTE: This differs from K8s Ingress which doesn't allow retry settings.
 	// +optional
 	Retries *HTTPRetry `json:"retries,omitempty"`
+
+	// RewriteHost rewrites the Host header of the requests to this path
+	// before forwarding them, e.g. to the name of a destination outside of
+	// the cluster.
+	//
+	// NOTE: This differs from K8s Ingress which doesn't allow rewriting the host.
+	// +optional
+	RewriteHost string `json:"rewriteHost,omitempty"`
 }
 
 // IngressBackendSplit describes all endpoints for a given service and port.
@@ -244,6 +252,12 @@ type IngressBackendSplit struct {
 	// NOTE: This differs from K8s Ingress which doesn't allow header appending.
 	// +optional
 	AppendHeaders map[string]string `json:"appendHeaders,omitempty"`
+
+	// External marks a backend outside of Knative, which doesn't answer
+	// the probes of the gateways.  Probes are only sent to the other splits
+	// of the path, and paths without any are not probed.
+	// +optional
+	External bool `json:"external,omitempty"`
 }
 
 // IngressBackend describes all endpoints for a given service and port.
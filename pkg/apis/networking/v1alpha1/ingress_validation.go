@@ -23,6 +23,7 @@ import (
 	"knative.dev/pkg/apis"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // Validate inspects and validates Ingress object.
@@ -110,6 +111,11 @@ func (h HTTPIngressPath) Validate(ctx context.Context) *apis.FieldError {
 	if h.Retries != nil {
 		all = all.Also(h.Retries.Validate(ctx).ViaField("retries"))
 	}
+	if h.RewriteHost != "" {
+		if el := validation.IsDNS1123Subdomain(h.RewriteHost); len(el) > 0 {
+			all = all.Also(apis.ErrInvalidValue(h.RewriteHost, "rewriteHost"))
+		}
+	}
 	return all
 }
 
@@ -270,6 +270,48 @@ func TestIngressSpecValidation(t *testing.T) {
 			}},
 		},
 		want: apis.ErrInvalidValue(-1, "rules[0].http.paths[0].retries.attempts"),
+	}, {
+		name: "external-split-with-rewrite-host",
+		is: &IngressSpec{
+			Rules: []IngressRule{{
+				Hosts: []string{"example.com"},
+				HTTP: &HTTPIngressRuleValue{
+					Paths: []HTTPIngressPath{{
+						Splits: []IngressBackendSplit{{
+							IngressBackend: IngressBackend{
+								ServiceName:      "external-000",
+								ServiceNamespace: "default",
+								ServicePort:      intstr.FromInt(80),
+							},
+							External: true,
+						}},
+						RewriteHost: "www.example.org",
+					}},
+				},
+			}},
+		},
+		want: nil,
+	}, {
+		name: "wrong-rewrite-host",
+		is: &IngressSpec{
+			Rules: []IngressRule{{
+				Hosts: []string{"example.com"},
+				HTTP: &HTTPIngressRuleValue{
+					Paths: []HTTPIngressPath{{
+						Splits: []IngressBackendSplit{{
+							IngressBackend: IngressBackend{
+								ServiceName:      "external-000",
+								ServiceNamespace: "default",
+								ServicePort:      intstr.FromInt(80),
+							},
+							External: true,
+						}},
+						RewriteHost: "not a host",
+					}},
+				},
+			}},
+		},
+		want: apis.ErrInvalidValue("not a host", "rules[0].http.paths[0].rewriteHost"),
 	}, {
 		name: "empty-tls",
 		is: &IngressSpec{
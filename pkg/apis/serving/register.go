@@ -63,4 +63,52 @@ const (
 	// QueueSideCarResourcePercentageAnnotation is the percentage of user container resources to be used for queue-proxy
 	// It has to be in [0.1,100]
 	QueueSideCarResourcePercentageAnnotation = "queue.sidecar." + GroupName + "/resourcePercentage"
+
+	// QueueSideCarPodRateLimitAnnotation is the number of requests per second
+	// the queue-proxy of each pod lets through to its user container. Requests
+	// above the limit are rejected with 429. The rate the revision accepts
+	// grows with the number of pods. It has to be positive.
+	QueueSideCarPodRateLimitAnnotation = "queue.sidecar." + GroupName + "/podRateLimit"
+	// QueueSideCarPodRateLimitBurstAnnotation is the number of requests the
+	// queue-proxy of each pod lets through at once on top of the rate limit.
+	// It has to be a positive integer and defaults to 1.
+	QueueSideCarPodRateLimitBurstAnnotation = "queue.sidecar." + GroupName + "/podRateLimitBurst"
+	// QueueSideCarQueuePolicyAnnotation is the order in which the
+	// queue-proxy serves the requests queued in excess of the container
+	// concurrency: "fifo" (the default), "lifo" or "codel".
+	QueueSideCarQueuePolicyAnnotation = "queue.sidecar." + GroupName + "/queuePolicy"
+	// QueueSideCarUnixSocketAnnotation makes the queue-proxy reach the user
+	// container over a Unix domain socket in a volume shared by both
+	// containers, rather than over TCP, when set to "true". The user
+	// container is told the path of the socket to listen on via the
+	// UNIX_SOCKET environment variable. TCP liveness probes are rejected
+	// for such revisions.
+	QueueSideCarUnixSocketAnnotation = "queue.sidecar." + GroupName + "/unixSocket"
+	// QueueSideCarOutlierConsecutiveFailuresAnnotation is the number of
+	// requests in a row which must fail (5xx, proxy error or timeout) for
+	// the queue-proxy to mark its pod not ready until the user container
+	// passes its readiness probe again. It has to be a positive integer.
+	// Outlier detection is disabled if unset.
+	QueueSideCarOutlierConsecutiveFailuresAnnotation = "queue.sidecar." + GroupName + "/outlierConsecutiveFailures"
+	// QueueSideCarOutlierLatencyThresholdAnnotation is the time to the
+	// response headers, e.g. "5s", above which a request counts as failed
+	// for outlier detection. It requires
+	// QueueSideCarOutlierConsecutiveFailuresAnnotation to be set.
+	QueueSideCarOutlierLatencyThresholdAnnotation = "queue.sidecar." + GroupName + "/outlierLatencyThreshold"
+	// QueueSideCarOutlierMaxEjectionPercentAnnotation is the share of the
+	// pods of the revision, from 0 to 100, which may be unavailable for a
+	// pod to eject itself. A pod may always be ejected as long as another
+	// one remains ready. It requires
+	// QueueSideCarOutlierConsecutiveFailuresAnnotation to be set, and
+	// defaults to 10.
+	QueueSideCarOutlierMaxEjectionPercentAnnotation = "queue.sidecar." + GroupName + "/outlierMaxEjectionPercent"
+	// QueueSideCarGRPCHealthCheckAnnotation makes the queue-proxy probe the
+	// user container with the standard gRPC health checking protocol
+	// (grpc.health.v1.Health/Check) when set to "true", rather than with
+	// the handler of its readiness probe. It requires the h2c protocol.
+	QueueSideCarGRPCHealthCheckAnnotation = "queue.sidecar." + GroupName + "/grpcHealthCheck"
+	// AsyncInvocationsAnnotation makes the revision accept asynchronous
+	// invocations, the requests preferring respond-async, when set to
+	// "true". The activator then stays in its request path, at any scale.
+	AsyncInvocationsAnnotation = GroupName + "/asyncInvocations"
 )
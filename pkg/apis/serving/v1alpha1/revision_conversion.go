@@ -51,6 +51,12 @@ func (source *RevisionSpec) ConvertUp(ctx context.Context, sink *v1beta1.Revisio
 	if source.TimeoutSeconds != nil {
 		sink.TimeoutSeconds = ptr.Int64(*source.TimeoutSeconds)
 	}
+	if source.MaxDurationSeconds != nil {
+		sink.MaxDurationSeconds = ptr.Int64(*source.MaxDurationSeconds)
+	}
+	if source.IdleTimeoutSeconds != nil {
+		sink.IdleTimeoutSeconds = ptr.Int64(*source.IdleTimeoutSeconds)
+	}
 	switch {
 	case source.DeprecatedContainer != nil && len(source.Containers) > 0:
 		return apis.ErrMultipleOneOf("container", "containers")
@@ -77,6 +77,8 @@ func TestRevisionConversion(t *testing.T) {
 						}},
 					},
 					TimeoutSeconds:       ptr.Int64(18),
+					MaxDurationSeconds:   ptr.Int64(180),
+					IdleTimeoutSeconds:   ptr.Int64(9),
 					ContainerConcurrency: 53,
 				},
 			},
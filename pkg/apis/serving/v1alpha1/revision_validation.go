@@ -21,12 +21,16 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/knative/serving/pkg/apis/config"
 
 	"knative.dev/pkg/apis"
 	"knative.dev/pkg/kmp"
+	net "github.com/knative/serving/pkg/apis/networking"
 	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 )
 
@@ -85,6 +89,8 @@ func (rt *RevisionTemplateSpec) Validate(ctx context.Context) *apis.FieldError {
 	}
 
 	errs = errs.Also(validateAnnotations(rt.Annotations))
+	errs = errs.Also(validateGRPCHealthCheckAnnotation(rt.Annotations, rt.Spec.GetContainer()))
+	errs = errs.Also(validateUnixSocketAnnotation(rt.Annotations, rt.Spec.GetContainer()))
 	return errs
 }
 
@@ -136,6 +142,7 @@ func (rs *RevisionSpec) Validate(ctx context.Context) *apis.FieldError {
 		}
 		errs = errs.Also(serving.ValidateContainer(
 			*rs.DeprecatedContainer, volumes).ViaField("container"))
+		errs = errs.Also(validateStreamTimeouts(ctx, rs.RevisionSpec))
 	default:
 		errs = errs.Also(apis.ErrMissingOneOf("container", "containers"))
 	}
@@ -157,7 +164,103 @@ func (rs *RevisionSpec) Validate(ctx context.Context) *apis.FieldError {
 }
 
 func validateAnnotations(annotations map[string]string) *apis.FieldError {
-	return validatePercentageAnnotationKey(annotations, serving.QueueSideCarResourcePercentageAnnotation)
+	errs := validatePercentageAnnotationKey(annotations, serving.QueueSideCarResourcePercentageAnnotation).Also(
+		validateRateLimitAnnotations(annotations)).Also(
+		validateOutlierAnnotations(annotations))
+	if v, ok := annotations[serving.QueueSideCarQueuePolicyAnnotation]; ok {
+		switch v {
+		case "fifo", "lifo", "codel":
+		default:
+			errs = errs.Also(apis.ErrInvalidValue(v, apis.CurrentField).ViaKey(serving.QueueSideCarQueuePolicyAnnotation))
+		}
+	}
+	if v, ok := annotations[serving.AsyncInvocationsAnnotation]; ok {
+		if _, err := strconv.ParseBool(v); err != nil {
+			errs = errs.Also(apis.ErrInvalidValue(v, apis.CurrentField).ViaKey(serving.AsyncInvocationsAnnotation))
+		}
+	}
+	return errs
+}
+
+func validateRateLimitAnnotations(annotations map[string]string) *apis.FieldError {
+	var errs *apis.FieldError
+	if v, ok := annotations[serving.QueueSideCarPodRateLimitAnnotation]; ok {
+		if limit, err := strconv.ParseFloat(v, 64); err != nil || limit <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(v, apis.CurrentField).ViaKey(serving.QueueSideCarPodRateLimitAnnotation))
+		}
+	}
+	if v, ok := annotations[serving.QueueSideCarPodRateLimitBurstAnnotation]; ok {
+		if burst, err := strconv.Atoi(v); err != nil || burst < 1 {
+			errs = errs.Also(apis.ErrInvalidValue(v, apis.CurrentField).ViaKey(serving.QueueSideCarPodRateLimitBurstAnnotation))
+		}
+	}
+	return errs
+}
+
+func validateOutlierAnnotations(annotations map[string]string) *apis.FieldError {
+	var errs *apis.FieldError
+	v, hasFailures := annotations[serving.QueueSideCarOutlierConsecutiveFailuresAnnotation]
+	if hasFailures {
+		if failures, err := strconv.Atoi(v); err != nil || failures < 1 {
+			errs = errs.Also(apis.ErrInvalidValue(v, apis.CurrentField).ViaKey(serving.QueueSideCarOutlierConsecutiveFailuresAnnotation))
+		}
+	}
+	if v, ok := annotations[serving.QueueSideCarOutlierLatencyThresholdAnnotation]; ok {
+		if threshold, err := time.ParseDuration(v); err != nil || threshold <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(v, apis.CurrentField).ViaKey(serving.QueueSideCarOutlierLatencyThresholdAnnotation))
+		} else if !hasFailures {
+			errs = errs.Also(apis.ErrMissingField(apis.CurrentField).ViaKey(serving.QueueSideCarOutlierConsecutiveFailuresAnnotation))
+		}
+	}
+	if v, ok := annotations[serving.QueueSideCarOutlierMaxEjectionPercentAnnotation]; ok {
+		if percent, err := strconv.Atoi(v); err != nil || percent < 0 || percent > 100 {
+			errs = errs.Also(apis.ErrInvalidValue(v, apis.CurrentField).ViaKey(serving.QueueSideCarOutlierMaxEjectionPercentAnnotation))
+		} else if !hasFailures {
+			errs = errs.Also(apis.ErrMissingField(apis.CurrentField).ViaKey(serving.QueueSideCarOutlierConsecutiveFailuresAnnotation))
+		}
+	}
+	return errs
+}
+
+// validateGRPCHealthCheckAnnotation checks that gRPC health checks are only
+// enabled for containers speaking h2c.
+func validateGRPCHealthCheckAnnotation(annotations map[string]string, container *corev1.Container) *apis.FieldError {
+	v, ok := annotations[serving.QueueSideCarGRPCHealthCheckAnnotation]
+	if !ok {
+		return nil
+	}
+	enabled, err := strconv.ParseBool(v)
+	if err != nil {
+		return apis.ErrInvalidValue(v, apis.CurrentField).ViaKey(serving.QueueSideCarGRPCHealthCheckAnnotation)
+	}
+	if enabled && (len(container.Ports) == 0 || container.Ports[0].Name != string(net.ProtocolH2C)) {
+		return (&apis.FieldError{
+			Message: "gRPC health checks require the h2c protocol",
+			Paths:   []string{apis.CurrentField},
+		}).ViaKey(serving.QueueSideCarGRPCHealthCheckAnnotation)
+	}
+	return nil
+}
+
+// validateUnixSocketAnnotation rejects TCP liveness probes when the user
+// container serves over a Unix domain socket, as they would still target a
+// TCP port the container may not listen on.
+func validateUnixSocketAnnotation(annotations map[string]string, container *corev1.Container) *apis.FieldError {
+	v, ok := annotations[serving.QueueSideCarUnixSocketAnnotation]
+	if !ok {
+		return nil
+	}
+	enabled, err := strconv.ParseBool(v)
+	if err != nil {
+		return apis.ErrInvalidValue(v, apis.CurrentField).ViaKey(serving.QueueSideCarUnixSocketAnnotation)
+	}
+	if enabled && container.LivenessProbe != nil && container.LivenessProbe.TCPSocket != nil {
+		return (&apis.FieldError{
+			Message: "TCP liveness probes are not supported with a Unix socket",
+			Paths:   []string{apis.CurrentField},
+		}).ViaKey(serving.QueueSideCarUnixSocketAnnotation)
+	}
+	return nil
 }
 
 func validatePercentageAnnotationKey(annotations map[string]string, resourcePercentageAnnotationKey string) *apis.FieldError {
@@ -193,6 +296,33 @@ func validateTimeoutSeconds(ctx context.Context, timeoutSeconds int64) *apis.Fie
 	return nil
 }
 
+func validateStreamTimeouts(ctx context.Context, rs v1beta1.RevisionSpec) *apis.FieldError {
+	var errs *apis.FieldError
+	cfg := config.FromContextOrDefaults(ctx)
+	if rs.MaxDurationSeconds != nil {
+		md := *rs.MaxDurationSeconds
+		if md < 0 || md > cfg.Defaults.MaxRevisionTimeoutSeconds {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(md, 0,
+				cfg.Defaults.MaxRevisionTimeoutSeconds,
+				"maxDurationSeconds"))
+		} else if rs.TimeoutSeconds != nil && md != 0 && md < *rs.TimeoutSeconds {
+			errs = errs.Also(&apis.FieldError{
+				Message: "maxDurationSeconds must not be less than timeoutSeconds",
+				Paths:   []string{"maxDurationSeconds"},
+			})
+		}
+	}
+	if rs.IdleTimeoutSeconds != nil {
+		it := *rs.IdleTimeoutSeconds
+		if it < 0 || it > cfg.Defaults.MaxRevisionTimeoutSeconds {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(it, 0,
+				cfg.Defaults.MaxRevisionTimeoutSeconds,
+				"idleTimeoutSeconds"))
+		}
+	}
+	return errs
+}
+
 // Validate ensures DeprecatedRevisionServingStateType is properly configured.
 func (ss DeprecatedRevisionServingStateType) Validate(ctx context.Context) *apis.FieldError {
 	switch ss {
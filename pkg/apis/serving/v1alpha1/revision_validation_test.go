@@ -265,6 +265,34 @@ func TestRevisionSpecValidation(t *testing.T) {
 		want: apis.ErrOutOfBoundsValue(-30, 0,
 			config.DefaultMaxRevisionTimeoutSeconds,
 			"timeoutSeconds"),
+	}, {
+		name: "max duration below timeout",
+		rs: &RevisionSpec{
+			DeprecatedContainer: &corev1.Container{
+				Image: "helloworld",
+			},
+			RevisionSpec: v1beta1.RevisionSpec{
+				TimeoutSeconds:     ptr.Int64(30),
+				MaxDurationSeconds: ptr.Int64(10),
+			},
+		},
+		want: &apis.FieldError{
+			Message: "maxDurationSeconds must not be less than timeoutSeconds",
+			Paths:   []string{"maxDurationSeconds"},
+		},
+	}, {
+		name: "exceed max idle timeout",
+		rs: &RevisionSpec{
+			DeprecatedContainer: &corev1.Container{
+				Image: "helloworld",
+			},
+			RevisionSpec: v1beta1.RevisionSpec{
+				IdleTimeoutSeconds: ptr.Int64(6000),
+			},
+		},
+		want: apis.ErrOutOfBoundsValue(6000, 0,
+			config.DefaultMaxRevisionTimeoutSeconds,
+			"idleTimeoutSeconds"),
 	}}
 
 	for _, test := range tests {
@@ -362,6 +390,397 @@ func TestRevisionTemplateSpecValidation(t *testing.T) {
 			Message: "invalid value: 50mx",
 			Paths:   []string{fmt.Sprintf("[%s]", serving.QueueSideCarResourcePercentageAnnotation)},
 		},
+	}, {
+		name: "Valid rate limit annotations",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarPodRateLimitAnnotation:      "0.5",
+					serving.QueueSideCarPodRateLimitBurstAnnotation: "10",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "Invalid rate limit annotation",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarPodRateLimitAnnotation: "0",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: 0",
+			Paths:   []string{fmt.Sprintf("[%s]", serving.QueueSideCarPodRateLimitAnnotation)},
+		},
+	}, {
+		name: "Invalid rate limit burst annotation",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarPodRateLimitBurstAnnotation: "1.5",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: 1.5",
+			Paths:   []string{fmt.Sprintf("[%s]", serving.QueueSideCarPodRateLimitBurstAnnotation)},
+		},
+	}, {
+		name: "Valid queue policy annotation",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarQueuePolicyAnnotation: "codel",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "Invalid queue policy annotation",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarQueuePolicyAnnotation: "random",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: random",
+			Paths:   []string{fmt.Sprintf("[%s]", serving.QueueSideCarQueuePolicyAnnotation)},
+		},
+	}, {
+		name: "Valid async invocations annotation",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.AsyncInvocationsAnnotation: "true",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "Invalid async invocations annotation",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.AsyncInvocationsAnnotation: "later",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: later",
+			Paths:   []string{fmt.Sprintf("[%s]", serving.AsyncInvocationsAnnotation)},
+		},
+	}, {
+		name: "Valid unix socket annotation",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarUnixSocketAnnotation: "true",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "Invalid unix socket annotation",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarUnixSocketAnnotation: "sometimes",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: sometimes",
+			Paths:   []string{fmt.Sprintf("[%s]", serving.QueueSideCarUnixSocketAnnotation)},
+		},
+	}, {
+		name: "Unix socket annotation with TCP liveness probe",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarUnixSocketAnnotation: "true",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+					LivenessProbe: &corev1.Probe{
+						Handler: corev1.Handler{
+							TCPSocket: &corev1.TCPSocketAction{},
+						},
+					},
+				},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "TCP liveness probes are not supported with a Unix socket",
+			Paths:   []string{fmt.Sprintf("[%s]", serving.QueueSideCarUnixSocketAnnotation)},
+		},
+	}, {
+		name: "Unix socket annotation with HTTP liveness probe",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarUnixSocketAnnotation: "true",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+					LivenessProbe: &corev1.Probe{
+						Handler: corev1.Handler{
+							HTTPGet: &corev1.HTTPGetAction{},
+						},
+					},
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "Disabled unix socket annotation with TCP liveness probe",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarUnixSocketAnnotation: "false",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+					LivenessProbe: &corev1.Probe{
+						Handler: corev1.Handler{
+							TCPSocket: &corev1.TCPSocketAction{},
+						},
+					},
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "Valid outlier detection annotations",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarOutlierConsecutiveFailuresAnnotation: "5",
+					serving.QueueSideCarOutlierLatencyThresholdAnnotation:    "2s",
+					serving.QueueSideCarOutlierMaxEjectionPercentAnnotation:  "50",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "Invalid outlier consecutive failures annotation",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarOutlierConsecutiveFailuresAnnotation: "0",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: 0",
+			Paths:   []string{fmt.Sprintf("[%s]", serving.QueueSideCarOutlierConsecutiveFailuresAnnotation)},
+		},
+	}, {
+		name: "Invalid outlier latency threshold annotation",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarOutlierConsecutiveFailuresAnnotation: "5",
+					serving.QueueSideCarOutlierLatencyThresholdAnnotation:    "soon",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: soon",
+			Paths:   []string{fmt.Sprintf("[%s]", serving.QueueSideCarOutlierLatencyThresholdAnnotation)},
+		},
+	}, {
+		name: "Outlier latency threshold annotation without consecutive failures",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarOutlierLatencyThresholdAnnotation: "2s",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: apis.ErrMissingField(fmt.Sprintf("[%s]", serving.QueueSideCarOutlierConsecutiveFailuresAnnotation)),
+	}, {
+		name: "Invalid outlier max ejection percent annotation",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarOutlierConsecutiveFailuresAnnotation: "5",
+					serving.QueueSideCarOutlierMaxEjectionPercentAnnotation:  "101",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: 101",
+			Paths:   []string{fmt.Sprintf("[%s]", serving.QueueSideCarOutlierMaxEjectionPercentAnnotation)},
+		},
+	}, {
+		name: "Outlier max ejection percent annotation without consecutive failures",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarOutlierMaxEjectionPercentAnnotation: "50",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: apis.ErrMissingField(fmt.Sprintf("[%s]", serving.QueueSideCarOutlierConsecutiveFailuresAnnotation)),
+	}, {
+		name: "Valid gRPC health check annotation",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarGRPCHealthCheckAnnotation: "true",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+					Ports: []corev1.ContainerPort{{
+						Name:          "h2c",
+						ContainerPort: 8080,
+					}},
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "Invalid gRPC health check annotation",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarGRPCHealthCheckAnnotation: "maybe",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+					Ports: []corev1.ContainerPort{{
+						Name:          "h2c",
+						ContainerPort: 8080,
+					}},
+				},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: maybe",
+			Paths:   []string{fmt.Sprintf("[%s]", serving.QueueSideCarGRPCHealthCheckAnnotation)},
+		},
+	}, {
+		name: "gRPC health check annotation without h2c",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarGRPCHealthCheckAnnotation: "true",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "gRPC health checks require the h2c protocol",
+			Paths:   []string{fmt.Sprintf("[%s]", serving.QueueSideCarGRPCHealthCheckAnnotation)},
+		},
+	}, {
+		name: "Disabled gRPC health check annotation without h2c",
+		rts: &RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.QueueSideCarGRPCHealthCheckAnnotation: "false",
+				},
+			},
+			Spec: RevisionSpec{
+				DeprecatedContainer: &corev1.Container{
+					Image: "helloworld",
+				},
+			},
+		},
+		want: nil,
 	}}
 
 	for _, test := range tests {
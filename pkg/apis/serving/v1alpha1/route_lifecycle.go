@@ -67,6 +67,13 @@ func (rs *RouteStatus) MarkServiceNotOwned(name string) {
 		fmt.Sprintf("There is an existing placeholder Service %q that we do not own.", name))
 }
 
+// MarkIngressNotOwned changes the IngressReady status to be false with the reason being that
+// there is a pre-existing Ingress with the name we wanted to use.
+func (rs *RouteStatus) MarkIngressNotOwned(name string) {
+	routeCondSet.Manage(rs).MarkFalse(RouteConditionIngressReady, "NotOwned",
+		"There is an existing Ingress %q that we do not own.", name)
+}
+
 // MarkIngressNotConfigured changes the IngressReady condition to be unknown to reflect
 // that the Ingress does not yet have a Status
 func (rs *RouteStatus) MarkIngressNotConfigured() {
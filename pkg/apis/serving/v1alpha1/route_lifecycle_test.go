@@ -335,6 +335,16 @@ func TestRouteNotOwnedStuff(t *testing.T) {
 	apitesting.CheckConditionFailed(r.duck(), RouteConditionReady, t)
 }
 
+func TestRouteIngressNotOwned(t *testing.T) {
+	r := &RouteStatus{}
+	r.InitializeConditions()
+
+	r.MarkIngressNotOwned("evan")
+	apitesting.CheckConditionOngoing(r.duck(), RouteConditionAllTrafficAssigned, t)
+	apitesting.CheckConditionFailed(r.duck(), RouteConditionIngressReady, t)
+	apitesting.CheckConditionFailed(r.duck(), RouteConditionReady, t)
+}
+
 func TestRouteGetGroupVersionKind(t *testing.T) {
 	r := &Route{}
 	want := schema.GroupVersionKind{
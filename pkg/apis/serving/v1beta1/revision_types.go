@@ -92,6 +92,19 @@ type RevisionSpec struct {
 	// be provided.
 	// +optional
 	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty"`
+
+	// MaxDurationSeconds holds the max duration the instance is allowed
+	// for writing the whole response to a request, including streaming
+	// responses.  If unspecified, no limit is enforced beyond
+	// TimeoutSeconds.
+	// +optional
+	MaxDurationSeconds *int64 `json:"maxDurationSeconds,omitempty"`
+
+	// IdleTimeoutSeconds holds the max duration the instance is allowed
+	// to go without writing to a response once it has started.  If
+	// unspecified, no limit is enforced.
+	// +optional
+	IdleTimeoutSeconds *int64 `json:"idleTimeoutSeconds,omitempty"`
 }
 
 const (
@@ -113,18 +113,36 @@ func (rs *RevisionSpec) Validate(ctx context.Context) *apis.FieldError {
 
 	err = err.Also(serving.ValidatePodSpec(rs.PodSpec))
 
-	if rs.TimeoutSeconds != nil {
-		ts := *rs.TimeoutSeconds
-		cfg := config.FromContextOrDefaults(ctx)
-		if ts < 0 || ts > cfg.Defaults.MaxRevisionTimeoutSeconds {
-			err = err.Also(apis.ErrOutOfBoundsValue(
-				ts, 0, cfg.Defaults.MaxRevisionTimeoutSeconds, "timeoutSeconds"))
-		}
+	err = err.Also(validateTimeoutSeconds(ctx, rs.TimeoutSeconds, "timeoutSeconds"))
+	err = err.Also(validateTimeoutSeconds(ctx, rs.MaxDurationSeconds, "maxDurationSeconds"))
+	err = err.Also(validateTimeoutSeconds(ctx, rs.IdleTimeoutSeconds, "idleTimeoutSeconds"))
+
+	if rs.TimeoutSeconds != nil && rs.MaxDurationSeconds != nil &&
+		*rs.MaxDurationSeconds != 0 && *rs.MaxDurationSeconds < *rs.TimeoutSeconds {
+		err = err.Also(&apis.FieldError{
+			Message: "maxDurationSeconds must not be less than timeoutSeconds",
+			Paths:   []string{"maxDurationSeconds"},
+		})
 	}
 
 	return err
 }
 
+// validateTimeoutSeconds checks that the optional timeout lies within the
+// configured bounds.
+func validateTimeoutSeconds(ctx context.Context, timeout *int64, field string) *apis.FieldError {
+	if timeout == nil {
+		return nil
+	}
+	ts := *timeout
+	cfg := config.FromContextOrDefaults(ctx)
+	if ts < 0 || ts > cfg.Defaults.MaxRevisionTimeoutSeconds {
+		return apis.ErrOutOfBoundsValue(
+			ts, 0, cfg.Defaults.MaxRevisionTimeoutSeconds, field)
+	}
+	return nil
+}
+
 // Validate implements apis.Validatable.
 func (cc RevisionContainerConcurrencyType) Validate(ctx context.Context) *apis.FieldError {
 	if cc < 0 || cc > RevisionContainerConcurrencyMax {
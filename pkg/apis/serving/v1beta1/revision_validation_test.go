@@ -276,6 +276,60 @@ func TestRevisionSpecValidation(t *testing.T) {
 		want: apis.ErrOutOfBoundsValue(
 			-30, 0, config.DefaultMaxRevisionTimeoutSeconds,
 			"timeoutSeconds"),
+	}, {
+		name: "stream timeouts (ok)",
+		rs: &RevisionSpec{
+			PodSpec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Image: "helloworld",
+				}},
+			},
+			TimeoutSeconds:     ptr.Int64(30),
+			MaxDurationSeconds: ptr.Int64(300),
+			IdleTimeoutSeconds: ptr.Int64(10),
+		},
+		want: nil,
+	}, {
+		name: "exceed max duration",
+		rs: &RevisionSpec{
+			PodSpec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Image: "helloworld",
+				}},
+			},
+			MaxDurationSeconds: ptr.Int64(6000),
+		},
+		want: apis.ErrOutOfBoundsValue(
+			6000, 0, config.DefaultMaxRevisionTimeoutSeconds,
+			"maxDurationSeconds"),
+	}, {
+		name: "max duration below timeout",
+		rs: &RevisionSpec{
+			PodSpec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Image: "helloworld",
+				}},
+			},
+			TimeoutSeconds:     ptr.Int64(30),
+			MaxDurationSeconds: ptr.Int64(10),
+		},
+		want: &apis.FieldError{
+			Message: "maxDurationSeconds must not be less than timeoutSeconds",
+			Paths:   []string{"maxDurationSeconds"},
+		},
+	}, {
+		name: "negative idle timeout",
+		rs: &RevisionSpec{
+			PodSpec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Image: "helloworld",
+				}},
+			},
+			IdleTimeoutSeconds: ptr.Int64(-1),
+		},
+		want: apis.ErrOutOfBoundsValue(
+			-1, 0, config.DefaultMaxRevisionTimeoutSeconds,
+			"idleTimeoutSeconds"),
 	}}
 
 	for _, test := range tests {
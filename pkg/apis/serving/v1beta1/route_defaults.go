@@ -44,8 +44,20 @@ func (rs *RouteSpec) SetDefaults(ctx context.Context) {
 
 // SetDefaults implements apis.Defaultable
 func (tt *TrafficTarget) SetDefaults(ctx context.Context) {
+	// External targets neither pin nor float over Revisions.
+	if tt.External != nil {
+		tt.External.SetDefaults(ctx)
+		return
+	}
 	if tt.LatestRevision == nil {
 		sense := (tt.RevisionName == "")
 		tt.LatestRevision = &sense
 	}
 }
+
+// SetDefaults implements apis.Defaultable
+func (et *ExternalTarget) SetDefaults(ctx context.Context) {
+	if et.Port == 0 {
+		et.Port = 80
+	}
+}
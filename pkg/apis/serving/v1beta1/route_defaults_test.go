@@ -79,6 +79,36 @@ func TestRouteDefaulting(t *testing.T) {
 				}},
 			},
 		},
+	}, {
+		name: "external port defaulting",
+		in: &Route{
+			Spec: RouteSpec{
+				Traffic: []TrafficTarget{{
+					RevisionName: "foo",
+					Percent:      90,
+				}, {
+					External: &ExternalTarget{
+						Host: "legacy.example.com",
+					},
+					Percent: 10,
+				}},
+			},
+		},
+		want: &Route{
+			Spec: RouteSpec{
+				Traffic: []TrafficTarget{{
+					RevisionName:   "foo",
+					Percent:        90,
+					LatestRevision: ptr.Bool(false),
+				}, {
+					External: &ExternalTarget{
+						Host: "legacy.example.com",
+						Port: 80,
+					},
+					Percent: 10,
+				}},
+			},
+		},
 	}}
 
 	for _, test := range tests {
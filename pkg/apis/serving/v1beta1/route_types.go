@@ -89,6 +89,13 @@ type TrafficTarget struct {
 	// +optional
 	LatestRevision *bool `json:"latestRevision,omitempty"`
 
+	// External, when set, sends this portion of traffic to a destination
+	// outside of Knative, such as a legacy application being migrated,
+	// instead of to a Revision.  This is mutually exclusive with RevisionName,
+	// ConfigurationName and LatestRevision.
+	// +optional
+	External *ExternalTarget `json:"external,omitempty"`
+
 	// Percent specifies percent of the traffic to this Revision or Configuration.
 	// This defaults to zero if unspecified.
 	// +optional
@@ -101,6 +108,25 @@ type TrafficTarget struct {
 	URL *apis.URL `json:"url,omitempty"`
 }
 
+// ExternalTarget is a destination of traffic outside of Knative: either a
+// host outside of the cluster, or a K8s Service in the namespace of the Route.
+type ExternalTarget struct {
+	// Host is the DNS name of a destination outside of the cluster, which
+	// is reached through a K8s Service of type ExternalName.  This is
+	// mutually exclusive with ServiceName.
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// ServiceName is the name of a K8s Service in the namespace of the
+	// Route.  This is mutually exclusive with Host.
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
+
+	// Port is the port the destination serves HTTP on.  This defaults to 80.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+}
+
 // RouteSpec holds the desired state of the Route (from the client).
 type RouteSpec struct {
 	// Traffic specifies how to distribute traffic over a collection of
@@ -85,6 +85,15 @@ func (tt *TrafficTarget) validateRevisionAndConfiguration(ctx context.Context, e
 	// We only validate the sense of latestRevision in the context of a Spec,
 	// and only when it is specified.
 	switch {
+	// External targets don't send traffic to any Revision, so they
+	// can't name one.
+	case tt.External != nil:
+		if tt.RevisionName != "" || tt.ConfigurationName != "" {
+			errs = errs.Also(apis.ErrMultipleOneOf(
+				"external", "revisionName", "configurationName"))
+		}
+		errs = errs.Also(tt.External.Validate(ctx).ViaField("external"))
+
 	// When we have a default configurationName, we don't
 	// allow one to be specified.
 	case HasDefaultConfigurationName(ctx) && tt.ConfigurationName != "":
@@ -135,6 +144,12 @@ func (tt *TrafficTarget) validateTrafficPercentage(errs *apis.FieldError) *apis.
 }
 
 func (tt *TrafficTarget) validateLatestRevision(ctx context.Context) *apis.FieldError {
+	if tt.External != nil {
+		if tt.LatestRevision != nil {
+			return apis.ErrDisallowedFields("latestRevision")
+		}
+		return nil
+	}
 	if apis.IsInSpec(ctx) && tt.LatestRevision != nil {
 		lr := *tt.LatestRevision
 		pinned := tt.RevisionName != ""
@@ -168,6 +183,29 @@ func (tt *TrafficTarget) validateUrl(ctx context.Context, errs *apis.FieldError)
 	return errs
 }
 
+// Validate verifies that ExternalTarget names exactly one destination.
+func (et *ExternalTarget) Validate(ctx context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+	switch {
+	case et.Host != "" && et.ServiceName != "":
+		errs = errs.Also(apis.ErrMultipleOneOf("host", "serviceName"))
+	case et.Host != "":
+		if el := validation.IsDNS1123Subdomain(et.Host); len(el) > 0 {
+			errs = errs.Also(apis.ErrInvalidValue(et.Host, "host"))
+		}
+	case et.ServiceName != "":
+		if el := validation.IsDNS1123Label(et.ServiceName); len(el) > 0 {
+			errs = errs.Also(apis.ErrInvalidValue(et.ServiceName, "serviceName"))
+		}
+	default:
+		errs = errs.Also(apis.ErrMissingOneOf("host", "serviceName"))
+	}
+	if et.Port < 0 || et.Port > 65535 {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(et.Port, 0, 65535, "port"))
+	}
+	return errs
+}
+
 // Validate implements apis.Validatable
 func (rs *RouteStatus) Validate(ctx context.Context) *apis.FieldError {
 	return rs.RouteStatusFields.Validate(ctx)
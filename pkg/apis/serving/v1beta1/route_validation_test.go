@@ -262,6 +262,92 @@ func TestTrafficTargetValidation(t *testing.T) {
 		},
 		wc:   apis.WithinSpec,
 		want: apis.ErrDisallowedFields("url"),
+	}, {
+		name: "valid with external host",
+		tt: &TrafficTarget{
+			External: &ExternalTarget{
+				Host: "legacy.example.com",
+				Port: 8080,
+			},
+			Percent: 10,
+		},
+		wc:   apis.WithinSpec,
+		want: nil,
+	}, {
+		name: "valid with external service (status)",
+		tt: &TrafficTarget{
+			External: &ExternalTarget{
+				ServiceName: "legacy",
+			},
+			Percent: 10,
+		},
+		wc:   apis.WithinStatus,
+		want: nil,
+	}, {
+		name: "valid with external host and default configuration",
+		tt: &TrafficTarget{
+			External: &ExternalTarget{
+				Host: "legacy.example.com",
+			},
+			Percent: 10,
+		},
+		wc: func(ctx context.Context) context.Context {
+			return WithDefaultConfigurationName(apis.WithinSpec(ctx))
+		},
+		want: nil,
+	}, {
+		name: "invalid with external and revisionName",
+		tt: &TrafficTarget{
+			RevisionName: "foo",
+			External: &ExternalTarget{
+				Host: "legacy.example.com",
+			},
+			Percent: 10,
+		},
+		wc:   apis.WithinSpec,
+		want: apis.ErrMultipleOneOf("external", "revisionName", "configurationName"),
+	}, {
+		name: "invalid with external and latestRevision",
+		tt: &TrafficTarget{
+			LatestRevision: ptr.Bool(true),
+			External: &ExternalTarget{
+				Host: "legacy.example.com",
+			},
+			Percent: 10,
+		},
+		wc:   apis.WithinSpec,
+		want: apis.ErrDisallowedFields("latestRevision"),
+	}, {
+		name: "invalid with external host and serviceName",
+		tt: &TrafficTarget{
+			External: &ExternalTarget{
+				Host:        "legacy.example.com",
+				ServiceName: "legacy",
+			},
+			Percent: 10,
+		},
+		wc:   apis.WithinSpec,
+		want: apis.ErrMultipleOneOf("external.host", "external.serviceName"),
+	}, {
+		name: "invalid with empty external",
+		tt: &TrafficTarget{
+			External: &ExternalTarget{},
+			Percent:  10,
+		},
+		wc:   apis.WithinSpec,
+		want: apis.ErrMissingOneOf("external.host", "external.serviceName"),
+	}, {
+		name: "invalid with bad external host and port",
+		tt: &TrafficTarget{
+			External: &ExternalTarget{
+				Host: "Legacy_Example",
+				Port: 70000,
+			},
+			Percent: 10,
+		},
+		wc: apis.WithinSpec,
+		want: apis.ErrInvalidValue("Legacy_Example", "external.host").Also(
+			apis.ErrOutOfBoundsValue(70000, 0, 65535, "external.port")),
 	}}
 
 	for _, test := range tests {
@@ -50,3 +50,24 @@ func (a *Average) Value() float64 {
 	}
 	return a.sum / a.count
 }
+
+// PodAverage is used to keep the values necessary to compute the average
+// value of a single pod, rather than of the sum over all pods.
+type PodAverage struct {
+	sum   float64
+	count float64
+}
+
+// Accumulate accumulates the values needed to compute the average.
+func (a *PodAverage) Accumulate(_ time.Time, bucket float64Bucket) {
+	a.sum += bucket.Sum()
+	a.count += float64(len(bucket))
+}
+
+// Value returns the average or 0 if no buckets have been accumulated.
+func (a *PodAverage) Value() float64 {
+	if a.count == 0 {
+		return 0
+	}
+	return a.sum / a.count
+}
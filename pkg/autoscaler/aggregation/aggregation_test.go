@@ -52,6 +52,42 @@ func TestAverage(t *testing.T) {
 	}
 }
 
+func TestPodAverage(t *testing.T) {
+	tests := []struct {
+		name    string
+		buckets []map[string]float64
+		want    float64
+	}{{
+		name: "empty",
+		want: 0.0,
+	}, {
+		name:    "single pod",
+		buckets: []map[string]float64{{"pod1": 2.0}, {"pod1": 4.0}},
+		want:    3.0,
+	}, {
+		name:    "several pods",
+		buckets: []map[string]float64{{"pod1": 2.0, "pod2": 4.0}, {"pod1": 9.0}},
+		want:    5.0,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			average := PodAverage{}
+			for _, values := range tt.buckets {
+				bucket := float64Bucket{}
+				for pod, value := range values {
+					bucket.Record(pod, value)
+				}
+				average.Accumulate(time.Now(), bucket)
+			}
+
+			if got := average.Value(); got != tt.want {
+				t.Errorf("Value() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestYoungerThan(t *testing.T) {
 	t0 := time.Now()
 	t1 := t0.Add(1 * time.Second)
@@ -115,6 +115,17 @@ func (a *Autoscaler) Scale(ctx context.Context, now time.Time) (desiredPodCount
 		return 0, 0, false
 	}
 
+	// Pods with an adaptive concurrency limit report the limit they adapted
+	// to, which takes the place of the container concurrency the targets
+	// are derived from.
+	if limit, err := a.metricClient.ConcurrencyLimit(metricKey); err == nil && spec.TotalConcurrency > 0 {
+		ratio := limit / spec.TotalConcurrency
+		spec.TargetConcurrency *= ratio
+		spec.PanicThreshold *= ratio
+		spec.TotalConcurrency = limit
+		logger.Debugf("Pods adapted their concurrency limit to %0.3f.", limit)
+	}
+
 	maxScaleUp := spec.MaxScaleUpRate * readyPodsCount
 	desiredStablePodCount := int32(math.Min(math.Ceil(observedStableConcurrency/spec.TargetConcurrency), maxScaleUp))
 	desiredPanicPodCount := int32(math.Min(math.Ceil(observedPanicConcurrency/spec.TargetConcurrency), maxScaleUp))
@@ -165,8 +176,8 @@ func (a *Autoscaler) Scale(ctx context.Context, now time.Time) (desiredPodCount
 	// be making knee-jerk decisions about Activator in the request path. Negative EBC means
 	// that the deployment does not have enough capacity to serve the desired burst off hand.
 	// EBC = TotCapacity - Cur#ReqInFlight - TargetBurstCapacity
-	excessBC = int32(float64(originalReadyPodsCount)*a.deciderSpec.TotalConcurrency - observedStableConcurrency -
-		a.deciderSpec.TargetBurstCapacity)
+	excessBC = int32(float64(originalReadyPodsCount)*spec.TotalConcurrency - observedStableConcurrency -
+		spec.TargetBurstCapacity)
 	logger.Debug("Excess burst capacity = ", excessBC)
 
 	a.reporter.ReportDesiredPodCount(int64(desiredPodCount))
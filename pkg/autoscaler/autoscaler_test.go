@@ -116,6 +116,14 @@ func TestAutoscalerStableModeDecrease(t *testing.T) {
 	a.expectScale(t, time.Now(), 5, expectedEBC(10, 98, 50, 8), true)
 }
 
+func TestAutoscalerStableModeAdaptiveConcurrencyLimit(t *testing.T) {
+	// The pods adapted to half of the container concurrency, which halves
+	// the target as well.
+	metrics := &testMetricClient{stableConcurrency: 50.0, concurrencyLimit: 10 / targetUtilization / 2}
+	a := newTestAutoscaler(10, 100, metrics)
+	a.expectScale(t, time.Now(), 10, expectedEBC(5, 100, 50, 1), true)
+}
+
 func TestAutoscalerStableModeNoTrafficScaleToZero(t *testing.T) {
 	metrics := &testMetricClient{stableConcurrency: 1}
 	a := newTestAutoscaler(10, 75, metrics)
@@ -293,6 +301,7 @@ func (a *Autoscaler) expectScale(t *testing.T, now time.Time, expectScale, expec
 type testMetricClient struct {
 	stableConcurrency float64
 	panicConcurrency  float64
+	concurrencyLimit  float64
 	err               error
 }
 
@@ -300,6 +309,13 @@ func (t *testMetricClient) StableAndPanicConcurrency(key string) (float64, float
 	return t.stableConcurrency, t.panicConcurrency, t.err
 }
 
+func (t *testMetricClient) ConcurrencyLimit(key string) (float64, error) {
+	if t.concurrencyLimit == 0 {
+		return 0, ErrNoData
+	}
+	return t.concurrencyLimit, nil
+}
+
 func endpoints(count int) {
 	epAddresses := make([]corev1.EndpointAddress, count)
 	for i := 0; i < count; i++ {
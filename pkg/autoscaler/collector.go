@@ -88,6 +88,14 @@ type Stat struct {
 
 	// Part of RequestCount, for requests going through a proxy.
 	ProxiedRequestCount float64
+
+	// The concurrency limit the pod adapted to, or 0 if its limit is
+	// fixed to the container concurrency.
+	ConcurrencyLimit float64
+
+	// Number of requests rejected by the rate limiter since last Stat.
+	// These are not part of RequestCount.
+	RateLimitedRequestCount float64
 }
 
 // StatMessage wraps a Stat with identifying information so it can be routed
@@ -101,6 +109,9 @@ type StatMessage struct {
 type MetricClient interface {
 	// StableAndPanicConcurrency returns both the stable and the panic concurrency.
 	StableAndPanicConcurrency(key string) (float64, float64, error)
+	// ConcurrencyLimit returns the average concurrency limit the pods
+	// adapted to, or ErrNoData if their limit is fixed.
+	ConcurrencyLimit(key string) (float64, error)
 }
 
 // MetricCollector manages collection of metrics for many entities.
@@ -217,6 +228,19 @@ func (c *MetricCollector) StableAndPanicConcurrency(key string) (float64, float6
 	return collection.stableAndPanicConcurrency(time.Now())
 }
 
+// ConcurrencyLimit returns the average concurrency limit the pods adapted to.
+func (c *MetricCollector) ConcurrencyLimit(key string) (float64, error) {
+	c.collectionsMutex.RLock()
+	defer c.collectionsMutex.RUnlock()
+
+	collection, exists := c.collections[key]
+	if !exists {
+		return 0, k8serrors.NewNotFound(kpa.Resource("Metrics"), key)
+	}
+
+	return collection.concurrencyLimit(time.Now())
+}
+
 // collection represents the collection of metrics for one specific entity.
 type collection struct {
 	metricMutex sync.RWMutex
@@ -225,6 +249,9 @@ type collection struct {
 	scraperMutex sync.RWMutex
 	scraper      StatsScraper
 	buckets      *aggregation.TimedFloat64Buckets
+	// limitBuckets keeps the concurrency limits reported by pods which
+	// adapt them.
+	limitBuckets *aggregation.TimedFloat64Buckets
 
 	grp    sync.WaitGroup
 	stopCh chan struct{}
@@ -245,9 +272,10 @@ func (c *collection) getScraper() StatsScraper {
 // newCollection creates a new collection.
 func newCollection(metric *Metric, scraper StatsScraper, logger *zap.SugaredLogger) *collection {
 	c := &collection{
-		metric:  metric,
-		buckets: aggregation.NewTimedFloat64Buckets(BucketSize),
-		scraper: scraper,
+		metric:       metric,
+		buckets:      aggregation.NewTimedFloat64Buckets(BucketSize),
+		limitBuckets: aggregation.NewTimedFloat64Buckets(BucketSize),
+		scraper:      scraper,
 
 		stopCh: make(chan struct{}),
 	}
@@ -298,6 +326,9 @@ func (c *collection) record(stat Stat) {
 	// Proxied requests have been counted at the activator. Subtract
 	// AverageProxiedConcurrentRequests to avoid double counting.
 	c.buckets.Record(*stat.Time, stat.PodName, stat.AverageConcurrentRequests-stat.AverageProxiedConcurrentRequests)
+	if stat.ConcurrencyLimit > 0 {
+		c.limitBuckets.Record(*stat.Time, stat.PodName, stat.ConcurrencyLimit)
+	}
 }
 
 // stableAndPanicConcurrency calculates both stable and panic concurrency based on the
@@ -321,6 +352,22 @@ func (c *collection) stableAndPanicConcurrency(now time.Time) (float64, float64,
 	return stableAverage.Value(), panicAverage.Value(), nil
 }
 
+// concurrencyLimit calculates the average concurrency limit of a pod over
+// the stable window.
+func (c *collection) concurrencyLimit(now time.Time) (float64, error) {
+	spec := c.currentMetric().Spec
+
+	c.limitBuckets.RemoveOlderThan(now.Add(-spec.StableWindow))
+
+	if c.limitBuckets.IsEmpty() {
+		return 0, ErrNoData
+	}
+
+	average := aggregation.PodAverage{}
+	c.limitBuckets.ForEachBucket(average.Accumulate)
+	return average.Value(), nil
+}
+
 // close stops collecting metrics, stops the scraper.
 func (c *collection) close() {
 	close(c.stopCh)
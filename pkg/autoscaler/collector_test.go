@@ -208,6 +208,39 @@ func TestMetricCollectorRecord(t *testing.T) {
 	}
 }
 
+func TestMetricCollectorConcurrencyLimit(t *testing.T) {
+	defer ClearAll()
+
+	logger := TestLogger(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	metricKey := NewMetricKey(defaultNamespace, defaultName)
+	scraper := &testScraper{
+		s: func() (*StatMessage, error) {
+			return nil, nil
+		},
+	}
+	coll := NewMetricCollector(scraperFactory(scraper, nil), logger)
+	coll.Create(ctx, defaultMetric)
+
+	// Pods with a fixed limit don't report one.
+	coll.Record(metricKey, Stat{Time: &now, PodName: "pod1"})
+	if _, err := coll.ConcurrencyLimit(metricKey); err != ErrNoData {
+		t.Errorf("ConcurrencyLimit() = %v, want: %v", err, ErrNoData)
+	}
+
+	coll.Record(metricKey, Stat{Time: &now, PodName: "pod1", ConcurrencyLimit: 4})
+	coll.Record(metricKey, Stat{Time: &now, PodName: "pod2", ConcurrencyLimit: 8})
+	if got, err := coll.ConcurrencyLimit(metricKey); got != 6 || err != nil {
+		t.Errorf("ConcurrencyLimit() = %v, %v; want 6, nil", got, err)
+	}
+
+	if _, err := coll.ConcurrencyLimit("unknown/key"); err == nil {
+		t.Error("ConcurrencyLimit() = nil for an unknown key, wanted an error")
+	}
+}
+
 func scraperFactory(scraper StatsScraper, err error) StatsScraperFactory {
 	return func(*Metric) (StatsScraper, error) {
 		return scraper, err
@@ -84,6 +84,11 @@ func extractData(body io.Reader) (*Stat, error) {
 			}
 		}
 	}
+	// The limit is only reported by pods with an adaptive concurrency limit,
+	// and not by those of older releases.
+	if pm := prometheusMetric(metricFamilies, "queue_concurrency_limit"); pm != nil {
+		stat.ConcurrencyLimit = *pm.Gauge.Value
+	}
 	return &stat, nil
 }
 
@@ -44,6 +44,10 @@ queue_average_proxied_concurrent_requests{destination_namespace="test-namespace"
 	testProxiedQPSContext = `# HELP queue_proxied_operations_per_second Number of proxied requests received since last Stat
 # TYPE queue_proxied_operations_per_second gauge
 queue_proxied_operations_per_second{destination_namespace="test-namespace",destination_revision="test-revision",destination_pod="test-revision-1234"} 4
+`
+	testConcurrencyLimitContext = `# HELP queue_concurrency_limit The adaptive limit of requests handled by this pod concurrently
+# TYPE queue_concurrency_limit gauge
+queue_concurrency_limit{destination_namespace="test-namespace",destination_revision="test-revision",destination_pod="test-revision-1234"} 7
 `
 	testFullContext = testAverageConcurrencyContext + testQPSContext + testAverageProxiedConcurrenyContext + testProxiedQPSContext
 )
@@ -99,6 +103,25 @@ func TestHTTPScrapeClient_Scrape_HappyCase(t *testing.T) {
 	if stat.PodName != "test-revision-1234" {
 		t.Errorf("stat.PodName = %s, want test-revision-1234", stat.PodName)
 	}
+	if stat.ConcurrencyLimit != 0 {
+		t.Errorf("stat.ConcurrencyLimit = %v, want 0", stat.ConcurrencyLimit)
+	}
+}
+
+func TestHTTPScrapeClient_Scrape_ConcurrencyLimit(t *testing.T) {
+	hClient := newTestHTTPClient(getHTTPResponse(http.StatusOK, testFullContext+testConcurrencyLimitContext), nil)
+	sClient, err := newHTTPScrapeClient(hClient)
+	if err != nil {
+		t.Fatalf("newHTTPScrapeClient = %v, want no error", err)
+	}
+
+	stat, err := sClient.Scrape(testURL)
+	if err != nil {
+		t.Fatalf("scrapeViaURL = %v, want no error", err)
+	}
+	if stat.ConcurrencyLimit != 7 {
+		t.Errorf("stat.ConcurrencyLimit = %v, want 7", stat.ConcurrencyLimit)
+	}
 }
 
 func TestHTTPScrapeClient_Scrape_ErrorCases(t *testing.T) {
@@ -117,3 +117,7 @@ func (s staticConcurrency) StableAndPanicConcurrency(key string) (float64, float
 	}
 	return 0.0, 0.0, errors.New("doesn't exist")
 }
+
+func (s staticConcurrency) ConcurrencyLimit(key string) (float64, error) {
+	return 0.0, ErrNoData
+}
@@ -18,7 +18,9 @@ package deployment
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -31,6 +33,14 @@ const (
 	// QueueSidecarImageKey is the config map key for queue sidecar image
 	QueueSidecarImageKey           = "queueSidecarImage"
 	registriesSkippingTagResolving = "registriesSkippingTagResolving"
+
+	// QueueSidecarDrainDelayKey is the config map key for the minimum time
+	// the queue sidecar keeps serving after it was told to shut down.
+	QueueSidecarDrainDelayKey = "queueSidecarDrainDelay"
+
+	// DefaultQueueSidecarDrainDelay is the default minimum drain time,
+	// which gives the networking layer time to stop routing to the pod.
+	DefaultQueueSidecarDrainDelay = 20 * time.Second
 )
 
 // NewConfigFromMap creates a DeploymentConfig from the supplied Map
@@ -48,6 +58,18 @@ func NewConfigFromMap(configMap map[string]string) (*Config, error) {
 	} else {
 		nc.RegistriesSkippingTagResolving = sets.NewString(strings.Split(registries, ",")...)
 	}
+
+	nc.QueueSidecarDrainDelay = DefaultQueueSidecarDrainDelay
+	if v, ok := configMap[QueueSidecarDrainDelayKey]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", QueueSidecarDrainDelayKey, err)
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("%s must not be negative, was %v", QueueSidecarDrainDelayKey, d)
+		}
+		nc.QueueSidecarDrainDelay = d
+	}
 	return nc, nil
 }
 
@@ -64,4 +86,9 @@ type Config struct {
 
 	// Repositories for which tag to digest resolving should be skipped
 	RegistriesSkippingTagResolving sets.String
+
+	// QueueSidecarDrainDelay is the minimum time the queue sidecar keeps
+	// serving after it was told to shut down. It then waits for the
+	// requests in flight, for up to the revision timeout.
+	QueueSidecarDrainDelay time.Duration
 }
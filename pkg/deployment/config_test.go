@@ -18,6 +18,7 @@ package deployment
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"knative.dev/pkg/system"
@@ -55,6 +56,7 @@ func TestControllerConfiguration(t *testing.T) {
 		wantController: &Config{
 			RegistriesSkippingTagResolving: sets.NewString("ko.local", ""),
 			QueueSidecarImage:              noSidecarImage,
+			QueueSidecarDrainDelay:         DefaultQueueSidecarDrainDelay,
 		},
 		config: &corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
@@ -71,6 +73,7 @@ func TestControllerConfiguration(t *testing.T) {
 		wantController: &Config{
 			RegistriesSkippingTagResolving: sets.NewString("ko.local", "ko.dev"),
 			QueueSidecarImage:              noSidecarImage,
+			QueueSidecarDrainDelay:         DefaultQueueSidecarDrainDelay,
 		},
 		config: &corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
@@ -82,6 +85,52 @@ func TestControllerConfiguration(t *testing.T) {
 				registriesSkippingTagResolving: "ko.local,ko.dev",
 			},
 		},
+	}, {
+		name:    "controller configuration with a drain delay",
+		wantErr: false,
+		wantController: &Config{
+			RegistriesSkippingTagResolving: sets.NewString("ko.local", "dev.local"),
+			QueueSidecarImage:              noSidecarImage,
+			QueueSidecarDrainDelay:         5 * time.Second,
+		},
+		config: &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: system.Namespace(),
+				Name:      ConfigName,
+			},
+			Data: map[string]string{
+				QueueSidecarImageKey:      noSidecarImage,
+				QueueSidecarDrainDelayKey: "5s",
+			},
+		},
+	}, {
+		name:           "controller with an invalid drain delay",
+		wantErr:        true,
+		wantController: (*Config)(nil),
+		config: &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: system.Namespace(),
+				Name:      ConfigName,
+			},
+			Data: map[string]string{
+				QueueSidecarImageKey:      noSidecarImage,
+				QueueSidecarDrainDelayKey: "soon",
+			},
+		},
+	}, {
+		name:           "controller with a negative drain delay",
+		wantErr:        true,
+		wantController: (*Config)(nil),
+		config: &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: system.Namespace(),
+				Name:      ConfigName,
+			},
+			Data: map[string]string{
+				QueueSidecarImageKey:      noSidecarImage,
+				QueueSidecarDrainDelayKey: "-1s",
+			},
+		},
 	}, {
 		name:           "controller with no side car image",
 		wantErr:        true,
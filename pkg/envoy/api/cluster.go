@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/duration"
+)
+
+// Cluster_DiscoveryType is envoy.api.v2.Cluster.DiscoveryType.
+type Cluster_DiscoveryType int32
+
+const (
+	Cluster_STATIC       Cluster_DiscoveryType = 0
+	Cluster_STRICT_DNS   Cluster_DiscoveryType = 1
+	Cluster_LOGICAL_DNS  Cluster_DiscoveryType = 2
+	Cluster_EDS          Cluster_DiscoveryType = 3
+	Cluster_ORIGINAL_DST Cluster_DiscoveryType = 4
+)
+
+// Cluster_LbPolicy is envoy.api.v2.Cluster.LbPolicy.
+type Cluster_LbPolicy int32
+
+const (
+	Cluster_ROUND_ROBIN   Cluster_LbPolicy = 0
+	Cluster_LEAST_REQUEST Cluster_LbPolicy = 1
+	Cluster_RANDOM        Cluster_LbPolicy = 3
+)
+
+// Cluster is an envoy.api.v2.Cluster.  Only the type variant of the
+// cluster_discovery_type oneof is supported.
+type Cluster struct {
+	Name                 string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type                 Cluster_DiscoveryType  `protobuf:"varint,2,opt,name=type,proto3" json:"type,omitempty"`
+	ConnectTimeout       *duration.Duration     `protobuf:"bytes,4,opt,name=connect_timeout,json=connectTimeout,proto3" json:"connect_timeout,omitempty"`
+	LbPolicy             Cluster_LbPolicy       `protobuf:"varint,6,opt,name=lb_policy,json=lbPolicy,proto3" json:"lb_policy,omitempty"`
+	Http2ProtocolOptions *Http2ProtocolOptions  `protobuf:"bytes,14,opt,name=http2_protocol_options,json=http2ProtocolOptions,proto3" json:"http2_protocol_options,omitempty"`
+	LoadAssignment       *ClusterLoadAssignment `protobuf:"bytes,33,opt,name=load_assignment,json=loadAssignment,proto3" json:"load_assignment,omitempty"`
+}
+
+func (m *Cluster) Reset()         { *m = Cluster{} }
+func (m *Cluster) String() string { return proto.CompactTextString(m) }
+func (*Cluster) ProtoMessage()    {}
+
+// ClusterLoadAssignment is an envoy.api.v2.ClusterLoadAssignment.
+type ClusterLoadAssignment struct {
+	ClusterName string                 `protobuf:"bytes,1,opt,name=cluster_name,json=clusterName,proto3" json:"cluster_name,omitempty"`
+	Endpoints   []*LocalityLbEndpoints `protobuf:"bytes,2,rep,name=endpoints,proto3" json:"endpoints,omitempty"`
+}
+
+func (m *ClusterLoadAssignment) Reset()         { *m = ClusterLoadAssignment{} }
+func (m *ClusterLoadAssignment) String() string { return proto.CompactTextString(m) }
+func (*ClusterLoadAssignment) ProtoMessage()    {}
+
+// LocalityLbEndpoints is an envoy.api.v2.endpoint.LocalityLbEndpoints.
+type LocalityLbEndpoints struct {
+	LbEndpoints []*LbEndpoint `protobuf:"bytes,2,rep,name=lb_endpoints,json=lbEndpoints,proto3" json:"lb_endpoints,omitempty"`
+}
+
+func (m *LocalityLbEndpoints) Reset()         { *m = LocalityLbEndpoints{} }
+func (m *LocalityLbEndpoints) String() string { return proto.CompactTextString(m) }
+func (*LocalityLbEndpoints) ProtoMessage()    {}
+
+// LbEndpoint is an envoy.api.v2.endpoint.LbEndpoint.
+type LbEndpoint struct {
+	Endpoint *Endpoint `protobuf:"bytes,1,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+}
+
+func (m *LbEndpoint) Reset()         { *m = LbEndpoint{} }
+func (m *LbEndpoint) String() string { return proto.CompactTextString(m) }
+func (*LbEndpoint) ProtoMessage()    {}
+
+// Endpoint is an envoy.api.v2.endpoint.Endpoint.
+type Endpoint struct {
+	Address *Address `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+}
+
+func (m *Endpoint) Reset()         { *m = Endpoint{} }
+func (m *Endpoint) String() string { return proto.CompactTextString(m) }
+func (*Endpoint) ProtoMessage()    {}
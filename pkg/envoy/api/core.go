@@ -0,0 +1,99 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+)
+
+// Node identifies the Envoy instance talking to the management server
+// (envoy.api.v2.core.Node).
+type Node struct {
+	Id      string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Cluster string `protobuf:"bytes,2,opt,name=cluster,proto3" json:"cluster,omitempty"`
+}
+
+func (m *Node) Reset()         { *m = Node{} }
+func (m *Node) String() string { return proto.CompactTextString(m) }
+func (*Node) ProtoMessage()    {}
+
+// Address is an envoy.api.v2.core.Address.  Only the socket_address
+// variant of the address oneof is supported.
+type Address struct {
+	SocketAddress *SocketAddress `protobuf:"bytes,1,opt,name=socket_address,json=socketAddress,proto3" json:"socket_address,omitempty"`
+}
+
+func (m *Address) Reset()         { *m = Address{} }
+func (m *Address) String() string { return proto.CompactTextString(m) }
+func (*Address) ProtoMessage()    {}
+
+// SocketAddress is an envoy.api.v2.core.SocketAddress.  Only the
+// port_value variant of the port_specifier oneof is supported.
+type SocketAddress struct {
+	Address   string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	PortValue uint32 `protobuf:"varint,3,opt,name=port_value,json=portValue,proto3" json:"port_value,omitempty"`
+}
+
+func (m *SocketAddress) Reset()         { *m = SocketAddress{} }
+func (m *SocketAddress) String() string { return proto.CompactTextString(m) }
+func (*SocketAddress) ProtoMessage()    {}
+
+// HeaderValue is an envoy.api.v2.core.HeaderValue.
+type HeaderValue struct {
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *HeaderValue) Reset()         { *m = HeaderValue{} }
+func (m *HeaderValue) String() string { return proto.CompactTextString(m) }
+func (*HeaderValue) ProtoMessage()    {}
+
+// HeaderValueOption is an envoy.api.v2.core.HeaderValueOption.
+type HeaderValueOption struct {
+	Header *HeaderValue        `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	Append *wrappers.BoolValue `protobuf:"bytes,2,opt,name=append,proto3" json:"append,omitempty"`
+}
+
+func (m *HeaderValueOption) Reset()         { *m = HeaderValueOption{} }
+func (m *HeaderValueOption) String() string { return proto.CompactTextString(m) }
+func (*HeaderValueOption) ProtoMessage()    {}
+
+// ConfigSource is an envoy.api.v2.core.ConfigSource.  Knative always
+// serves over ADS, so only the ads variant of the oneof is supported.
+type ConfigSource struct {
+	Ads *AggregatedConfigSource `protobuf:"bytes,3,opt,name=ads,proto3" json:"ads,omitempty"`
+}
+
+func (m *ConfigSource) Reset()         { *m = ConfigSource{} }
+func (m *ConfigSource) String() string { return proto.CompactTextString(m) }
+func (*ConfigSource) ProtoMessage()    {}
+
+// AggregatedConfigSource is an envoy.api.v2.core.AggregatedConfigSource.
+type AggregatedConfigSource struct{}
+
+func (m *AggregatedConfigSource) Reset()         { *m = AggregatedConfigSource{} }
+func (m *AggregatedConfigSource) String() string { return proto.CompactTextString(m) }
+func (*AggregatedConfigSource) ProtoMessage()    {}
+
+// Http2ProtocolOptions is an envoy.api.v2.core.Http2ProtocolOptions.
+// Its mere presence on a Cluster makes Envoy speak HTTP/2 upstream.
+type Http2ProtocolOptions struct{}
+
+func (m *Http2ProtocolOptions) Reset()         { *m = Http2ProtocolOptions{} }
+func (m *Http2ProtocolOptions) String() string { return proto.CompactTextString(m) }
+func (*Http2ProtocolOptions) ProtoMessage()    {}
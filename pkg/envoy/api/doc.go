@@ -0,0 +1,22 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package api holds the subset of the Envoy v2 xDS API that Knative
+// programs.  The messages mirror the upstream protobuf definitions
+// (field numbers and wire types) so that they can be served to a stock
+// Envoy, but only carry the fields we actually populate.  Unknown fields
+// sent by Envoy are dropped on decode.
+package api
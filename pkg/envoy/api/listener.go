@@ -0,0 +1,107 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+)
+
+// Listener is an envoy.api.v2.Listener.
+type Listener struct {
+	Name         string         `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Address      *Address       `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	FilterChains []*FilterChain `protobuf:"bytes,3,rep,name=filter_chains,json=filterChains,proto3" json:"filter_chains,omitempty"`
+}
+
+func (m *Listener) Reset()         { *m = Listener{} }
+func (m *Listener) String() string { return proto.CompactTextString(m) }
+func (*Listener) ProtoMessage()    {}
+
+// FilterChain is an envoy.api.v2.listener.FilterChain.
+type FilterChain struct {
+	Filters []*Filter `protobuf:"bytes,3,rep,name=filters,proto3" json:"filters,omitempty"`
+}
+
+func (m *FilterChain) Reset()         { *m = FilterChain{} }
+func (m *FilterChain) String() string { return proto.CompactTextString(m) }
+func (*FilterChain) ProtoMessage()    {}
+
+// Filter is an envoy.api.v2.listener.Filter.  Only the typed_config
+// variant of the config_type oneof is supported.
+type Filter struct {
+	Name        string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	TypedConfig *any.Any `protobuf:"bytes,4,opt,name=typed_config,json=typedConfig,proto3" json:"typed_config,omitempty"`
+}
+
+func (m *Filter) Reset()         { *m = Filter{} }
+func (m *Filter) String() string { return proto.CompactTextString(m) }
+func (*Filter) ProtoMessage()    {}
+
+// HttpConnectionManager_CodecType is
+// envoy.config.filter.network.http_connection_manager.v2.HttpConnectionManager.CodecType.
+type HttpConnectionManager_CodecType int32
+
+const (
+	HttpConnectionManager_AUTO  HttpConnectionManager_CodecType = 0
+	HttpConnectionManager_HTTP1 HttpConnectionManager_CodecType = 1
+	HttpConnectionManager_HTTP2 HttpConnectionManager_CodecType = 2
+)
+
+// HttpConnectionManager is an
+// envoy.config.filter.network.http_connection_manager.v2.HttpConnectionManager.
+// Only the rds variant of the route_specifier oneof is supported.
+type HttpConnectionManager struct {
+	CodecType      HttpConnectionManager_CodecType `protobuf:"varint,1,opt,name=codec_type,json=codecType,proto3" json:"codec_type,omitempty"`
+	StatPrefix     string                          `protobuf:"bytes,2,opt,name=stat_prefix,json=statPrefix,proto3" json:"stat_prefix,omitempty"`
+	Rds            *Rds                            `protobuf:"bytes,3,opt,name=rds,proto3" json:"rds,omitempty"`
+	HttpFilters    []*HttpFilter                   `protobuf:"bytes,5,rep,name=http_filters,json=httpFilters,proto3" json:"http_filters,omitempty"`
+	UpgradeConfigs []*UpgradeConfig                `protobuf:"bytes,23,rep,name=upgrade_configs,json=upgradeConfigs,proto3" json:"upgrade_configs,omitempty"`
+}
+
+func (m *HttpConnectionManager) Reset()         { *m = HttpConnectionManager{} }
+func (m *HttpConnectionManager) String() string { return proto.CompactTextString(m) }
+func (*HttpConnectionManager) ProtoMessage()    {}
+
+// Rds is an envoy.config.filter.network.http_connection_manager.v2.Rds.
+type Rds struct {
+	ConfigSource    *ConfigSource `protobuf:"bytes,1,opt,name=config_source,json=configSource,proto3" json:"config_source,omitempty"`
+	RouteConfigName string        `protobuf:"bytes,2,opt,name=route_config_name,json=routeConfigName,proto3" json:"route_config_name,omitempty"`
+}
+
+func (m *Rds) Reset()         { *m = Rds{} }
+func (m *Rds) String() string { return proto.CompactTextString(m) }
+func (*Rds) ProtoMessage()    {}
+
+// HttpFilter is an envoy.config.filter.network.http_connection_manager.v2.HttpFilter.
+type HttpFilter struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (m *HttpFilter) Reset()         { *m = HttpFilter{} }
+func (m *HttpFilter) String() string { return proto.CompactTextString(m) }
+func (*HttpFilter) ProtoMessage()    {}
+
+// UpgradeConfig is an
+// envoy.config.filter.network.http_connection_manager.v2.HttpConnectionManager.UpgradeConfig.
+type UpgradeConfig struct {
+	UpgradeType string `protobuf:"bytes,1,opt,name=upgrade_type,json=upgradeType,proto3" json:"upgrade_type,omitempty"`
+}
+
+func (m *UpgradeConfig) Reset()         { *m = UpgradeConfig{} }
+func (m *UpgradeConfig) String() string { return proto.CompactTextString(m) }
+func (*UpgradeConfig) ProtoMessage()    {}
@@ -0,0 +1,75 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import "github.com/golang/protobuf/proto"
+
+const typePrefix = "type.googleapis.com/"
+
+// Type URLs of the resources served over xDS.
+const (
+	ListenerType = typePrefix + "envoy.api.v2.Listener"
+	RouteType    = typePrefix + "envoy.api.v2.RouteConfiguration"
+	ClusterType  = typePrefix + "envoy.api.v2.Cluster"
+	EndpointType = typePrefix + "envoy.api.v2.ClusterLoadAssignment"
+)
+
+// Well-known Envoy filter names.
+const (
+	HTTPConnectionManagerFilter = "envoy.http_connection_manager"
+	RouterFilter                = "envoy.router"
+)
+
+func init() {
+	// Registering the fully qualified names lets ptypes.MarshalAny and
+	// ptypes.UnmarshalAny compute the matching type URLs.
+	proto.RegisterType((*Node)(nil), "envoy.api.v2.core.Node")
+	proto.RegisterType((*Address)(nil), "envoy.api.v2.core.Address")
+	proto.RegisterType((*SocketAddress)(nil), "envoy.api.v2.core.SocketAddress")
+	proto.RegisterType((*HeaderValue)(nil), "envoy.api.v2.core.HeaderValue")
+	proto.RegisterType((*HeaderValueOption)(nil), "envoy.api.v2.core.HeaderValueOption")
+	proto.RegisterType((*ConfigSource)(nil), "envoy.api.v2.core.ConfigSource")
+	proto.RegisterType((*AggregatedConfigSource)(nil), "envoy.api.v2.core.AggregatedConfigSource")
+	proto.RegisterType((*Http2ProtocolOptions)(nil), "envoy.api.v2.core.Http2ProtocolOptions")
+
+	proto.RegisterType((*DiscoveryRequest)(nil), "envoy.api.v2.DiscoveryRequest")
+	proto.RegisterType((*DiscoveryResponse)(nil), "envoy.api.v2.DiscoveryResponse")
+
+	proto.RegisterType((*Cluster)(nil), "envoy.api.v2.Cluster")
+	proto.RegisterType((*ClusterLoadAssignment)(nil), "envoy.api.v2.ClusterLoadAssignment")
+	proto.RegisterType((*LocalityLbEndpoints)(nil), "envoy.api.v2.endpoint.LocalityLbEndpoints")
+	proto.RegisterType((*LbEndpoint)(nil), "envoy.api.v2.endpoint.LbEndpoint")
+	proto.RegisterType((*Endpoint)(nil), "envoy.api.v2.endpoint.Endpoint")
+
+	proto.RegisterType((*Listener)(nil), "envoy.api.v2.Listener")
+	proto.RegisterType((*FilterChain)(nil), "envoy.api.v2.listener.FilterChain")
+	proto.RegisterType((*Filter)(nil), "envoy.api.v2.listener.Filter")
+	proto.RegisterType((*HttpConnectionManager)(nil), "envoy.config.filter.network.http_connection_manager.v2.HttpConnectionManager")
+	proto.RegisterType((*Rds)(nil), "envoy.config.filter.network.http_connection_manager.v2.Rds")
+	proto.RegisterType((*HttpFilter)(nil), "envoy.config.filter.network.http_connection_manager.v2.HttpFilter")
+	proto.RegisterType((*UpgradeConfig)(nil), "envoy.config.filter.network.http_connection_manager.v2.HttpConnectionManager.UpgradeConfig")
+
+	proto.RegisterType((*RouteConfiguration)(nil), "envoy.api.v2.RouteConfiguration")
+	proto.RegisterType((*VirtualHost)(nil), "envoy.api.v2.route.VirtualHost")
+	proto.RegisterType((*Route)(nil), "envoy.api.v2.route.Route")
+	proto.RegisterType((*RouteMatch)(nil), "envoy.api.v2.route.RouteMatch")
+	proto.RegisterType((*RouteAction)(nil), "envoy.api.v2.route.RouteAction")
+	proto.RegisterType((*WeightedCluster)(nil), "envoy.api.v2.route.WeightedCluster")
+	proto.RegisterType((*WeightedCluster_ClusterWeight)(nil), "envoy.api.v2.route.WeightedCluster.ClusterWeight")
+	proto.RegisterType((*DirectResponseAction)(nil), "envoy.api.v2.route.DirectResponseAction")
+	proto.RegisterType((*RetryPolicy)(nil), "envoy.api.v2.route.RetryPolicy")
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/wrappers"
+)
+
+// RouteConfiguration is an envoy.api.v2.RouteConfiguration.
+type RouteConfiguration struct {
+	Name         string         `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	VirtualHosts []*VirtualHost `protobuf:"bytes,2,rep,name=virtual_hosts,json=virtualHosts,proto3" json:"virtual_hosts,omitempty"`
+}
+
+func (m *RouteConfiguration) Reset()         { *m = RouteConfiguration{} }
+func (m *RouteConfiguration) String() string { return proto.CompactTextString(m) }
+func (*RouteConfiguration) ProtoMessage()    {}
+
+// VirtualHost is an envoy.api.v2.route.VirtualHost.
+type VirtualHost struct {
+	Name    string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Domains []string `protobuf:"bytes,2,rep,name=domains,proto3" json:"domains,omitempty"`
+	Routes  []*Route `protobuf:"bytes,3,rep,name=routes,proto3" json:"routes,omitempty"`
+}
+
+func (m *VirtualHost) Reset()         { *m = VirtualHost{} }
+func (m *VirtualHost) String() string { return proto.CompactTextString(m) }
+func (*VirtualHost) ProtoMessage()    {}
+
+// Route is an envoy.api.v2.route.Route.  Route and DirectResponse are
+// variants of the action oneof, so exactly one must be set.
+type Route struct {
+	Match               *RouteMatch           `protobuf:"bytes,1,opt,name=match,proto3" json:"match,omitempty"`
+	Route               *RouteAction          `protobuf:"bytes,2,opt,name=route,proto3" json:"route,omitempty"`
+	DirectResponse      *DirectResponseAction `protobuf:"bytes,7,opt,name=direct_response,json=directResponse,proto3" json:"direct_response,omitempty"`
+	RequestHeadersToAdd []*HeaderValueOption  `protobuf:"bytes,9,rep,name=request_headers_to_add,json=requestHeadersToAdd,proto3" json:"request_headers_to_add,omitempty"`
+}
+
+func (m *Route) Reset()         { *m = Route{} }
+func (m *Route) String() string { return proto.CompactTextString(m) }
+func (*Route) ProtoMessage()    {}
+
+// RouteMatch is an envoy.api.v2.route.RouteMatch.  Prefix and Regex are
+// variants of the path_specifier oneof, so exactly one must be set.
+type RouteMatch struct {
+	Prefix string `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Regex  string `protobuf:"bytes,3,opt,name=regex,proto3" json:"regex,omitempty"`
+}
+
+func (m *RouteMatch) Reset()         { *m = RouteMatch{} }
+func (m *RouteMatch) String() string { return proto.CompactTextString(m) }
+func (*RouteMatch) ProtoMessage()    {}
+
+// RouteAction is an envoy.api.v2.route.RouteAction.  Cluster and
+// WeightedClusters are variants of the cluster_specifier oneof, so
+// exactly one must be set.
+type RouteAction struct {
+	Cluster          string             `protobuf:"bytes,1,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	WeightedClusters *WeightedCluster   `protobuf:"bytes,3,opt,name=weighted_clusters,json=weightedClusters,proto3" json:"weighted_clusters,omitempty"`
+	HostRewrite      string             `protobuf:"bytes,6,opt,name=host_rewrite,json=hostRewrite,proto3" json:"host_rewrite,omitempty"`
+	Timeout          *duration.Duration `protobuf:"bytes,8,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	RetryPolicy      *RetryPolicy       `protobuf:"bytes,9,opt,name=retry_policy,json=retryPolicy,proto3" json:"retry_policy,omitempty"`
+}
+
+func (m *RouteAction) Reset()         { *m = RouteAction{} }
+func (m *RouteAction) String() string { return proto.CompactTextString(m) }
+func (*RouteAction) ProtoMessage()    {}
+
+// WeightedCluster is an envoy.api.v2.route.WeightedCluster.
+type WeightedCluster struct {
+	Clusters    []*WeightedCluster_ClusterWeight `protobuf:"bytes,1,rep,name=clusters,proto3" json:"clusters,omitempty"`
+	TotalWeight *wrappers.UInt32Value            `protobuf:"bytes,3,opt,name=total_weight,json=totalWeight,proto3" json:"total_weight,omitempty"`
+}
+
+func (m *WeightedCluster) Reset()         { *m = WeightedCluster{} }
+func (m *WeightedCluster) String() string { return proto.CompactTextString(m) }
+func (*WeightedCluster) ProtoMessage()    {}
+
+// WeightedCluster_ClusterWeight is an
+// envoy.api.v2.route.WeightedCluster.ClusterWeight.
+type WeightedCluster_ClusterWeight struct {
+	Name                string                `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Weight              *wrappers.UInt32Value `protobuf:"bytes,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	RequestHeadersToAdd []*HeaderValueOption  `protobuf:"bytes,4,rep,name=request_headers_to_add,json=requestHeadersToAdd,proto3" json:"request_headers_to_add,omitempty"`
+}
+
+func (m *WeightedCluster_ClusterWeight) Reset()         { *m = WeightedCluster_ClusterWeight{} }
+func (m *WeightedCluster_ClusterWeight) String() string { return proto.CompactTextString(m) }
+func (*WeightedCluster_ClusterWeight) ProtoMessage()    {}
+
+// DirectResponseAction is an envoy.api.v2.route.DirectResponseAction,
+// answering requests without forwarding them.
+type DirectResponseAction struct {
+	Status uint32 `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+}
+
+func (m *DirectResponseAction) Reset()         { *m = DirectResponseAction{} }
+func (m *DirectResponseAction) String() string { return proto.CompactTextString(m) }
+func (*DirectResponseAction) ProtoMessage()    {}
+
+// RetryPolicy is an envoy.api.v2.route.RetryPolicy.
+type RetryPolicy struct {
+	RetryOn       string                `protobuf:"bytes,1,opt,name=retry_on,json=retryOn,proto3" json:"retry_on,omitempty"`
+	NumRetries    *wrappers.UInt32Value `protobuf:"bytes,2,opt,name=num_retries,json=numRetries,proto3" json:"num_retries,omitempty"`
+	PerTryTimeout *duration.Duration    `protobuf:"bytes,3,opt,name=per_try_timeout,json=perTryTimeout,proto3" json:"per_try_timeout,omitempty"`
+}
+
+func (m *RetryPolicy) Reset()         { *m = RetryPolicy{} }
+func (m *RetryPolicy) String() string { return proto.CompactTextString(m) }
+func (*RetryPolicy) ProtoMessage()    {}
@@ -0,0 +1,162 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xds
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/knative/serving/pkg/envoy/api"
+)
+
+// Snapshot is a consistent set of xDS resources, keyed by type URL.
+type Snapshot struct {
+	// Version identifies the content of the snapshot.  Two snapshots
+	// with the same resources have the same version.
+	Version string
+
+	// Resources holds the resources of each type.
+	Resources map[string][]proto.Message
+}
+
+// NewSnapshot creates a Snapshot from the given resources, computing its
+// version from their serialized form.
+func NewSnapshot(listeners []*api.Listener, routes []*api.RouteConfiguration, clusters []*api.Cluster) (*Snapshot, error) {
+	resources := map[string][]proto.Message{
+		api.ListenerType: make([]proto.Message, 0, len(listeners)),
+		api.RouteType:    make([]proto.Message, 0, len(routes)),
+		api.ClusterType:  make([]proto.Message, 0, len(clusters)),
+	}
+	for _, l := range listeners {
+		resources[api.ListenerType] = append(resources[api.ListenerType], l)
+	}
+	for _, r := range routes {
+		resources[api.RouteType] = append(resources[api.RouteType], r)
+	}
+	for _, c := range clusters {
+		resources[api.ClusterType] = append(resources[api.ClusterType], c)
+	}
+
+	h := sha256.New()
+	for _, typ := range []string{api.ListenerType, api.RouteType, api.ClusterType} {
+		h.Write([]byte(typ))
+		for _, r := range resources[typ] {
+			b, err := proto.Marshal(r)
+			if err != nil {
+				return nil, err
+			}
+			h.Write(b)
+		}
+	}
+	return &Snapshot{
+		Version:   hex.EncodeToString(h.Sum(nil))[:16],
+		Resources: resources,
+	}, nil
+}
+
+// Cache holds the Snapshot currently served to Envoy, and tracks which
+// snapshot version each connected Envoy has acknowledged.
+type Cache struct {
+	mu       sync.RWMutex
+	snapshot *Snapshot
+	// updated is closed (and replaced) every time the snapshot changes.
+	updated chan struct{}
+
+	// acks holds the version last acknowledged by each connected stream,
+	// empty until it acknowledged one.
+	acks map[uint64]string
+	// acked is closed (and replaced) every time acks changes.
+	acked chan struct{}
+}
+
+// NewCache creates an empty Cache.
+func NewCache() *Cache {
+	return &Cache{
+		updated: make(chan struct{}),
+		acks:    make(map[uint64]string),
+		acked:   make(chan struct{}),
+	}
+}
+
+// Set replaces the served snapshot and notifies the watchers, unless the
+// snapshot has the same version as the current one.
+func (c *Cache) Set(s *Snapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.snapshot != nil && c.snapshot.Version == s.Version {
+		return
+	}
+	c.snapshot = s
+	close(c.updated)
+	c.updated = make(chan struct{})
+}
+
+// Get returns the current snapshot, which is nil until the first call to
+// Set, along with a channel that is closed when it is replaced.
+func (c *Cache) Get() (*Snapshot, <-chan struct{}) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.snapshot, c.updated
+}
+
+// Acked returns whether at least one Envoy is connected, and every
+// connected Envoy has acknowledged the given snapshot version, along with
+// a channel that is closed when that may have changed.
+func (c *Cache) Acked(version string) (bool, <-chan struct{}) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.acks) == 0 {
+		return false, c.acked
+	}
+	for _, v := range c.acks {
+		if v != version {
+			return false, c.acked
+		}
+	}
+	return true, c.acked
+}
+
+// ack records that the given stream acknowledged the version, which is
+// empty when the stream just connected.
+func (c *Cache) ack(stream uint64, version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.acks[stream]; ok && v == version {
+		return
+	}
+	c.acks[stream] = version
+	c.notifyAcked()
+}
+
+// disconnect forgets about the given stream.
+func (c *Cache) disconnect(stream uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.acks[stream]; !ok {
+		return
+	}
+	delete(c.acks, stream)
+	c.notifyAcked()
+}
+
+func (c *Cache) notifyAcked() {
+	close(c.acked)
+	c.acked = make(chan struct{})
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xds
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/knative/serving/pkg/envoy/api"
+)
+
+// aggregatedDiscoveryServer is the server API of
+// envoy.service.discovery.v2.AggregatedDiscoveryService.
+type aggregatedDiscoveryServer interface {
+	StreamAggregatedResources(grpc.ServerStream) error
+}
+
+var adsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "envoy.service.discovery.v2.AggregatedDiscoveryService",
+	HandlerType: (*aggregatedDiscoveryServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{{
+		StreamName: "StreamAggregatedResources",
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			return srv.(aggregatedDiscoveryServer).StreamAggregatedResources(stream)
+		},
+		ServerStreams: true,
+		ClientStreams: true,
+	}},
+	Metadata: "envoy/service/discovery/v2/ads.proto",
+}
+
+// StreamAggregatedResourcesMethod is the full gRPC method name of the
+// ADS stream, for use by clients.
+const StreamAggregatedResourcesMethod = "/envoy.service.discovery.v2.AggregatedDiscoveryService/StreamAggregatedResources"
+
+// Server serves the snapshots of a Cache over ADS.
+type Server struct {
+	cache   *Cache
+	logger  *zap.SugaredLogger
+	nonce   uint64
+	streams uint64
+}
+
+var _ aggregatedDiscoveryServer = (*Server)(nil)
+
+// NewServer creates a Server serving the content of the given cache.
+func NewServer(cache *Cache, logger *zap.SugaredLogger) *Server {
+	return &Server{
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+// Register registers the ADS service on the given gRPC server.
+func (s *Server) Register(gs *grpc.Server) {
+	gs.RegisterService(&adsServiceDesc, s)
+}
+
+// sentState tracks what was last sent to a stream for a given type, and
+// what Envoy acknowledged.
+type sentState struct {
+	version string
+	nonce   string
+	acked   string
+}
+
+// StreamAggregatedResources implements the ADS protocol for a single Envoy.
+// Every subscribed type is answered with the full set of resources of the
+// current snapshot, and re-sent whenever the snapshot changes.  Once Envoy
+// acknowledged a version for all of the types it subscribed to, the version
+// is recorded as acknowledged in the cache.
+func (s *Server) StreamAggregatedResources(stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	id := atomic.AddUint64(&s.streams, 1)
+	defer s.cache.disconnect(id)
+	reqCh := make(chan *api.DiscoveryRequest)
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			req := &api.DiscoveryRequest{}
+			if err := stream.RecvMsg(req); err != nil {
+				errCh <- err
+				return
+			}
+			select {
+			case reqCh <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	sent := make(map[string]*sentState)
+	snapshot, updated := s.cache.Get()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err := <-errCh:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+
+		case req := <-reqCh:
+			state, ok := sent[req.TypeUrl]
+			if ok {
+				if req.ResponseNonce != state.nonce {
+					// A response to something we've since superseded.
+					continue
+				}
+				if req.VersionInfo != state.version {
+					s.logger.Warnf("Envoy %q rejected version %s of %s", nodeID(req), state.version, req.TypeUrl)
+					continue
+				}
+				state.acked = state.version
+				if acked(sent, state.version) {
+					s.cache.ack(id, state.version)
+				}
+				// ACKs and NACKs alike wait for the next snapshot.
+				continue
+			}
+			state = &sentState{}
+			sent[req.TypeUrl] = state
+			s.cache.ack(id, "")
+			if snapshot == nil {
+				continue
+			}
+			if err := s.send(stream, snapshot, req.TypeUrl, state); err != nil {
+				return err
+			}
+
+		case <-updated:
+			snapshot, updated = s.cache.Get()
+			for _, typ := range pushOrder(sent) {
+				state := sent[typ]
+				if state.version == snapshot.Version {
+					continue
+				}
+				if err := s.send(stream, snapshot, typ, state); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// typeOrder is the order in which updates are pushed, so that Envoy never
+// receives a resource referring to another one it doesn't know about yet:
+// clusters before their endpoints, and listeners before their routes.
+var typeOrder = []string{api.ClusterType, api.EndpointType, api.ListenerType, api.RouteType}
+
+// pushOrder returns the subscribed types in the order updates must be
+// pushed in, any type unknown to typeOrder coming last.
+func pushOrder(sent map[string]*sentState) []string {
+	types := make([]string, 0, len(sent))
+	for _, typ := range typeOrder {
+		if _, ok := sent[typ]; ok {
+			types = append(types, typ)
+		}
+	}
+	var others []string
+	for typ := range sent {
+		if !known(typ) {
+			others = append(others, typ)
+		}
+	}
+	sort.Strings(others)
+	return append(types, others...)
+}
+
+// acked returns whether every subscribed type acknowledged the version.
+func acked(sent map[string]*sentState, version string) bool {
+	for _, state := range sent {
+		if state.acked != version {
+			return false
+		}
+	}
+	return true
+}
+
+func known(typ string) bool {
+	for _, t := range typeOrder {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) send(stream grpc.ServerStream, snapshot *Snapshot, typ string, state *sentState) error {
+	resources := snapshot.Resources[typ]
+	anys := make([]*any.Any, 0, len(resources))
+	for _, r := range resources {
+		a, err := ptypes.MarshalAny(r)
+		if err != nil {
+			return err
+		}
+		anys = append(anys, a)
+	}
+
+	state.version = snapshot.Version
+	state.nonce = strconv.FormatUint(atomic.AddUint64(&s.nonce, 1), 10)
+	return stream.SendMsg(&api.DiscoveryResponse{
+		VersionInfo: state.version,
+		Resources:   anys,
+		TypeUrl:     typ,
+		Nonce:       state.nonce,
+	})
+}
+
+func nodeID(req *api.DiscoveryRequest) string {
+	if req.Node == nil {
+		return ""
+	}
+	return req.Node.Id
+}
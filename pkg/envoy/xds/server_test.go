@@ -0,0 +1,295 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xds
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+
+	"github.com/knative/serving/pkg/envoy/api"
+	. "knative.dev/pkg/logging/testing"
+)
+
+func startServer(t *testing.T, cache *Cache) (grpc.ClientStream, func()) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	gs := grpc.NewServer()
+	NewServer(cache, TestLogger(t)).Register(gs)
+	go gs.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Dial() = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{
+		ServerStreams: true,
+		ClientStreams: true,
+	}, StreamAggregatedResourcesMethod)
+	if err != nil {
+		t.Fatalf("NewStream() = %v", err)
+	}
+	return stream, func() {
+		cancel()
+		conn.Close()
+		gs.Stop()
+	}
+}
+
+func recv(t *testing.T, stream grpc.ClientStream) *api.DiscoveryResponse {
+	t.Helper()
+	respCh := make(chan *api.DiscoveryResponse)
+	errCh := make(chan error)
+	go func() {
+		resp := &api.DiscoveryResponse{}
+		if err := stream.RecvMsg(resp); err != nil {
+			errCh <- err
+			return
+		}
+		respCh <- resp
+	}()
+	select {
+	case resp := <-respCh:
+		return resp
+	case err := <-errCh:
+		t.Fatalf("RecvMsg() = %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a DiscoveryResponse")
+	}
+	return nil
+}
+
+func clusterNames(t *testing.T, resp *api.DiscoveryResponse) []string {
+	t.Helper()
+	names := []string{}
+	for _, r := range resp.Resources {
+		c := &api.Cluster{}
+		if err := ptypes.UnmarshalAny(r, c); err != nil {
+			t.Fatalf("UnmarshalAny() = %v", err)
+		}
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func mustSnapshot(t *testing.T, clusters ...string) *Snapshot {
+	t.Helper()
+	cs := make([]*api.Cluster, 0, len(clusters))
+	for _, c := range clusters {
+		cs = append(cs, &api.Cluster{Name: c, Type: api.Cluster_STRICT_DNS})
+	}
+	s, err := NewSnapshot(nil, nil, cs)
+	if err != nil {
+		t.Fatalf("NewSnapshot() = %v", err)
+	}
+	return s
+}
+
+func TestServerPushesSnapshots(t *testing.T) {
+	cache := NewCache()
+	stream, stop := startServer(t, cache)
+	defer stop()
+
+	// Subscribe before there's anything to serve.
+	if err := stream.SendMsg(&api.DiscoveryRequest{
+		Node:    &api.Node{Id: "envoy"},
+		TypeUrl: api.ClusterType,
+	}); err != nil {
+		t.Fatalf("SendMsg() = %v", err)
+	}
+
+	first := mustSnapshot(t, "foo")
+	cache.Set(first)
+	resp := recv(t, stream)
+	if got, want := resp.VersionInfo, first.Version; got != want {
+		t.Errorf("VersionInfo = %s, want: %s", got, want)
+	}
+	if got, want := resp.TypeUrl, api.ClusterType; got != want {
+		t.Errorf("TypeUrl = %s, want: %s", got, want)
+	}
+	if diff := cmp.Diff([]string{"foo"}, clusterNames(t, resp)); diff != "" {
+		t.Errorf("Clusters (-want, +got): %s", diff)
+	}
+
+	// ACK and then push a new version.
+	if err := stream.SendMsg(&api.DiscoveryRequest{
+		VersionInfo:   resp.VersionInfo,
+		ResponseNonce: resp.Nonce,
+		TypeUrl:       api.ClusterType,
+	}); err != nil {
+		t.Fatalf("SendMsg() = %v", err)
+	}
+	// Setting the same content again must not produce a response.
+	cache.Set(mustSnapshot(t, "foo"))
+
+	second := mustSnapshot(t, "foo", "bar")
+	cache.Set(second)
+	resp = recv(t, stream)
+	if got, want := resp.VersionInfo, second.Version; got != want {
+		t.Errorf("VersionInfo = %s, want: %s", got, want)
+	}
+	if diff := cmp.Diff([]string{"foo", "bar"}, clusterNames(t, resp)); diff != "" {
+		t.Errorf("Clusters (-want, +got): %s", diff)
+	}
+}
+
+func TestServerAnswersNewSubscriptions(t *testing.T) {
+	cache := NewCache()
+	cache.Set(mustSnapshot(t, "foo"))
+	stream, stop := startServer(t, cache)
+	defer stop()
+
+	for _, typ := range []string{api.ClusterType, api.ListenerType} {
+		if err := stream.SendMsg(&api.DiscoveryRequest{TypeUrl: typ}); err != nil {
+			t.Fatalf("SendMsg() = %v", err)
+		}
+		if got := recv(t, stream).TypeUrl; got != typ {
+			t.Errorf("TypeUrl = %s, want: %s", got, typ)
+		}
+	}
+}
+
+func TestServerPushesInOrder(t *testing.T) {
+	cache := NewCache()
+	stream, stop := startServer(t, cache)
+	defer stop()
+
+	// Subscribe in the reverse of the order updates must be pushed in.
+	for _, typ := range []string{api.RouteType, api.ListenerType, api.ClusterType} {
+		if err := stream.SendMsg(&api.DiscoveryRequest{TypeUrl: typ}); err != nil {
+			t.Fatalf("SendMsg() = %v", err)
+		}
+	}
+	// Let the server register all of the subscriptions.
+	time.Sleep(100 * time.Millisecond)
+
+	for i := 0; i < 10; i++ {
+		cache.Set(mustSnapshot(t, "foo", strconv.Itoa(i)))
+		got := []string{}
+		for j := 0; j < 3; j++ {
+			got = append(got, recv(t, stream).TypeUrl)
+		}
+		if diff := cmp.Diff([]string{api.ClusterType, api.ListenerType, api.RouteType}, got); diff != "" {
+			t.Fatalf("Push order (-want, +got): %s", diff)
+		}
+	}
+}
+
+// waitAcked waits until the cache reports whether the version is
+// acknowledged as want.
+func waitAcked(t *testing.T, cache *Cache, version string, want bool) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		got, ch := cache.Acked(version)
+		if got == want {
+			return
+		}
+		select {
+		case <-ch:
+		case <-timeout:
+			t.Fatalf("Acked(%s) = %v, want: %v", version, got, want)
+		}
+	}
+}
+
+func TestServerRecordsAcks(t *testing.T) {
+	cache := NewCache()
+	first := mustSnapshot(t, "foo")
+	cache.Set(first)
+	if acked, _ := cache.Acked(first.Version); acked {
+		t.Error("Acked() = true without any Envoy connected")
+	}
+	stream, stop := startServer(t, cache)
+
+	for _, typ := range []string{api.ClusterType, api.ListenerType} {
+		if err := stream.SendMsg(&api.DiscoveryRequest{TypeUrl: typ}); err != nil {
+			t.Fatalf("SendMsg() = %v", err)
+		}
+	}
+	var resps []*api.DiscoveryResponse
+	for i := 0; i < 2; i++ {
+		resps = append(resps, recv(t, stream))
+	}
+
+	// A NACK doesn't count.
+	if err := stream.SendMsg(&api.DiscoveryRequest{
+		ResponseNonce: resps[0].Nonce,
+		TypeUrl:       resps[0].TypeUrl,
+	}); err != nil {
+		t.Fatalf("SendMsg() = %v", err)
+	}
+	// Neither does an ACK of only some of the types.
+	if err := stream.SendMsg(&api.DiscoveryRequest{
+		VersionInfo:   resps[1].VersionInfo,
+		ResponseNonce: resps[1].Nonce,
+		TypeUrl:       resps[1].TypeUrl,
+	}); err != nil {
+		t.Fatalf("SendMsg() = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if acked, _ := cache.Acked(first.Version); acked {
+		t.Error("Acked() = true before all types were acknowledged")
+	}
+
+	// Envoy retries the rejected type and accepts it this time.
+	second := mustSnapshot(t, "foo", "bar")
+	cache.Set(second)
+	for i := 0; i < 2; i++ {
+		resp := recv(t, stream)
+		if err := stream.SendMsg(&api.DiscoveryRequest{
+			VersionInfo:   resp.VersionInfo,
+			ResponseNonce: resp.Nonce,
+			TypeUrl:       resp.TypeUrl,
+		}); err != nil {
+			t.Fatalf("SendMsg() = %v", err)
+		}
+	}
+	waitAcked(t, cache, second.Version, true)
+
+	// Disconnected Envoys are forgotten.
+	stop()
+	waitAcked(t, cache, second.Version, false)
+}
+
+func TestNewSnapshotVersion(t *testing.T) {
+	a, b := mustSnapshot(t, "foo"), mustSnapshot(t, "foo")
+	if a.Version != b.Version {
+		t.Errorf("Versions of equal snapshots differ: %s vs %s", a.Version, b.Version)
+	}
+	c := mustSnapshot(t, "bar")
+	if a.Version == c.Version {
+		t.Errorf("Versions of different snapshots are both %s", a.Version)
+	}
+	if got, want := len(a.Resources[api.ClusterType]), 1; got != want {
+		t.Errorf("len(Clusters) = %d, want: %d", got, want)
+	}
+	if !proto.Equal(a.Resources[api.ClusterType][0], b.Resources[api.ClusterType][0]) {
+		t.Errorf("Clusters differ: %v vs %v", a.Resources[api.ClusterType][0], b.Resources[api.ClusterType][0])
+	}
+}
@@ -17,15 +17,42 @@ limitations under the License.
 package http
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"text/template"
 	"time"
 )
 
+// RequestLogFormat is the format in which request logs are written.
+type RequestLogFormat string
+
+const (
+	// RequestLogFormatTemplate writes request logs by executing the
+	// configured Go template.
+	RequestLogFormatTemplate RequestLogFormat = "template"
+
+	// RequestLogFormatJSON writes request logs as JSON objects following
+	// the RequestLogEntry schema.
+	RequestLogFormatJSON RequestLogFormat = "json"
+)
+
+// Validate returns an error if the format is not supported. The empty
+// format is accepted and defaults to RequestLogFormatTemplate.
+func (f RequestLogFormat) Validate() error {
+	switch f {
+	case "", RequestLogFormatTemplate, RequestLogFormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("unsupported request log format %q", f)
+	}
+}
+
 // RequestLogHandler implements an http.Handler that writes request logs
 // and calls the next handler.
 type RequestLogHandler struct {
@@ -35,6 +62,8 @@ type RequestLogHandler struct {
 	templateMux sync.RWMutex
 	templateStr string
 	template    *template.Template
+	format      RequestLogFormat
+	filter      *RequestLogFilter
 }
 
 // RequestLogRevision provides revision related static information
@@ -53,6 +82,9 @@ type RequestLogResponse struct {
 	Code    int
 	Size    int
 	Latency float64
+	// QueueWait is the part of Latency, in seconds, the request spent
+	// queued before being handled. See ReportQueueWait.
+	QueueWait float64
 }
 
 // RequestLogTemplateInput is the wrapper struct that provides all
@@ -118,38 +150,78 @@ func (h *RequestLogHandler) SetTemplate(templateStr string) error {
 	return nil
 }
 
-func (h *RequestLogHandler) getTemplate() *template.Template {
+// SetFormat sets the format to use for writing request logs. With
+// RequestLogFormatJSON, request logs are written even if no template is set.
+func (h *RequestLogHandler) SetFormat(format RequestLogFormat) error {
+	if err := format.Validate(); err != nil {
+		return err
+	}
+
+	h.templateMux.Lock()
+	defer h.templateMux.Unlock()
+	h.format = format
+	return nil
+}
+
+// SetFilter sets the filter deciding which requests are logged. A nil
+// filter logs all requests.
+func (h *RequestLogHandler) SetFilter(filter *RequestLogFilter) {
+	h.templateMux.Lock()
+	defer h.templateMux.Unlock()
+	h.filter = filter
+}
+
+func (h *RequestLogHandler) getSettings() (*template.Template, RequestLogFormat, *RequestLogFilter) {
 	h.templateMux.RLock()
 	defer h.templateMux.RUnlock()
-	return h.template
+	return h.template, h.format, h.filter
+}
+
+type queueWaitKey struct{}
+
+// ReportQueueWait records how long the request with the given context was
+// queued before being handled, so that it is included in its request log.
+// It is a no-op for requests that are not served by a RequestLogHandler.
+func ReportQueueWait(ctx context.Context, d time.Duration) {
+	if qw, ok := ctx.Value(queueWaitKey{}).(*int64); ok {
+		atomic.StoreInt64(qw, int64(d))
+	}
 }
 
 func (h *RequestLogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	t := h.getTemplate()
-	if t == nil {
+	t, format, filter := h.getSettings()
+	if t == nil && format != RequestLogFormatJSON {
 		h.handler.ServeHTTP(w, r)
 		return
 	}
 
+	var queueWait int64
+	r = r.WithContext(context.WithValue(r.Context(), queueWaitKey{}, &queueWait))
 	rr := NewResponseRecorder(w, http.StatusOK)
 	startTime := time.Now()
 	defer func() {
 		// If ServeHTTP panics, recover, record the failure and panic again.
 		err := recover()
-		latency := time.Since(startTime).Seconds()
+		resp := &RequestLogResponse{
+			Code:      rr.ResponseCode,
+			Latency:   time.Since(startTime).Seconds(),
+			Size:      (int)(rr.ResponseSize),
+			QueueWait: time.Duration(atomic.LoadInt64(&queueWait)).Seconds(),
+		}
+		if err != nil {
+			resp.Code = http.StatusInternalServerError
+			resp.Size = 0
+		}
+		if filter.shouldLog(resp.Code, rand.Float64()) {
+			in := h.inputGetter(r, resp)
+			if format == RequestLogFormatJSON {
+				h.writeJSON(startTime, in)
+			} else {
+				h.write(t, in)
+			}
+		}
 		if err != nil {
-			h.write(t, h.inputGetter(r, &RequestLogResponse{
-				Code:    http.StatusInternalServerError,
-				Latency: latency,
-				Size:    0,
-			}))
 			panic(err)
-		} else {
-			h.write(t, h.inputGetter(r, &RequestLogResponse{
-				Code:    rr.ResponseCode,
-				Latency: latency,
-				Size:    (int)(rr.ResponseSize),
-			}))
 		}
 	}()
 	h.handler.ServeHTTP(rr, r)
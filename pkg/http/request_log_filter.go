@@ -0,0 +1,75 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RequestLogFilter decides which requests are written to the request log.
+type RequestLogFilter struct {
+	sampleRate    float64
+	statusCodes   map[int]bool
+	statusClasses map[int]bool
+}
+
+// NewRequestLogFilter creates a RequestLogFilter logging the given fraction,
+// between 0 and 1, of the requests whose response status matches statusCodes.
+// statusCodes is a comma separated list of status codes, e.g. "429", or
+// classes, e.g. "5xx". An empty list matches all responses.
+func NewRequestLogFilter(sampleRate float64, statusCodes string) (*RequestLogFilter, error) {
+	if sampleRate < 0 || sampleRate > 1 {
+		return nil, fmt.Errorf("request log sample rate must be between 0 and 1, was %v", sampleRate)
+	}
+	f := &RequestLogFilter{
+		sampleRate:    sampleRate,
+		statusCodes:   make(map[int]bool),
+		statusClasses: make(map[int]bool),
+	}
+	for _, s := range strings.Split(statusCodes, ",") {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" {
+			continue
+		}
+		if len(s) == 3 && strings.HasSuffix(s, "xx") && s[0] >= '1' && s[0] <= '5' {
+			f.statusClasses[int(s[0]-'0')] = true
+			continue
+		}
+		code, err := strconv.Atoi(s)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid request log status code %q", s)
+		}
+		f.statusCodes[code] = true
+	}
+	return f, nil
+}
+
+// shouldLog returns whether a request answered with the given status code is
+// logged, given a random sample in [0, 1). A nil filter logs all requests.
+func (f *RequestLogFilter) shouldLog(code int, sample float64) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.statusCodes) > 0 || len(f.statusClasses) > 0 {
+		if !f.statusCodes[code] && !f.statusClasses[code/100] {
+			return false
+		}
+	}
+	return sample < f.sampleRate
+}
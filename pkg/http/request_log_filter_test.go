@@ -0,0 +1,95 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import "testing"
+
+func TestNewRequestLogFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		sampleRate  float64
+		statusCodes string
+		wantErr     bool
+	}{{
+		name:       "no status codes",
+		sampleRate: 0.5,
+	}, {
+		name:        "codes and classes",
+		sampleRate:  1,
+		statusCodes: "429, 5XX,4xx",
+	}, {
+		name:       "negative sample rate",
+		sampleRate: -0.1,
+		wantErr:    true,
+	}, {
+		name:       "sample rate above one",
+		sampleRate: 1.5,
+		wantErr:    true,
+	}, {
+		name:        "unknown class",
+		sampleRate:  1,
+		statusCodes: "6xx",
+		wantErr:     true,
+	}, {
+		name:        "out of range code",
+		sampleRate:  1,
+		statusCodes: "42",
+		wantErr:     true,
+	}, {
+		name:        "garbage",
+		sampleRate:  1,
+		statusCodes: "errors",
+		wantErr:     true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewRequestLogFilter(test.sampleRate, test.statusCodes)
+			if test.wantErr != (err != nil) {
+				t.Errorf("NewRequestLogFilter() = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequestLogFilterShouldLog(t *testing.T) {
+	f, err := NewRequestLogFilter(0.25, "5xx,429")
+	if err != nil {
+		t.Fatalf("NewRequestLogFilter() = %v", err)
+	}
+	tests := []struct {
+		code   int
+		sample float64
+		want   bool
+	}{
+		{code: 500, sample: 0.1, want: true},
+		{code: 503, sample: 0.3, want: false},
+		{code: 429, sample: 0, want: true},
+		{code: 404, sample: 0, want: false},
+		{code: 200, sample: 0, want: false},
+	}
+	for _, test := range tests {
+		if got := f.shouldLog(test.code, test.sample); got != test.want {
+			t.Errorf("shouldLog(%d, %v) = %v, want %v", test.code, test.sample, got, test.want)
+		}
+	}
+
+	var nilFilter *RequestLogFilter
+	if !nilFilter.shouldLog(200, 0.99) {
+		t.Error("nil filter should log all requests")
+	}
+}
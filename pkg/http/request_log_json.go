@@ -0,0 +1,101 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.opencensus.io/plugin/ochttp/propagation/b3"
+)
+
+// RequestLogSchemaVersion is the version of the RequestLogEntry schema. It
+// is bumped whenever fields are renamed, removed or change meaning.
+const RequestLogSchemaVersion = "1"
+
+// RequestLogEntry is a request log written in the RequestLogFormatJSON format.
+type RequestLogEntry struct {
+	SchemaVersion string                  `json:"schemaVersion"`
+	Timestamp     string                  `json:"timestamp"`
+	TraceID       string                  `json:"traceId,omitempty"`
+	Revision      RequestLogEntryRevision `json:"revision"`
+	Request       RequestLogEntryRequest  `json:"request"`
+	Response      RequestLogEntryResponse `json:"response"`
+}
+
+// RequestLogEntryRevision identifies the revision serving a logged request.
+type RequestLogEntryRevision struct {
+	Name          string `json:"name,omitempty"`
+	Namespace     string `json:"namespace,omitempty"`
+	Service       string `json:"service,omitempty"`
+	Configuration string `json:"configuration,omitempty"`
+	PodName       string `json:"podName,omitempty"`
+	PodIP         string `json:"podIp,omitempty"`
+}
+
+// RequestLogEntryRequest describes a logged request.
+type RequestLogEntryRequest struct {
+	Method    string `json:"method"`
+	URL       string `json:"url"`
+	Host      string `json:"host"`
+	Protocol  string `json:"protocol"`
+	Size      int64  `json:"size"`
+	UserAgent string `json:"userAgent,omitempty"`
+	RemoteIP  string `json:"remoteIp,omitempty"`
+	Referer   string `json:"referer,omitempty"`
+}
+
+// RequestLogEntryResponse describes the response to a logged request.
+// Durations are in seconds.
+type RequestLogEntryResponse struct {
+	Code      int     `json:"code"`
+	Size      int     `json:"size"`
+	Latency   float64 `json:"latency"`
+	QueueWait float64 `json:"queueWait"`
+}
+
+// newRequestLogEntry creates the entry for a request received at start.
+func newRequestLogEntry(start time.Time, in *RequestLogTemplateInput) *RequestLogEntry {
+	e := &RequestLogEntry{
+		SchemaVersion: RequestLogSchemaVersion,
+		Timestamp:     start.UTC().Format(time.RFC3339Nano),
+		TraceID:       in.Request.Header.Get(b3.TraceIDHeader),
+		Request: RequestLogEntryRequest{
+			Method:    in.Request.Method,
+			URL:       in.Request.RequestURI,
+			Host:      in.Request.Host,
+			Protocol:  in.Request.Proto,
+			Size:      in.Request.ContentLength,
+			UserAgent: in.Request.UserAgent(),
+			RemoteIP:  in.Request.RemoteAddr,
+			Referer:   in.Request.Referer(),
+		},
+	}
+	if in.Revision != nil {
+		e.Revision = RequestLogEntryRevision(*in.Revision)
+	}
+	if in.Response != nil {
+		e.Response = RequestLogEntryResponse(*in.Response)
+	}
+	return e
+}
+
+func (h *RequestLogHandler) writeJSON(start time.Time, in *RequestLogTemplateInput) {
+	// json.Encoder terminates each entry with a newline, so that logging
+	// backends can parse them separately.
+	json.NewEncoder(h.writer).Encode(newRequestLogEntry(start, in))
+}
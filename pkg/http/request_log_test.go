@@ -18,10 +18,15 @@ package http
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 var defaultRevInfo = &RequestLogRevision{
@@ -223,3 +228,148 @@ func TestSetTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestLogHandlerJSON(t *testing.T) {
+	baseHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ReportQueueWait(r.Context(), 2*time.Second)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("hello"))
+	})
+	buf := bytes.NewBufferString("")
+	handler, err := NewRequestLogHandler(baseHandler, buf, "",
+		RequestLogTemplateInputGetterFromRevision(defaultRevInfo))
+	if err != nil {
+		t.Fatalf("want: no error, got: %v", err)
+	}
+	if err := handler.SetFormat(RequestLogFormatJSON); err != nil {
+		t.Fatalf("SetFormat() = %v", err)
+	}
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/testpage", bytes.NewBufferString("test"))
+	req.Header.Set("X-B3-Traceid", "abc123")
+	req.Header.Set("User-Agent", "test-agent")
+	handler.ServeHTTP(resp, req)
+
+	if !strings.HasSuffix(buf.String(), "\n") {
+		t.Errorf("got %q, want a trailing newline", buf.String())
+	}
+	var got RequestLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal(%q) = %v", buf.String(), err)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, got.Timestamp); err != nil {
+		t.Errorf("Timestamp %q is not in RFC3339 format: %v", got.Timestamp, err)
+	}
+	want := RequestLogEntry{
+		SchemaVersion: RequestLogSchemaVersion,
+		TraceID:       "abc123",
+		Revision: RequestLogEntryRevision{
+			Name:          "rev",
+			Namespace:     "ns",
+			Service:       "svc",
+			Configuration: "cfg",
+			PodName:       "pn",
+			PodIP:         "ip",
+		},
+		Request: RequestLogEntryRequest{
+			Method:    http.MethodPost,
+			URL:       "http://example.com/testpage",
+			Host:      "example.com",
+			Protocol:  "HTTP/1.1",
+			Size:      4,
+			UserAgent: "test-agent",
+			RemoteIP:  "192.0.2.1:1234",
+		},
+		Response: RequestLogEntryResponse{
+			Code:      http.StatusAccepted,
+			Size:      5,
+			QueueWait: 2,
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(RequestLogEntry{}, "Timestamp"),
+		cmpopts.IgnoreFields(RequestLogEntryResponse{}, "Latency")); diff != "" {
+		t.Errorf("Unexpected request log (-want +got): %s", diff)
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+	handler, err := NewRequestLogHandler(http.NotFoundHandler(), bytes.NewBufferString(""), "",
+		RequestLogTemplateInputGetterFromRevision(defaultRevInfo))
+	if err != nil {
+		t.Fatalf("want: no error, got: %v", err)
+	}
+	for _, f := range []RequestLogFormat{"", RequestLogFormatTemplate, RequestLogFormatJSON} {
+		if err := handler.SetFormat(f); err != nil {
+			t.Errorf("SetFormat(%q) = %v", f, err)
+		}
+	}
+	if err := handler.SetFormat("xml"); err == nil {
+		t.Error("SetFormat(xml) = nil, wanted an error")
+	}
+}
+
+func TestRequestLogHandlerFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        int
+		sampleRate  float64
+		statusCodes string
+		want        string
+	}{{
+		name:       "sample all",
+		code:       http.StatusOK,
+		sampleRate: 1,
+		want:       "200\n",
+	}, {
+		name:       "sample none",
+		code:       http.StatusOK,
+		sampleRate: 0,
+		want:       "",
+	}, {
+		name:        "matching status class",
+		code:        http.StatusBadGateway,
+		sampleRate:  1,
+		statusCodes: "5xx",
+		want:        "502\n",
+	}, {
+		name:        "matching status code",
+		code:        http.StatusTooManyRequests,
+		sampleRate:  1,
+		statusCodes: "5xx, 429",
+		want:        "429\n",
+	}, {
+		name:        "filtered status",
+		code:        http.StatusOK,
+		sampleRate:  1,
+		statusCodes: "5xx,429",
+		want:        "",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			baseHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.code)
+			})
+			buf := bytes.NewBufferString("")
+			handler, err := NewRequestLogHandler(baseHandler, buf, "{{.Response.Code}}",
+				RequestLogTemplateInputGetterFromRevision(defaultRevInfo))
+			if err != nil {
+				t.Fatalf("want: no error, got: %v", err)
+			}
+			filter, err := NewRequestLogFilter(test.sampleRate, test.statusCodes)
+			if err != nil {
+				t.Fatalf("NewRequestLogFilter() = %v", err)
+			}
+			handler.SetFilter(filter)
+
+			resp := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+			handler.ServeHTTP(resp, req)
+
+			if got := buf.String(); got != test.want {
+				t.Errorf("got '%v', want '%v'", got, test.want)
+			}
+		})
+	}
+}
@@ -17,9 +17,12 @@ limitations under the License.
 package metrics
 
 import (
+	"strconv"
 	"strings"
 	"text/template"
 
+	pkghttp "github.com/knative/serving/pkg/http"
+
 	"knative.dev/pkg/metrics"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
@@ -56,6 +59,19 @@ type ObservabilityConfig struct {
 	// RequestLogTemplate is the go template to use to shape the request logs.
 	RequestLogTemplate string
 
+	// RequestLogFormat is the format of the request logs, either "template"
+	// (the default) or "json".
+	RequestLogFormat string
+
+	// RequestLogSampleRate is the fraction, between 0 and 1, of the requests
+	// matching RequestLogStatusCodes that are logged.
+	RequestLogSampleRate float64
+
+	// RequestLogStatusCodes is a comma separated list of response status
+	// codes or classes (e.g. "5xx") to write request logs for. If empty,
+	// all requests are logged.
+	RequestLogStatusCodes string
+
 	// RequestMetricsBackend specifies the request metrics destination, e.g. Prometheus,
 	// Stackdriver.
 	RequestMetricsBackend string
@@ -63,7 +79,9 @@ type ObservabilityConfig struct {
 
 // NewObservabilityConfigFromConfigMap creates a ObservabilityConfig from the supplied ConfigMap
 func NewObservabilityConfigFromConfigMap(configMap *corev1.ConfigMap) (*ObservabilityConfig, error) {
-	oc := &ObservabilityConfig{}
+	oc := &ObservabilityConfig{
+		RequestLogSampleRate: 1,
+	}
 	if evlc, ok := configMap.Data["logging.enable-var-log-collection"]; ok {
 		oc.EnableVarLogCollection = strings.ToLower(evlc) == "true"
 	}
@@ -82,9 +100,38 @@ func NewObservabilityConfigFromConfigMap(configMap *corev1.ConfigMap) (*Observab
 		oc.RequestLogTemplate = rlt
 	}
 
+	if rlf, ok := configMap.Data["logging.request-log-format"]; ok {
+		if err := pkghttp.RequestLogFormat(rlf).Validate(); err != nil {
+			return nil, err
+		}
+		oc.RequestLogFormat = rlf
+	}
+
+	if rlsr, ok := configMap.Data["logging.request-log-sample-rate"]; ok {
+		sr, err := strconv.ParseFloat(rlsr, 64)
+		if err != nil {
+			return nil, err
+		}
+		oc.RequestLogSampleRate = sr
+	}
+
+	if rlsc, ok := configMap.Data["logging.request-log-status-codes"]; ok {
+		oc.RequestLogStatusCodes = rlsc
+	}
+
+	// Verify that the sample rate and status codes make a valid filter.
+	if _, err := oc.RequestLogFilter(); err != nil {
+		return nil, err
+	}
+
 	if mb, ok := configMap.Data["metrics.request-metrics-backend-destination"]; ok {
 		oc.RequestMetricsBackend = mb
 	}
 
 	return oc, nil
 }
+
+// RequestLogFilter returns the filter deciding which requests are logged.
+func (oc *ObservabilityConfig) RequestLogFilter() (*pkghttp.RequestLogFilter, error) {
+	return pkghttp.NewRequestLogFilter(oc.RequestLogSampleRate, oc.RequestLogStatusCodes)
+}
@@ -54,6 +54,9 @@ func TestObservabilityConfiguration(t *testing.T) {
 			LoggingURLTemplate:     "https://logging.io",
 			EnableVarLogCollection: true,
 			RequestLogTemplate:     `{"requestMethod": "{{.Request.Method}}"}`,
+			RequestLogFormat:       "json",
+			RequestLogSampleRate:   0.1,
+			RequestLogStatusCodes:  "5xx,429",
 			RequestMetricsBackend:  "stackdriver",
 		},
 		config: &corev1.ConfigMap{
@@ -66,6 +69,9 @@ func TestObservabilityConfiguration(t *testing.T) {
 				"logging.revision-url-template":               "https://logging.io",
 				"logging.write-request-logs":                  "true",
 				"logging.request-log-template":                `{"requestMethod": "{{.Request.Method}}"}`,
+				"logging.request-log-format":                  "json",
+				"logging.request-log-sample-rate":             "0.1",
+				"logging.request-log-status-codes":            "5xx,429",
 				"metrics.request-metrics-backend-destination": "stackdriver",
 			},
 		},
@@ -76,6 +82,7 @@ func TestObservabilityConfiguration(t *testing.T) {
 			EnableVarLogCollection: false,
 			LoggingURLTemplate:     defaultLogURLTemplate,
 			RequestLogTemplate:     "",
+			RequestLogSampleRate:   1,
 			RequestMetricsBackend:  "",
 		},
 		config: &corev1.ConfigMap{
@@ -97,6 +104,45 @@ func TestObservabilityConfiguration(t *testing.T) {
 				"logging.request-log-template": `{{ something }}`,
 			},
 		},
+	}, {
+		name:           "invalid request log format",
+		wantErr:        true,
+		wantController: (*ObservabilityConfig)(nil),
+		config: &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: system.Namespace(),
+				Name:      metrics.ConfigMapName(),
+			},
+			Data: map[string]string{
+				"logging.request-log-format": "xml",
+			},
+		},
+	}, {
+		name:           "invalid request log sample rate",
+		wantErr:        true,
+		wantController: (*ObservabilityConfig)(nil),
+		config: &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: system.Namespace(),
+				Name:      metrics.ConfigMapName(),
+			},
+			Data: map[string]string{
+				"logging.request-log-sample-rate": "2",
+			},
+		},
+	}, {
+		name:           "invalid request log status codes",
+		wantErr:        true,
+		wantController: (*ObservabilityConfig)(nil),
+		config: &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: system.Namespace(),
+				Name:      metrics.ConfigMapName(),
+			},
+			Data: map[string]string{
+				"logging.request-log-status-codes": "oops",
+			},
+		},
 	}}
 
 	for _, tt := range observabilityConfigTests {
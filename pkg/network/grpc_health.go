@@ -0,0 +1,93 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/golang/protobuf/proto"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// GRPCHealthCheckPath is the path of the method of the standard gRPC
+	// health checking protocol.
+	GRPCHealthCheckPath = "/grpc.health.v1.Health/Check"
+
+	// grpcFrameHeaderLen is the length of the header prefixing each gRPC
+	// message: a compression flag and the length of the message.
+	grpcFrameHeaderLen = 5
+)
+
+// PrepareGRPCHealthCheck turns r into a call of grpc.health.v1.Health/Check
+// for the given service, the empty service standing for the whole server.
+// The request must be sent through a transport supporting h2c.
+func PrepareGRPCHealthCheck(r *http.Request, service string) *http.Request {
+	// Marshalling a message made of a string cannot fail.
+	msg, _ := proto.Marshal(&healthpb.HealthCheckRequest{Service: service})
+	body := make([]byte, grpcFrameHeaderLen+len(msg))
+	binary.BigEndian.PutUint32(body[1:grpcFrameHeaderLen], uint32(len(msg)))
+	copy(body[grpcFrameHeaderLen:], msg)
+
+	r.Method = http.MethodPost
+	r.URL.Path = GRPCHealthCheckPath
+	// Make sure the request is sent with HTTP/2, e.g. by AutoTransport.
+	r.Proto, r.ProtoMajor, r.ProtoMinor = "HTTP/2.0", 2, 0
+	r.Header.Set("Content-Type", "application/grpc")
+	r.Header.Set("TE", "trailers")
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	return r
+}
+
+// GRPCHealthCheckServing returns whether the response to a call prepared by
+// PrepareGRPCHealthCheck, whose body has been read entirely, reports the
+// service as serving.
+func GRPCHealthCheckServing(resp *http.Response, body []byte) (bool, error) {
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("gRPC health check returned HTTP status %d", resp.StatusCode)
+	}
+	// Errors are reported in the trailers, or in the headers of responses
+	// without a body.
+	status, msg := resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status, msg = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	if status != "0" {
+		return false, fmt.Errorf("gRPC health check failed with status %q: %s", status, msg)
+	}
+
+	if len(body) < grpcFrameHeaderLen {
+		return false, fmt.Errorf("gRPC health check response is too short: %d bytes", len(body))
+	}
+	if body[0] != 0 {
+		return false, fmt.Errorf("gRPC health check response is compressed")
+	}
+	n := binary.BigEndian.Uint32(body[1:grpcFrameHeaderLen])
+	if int(n) != len(body)-grpcFrameHeaderLen {
+		return false, fmt.Errorf("gRPC health check response has length %d, want: %d", len(body)-grpcFrameHeaderLen, n)
+	}
+	hc := &healthpb.HealthCheckResponse{}
+	if err := proto.Unmarshal(body[grpcFrameHeaderLen:], hc); err != nil {
+		return false, fmt.Errorf("failed to decode gRPC health check response: %v", err)
+	}
+	return hc.Status == healthpb.HealthCheckResponse_SERVING, nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// grpcHealthServer returns a server implementing grpc.health.v1.Health/Check
+// over h2c, reporting status for the service "ok" and NOT_FOUND otherwise.
+func grpcHealthServer(t *testing.T, status healthpb.HealthCheckResponse_ServingStatus) *httptest.Server {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != GRPCHealthCheckPath || r.ProtoMajor != 2 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil || len(b) < grpcFrameHeaderLen {
+			t.Errorf("Failed to read request: %v, body: %v", err, b)
+			return
+		}
+		req := &healthpb.HealthCheckRequest{}
+		if err := proto.Unmarshal(b[grpcFrameHeaderLen:], req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		if req.Service != "ok" {
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "unknown service")
+			return
+		}
+		msg, _ := proto.Marshal(&healthpb.HealthCheckResponse{Status: status})
+		frame := make([]byte, grpcFrameHeaderLen, grpcFrameHeaderLen+len(msg))
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+		w.Write(append(frame, msg...))
+		w.Header().Set("Grpc-Status", "0")
+	})
+	return httptest.NewServer(h2c.NewHandler(h, &http2.Server{}))
+}
+
+func TestGRPCHealthCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  healthpb.HealthCheckResponse_ServingStatus
+		service string
+		want    bool
+		wantErr bool
+	}{{
+		name:    "serving",
+		status:  healthpb.HealthCheckResponse_SERVING,
+		service: "ok",
+		want:    true,
+	}, {
+		name:    "not serving",
+		status:  healthpb.HealthCheckResponse_NOT_SERVING,
+		service: "ok",
+	}, {
+		name:    "unknown service",
+		status:  healthpb.HealthCheckResponse_SERVING,
+		service: "unknown",
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := grpcHealthServer(t, test.status)
+			defer ts.Close()
+
+			req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+			if err != nil {
+				t.Fatalf("NewRequest() = %v", err)
+			}
+			resp, err := NewAutoTransport().RoundTrip(PrepareGRPCHealthCheck(req, test.service))
+			if err != nil {
+				t.Fatalf("RoundTrip() = %v", err)
+			}
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("ReadAll() = %v", err)
+			}
+
+			got, err := GRPCHealthCheckServing(resp, body)
+			if (err != nil) != test.wantErr {
+				t.Errorf("GRPCHealthCheckServing() = %v, wantErr: %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("GRPCHealthCheckServing() = %v, want: %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGRPCHealthCheckServingErrors(t *testing.T) {
+	ok := http.Header{"Grpc-Status": []string{"0"}}
+	tests := []struct {
+		name string
+		resp *http.Response
+		body []byte
+	}{{
+		name: "http error",
+		resp: &http.Response{StatusCode: http.StatusServiceUnavailable, Header: ok},
+	}, {
+		name: "no grpc status",
+		resp: &http.Response{StatusCode: http.StatusOK, Header: http.Header{}},
+	}, {
+		name: "short body",
+		resp: &http.Response{StatusCode: http.StatusOK, Header: ok},
+		body: []byte{0, 0},
+	}, {
+		name: "compressed",
+		resp: &http.Response{StatusCode: http.StatusOK, Header: ok},
+		body: []byte{1, 0, 0, 0, 0},
+	}, {
+		name: "wrong length",
+		resp: &http.Response{StatusCode: http.StatusOK, Header: ok},
+		body: []byte{0, 0, 0, 0, 2, 8},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, err := GRPCHealthCheckServing(test.resp, test.body); err == nil || got {
+				t.Errorf("GRPCHealthCheckServing() = %v, %v, want an error", got, err)
+			}
+		})
+	}
+}
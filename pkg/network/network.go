@@ -38,6 +38,16 @@ const (
 	// included in request metrics.
 	ProbeHeaderName = "K-Network-Probe"
 
+	// ProbeHeaderValue is the value of ProbeHeaderName used by the
+	// ClusterIngress reconciler to verify that the gateways serve its
+	// latest configuration.
+	ProbeHeaderValue = "probe"
+
+	// HashHeaderName is the name of a header the gateways append to the
+	// requests they route, carrying the hash of the ClusterIngress spec
+	// they were programmed with.  Probe responses echo it back.
+	HashHeaderName = "K-Network-Hash"
+
 	// ProxyHeaderName is the name of an internal header that activator
 	// uses to mark requests going through it.
 	ProxyHeaderName = "K-Proxy-Request"
@@ -48,6 +58,12 @@ const (
 	// at the Queue proxy level back to be a host header.
 	OriginalHostHeader = "K-Original-Host"
 
+	// RequestDeadlineHeaderName is the name of a header clients and
+	// proxies can set to the time, in RFC 3339 format, after which they
+	// stop waiting for the response. Queued requests are dropped once it
+	// has passed.
+	RequestDeadlineHeaderName = "K-Request-Deadline"
+
 	// ConfigName is the name of the configmap containing all
 	// customizations for networking features.
 	ConfigName = "config-network"
@@ -64,6 +80,10 @@ const (
 	// ClusterIngress reconciler.
 	IstioIngressClassName = "istio.ingress.networking.knative.dev"
 
+	// EnvoyIngressClassName value for specifying knative's Envoy
+	// ClusterIngress reconciler, which programs Envoy directly over xDS.
+	EnvoyIngressClassName = "envoy.ingress.networking.knative.dev"
+
 	// DomainTemplateKey is the name of the configuration entry that
 	// specifies the golang template string to use to construct the
 	// Knative service's DNS name.
@@ -321,6 +341,20 @@ func IsKubeletProbe(r *http.Request) bool {
 		r.Header.Get(KubeletProbeHeaderName) != ""
 }
 
+// RequestDeadline returns the deadline propagated with the request, and
+// whether it carries a valid one.
+func RequestDeadline(r *http.Request) (time.Time, bool) {
+	v := r.Header.Get(RequestDeadlineHeaderName)
+	if v == "" {
+		return time.Time{}, false
+	}
+	deadline, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return deadline, true
+}
+
 // RewriteHostIn removes the `Host` header from the inbound (server) request
 // and replaces it with our custom header.
 // This is done to avoid Istio Host based routing, see #3870.
@@ -22,6 +22,7 @@ import (
 	"net/http/httptest"
 	"testing"
 	"text/template"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -603,6 +604,39 @@ func TestIsKubeletProbe(t *testing.T) {
 	}
 }
 
+func TestRequestDeadline(t *testing.T) {
+	deadline := time.Date(2019, 7, 1, 12, 0, 0, 500000000, time.UTC)
+	tests := []struct {
+		name   string
+		header string
+		want   time.Time
+		wantOK bool
+	}{{
+		name: "no header",
+	}, {
+		name:   "invalid header",
+		header: "tomorrow",
+	}, {
+		name:   "valid header",
+		header: deadline.Format(time.RFC3339Nano),
+		want:   deadline,
+		wantOK: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			if test.header != "" {
+				r.Header.Set(RequestDeadlineHeaderName, test.header)
+			}
+			got, ok := RequestDeadline(r)
+			if ok != test.wantOK || !got.Equal(test.want) {
+				t.Errorf("RequestDeadline() = %v, %v, want: %v, %v", got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
+
 func TestRewriteHost(t *testing.T) {
 	r := httptest.NewRequest(http.MethodGet, "http://love.is/not-hate", nil)
 	r.Header.Set("Host", "love.is")
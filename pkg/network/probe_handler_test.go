@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProbeHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantStatus int
+		wantHash   string
+		wantNext   bool
+	}{{
+		name:       "not a probe",
+		wantStatus: http.StatusTeapot,
+		wantNext:   true,
+	}, {
+		name:       "hash without probe is stripped",
+		headers:    map[string]string{HashHeaderName: "deadbeef"},
+		wantStatus: http.StatusTeapot,
+		wantNext:   true,
+	}, {
+		name:       "other component's probe",
+		headers:    map[string]string{ProbeHeaderName: "activator"},
+		wantStatus: http.StatusTeapot,
+		wantNext:   true,
+	}, {
+		name:       "probe without hash",
+		headers:    map[string]string{ProbeHeaderName: ProbeHeaderValue},
+		wantStatus: http.StatusBadRequest,
+	}, {
+		name: "probe with hash",
+		headers: map[string]string{
+			ProbeHeaderName: ProbeHeaderValue,
+			HashHeaderName:  "deadbeef",
+		},
+		wantStatus: http.StatusOK,
+		wantHash:   "deadbeef",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotNext bool
+			h := NewProbeHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotNext = true
+				if got := r.Header.Get(HashHeaderName); got != "" {
+					t.Errorf("%s = %q reached the next handler", HashHeaderName, got)
+				}
+				w.WriteHeader(http.StatusTeapot)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+			resp := httptest.NewRecorder()
+			h.ServeHTTP(resp, req)
+
+			if got, want := resp.Code, test.wantStatus; got != want {
+				t.Errorf("StatusCode = %d, want: %d", got, want)
+			}
+			if got, want := resp.Header().Get(HashHeaderName), test.wantHash; got != want {
+				t.Errorf("%s = %q, want: %q", HashHeaderName, got, want)
+			}
+			if gotNext != test.wantNext {
+				t.Errorf("next handler called = %v, want: %v", gotNext, test.wantNext)
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/knative/serving/pkg/network"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -45,6 +46,23 @@ func WithHeader(name, value string) Preparer {
 	}
 }
 
+// WithHost sets the host in the probe request.
+func WithHost(host string) Preparer {
+	return func(r *http.Request) *http.Request {
+		r.Host = host
+		return r
+	}
+}
+
+// WithGRPCHealthCheck turns the probe request into a call of the standard
+// grpc.health.v1.Health/Check method for the given service, sent over h2c.
+// It should be paired with ExpectsGRPCServing.
+func WithGRPCHealthCheck(service string) Preparer {
+	return func(r *http.Request) *http.Request {
+		return network.PrepareGRPCHealthCheck(r, service)
+	}
+}
+
 // ExpectsBody validates that the body of the probe response matches the provided string.
 func ExpectsBody(body string) Verifier {
 	return func(r *http.Response, b []byte) (bool, error) {
@@ -52,6 +70,19 @@ func ExpectsBody(body string) Verifier {
 	}
 }
 
+// ExpectsHeader validates that the given header of the probe response matches the provided string.
+func ExpectsHeader(name, value string) Verifier {
+	return func(r *http.Response, _ []byte) (bool, error) {
+		return r.Header.Get(name) == value, nil
+	}
+}
+
+// ExpectsGRPCServing validates that the gRPC health check response reports
+// the service as SERVING.
+func ExpectsGRPCServing() Verifier {
+	return network.GRPCHealthCheckServing
+}
+
 // Do sends a single probe to given target, e.g. `http://revision.default.svc.cluster.local:81`.
 // Do returns whether the probe was successful or not, or there was an error probing.
 func Do(ctx context.Context, transport http.RoundTripper, target string, ops ...interface{}) (bool, error) {
@@ -127,23 +158,30 @@ func New(cb Done, transportFactory TransportFactory) *Manager {
 // `Do`, until timeout is reached, the probe succeeds, or fails with an error.
 // In the end the callback is invoked with the provided `arg` and probing results.
 func (m *Manager) Offer(ctx context.Context, target string, arg interface{}, period, timeout time.Duration, ops ...interface{}) bool {
+	return m.OfferWithKey(ctx, target, target, arg, period, timeout, ops...)
+}
+
+// OfferWithKey is like Offer, but coalesces concurrent probes on `key` rather
+// than on `target`. This allows probing the same target with e.g. different
+// hosts concurrently.
+func (m *Manager) OfferWithKey(ctx context.Context, key, target string, arg interface{}, period, timeout time.Duration, ops ...interface{}) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	if m.keys.Has(target) {
+	if m.keys.Has(key) {
 		return false
 	}
-	m.keys.Insert(target)
-	m.doAsync(ctx, m.transportFactory, target, arg, period, timeout, ops...)
+	m.keys.Insert(key)
+	m.doAsync(ctx, m.transportFactory, key, target, arg, period, timeout, ops...)
 	return true
 }
 
 // doAsync starts a go routine that probes the target with given period.
-func (m *Manager) doAsync(ctx context.Context, transportFactory TransportFactory, target string, arg interface{}, period, timeout time.Duration, ops ...interface{}) {
+func (m *Manager) doAsync(ctx context.Context, transportFactory TransportFactory, key, target string, arg interface{}, period, timeout time.Duration, ops ...interface{}) {
 	go func() {
 		defer func() {
 			m.mu.Lock()
 			defer m.mu.Unlock()
-			m.keys.Delete(target)
+			m.keys.Delete(key)
 		}()
 		var (
 			result bool
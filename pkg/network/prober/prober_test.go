@@ -17,13 +17,17 @@ limitations under the License.
 package prober
 
 import (
+	"bytes"
 	"context"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/knative/serving/pkg/network"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -244,6 +248,111 @@ func TestAsyncMultiple(t *testing.T) {
 	}
 }
 
+func TestOfferWithKey(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(probeServeFunc))
+	defer ts.Close()
+
+	wch := make(chan interface{})
+	defer close(wch)
+	cb := func(arg interface{}, done bool, err error) {
+		<-wch
+	}
+	m := New(cb, network.NewAutoTransport)
+	if !m.OfferWithKey(context.Background(), "a", ts.URL, 1984, 100*time.Millisecond, 1*time.Second) {
+		t.Error("First call to offer returned false")
+	}
+	if !m.OfferWithKey(context.Background(), "b", ts.URL, 1982, 100*time.Millisecond, 1*time.Second) {
+		t.Error("Call to offer with a different key returned false")
+	}
+	if m.OfferWithKey(context.Background(), "a", ts.URL, 2006, 100*time.Millisecond, 1*time.Second) {
+		t.Error("Call to offer with the same key returned true")
+	}
+	if got, want := m.len(), 2; got != want {
+		t.Errorf("Number of queued items = %d, want: %d", got, want)
+	}
+	wch <- 1
+	wch <- 2
+}
+
+func TestProbeHostAndHeader(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(network.HashHeaderName, r.Host)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name string
+		host string
+		want bool
+	}{{
+		name: "matching header",
+		host: "foo.bar.com",
+		want: true,
+	}, {
+		name: "mismatching header",
+		host: "bar.baz.com",
+		want: false,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Do(context.Background(), network.NewAutoTransport(), ts.URL,
+				WithHost(test.host), ExpectsHeader(network.HashHeaderName, "foo.bar.com"))
+			if err != nil {
+				t.Errorf("Do() = %v", err)
+			}
+			if got != test.want {
+				t.Errorf("Do() = %v, want: %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestProbeGRPCHealthCheck(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != network.GRPCHealthCheckPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Trailer", "Grpc-Status")
+		// A framed HealthCheckResponse, SERVING only for the service "ok".
+		if bytes.HasSuffix(b, []byte("ok")) {
+			w.Write([]byte{0, 0, 0, 0, 2, 8, 1})
+		} else {
+			w.Write([]byte{0, 0, 0, 0, 2, 8, 2})
+		}
+		w.Header().Set("Grpc-Status", "0")
+	})
+	ts := httptest.NewServer(h2c.NewHandler(h, &http2.Server{}))
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		service string
+		want    bool
+	}{{
+		name:    "serving",
+		service: "ok",
+		want:    true,
+	}, {
+		name:    "not serving",
+		service: "nope",
+		want:    false,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Do(context.Background(), network.NewAutoTransport(), ts.URL,
+				WithGRPCHealthCheck(test.service), ExpectsGRPCServing())
+			if err != nil {
+				t.Errorf("Do() = %v", err)
+			}
+			if got != test.want {
+				t.Errorf("Do() = %v, want: %v", got, test.want)
+			}
+		})
+	}
+}
+
 func (m *Manager) len() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
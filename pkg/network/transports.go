@@ -17,11 +17,13 @@ package network
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"net"
 	"net/http"
 	"time"
 
+	"golang.org/x/net/http2"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -54,6 +56,8 @@ var errDialTimeout = errors.New("timed out dialing")
 
 // dialWithBackOff executes `net.Dialer.DialContext()` with exponentially increasing
 // dial timeouts. In addition it sleeps with random jitter between tries.
+// Once all of the tries timed out, it fails with a dial *net.OpError, like
+// any other failure to connect.
 func dialWithBackOff(ctx context.Context, network, address string) (net.Conn, error) {
 	return dialBackOffHelper(ctx, network, address, numSteps, initialTO, sleepTO)
 }
@@ -82,7 +86,7 @@ func dialBackOffHelper(ctx context.Context, network, address string, steps int,
 		}
 		return c, err
 	}
-	return nil, errDialTimeout
+	return nil, &net.OpError{Op: "dial", Net: network, Err: errDialTimeout}
 }
 
 func newHTTPTransport(connTimeout time.Duration) http.RoundTripper {
@@ -107,3 +111,23 @@ func NewAutoTransport() http.RoundTripper {
 
 // AutoTransport uses h2c for HTTP2 requests and falls back to `http.DefaultTransport` for all others
 var AutoTransport = NewAutoTransport()
+
+// NewUnixAutoTransport creates a RoundTripper like NewAutoTransport, which
+// connects to the Unix domain socket at path, whatever the request's host.
+func NewUnixAutoTransport(path string) http.RoundTripper {
+	dial := func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialWithBackOff(ctx, "unix", path)
+	}
+
+	v1 := newHTTPTransport(DefaultConnTimeout).(*http.Transport)
+	// A proxy would not reach the socket.
+	v1.Proxy = nil
+	v1.DialContext = dial
+	v2 := &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(netw, addr string, cfg *tls.Config) (net.Conn, error) {
+			return dial(context.Background(), netw, addr)
+		},
+	}
+	return newAutoTransport(v1, v2)
+}
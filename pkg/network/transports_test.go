@@ -17,11 +17,17 @@ package network
 
 import (
 	"context"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -85,8 +91,8 @@ func TestDialWithBackoff(t *testing.T) {
 		c.Close()
 		t.Error("Unexpected success dialing")
 	}
-	if err != errDialTimeout {
-		t.Errorf("Error = %v, want: %v", err, errDialTimeout)
+	if opErr, ok := err.(*net.OpError); !ok || opErr.Op != "dial" || opErr.Err != errDialTimeout {
+		t.Errorf("Error = %v, want: a dial error timing out", err)
 	}
 
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
@@ -98,3 +104,45 @@ func TestDialWithBackoff(t *testing.T) {
 	}
 	c.Close()
 }
+
+func TestUnixAutoTransport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unix-transport")
+	if err != nil {
+		t.Fatalf("TempDir() = %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "user.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	s := &http.Server{
+		Handler: h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.Proto))
+		}), &http2.Server{}),
+	}
+	go s.Serve(l)
+	defer s.Close()
+
+	rt := NewUnixAutoTransport(path)
+	for protoMajor, want := range map[int]string{1: "HTTP/1.1", 2: "HTTP/2.0"} {
+		// The host doesn't matter, the socket is dialed in any case.
+		req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1:8080/", nil)
+		if err != nil {
+			t.Fatalf("NewRequest() = %v", err)
+		}
+		req.ProtoMajor = protoMajor
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("RoundTrip(HTTP/%d) = %v", protoMajor, err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("ReadAll() = %v", err)
+		}
+		if got := string(body); got != want {
+			t.Errorf("Protocol = %q, want: %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultAdaptiveTolerance is the default factor by which the average
+	// latency may exceed the baseline before the limit is reduced.
+	DefaultAdaptiveTolerance = 2.0
+	// DefaultAdaptiveInterval is the default period over which the latency
+	// is averaged before the limit is adjusted.
+	DefaultAdaptiveInterval = time.Second
+
+	// adaptiveBackoff is the factor the limit is multiplied with when the
+	// latency degrades.
+	adaptiveBackoff = 0.9
+	// baselineDrift is the fraction the baseline latency grows by every
+	// interval, so that a single fast interval doesn't pin it forever.
+	baselineDrift = 0.01
+)
+
+// AdaptiveParams defines the parameters of a Breaker which adapts its
+// concurrency limit to the latency of the requests it executes.
+type AdaptiveParams struct {
+	// MinConcurrency is the lowest the concurrency limit is reduced to.
+	// Defaults to 1.
+	MinConcurrency int
+	// Tolerance is how many times the baseline latency the average latency
+	// of an interval may reach before the limit is reduced.
+	// Defaults to DefaultAdaptiveTolerance.
+	Tolerance float64
+	// Interval is the period the latency is averaged over.
+	// Defaults to DefaultAdaptiveInterval.
+	Interval time.Duration
+}
+
+// adaptiveLimiter computes a concurrency limit with additive increase and
+// multiplicative decrease: while the latency stays close to the lowest seen
+// and the limit is reached, it grows by one every interval; when the latency
+// degrades it shrinks by a fraction. It is not thread safe.
+type adaptiveLimiter struct {
+	min, max  int
+	limit     int
+	tolerance float64
+	interval  time.Duration
+
+	// baseline is the lowest average latency seen, drifting upwards.
+	baseline time.Duration
+
+	// The observations of the current interval.
+	start     time.Time
+	total     time.Duration
+	count     int
+	saturated bool
+}
+
+func newAdaptiveLimiter(params AdaptiveParams, max, initial int) *adaptiveLimiter {
+	if params.MinConcurrency == 0 {
+		params.MinConcurrency = 1
+	}
+	if params.Tolerance == 0 {
+		params.Tolerance = DefaultAdaptiveTolerance
+	}
+	if params.Interval == 0 {
+		params.Interval = DefaultAdaptiveInterval
+	}
+	if params.MinConcurrency < 1 || params.MinConcurrency > max {
+		panic(fmt.Sprintf("Min concurrency must be between 1 and max concurrency. Got %v.", params.MinConcurrency))
+	}
+	if params.Tolerance < 1 {
+		panic(fmt.Sprintf("Tolerance must be 1 or greater. Got %v.", params.Tolerance))
+	}
+	if params.Interval < 0 {
+		panic(fmt.Sprintf("Interval must be greater than 0. Got %v.", params.Interval))
+	}
+	if initial < params.MinConcurrency {
+		initial = params.MinConcurrency
+	}
+	return &adaptiveLimiter{
+		min:       params.MinConcurrency,
+		max:       max,
+		limit:     initial,
+		tolerance: params.Tolerance,
+		interval:  params.Interval,
+	}
+}
+
+// record observes a request which took latency to execute while inFlight
+// requests, itself included, were executing. The limit is adjusted once the
+// first observation after the end of an interval comes in. It returns the
+// limit and whether it changed.
+func (l *adaptiveLimiter) record(now time.Time, latency time.Duration, inFlight int) (int, bool) {
+	changed := false
+	if l.count > 0 && now.Sub(l.start) >= l.interval {
+		changed = l.adjust()
+	}
+	if l.count == 0 {
+		l.start = now
+	}
+	l.total += latency
+	l.count++
+	if inFlight >= l.limit {
+		l.saturated = true
+	}
+	return l.limit, changed
+}
+
+// adjust computes the limit from the observations of the interval that
+// ended and starts a new one. It returns whether the limit changed.
+func (l *adaptiveLimiter) adjust() bool {
+	average := l.total / time.Duration(l.count)
+	saturated := l.saturated
+	l.total, l.count, l.saturated = 0, 0, false
+
+	if l.baseline == 0 || average < l.baseline {
+		l.baseline = average
+	} else {
+		l.baseline += time.Duration(float64(l.baseline) * baselineDrift)
+	}
+
+	limit := l.limit
+	if float64(average) > float64(l.baseline)*l.tolerance {
+		limit = int(float64(limit) * adaptiveBackoff)
+		if limit >= l.limit {
+			limit = l.limit - 1
+		}
+		if limit < l.min {
+			limit = l.min
+		}
+	} else if saturated && limit < l.max {
+		limit++
+	}
+	changed := limit != l.limit
+	l.limit = limit
+	return changed
+}
+
+// reset sets the limit, e.g. after the bounds were changed from outside.
+func (l *adaptiveLimiter) reset(limit int) {
+	l.limit = limit
+	l.total, l.count, l.saturated = 0, 0, false
+}
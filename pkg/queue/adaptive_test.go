@@ -0,0 +1,136 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimiter(t *testing.T) {
+	type observation struct {
+		latency  time.Duration
+		inFlight int
+	}
+	tests := []struct {
+		name    string
+		initial int
+		// intervals are recorded one after the other.
+		intervals   [][]observation
+		wantLimit   int
+		wantChanged bool
+	}{{
+		name:        "saturated at a steady latency",
+		initial:     5,
+		intervals:   [][]observation{{{10 * time.Millisecond, 5}}, {{10 * time.Millisecond, 6}}},
+		wantLimit:   7,
+		wantChanged: true,
+	}, {
+		name:      "not saturated",
+		initial:   5,
+		intervals: [][]observation{{{10 * time.Millisecond, 2}}, {{10 * time.Millisecond, 3}}},
+		wantLimit: 5,
+	}, {
+		name:        "saturated up to the maximum",
+		initial:     9,
+		intervals:   [][]observation{{{10 * time.Millisecond, 9}}, {{10 * time.Millisecond, 10}}, {{10 * time.Millisecond, 10}}},
+		wantLimit:   10,
+		wantChanged: false,
+	}, {
+		name:        "latency degrades",
+		initial:     10,
+		intervals:   [][]observation{{{10 * time.Millisecond, 1}}, {{50 * time.Millisecond, 10}}},
+		wantLimit:   9,
+		wantChanged: true,
+	}, {
+		name:    "latency degrades down to the minimum",
+		initial: 3,
+		intervals: [][]observation{
+			{{10 * time.Millisecond, 1}},
+			{{50 * time.Millisecond, 3}},
+			{{50 * time.Millisecond, 2}},
+			{{50 * time.Millisecond, 2}},
+		},
+		wantLimit: 2,
+	}, {
+		name:    "latency within tolerance of the average",
+		initial: 4,
+		intervals: [][]observation{
+			{{10 * time.Millisecond, 1}},
+			{{5 * time.Millisecond, 4}, {25 * time.Millisecond, 4}},
+		},
+		wantLimit:   5,
+		wantChanged: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := newAdaptiveLimiter(AdaptiveParams{MinConcurrency: 2, Interval: time.Second}, 10, test.initial)
+			now := time.Now()
+			var (
+				limit   int
+				changed bool
+			)
+			for _, interval := range test.intervals {
+				for _, o := range interval {
+					l.record(now, o.latency, o.inFlight)
+				}
+				now = now.Add(time.Second)
+			}
+			// The first observation of the next interval adjusts the limit.
+			limit, changed = l.record(now, 0, 0)
+			if limit != test.wantLimit || changed != test.wantChanged {
+				t.Errorf("record() = (%d, %v), want: (%d, %v)", limit, changed, test.wantLimit, test.wantChanged)
+			}
+		})
+	}
+}
+
+func TestAdaptiveLimiterDefaults(t *testing.T) {
+	l := newAdaptiveLimiter(AdaptiveParams{}, 10, 0)
+	if l.min != 1 || l.limit != 1 || l.tolerance != DefaultAdaptiveTolerance || l.interval != DefaultAdaptiveInterval {
+		t.Errorf("newAdaptiveLimiter() = %#v, want the defaults", l)
+	}
+}
+
+func TestBreakerAdaptive(t *testing.T) {
+	params := BreakerParams{
+		QueueDepth:      1,
+		MaxConcurrency:  10,
+		InitialCapacity: 10,
+		Adaptive:        &AdaptiveParams{Interval: 1},
+	}
+	b := NewBreaker(params)
+
+	// Every request is an interval of its own. The first sets the baseline,
+	// the latency of the second degrades and the third applies that.
+	for _, latency := range []time.Duration{0, 20 * time.Millisecond, 0} {
+		if !b.Maybe(0, func() { time.Sleep(latency) }) {
+			t.Fatal("Maybe() = false, want: true")
+		}
+	}
+	if got, want := b.Capacity(), 9; got != want {
+		t.Errorf("Capacity() = %d, want: %d", got, want)
+	}
+
+	if err := b.UpdateConcurrency(2); err != nil {
+		t.Fatalf("UpdateConcurrency() = %v", err)
+	}
+	if got, want := b.Capacity(), 2; got != want {
+		t.Errorf("Capacity() = %d, want: %d", got, want)
+	}
+}
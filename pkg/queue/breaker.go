@@ -17,6 +17,7 @@ limitations under the License.
 package queue
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
@@ -35,6 +36,13 @@ type BreakerParams struct {
 	QueueDepth      int
 	MaxConcurrency  int
 	InitialCapacity int
+	// Adaptive makes the breaker adapt its capacity between
+	// Adaptive.MinConcurrency and MaxConcurrency to the observed latency.
+	// Nil keeps the capacity fixed.
+	Adaptive *AdaptiveParams
+	// QueuePolicy defines the order in which queued requests get
+	// capacity. Defaults to FIFOQueuePolicy.
+	QueuePolicy QueuePolicy
 }
 
 // Breaker is a component that enforces a concurrency limit on the
@@ -44,6 +52,13 @@ type BreakerParams struct {
 type Breaker struct {
 	pendingRequests chan struct{}
 	sem             *semaphore
+	// waitQueue orders the queued requests. Nil for FIFOQueuePolicy, which
+	// the semaphore already serves in order.
+	waitQueue *waitQueue
+
+	// limiterMux guards limiter and orders the capacity updates it makes.
+	limiterMux sync.Mutex
+	limiter    *adaptiveLimiter
 }
 
 // NewBreaker creates a Breaker with the desired queue depth,
@@ -58,10 +73,24 @@ func NewBreaker(params BreakerParams) *Breaker {
 	if params.InitialCapacity < 0 || params.InitialCapacity > params.MaxConcurrency {
 		panic(fmt.Sprintf("Initial capacity must be between 0 and max concurrency. Got %v.", params.InitialCapacity))
 	}
+	if err := params.QueuePolicy.Validate(); err != nil {
+		panic(err.Error())
+	}
+	var wq *waitQueue
+	if params.QueuePolicy != "" && params.QueuePolicy != FIFOQueuePolicy {
+		wq = newWaitQueue(params.QueuePolicy)
+	}
+	var limiter *adaptiveLimiter
+	if params.Adaptive != nil {
+		limiter = newAdaptiveLimiter(*params.Adaptive, params.MaxConcurrency, params.InitialCapacity)
+		params.InitialCapacity = limiter.limit
+	}
 	sem := newSemaphore(params.MaxConcurrency, params.InitialCapacity)
 	return &Breaker{
 		pendingRequests: make(chan struct{}, params.QueueDepth+params.MaxConcurrency),
 		sem:             sem,
+		waitQueue:       wq,
+		limiter:         limiter,
 	}
 }
 
@@ -72,6 +101,22 @@ func NewBreaker(params BreakerParams) *Breaker {
 // time before this function returns false without calling thunk. A 0
 // timeout value is infinite timeout.
 func (b *Breaker) Maybe(timeout time.Duration, thunk func()) bool {
+	ctx := context.Background()
+	if timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return b.MaybeContext(ctx, thunk)
+}
+
+// MaybeContext is like Maybe, but gives up waiting for capacity once ctx
+// is done, for example because the client went away. A request whose ctx
+// is done already is not even queued.
+func (b *Breaker) MaybeContext(ctx context.Context, thunk func()) bool {
+	if ctx.Err() != nil {
+		return false
+	}
 	select {
 	default:
 		// Pending request queue is full.  Report failure.
@@ -79,7 +124,8 @@ func (b *Breaker) Maybe(timeout time.Duration, thunk func()) bool {
 	case b.pendingRequests <- struct{}{}:
 		// Pending request has capacity.
 		// Wait for capacity in the active queue.
-		if !b.sem.acquire(timeout) {
+		if !b.acquire(ctx) {
+			<-b.pendingRequests
 			return false
 		}
 		// Defer releasing capacity in the active and pending request queue.
@@ -91,15 +137,54 @@ func (b *Breaker) Maybe(timeout time.Duration, thunk func()) bool {
 			<-b.pendingRequests
 		}()
 		// Do the thing.
-		thunk()
+		if b.limiter == nil {
+			thunk()
+		} else {
+			inFlight := b.sem.InFlight()
+			start := time.Now()
+			thunk()
+			b.adapt(start, inFlight)
+		}
 		// Report success
 		return true
 	}
 }
 
+// acquire waits for a token of the semaphore, in the order defined by the
+// queue policy.
+func (b *Breaker) acquire(ctx context.Context) bool {
+	if b.waitQueue == nil {
+		return b.sem.acquireContext(ctx)
+	}
+	return b.waitQueue.acquire(ctx, b.sem)
+}
+
+// adapt feeds the latency of a request started at start, with inFlight
+// requests executing, to the limiter and applies the limit it computes.
+func (b *Breaker) adapt(start time.Time, inFlight int) {
+	b.limiterMux.Lock()
+	defer b.limiterMux.Unlock()
+
+	now := time.Now()
+	if limit, changed := b.limiter.record(now, now.Sub(start), inFlight); changed {
+		// The limiter keeps the limit within the bounds of the semaphore.
+		b.sem.updateCapacity(limit)
+	}
+}
+
 // UpdateConcurrency updates the maximum number of in-flight requests.
+// An adaptive breaker continues adapting from the new value.
 func (b *Breaker) UpdateConcurrency(size int) error {
-	return b.sem.updateCapacity(size)
+	if b.limiter == nil {
+		return b.sem.updateCapacity(size)
+	}
+	b.limiterMux.Lock()
+	defer b.limiterMux.Unlock()
+	if err := b.sem.updateCapacity(size); err != nil {
+		return err
+	}
+	b.limiter.reset(size)
+	return nil
 }
 
 // Capacity returns the number of allowed in-flight requests on this breaker.
@@ -107,6 +192,21 @@ func (b *Breaker) Capacity() int {
 	return b.sem.Capacity()
 }
 
+// InFlight returns the number of requests currently executing on this breaker.
+func (b *Breaker) InFlight() int {
+	return b.sem.InFlight()
+}
+
+// Pending returns the number of requests queued on this breaker, waiting
+// for capacity.
+func (b *Breaker) Pending() int {
+	// The requests in flight hold a slot of pendingRequests as well.
+	if pending := len(b.pendingRequests) - b.InFlight(); pending > 0 {
+		return pending
+	}
+	return 0
+}
+
 // newSemaphore creates a semaphore with the desired maximal and initial capacity.
 // Maximal capacity is the size of the buffered channel, it defines maximum number of tokens
 // in the rotation. Attempting to add more capacity then the max will result in error.
@@ -150,6 +250,17 @@ func (s *semaphore) acquire(timeout time.Duration) bool {
 	}
 }
 
+// acquireContext receives the token from the semaphore, blocking until
+// ctx is done.
+func (s *semaphore) acquireContext(ctx context.Context) bool {
+	select {
+	case <-s.queue:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // release potentially puts the token back to the queue.
 // If the semaphore capacity was reduced in between and is not yet reflected,
 // we remove the tokens from the rotation instead of returning them back.
@@ -234,3 +345,12 @@ func (s *semaphore) Capacity() int {
 
 	return s.effectiveCapacity()
 }
+
+// InFlight is the number of tokens currently handed out.
+func (s *semaphore) InFlight() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	// The tokens in rotation are either in the queue or handed out.
+	return s.capacity - len(s.queue)
+}
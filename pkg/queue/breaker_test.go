@@ -61,6 +61,12 @@ func TestBreakerInvalidConstructor(t *testing.T) {
 	}, {
 		"InitialCapacity out-of-bounds",
 		BreakerParams{QueueDepth: 1, MaxConcurrency: 5, InitialCapacity: 6},
+	}, {
+		"Adaptive MinConcurrency out-of-bounds",
+		BreakerParams{QueueDepth: 1, MaxConcurrency: 5, InitialCapacity: 5, Adaptive: &AdaptiveParams{MinConcurrency: 6}},
+	}, {
+		"Adaptive Tolerance below 1",
+		BreakerParams{QueueDepth: 1, MaxConcurrency: 5, InitialCapacity: 5, Adaptive: &AdaptiveParams{Tolerance: 0.5}},
 	}}
 
 	for _, test := range tests {
@@ -220,6 +226,30 @@ func TestBreaker_UpdateConcurrency_Overlow(t *testing.T) {
 	}
 }
 
+func TestBreaker_InFlightAndPending(t *testing.T) {
+	params := BreakerParams{QueueDepth: 2, MaxConcurrency: 1, InitialCapacity: 1}
+	b := NewBreaker(params)
+	if got, want := b.InFlight(), 0; got != want {
+		t.Errorf("InFlight() = %d, want: %d", got, want)
+	}
+
+	locks := b.concurrentRequests(2, 0)
+	if got, want := b.InFlight(), 1; got != want {
+		t.Errorf("InFlight() = %d, want: %d", got, want)
+	}
+	if got, want := b.Pending(), 1; got != want {
+		t.Errorf("Pending() = %d, want: %d", got, want)
+	}
+
+	unlockAll(locks)
+	if got, want := b.InFlight(), 0; got != want {
+		t.Errorf("InFlight() = %d, want: %d", got, want)
+	}
+	if got, want := b.Pending(), 0; got != want {
+		t.Errorf("Pending() = %d, want: %d", got, want)
+	}
+}
+
 // Test empty semaphore, token cannot be acquired
 func TestSemaphore_acquire_HasNoCapacity(t *testing.T) {
 	gotChan := make(chan struct{}, 1)
@@ -373,6 +403,21 @@ func TestSemaphore_updateCapacity_DoNothing(t *testing.T) {
 	}
 }
 
+func TestSemaphore_InFlight(t *testing.T) {
+	sem := newSemaphore(2, 2)
+	sem.acquire(semAcquireTimeout)
+	sem.acquire(semAcquireTimeout)
+	// Reducing the capacity below the tokens handed out leaves them in flight.
+	sem.updateCapacity(0)
+	if got, want := sem.InFlight(), 2; got != want {
+		t.Errorf("InFlight() = %d, want: %d", got, want)
+	}
+	sem.release()
+	if got, want := sem.InFlight(), 1; got != want {
+		t.Errorf("InFlight() = %d, want: %d", got, want)
+	}
+}
+
 func TestSemaphore_WrongInitialCapacity(t *testing.T) {
 	defer func() {
 		if r := recover(); r == nil {
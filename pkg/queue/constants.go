@@ -26,4 +26,9 @@ const (
 	// Main usage is to delay the termination of user-container until all
 	// accepted requests have been processed.
 	RequestQueueDrainPath = "/wait-for-drain"
+
+	// RequestQueueReadyPath specifies the path reporting whether the
+	// queue-proxy is ready, without probing the user container nor
+	// recovering from an ejection, for its peers to query.
+	RequestQueueReadyPath = "/ready"
 )
@@ -0,0 +1,64 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// drainPollInterval is the interval at which Drain checks for the
+// requests in flight.
+const drainPollInterval = 100 * time.Millisecond
+
+// Drainer tracks the requests in flight through a handler, so that shutting
+// down can wait for them to complete instead of for a fixed time.
+type Drainer struct {
+	inFlight int64
+}
+
+// Handler wraps h to track the requests it serves.
+func (d *Drainer) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&d.inFlight, 1)
+		defer atomic.AddInt64(&d.inFlight, -1)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// InFlight returns the number of requests currently in flight.
+func (d *Drainer) InFlight() int {
+	return int(atomic.LoadInt64(&d.inFlight))
+}
+
+// Drain blocks for at least minDelay, during which requests may still be
+// routed to the pod while its removal from the endpoints propagates. It then
+// blocks until no requests are in flight, for up to timeout. It returns
+// whether all requests completed.
+func (d *Drainer) Drain(minDelay, timeout time.Duration) bool {
+	time.Sleep(minDelay)
+
+	deadline := time.Now().Add(timeout)
+	for d.InFlight() > 0 {
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(drainPollInterval)
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDrainer(t *testing.T) {
+	d := &Drainer{}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	h := d.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	// Without requests in flight, draining only waits for the minimum delay.
+	start := time.Now()
+	if !d.Drain(10*time.Millisecond, time.Second) {
+		t.Error("Drain() = false without requests, want: true")
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Errorf("Drain() took %v, want: about 10ms", elapsed)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+	<-started
+	if got, want := d.InFlight(), 1; got != want {
+		t.Errorf("InFlight() = %d, want: %d", got, want)
+	}
+
+	// The request outlives the timeout.
+	if d.Drain(0, 200*time.Millisecond) {
+		t.Error("Drain() = true with a request in flight, want: false")
+	}
+
+	// The request completes while draining.
+	time.AfterFunc(200*time.Millisecond, func() { close(release) })
+	if !d.Drain(0, 10*time.Second) {
+		t.Error("Drain() = false after the request completed, want: true")
+	}
+	<-done
+	if got, want := d.InFlight(), 0; got != want {
+		t.Errorf("InFlight() = %d, want: %d", got, want)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"io"
 	"net/http"
 	"sync"
+	"time"
 )
 
 // State holds state about the current healthiness of the component.
@@ -30,6 +31,9 @@ type State struct {
 
 	drainCh        chan struct{}
 	drainCompleted bool
+
+	ejected      bool
+	ejectedUntil time.Time
 }
 
 // IsAlive returns whether or not the health server is in a known
@@ -50,6 +54,44 @@ func (h *State) IsShuttingDown() bool {
 	return h.shuttingDown
 }
 
+// IsEjected returns whether or not the component has been ejected and
+// hasn't recovered yet.
+func (h *State) IsEjected() bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	return h.ejected
+}
+
+// Eject marks the component as not ready, e.g. because the container it
+// fronts misbehaves. It recovers once the prober passes again, after at
+// least the given duration.
+func (h *State) Eject(d time.Duration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.ejected = true
+	h.ejectedUntil = time.Now().Add(d)
+}
+
+// canReadmit returns whether the ejection lasted long enough to try to
+// readmit the component.
+func (h *State) canReadmit() bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	return time.Now().After(h.ejectedUntil)
+}
+
+// readmit updates the state to declare the component recovered from its
+// ejection.
+func (h *State) readmit() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.ejected = false
+}
+
 // setAlive updates the state to declare the service alive.
 func (h *State) setAlive() {
 	h.mutex.Lock()
@@ -82,8 +124,9 @@ func (h *State) drainFinished() {
 }
 
 // HealthHandler constructs a handler that returns the current state of
-// the health server.
-func (h *State) HealthHandler(prober func() bool) func(w http.ResponseWriter, r *http.Request) {
+// the health server. Once alive, the prober is only consulted again if
+// alwaysProbe is set.
+func (h *State) HealthHandler(prober func() bool, alwaysProbe bool) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		sendAlive := func() {
 			io.WriteString(w, "alive: true")
@@ -95,7 +138,17 @@ func (h *State) HealthHandler(prober func() bool) func(w http.ResponseWriter, r
 		}
 
 		switch {
-		case h.IsAlive():
+		case h.IsEjected():
+			// Ejected components are probed regardless of alwaysProbe, to
+			// recover as soon as allowed.
+			if h.canReadmit() && !h.IsShuttingDown() && (prober == nil || prober()) {
+				h.readmit()
+				h.setAlive()
+				sendAlive()
+			} else {
+				sendNotAlive()
+			}
+		case h.IsAlive() && !alwaysProbe:
 			sendAlive()
 		case h.IsShuttingDown():
 			sendNotAlive()
@@ -108,6 +161,20 @@ func (h *State) HealthHandler(prober func() bool) func(w http.ResponseWriter, r
 	}
 }
 
+// ReadyHandler constructs a handler that reports whether the component is
+// alive and not ejected. Unlike HealthHandler, it never probes nor changes
+// the state, so that other components may query it freely.
+func (h *State) ReadyHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.IsAlive() && !h.IsEjected() {
+			io.WriteString(w, "ready: true")
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, "ready: false")
+	}
+}
+
 // DrainHandler constructs a handler that waits until the proxy server is shut down.
 func (h *State) DrainHandler() func(_ http.ResponseWriter, _ *http.Request) {
 	h.mutex.Lock()
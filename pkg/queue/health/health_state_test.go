@@ -66,16 +66,30 @@ func TestHealthStateSetsState(t *testing.T) {
 
 func TestHealthStateHealthHandler(t *testing.T) {
 	tests := []struct {
-		name       string
-		state      *State
-		prober     func() bool
-		wantStatus int
-		wantBody   string
+		name        string
+		state       *State
+		prober      func() bool
+		alwaysProbe bool
+		wantStatus  int
+		wantBody    string
 	}{{
 		name:       "alive: true",
 		state:      &State{alive: true},
 		wantStatus: http.StatusOK,
 		wantBody:   aliveBody,
+	}, {
+		name:       "alive: true, prober: false",
+		state:      &State{alive: true},
+		prober:     func() bool { return false },
+		wantStatus: http.StatusOK,
+		wantBody:   aliveBody,
+	}, {
+		name:        "alive: true, prober: false, alwaysProbe",
+		state:       &State{alive: true},
+		prober:      func() bool { return false },
+		alwaysProbe: true,
+		wantStatus:  http.StatusBadRequest,
+		wantBody:    notAliveBody,
 	}, {
 		name:       "alive: false, prober: true",
 		state:      &State{alive: false},
@@ -98,6 +112,24 @@ func TestHealthStateHealthHandler(t *testing.T) {
 		state:      &State{shuttingDown: true},
 		wantStatus: http.StatusBadRequest,
 		wantBody:   notAliveBody,
+	}, {
+		name:       "ejected: true, within ejection time",
+		state:      &State{alive: true, ejected: true, ejectedUntil: time.Now().Add(time.Hour)},
+		prober:     func() bool { return true },
+		wantStatus: http.StatusBadRequest,
+		wantBody:   notAliveBody,
+	}, {
+		name:       "ejected: true, prober: false",
+		state:      &State{alive: true, ejected: true},
+		prober:     func() bool { return false },
+		wantStatus: http.StatusBadRequest,
+		wantBody:   notAliveBody,
+	}, {
+		name:       "ejected: true, prober: true",
+		state:      &State{alive: true, ejected: true},
+		prober:     func() bool { return true },
+		wantStatus: http.StatusOK,
+		wantBody:   aliveBody,
 	}}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -107,7 +139,7 @@ func TestHealthStateHealthHandler(t *testing.T) {
 			}
 
 			rr := httptest.NewRecorder()
-			handler := http.HandlerFunc(test.state.HealthHandler(test.prober))
+			handler := http.HandlerFunc(test.state.HealthHandler(test.prober, test.alwaysProbe))
 
 			handler.ServeHTTP(rr, req)
 
@@ -124,6 +156,37 @@ func TestHealthStateHealthHandler(t *testing.T) {
 	}
 }
 
+func TestHealthStateReadyHandler(t *testing.T) {
+	s := &State{}
+	handler := http.HandlerFunc(s.ReadyHandler())
+	ready := func() int {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rr.Code
+	}
+
+	if got, want := ready(), http.StatusServiceUnavailable; got != want {
+		t.Errorf("Status before alive = %d, want: %d", got, want)
+	}
+	if s.IsAlive() {
+		t.Error("State became alive from a readiness query")
+	}
+
+	s.setAlive()
+	if got, want := ready(), http.StatusOK; got != want {
+		t.Errorf("Status once alive = %d, want: %d", got, want)
+	}
+
+	s.Eject(0)
+	if got, want := ready(), http.StatusServiceUnavailable; got != want {
+		t.Errorf("Status once ejected = %d, want: %d", got, want)
+	}
+	// The ejection may be over, but only the health check readmits.
+	if !s.IsEjected() {
+		t.Error("State was readmitted from a readiness query")
+	}
+}
+
 func TestHealthStateDrainHandler(t *testing.T) {
 	state := &State{}
 	state.setAlive()
@@ -180,3 +243,30 @@ func TestHealthStateShutdown(t *testing.T) {
 		t.Errorf("wrong alive state: got %v want %v", state.alive, false)
 	}
 }
+
+func TestHealthStateEject(t *testing.T) {
+	s := &State{}
+	s.setAlive()
+	handler := http.HandlerFunc(s.HealthHandler(func() bool { return true }, false))
+	probe := func() int {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rr.Code
+	}
+
+	s.Eject(50 * time.Millisecond)
+	if !s.IsEjected() {
+		t.Fatal("State was not ejected but it should have been")
+	}
+	if got, want := probe(), http.StatusBadRequest; got != want {
+		t.Errorf("Status within the ejection time = %d, want: %d", got, want)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if got, want := probe(), http.StatusOK; got != want {
+		t.Errorf("Status after the ejection time = %d, want: %d", got, want)
+	}
+	if s.IsEjected() {
+		t.Error("State was ejected but it should have recovered")
+	}
+}
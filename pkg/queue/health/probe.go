@@ -17,10 +17,23 @@ limitations under the License.
 package health
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
+	"net/http"
 	"time"
+
+	"github.com/knative/serving/pkg/network/prober"
 )
 
+// maxProbeBodyBytes is how much of the body of a probe response is read, so
+// that the connection is closed cleanly.
+const maxProbeBodyBytes = 10 << 10
+
 // TCPProbe checks that a TCP socket to the address can be opened.
 // Did not reuse k8s.io/kubernetes/pkg/probe/tcp to not create a dependency
 // on klog.
@@ -32,3 +45,84 @@ func TCPProbe(addr string, socketTimeout time.Duration) error {
 	conn.Close()
 	return nil
 }
+
+// UnixProbe checks that a connection to the Unix domain socket at path can
+// be opened.
+func UnixProbe(path string, socketTimeout time.Duration) error {
+	conn, err := net.DialTimeout("unix", path, socketTimeout)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	return nil
+}
+
+// HTTPProbe checks that a GET request to the url succeeds with a status code
+// between 200 and 399, like the kubelet's HTTP probes do.  Like them, it
+// doesn't verify the certificate of an https url.
+func HTTPProbe(url string, header http.Header, timeout time.Duration) error {
+	return httpProbe(url, header, timeout, &http.Transport{
+		// Do not use the cached connection
+		DisableKeepAlives: true,
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+	})
+}
+
+// UnixHTTPProbe is like HTTPProbe, but sends the request over the Unix
+// domain socket at path, whatever the host of the url.
+func UnixHTTPProbe(path, url string, header http.Header, timeout time.Duration) error {
+	return httpProbe(url, header, timeout, &http.Transport{
+		// Do not use the cached connection
+		DisableKeepAlives: true,
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	})
+}
+
+// GRPCProbe checks that a call of the standard grpc.health.v1.Health/Check
+// method, sent to target through transport, reports the server as SERVING.
+// The transport must support h2c.
+func GRPCProbe(transport http.RoundTripper, target string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ok, err := prober.Do(ctx, transport, target,
+		prober.WithGRPCHealthCheck(""), prober.ExpectsGRPCServing())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("gRPC health check did not report SERVING")
+	}
+	return nil
+}
+
+func httpProbe(url string, header http.Header, timeout time.Duration, transport http.RoundTripper) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.CopyN(ioutil.Discard, res.Body, maxProbeBodyBytes)
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("HTTP probe returned status %d", res.StatusCode)
+	}
+	return nil
+}
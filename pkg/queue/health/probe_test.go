@@ -17,10 +17,18 @@ limitations under the License.
 package health
 
 import (
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/knative/serving/pkg/network"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 func TestTCPProbe(t *testing.T) {
@@ -41,3 +49,142 @@ func TestTCPProbe(t *testing.T) {
 		t.Error("Expected probe to fail but it didn't")
 	}
 }
+
+func TestHTTPProbe(t *testing.T) {
+	status := http.StatusOK
+	var gotHeader, gotHost string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Probe")
+		gotHost = r.Host
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	header := http.Header{"X-Probe": []string{"yes"}, "Host": []string{"example.com"}}
+	if err := HTTPProbe(server.URL, header, time.Second); err != nil {
+		t.Errorf("Expected probe to succeed but it failed with %v", err)
+	}
+	if gotHeader != "yes" || gotHost != "example.com" {
+		t.Errorf("Probe was sent with X-Probe = %q, Host = %q; want yes, example.com", gotHeader, gotHost)
+	}
+
+	status = http.StatusFound
+	if err := HTTPProbe(server.URL, nil, time.Second); err != nil {
+		t.Errorf("Expected probe to succeed on a redirect but it failed with %v", err)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := HTTPProbe(server.URL, nil, time.Second); err == nil {
+		t.Error("Expected probe to fail on a 503 but it didn't")
+	}
+
+	server.Close()
+	if err := HTTPProbe(server.URL, nil, time.Second); err == nil {
+		t.Error("Expected probe to fail but it didn't")
+	}
+
+	// The certificate of a TLS server is self-signed, which the kubelet's
+	// probes accept.
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer tlsServer.Close()
+	if err := HTTPProbe(tlsServer.URL, nil, time.Second); err != nil {
+		t.Errorf("Expected probe to succeed over https but it failed with %v", err)
+	}
+}
+
+// newUnixServer starts a server listening on a Unix domain socket, and
+// returns it along with the path of the socket.
+func newUnixServer(t *testing.T, h http.Handler) (*httptest.Server, string) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "health")
+	if err != nil {
+		t.Fatalf("TempDir() = %v", err)
+	}
+	path := filepath.Join(dir, "user.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Listen() = %v", err)
+	}
+	server := httptest.NewUnstartedServer(h)
+	server.Listener = l
+	server.Start()
+	return server, path
+}
+
+func TestUnixProbe(t *testing.T) {
+	server, path := newUnixServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer os.RemoveAll(filepath.Dir(path))
+	defer server.Close()
+
+	// Connecting to the socket should work
+	if err := UnixProbe(path, 1*time.Second); err != nil {
+		t.Errorf("Expected probe to succeed but it failed with %v", err)
+	}
+
+	// Close the server so probing fails afterwards
+	server.Close()
+	if err := UnixProbe(path, 1*time.Second); err == nil {
+		t.Error("Expected probe to fail but it didn't")
+	}
+}
+
+func TestUnixHTTPProbe(t *testing.T) {
+	status := http.StatusOK
+	var gotPath string
+	server, path := newUnixServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(status)
+	}))
+	defer os.RemoveAll(filepath.Dir(path))
+	defer server.Close()
+
+	// The host of the url is ignored, the request goes to the socket.
+	if err := UnixHTTPProbe(path, "http://127.0.0.1:8080/healthz", nil, time.Second); err != nil {
+		t.Errorf("Expected probe to succeed but it failed with %v", err)
+	}
+	if gotPath != "/healthz" {
+		t.Errorf("Probe was sent to %q, want /healthz", gotPath)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := UnixHTTPProbe(path, "http://127.0.0.1:8080/healthz", nil, time.Second); err == nil {
+		t.Error("Expected probe to fail on a 503 but it didn't")
+	}
+}
+
+func TestGRPCProbe(t *testing.T) {
+	// A framed HealthCheckResponse reporting SERVING.
+	response := []byte{0, 0, 0, 0, 2, 8, 1}
+	var gotPath string
+	server := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Write(response)
+		w.Header().Set("Grpc-Status", "0")
+	}), &http2.Server{}))
+	defer server.Close()
+
+	transport := network.NewAutoTransport()
+	if err := GRPCProbe(transport, server.URL, time.Second); err != nil {
+		t.Errorf("Expected probe to succeed but it failed with %v", err)
+	}
+	if gotPath != network.GRPCHealthCheckPath {
+		t.Errorf("Probe was sent to %q, want %s", gotPath, network.GRPCHealthCheckPath)
+	}
+
+	// NOT_SERVING.
+	response = []byte{0, 0, 0, 0, 2, 8, 2}
+	if err := GRPCProbe(transport, server.URL, time.Second); err == nil {
+		t.Error("Expected probe to fail on NOT_SERVING but it didn't")
+	}
+
+	server.Close()
+	if err := GRPCProbe(transport, server.URL, time.Second); err == nil {
+		t.Error("Expected probe to fail but it didn't")
+	}
+}
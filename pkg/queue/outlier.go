@@ -0,0 +1,99 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/knative/serving/pkg/network"
+)
+
+// OutlierEjectionTime is the minimum time a container detected as an
+// outlier stays ejected.
+const OutlierEjectionTime = 30 * time.Second
+
+// OutlierDetectionParams defines when OutlierDetector ejects a container.
+type OutlierDetectionParams struct {
+	// ConsecutiveFailures is the number of requests in a row which must
+	// fail for the container to be ejected. Zero disables outlier
+	// detection.
+	ConsecutiveFailures int
+	// LatencyThreshold, if positive, is the time to the response headers
+	// above which a request counts as failed.
+	LatencyThreshold time.Duration
+}
+
+// OutlierDetector passively detects a wedged container from the outcome of
+// the requests proxied to it. A request fails if it's answered with a 5xx
+// status, if it can't be proxied, if it times out or if it's slower than
+// the latency threshold.
+type OutlierDetector struct {
+	params   OutlierDetectionParams
+	onEject  func()
+	failures int32
+}
+
+// NewOutlierDetector creates an OutlierDetector calling onEject each time
+// the consecutive failures threshold of params is reached.
+func NewOutlierDetector(params OutlierDetectionParams, onEject func()) *OutlierDetector {
+	return &OutlierDetector{
+		params:  params,
+		onEject: onEject,
+	}
+}
+
+// ReportSuccess records a successful request, resetting the count of
+// consecutive failures.
+func (d *OutlierDetector) ReportSuccess() {
+	atomic.StoreInt32(&d.failures, 0)
+}
+
+// ReportFailure records a failed request, and ejects the container if it
+// reaches the consecutive failures threshold.
+func (d *OutlierDetector) ReportFailure() {
+	if d.params.ConsecutiveFailures <= 0 {
+		return
+	}
+	if atomic.AddInt32(&d.failures, 1) == int32(d.params.ConsecutiveFailures) {
+		atomic.StoreInt32(&d.failures, 0)
+		d.onEject()
+	}
+}
+
+// RoundTripper returns a RoundTripper reporting the outcome of the requests
+// sent with rt to the detector.
+func (d *OutlierDetector) RoundTripper(rt http.RoundTripper) http.RoundTripper {
+	return network.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := rt.RoundTrip(r)
+		switch {
+		case err != nil && r.Context().Err() == context.Canceled:
+			// The request was abandoned by the client, or timed out in
+			// which case the timeout is reported on its own.
+		case err != nil,
+			resp.StatusCode >= http.StatusInternalServerError,
+			d.params.LatencyThreshold > 0 && time.Since(start) > d.params.LatencyThreshold:
+			d.ReportFailure()
+		default:
+			d.ReportSuccess()
+		}
+		return resp, err
+	})
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/knative/serving/pkg/apis/networking"
+)
+
+const (
+	// DefaultMaxEjectionPercent is the share of the pods of a revision
+	// which may be unavailable for one of them to eject itself, if not
+	// configured otherwise.
+	DefaultMaxEjectionPercent = 10
+
+	// outlierEjectionJitter spreads the decisions of pods failing at the
+	// same time, so that they see each other's ejections.
+	outlierEjectionJitter = time.Second
+
+	// outlierProbeTimeout bounds the probe of each peer.
+	outlierProbeTimeout = time.Second
+)
+
+// OutlierEjectionGuard keeps a pod from ejecting itself when too many of
+// the pods of its revision are already unavailable, much like Envoy's
+// max_ejection_percent. The pods are listed through a headless Service
+// publishing not ready addresses, and each of them is asked whether it's
+// ready, which doesn't change its state. The decision is best effort: pods deciding at the same time
+// may still exceed the limit.
+type OutlierEjectionGuard struct {
+	host               string
+	self               string
+	maxEjectionPercent int
+	jitter             time.Duration
+
+	lookup func(ctx context.Context, host string) ([]string, error)
+	probe  func(ctx context.Context, addr string) bool
+}
+
+// NewOutlierEjectionGuard creates an OutlierEjectionGuard for the pod with
+// the IP self, whose peers are the addresses of host.
+func NewOutlierEjectionGuard(host, self string, maxEjectionPercent int) *OutlierEjectionGuard {
+	client := &http.Client{Timeout: outlierProbeTimeout}
+	return &OutlierEjectionGuard{
+		host:               host,
+		self:               self,
+		maxEjectionPercent: maxEjectionPercent,
+		jitter:             outlierEjectionJitter,
+		lookup:             net.DefaultResolver.LookupHost,
+		probe: func(ctx context.Context, addr string) bool {
+			return probeOutlierPeer(ctx, client, addr, networking.QueueAdminPort)
+		},
+	}
+}
+
+// AllowEject returns whether the pod may eject itself without exceeding
+// the maximum share of unavailable pods, or leaving no pod ready. A pod
+// is never ejected if its peers can't be listed.
+func (g *OutlierEjectionGuard) AllowEject(ctx context.Context) (bool, error) {
+	if g.jitter > 0 {
+		select {
+		case <-time.After(time.Duration(rand.Int63n(int64(g.jitter)))):
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+
+	addrs, err := g.lookup(ctx, g.host)
+	if err != nil {
+		return false, fmt.Errorf("failed to list the peers of the pod: %v", err)
+	}
+
+	// The pod itself is ready until it ejects itself, and may not be listed
+	// yet.
+	total, ready := 1, 1
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, addr := range addrs {
+		if addr == g.self {
+			continue
+		}
+		total++
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			if g.probe(ctx, addr) {
+				mu.Lock()
+				ready++
+				mu.Unlock()
+			}
+		}(addr)
+	}
+	wg.Wait()
+
+	return canEject(ready, total, g.maxEjectionPercent), nil
+}
+
+// canEject returns whether one of the ready pods out of total pods may be
+// ejected. At least one pod may be ejected regardless of maxPercent, as
+// long as another one remains ready.
+func canEject(ready, total, maxPercent int) bool {
+	if ready < 2 {
+		return false
+	}
+	maxUnavailable := total * maxPercent / 100
+	if maxUnavailable < 1 {
+		maxUnavailable = 1
+	}
+	return total-ready+1 <= maxUnavailable
+}
+
+// probeOutlierPeer returns whether the queue-proxy at addr reports its pod
+// as ready.
+func probeOutlierPeer(ctx context.Context, client *http.Client, addr string, port int) bool {
+	url := "http://" + net.JoinHostPort(addr, strconv.Itoa(port)) + RequestQueueReadyPath
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestCanEject(t *testing.T) {
+	tests := []struct {
+		name       string
+		ready      int
+		total      int
+		maxPercent int
+		want       bool
+	}{{
+		name:       "only ready pod",
+		ready:      1,
+		total:      1,
+		maxPercent: 100,
+	}, {
+		name:       "only ready pod out of many",
+		ready:      1,
+		total:      5,
+		maxPercent: 100,
+	}, {
+		name:       "one of two, at least one pod may be ejected",
+		ready:      2,
+		total:      2,
+		maxPercent: 10,
+		want:       true,
+	}, {
+		name:       "one of two with no ejection percent",
+		ready:      2,
+		total:      2,
+		maxPercent: 0,
+		want:       true,
+	}, {
+		name:       "one already unavailable out of ten",
+		ready:      9,
+		total:      10,
+		maxPercent: 10,
+	}, {
+		name:       "one already unavailable out of ten, up to 20%",
+		ready:      9,
+		total:      10,
+		maxPercent: 20,
+		want:       true,
+	}, {
+		name:       "two already unavailable out of ten, up to 20%",
+		ready:      8,
+		total:      10,
+		maxPercent: 20,
+	}, {
+		name:       "all but one unavailable, up to 100%",
+		ready:      2,
+		total:      10,
+		maxPercent: 100,
+		want:       true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := canEject(test.ready, test.total, test.maxPercent); got != test.want {
+				t.Errorf("canEject(%d, %d, %d) = %v, want: %v", test.ready, test.total, test.maxPercent, got, test.want)
+			}
+		})
+	}
+}
+
+func TestOutlierEjectionGuard(t *testing.T) {
+	tests := []struct {
+		name      string
+		addrs     []string
+		lookupErr error
+		ready     map[string]bool
+		want      bool
+		wantErr   bool
+	}{{
+		name:  "alone",
+		addrs: []string{"10.0.0.1"},
+	}, {
+		name: "not listed yet",
+	}, {
+		name:  "other pod not ready",
+		addrs: []string{"10.0.0.1", "10.0.0.2"},
+	}, {
+		name:  "other pod ready",
+		addrs: []string{"10.0.0.1", "10.0.0.2"},
+		ready: map[string]bool{"10.0.0.2": true},
+		want:  true,
+	}, {
+		name:  "other pod ready, not listed yet",
+		addrs: []string{"10.0.0.2"},
+		ready: map[string]bool{"10.0.0.2": true},
+		want:  true,
+	}, {
+		name:  "too many pods unavailable",
+		addrs: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"},
+		ready: map[string]bool{"10.0.0.2": true, "10.0.0.3": true},
+	}, {
+		name:      "lookup failure",
+		lookupErr: errors.New("no such host"),
+		wantErr:   true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := &OutlierEjectionGuard{
+				host:               "rev-peers.ns.svc.cluster.local",
+				self:               "10.0.0.1",
+				maxEjectionPercent: 10,
+				lookup: func(_ context.Context, host string) ([]string, error) {
+					if host != "rev-peers.ns.svc.cluster.local" {
+						t.Errorf("Looked up %q, want: rev-peers.ns.svc.cluster.local", host)
+					}
+					return test.addrs, test.lookupErr
+				},
+				probe: func(_ context.Context, addr string) bool {
+					if addr == "10.0.0.1" {
+						t.Error("The pod probed itself")
+					}
+					return test.ready[addr]
+				},
+			}
+			got, err := g.AllowEject(context.Background())
+			if (err != nil) != test.wantErr {
+				t.Fatalf("AllowEject() = %v, wantErr: %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("AllowEject() = %v, want: %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestProbeOutlierPeer(t *testing.T) {
+	ready := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != RequestQueueReadyPath {
+			t.Errorf("Path = %q, want: %q", r.URL.Path, RequestQueueReadyPath)
+		}
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse URL %q: %v", server.URL, err)
+	}
+	host, p, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		t.Fatalf("Failed to split host %q: %v", u.Host, err)
+	}
+	port, err := strconv.Atoi(p)
+	if err != nil {
+		t.Fatalf("Failed to parse port %q: %v", p, err)
+	}
+
+	if !probeOutlierPeer(context.Background(), server.Client(), host, port) {
+		t.Error("Ready peer was probed not ready")
+	}
+	ready = false
+	if probeOutlierPeer(context.Background(), server.Client(), host, port) {
+		t.Error("Not ready peer was probed ready")
+	}
+	server.Close()
+	if probeOutlierPeer(context.Background(), server.Client(), host, port) {
+		t.Error("Unreachable peer was probed ready")
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/knative/serving/pkg/network"
+)
+
+func TestOutlierDetectorConsecutiveFailures(t *testing.T) {
+	ejections := 0
+	d := NewOutlierDetector(OutlierDetectionParams{ConsecutiveFailures: 3}, func() { ejections++ })
+
+	d.ReportFailure()
+	d.ReportFailure()
+	d.ReportSuccess()
+	d.ReportFailure()
+	d.ReportFailure()
+	if ejections != 0 {
+		t.Fatalf("ejections = %d after non consecutive failures, want: 0", ejections)
+	}
+	d.ReportFailure()
+	if ejections != 1 {
+		t.Fatalf("ejections = %d after 3 consecutive failures, want: 1", ejections)
+	}
+	// The count starts over after an ejection.
+	d.ReportFailure()
+	d.ReportFailure()
+	if ejections != 1 {
+		t.Errorf("ejections = %d after 2 more failures, want: 1", ejections)
+	}
+}
+
+func TestOutlierDetectorDisabled(t *testing.T) {
+	d := NewOutlierDetector(OutlierDetectionParams{}, func() {
+		t.Error("Container was ejected with outlier detection disabled")
+	})
+	for i := 0; i < 10; i++ {
+		d.ReportFailure()
+	}
+}
+
+func TestOutlierDetectorRoundTripper(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		err         error
+		latency     time.Duration
+		cancelled   bool
+		wantFailure bool
+	}{{
+		name:   "success",
+		status: http.StatusOK,
+	}, {
+		name:   "client error",
+		status: http.StatusNotFound,
+	}, {
+		name:        "server error",
+		status:      http.StatusBadGateway,
+		wantFailure: true,
+	}, {
+		name:        "transport error",
+		err:         errors.New("connection refused"),
+		wantFailure: true,
+	}, {
+		name:      "cancelled request",
+		err:       context.Canceled,
+		cancelled: true,
+	}, {
+		name:        "slow response",
+		status:      http.StatusOK,
+		latency:     60 * time.Millisecond,
+		wantFailure: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ejected := false
+			d := NewOutlierDetector(OutlierDetectionParams{
+				ConsecutiveFailures: 1,
+				LatencyThreshold:    50 * time.Millisecond,
+			}, func() { ejected = true })
+			rt := d.RoundTripper(network.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				time.Sleep(test.latency)
+				if test.err != nil {
+					return nil, test.err
+				}
+				return &http.Response{StatusCode: test.status}, nil
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+			if test.cancelled {
+				ctx, cancel := context.WithCancel(req.Context())
+				cancel()
+				req = req.WithContext(ctx)
+			}
+			rt.RoundTrip(req)
+
+			if ejected != test.wantFailure {
+				t.Errorf("ejected = %v, want: %v", ejected, test.wantFailure)
+			}
+		})
+	}
+}
@@ -58,6 +58,12 @@ var (
 	averageProxiedConcurrentRequestsGV = newGV(
 		"queue_average_proxied_concurrent_requests",
 		"Number of proxied requests currently being handled by this pod")
+	concurrencyLimitGV = newGV(
+		"queue_concurrency_limit",
+		"The adaptive limit of requests handled by this pod concurrently")
+	rateLimitedRequestsPerSecondGV = newGV(
+		"queue_rate_limited_requests_per_second",
+		"Number of requests per second rejected by the rate limiter")
 )
 
 func newGV(n, h string) *prometheus.GaugeVec {
@@ -90,7 +96,7 @@ func NewPrometheusStatsReporter(namespace, config, revision, pod string) (*Prome
 	}
 
 	registry := prometheus.NewRegistry()
-	for _, gv := range []*prometheus.GaugeVec{operationsPerSecondGV, proxiedOperationsPerSecondGV, averageConcurrentRequestsGV, averageProxiedConcurrentRequestsGV} {
+	for _, gv := range []*prometheus.GaugeVec{operationsPerSecondGV, proxiedOperationsPerSecondGV, averageConcurrentRequestsGV, averageProxiedConcurrentRequestsGV, concurrencyLimitGV, rateLimitedRequestsPerSecondGV} {
 		if err := registry.Register(gv); err != nil {
 			return nil, fmt.Errorf("register metric failed: %v", err)
 		}
@@ -118,6 +124,8 @@ func (r *PrometheusStatsReporter) Report(stat *autoscaler.Stat) error {
 	proxiedOperationsPerSecondGV.With(r.labels).Set(stat.ProxiedRequestCount)
 	averageConcurrentRequestsGV.With(r.labels).Set(stat.AverageConcurrentRequests)
 	averageProxiedConcurrentRequestsGV.With(r.labels).Set(stat.AverageProxiedConcurrentRequests)
+	concurrencyLimitGV.With(r.labels).Set(stat.ConcurrencyLimit)
+	rateLimitedRequestsPerSecondGV.With(r.labels).Set(stat.RateLimitedRequestCount)
 
 	return nil
 }
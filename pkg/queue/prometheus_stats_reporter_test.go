@@ -98,6 +98,28 @@ func TestReporter_Report(t *testing.T) {
 	testReportWithProxiedRequests(t, &autoscaler.Stat{RequestCount: 39, AverageConcurrentRequests: 3, ProxiedRequestCount: 15, AverageProxiedConcurrentRequests: 2}, 39, 3, 15, 2)
 }
 
+func TestReporter_ReportConcurrencyLimit(t *testing.T) {
+	reporter, err := NewPrometheusStatsReporter(namespace, config, revision, pod)
+	if err != nil {
+		t.Fatalf("Something went wrong with creating a reporter, '%v'.", err)
+	}
+	if err := reporter.Report(&autoscaler.Stat{ConcurrencyLimit: 7}); err != nil {
+		t.Error(err)
+	}
+	checkData(t, concurrencyLimitGV, 7)
+}
+
+func TestReporter_ReportRateLimited(t *testing.T) {
+	reporter, err := NewPrometheusStatsReporter(namespace, config, revision, pod)
+	if err != nil {
+		t.Fatalf("Something went wrong with creating a reporter, '%v'.", err)
+	}
+	if err := reporter.Report(&autoscaler.Stat{RateLimitedRequestCount: 12}); err != nil {
+		t.Error(err)
+	}
+	checkData(t, rateLimitedRequestsPerSecondGV, 12)
+}
+
 func testReportWithProxiedRequests(t *testing.T, stat *autoscaler.Stat, reqCount, concurrency, proxiedCount, proxiedConcurrency float64) {
 	t.Helper()
 	reporter, err := NewPrometheusStatsReporter(namespace, config, revision, pod)
@@ -0,0 +1,170 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QueuePolicy defines the order in which the requests queued on a Breaker
+// get capacity, and whether they get dropped from the queue under overload.
+type QueuePolicy string
+
+const (
+	// FIFOQueuePolicy serves the queued requests in order of arrival.
+	FIFOQueuePolicy QueuePolicy = "fifo"
+	// LIFOQueuePolicy serves the queued requests in order of arrival,
+	// unless the queue is overloaded. Then the newest requests, whose
+	// clients are most likely still waiting, are served first.
+	LIFOQueuePolicy QueuePolicy = "lifo"
+	// CoDelQueuePolicy orders the queued requests like LIFOQueuePolicy.
+	// Additionally, requests which have been queued for QueueOverloadInterval
+	// are dropped, or for CoDelTarget only if the queue is overloaded.
+	CoDelQueuePolicy QueuePolicy = "codel"
+)
+
+const (
+	// QueueOverloadInterval is the time after which a queue that did not
+	// run empty is considered overloaded.
+	QueueOverloadInterval = 100 * time.Millisecond
+	// CoDelTarget is the time requests may be queued for by the
+	// CoDelQueuePolicy while the queue is overloaded.
+	CoDelTarget = 5 * time.Millisecond
+)
+
+// Validate returns an error if the policy is unknown. The zero value is
+// valid and means FIFOQueuePolicy.
+func (p QueuePolicy) Validate() error {
+	switch p {
+	case "", FIFOQueuePolicy, LIFOQueuePolicy, CoDelQueuePolicy:
+		return nil
+	}
+	return fmt.Errorf("unknown queue policy %q", p)
+}
+
+// waitQueue orders the requests waiting for a token of a semaphore. Only
+// the request holding the turn waits on the semaphore itself, the others
+// wait in line for the turn to be passed on to them.
+type waitQueue struct {
+	policy QueuePolicy
+
+	mux sync.Mutex
+	// busy is true while a request holds the turn.
+	busy bool
+	// waiters holds the *waiter in line for the turn, the next one first.
+	waiters *list.List
+	// lastEmpty is the last time no request was waiting.
+	lastEmpty time.Time
+}
+
+type waiter struct {
+	turn chan struct{}
+	// served is set once the turn has been passed to the waiter.
+	served bool
+}
+
+func newWaitQueue(policy QueuePolicy) *waitQueue {
+	return &waitQueue{
+		policy:    policy,
+		waiters:   list.New(),
+		lastEmpty: time.Now(),
+	}
+}
+
+// acquire waits for its turn and acquires a token of sem. It gives up
+// once ctx is done, or the policy drops the request.
+func (q *waitQueue) acquire(ctx context.Context, sem *semaphore) bool {
+	q.mux.Lock()
+	now := time.Now()
+	if !q.busy {
+		// Nobody is waiting, take the turn right away.
+		q.busy = true
+		q.lastEmpty = now
+		q.mux.Unlock()
+		ctx, cancel := q.withPolicyTimeout(ctx, false)
+		defer cancel()
+		return q.acquireTurn(ctx, sem)
+	}
+
+	overloaded := now.Sub(q.lastEmpty) > QueueOverloadInterval
+	w := &waiter{turn: make(chan struct{})}
+	var e *list.Element
+	if overloaded && q.policy != FIFOQueuePolicy {
+		e = q.waiters.PushFront(w)
+	} else {
+		e = q.waiters.PushBack(w)
+	}
+	q.mux.Unlock()
+
+	ctx, cancel := q.withPolicyTimeout(ctx, overloaded)
+	defer cancel()
+	select {
+	case <-w.turn:
+		return q.acquireTurn(ctx, sem)
+	case <-ctx.Done():
+		q.mux.Lock()
+		if !w.served {
+			q.waiters.Remove(e)
+			q.mux.Unlock()
+			return false
+		}
+		q.mux.Unlock()
+		// The turn was passed to us in the meantime, pass it on.
+		q.next()
+		return false
+	}
+}
+
+// acquireTurn acquires a token of sem while holding the turn, then passes
+// the turn on.
+func (q *waitQueue) acquireTurn(ctx context.Context, sem *semaphore) bool {
+	defer q.next()
+	return sem.acquireContext(ctx)
+}
+
+// next passes the turn to the next waiter, if any.
+func (q *waitQueue) next() {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	e := q.waiters.Front()
+	if e == nil {
+		q.busy = false
+		q.lastEmpty = time.Now()
+		return
+	}
+	w := q.waiters.Remove(e).(*waiter)
+	w.served = true
+	close(w.turn)
+}
+
+// withPolicyTimeout bounds the time a request may be queued for by the
+// CoDelQueuePolicy.
+func (q *waitQueue) withPolicyTimeout(ctx context.Context, overloaded bool) (context.Context, context.CancelFunc) {
+	if q.policy != CoDelQueuePolicy {
+		return ctx, func() {}
+	}
+	timeout := QueueOverloadInterval
+	if overloaded {
+		timeout = CoDelTarget
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestQueuePolicyValidate(t *testing.T) {
+	for _, p := range []QueuePolicy{"", FIFOQueuePolicy, LIFOQueuePolicy, CoDelQueuePolicy} {
+		if err := p.Validate(); err != nil {
+			t.Errorf("Validate(%q) = %v", p, err)
+		}
+	}
+	if err := QueuePolicy("random").Validate(); err == nil {
+		t.Error("Validate(random) = nil, want an error")
+	}
+}
+
+func TestBreakerQueuePolicyOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy QueuePolicy
+		want   []string
+	}{{
+		name:   "fifo",
+		policy: FIFOQueuePolicy,
+		want:   []string{"a", "b", "c"},
+	}, {
+		name:   "lifo",
+		policy: LIFOQueuePolicy,
+		// a waits on the semaphore already when b and c arrive on the
+		// overloaded queue.
+		want: []string{"a", "c", "b"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := NewBreaker(BreakerParams{
+				QueueDepth:      10,
+				MaxConcurrency:  1,
+				InitialCapacity: 1,
+				QueuePolicy:     test.policy,
+			})
+
+			blocker := make(chan struct{})
+			go b.Maybe(0, func() { <-blocker })
+			waitForPending(t, b, 0, 1)
+
+			var (
+				mux  sync.Mutex
+				got  []string
+				done sync.WaitGroup
+			)
+			request := func(name string, pending int) {
+				done.Add(1)
+				go func() {
+					defer done.Done()
+					b.Maybe(0, func() {
+						mux.Lock()
+						defer mux.Unlock()
+						got = append(got, name)
+					})
+				}()
+				waitForPending(t, b, pending, 1)
+			}
+
+			request("a", 1)
+			// Let the queue become overloaded.
+			time.Sleep(QueueOverloadInterval + 50*time.Millisecond)
+			request("b", 2)
+			request("c", 3)
+
+			close(blocker)
+			done.Wait()
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("Order (-want, +got) = %s", cmp.Diff(test.want, got))
+			}
+		})
+	}
+}
+
+func TestBreakerMaybeContext(t *testing.T) {
+	b := NewBreaker(BreakerParams{QueueDepth: 1, MaxConcurrency: 1, InitialCapacity: 0})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if b.MaybeContext(ctx, func() { t.Error("Unexpected call of thunk") }) {
+		t.Error("MaybeContext() = true for a cancelled context")
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	result := make(chan bool)
+	go func() {
+		result <- b.MaybeContext(ctx, func() { t.Error("Unexpected call of thunk") })
+	}()
+	waitForPending(t, b, 1, 0)
+	cancel()
+	if <-result {
+		t.Error("MaybeContext() = true for a context cancelled while queued")
+	}
+	if got := b.Pending(); got != 0 {
+		t.Errorf("Pending() = %d, want: 0", got)
+	}
+}
+
+func TestCoDelQueuePolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		overloaded bool
+		wantMin    time.Duration
+		wantMax    time.Duration
+	}{{
+		name:    "not overloaded",
+		wantMin: QueueOverloadInterval,
+		wantMax: 10 * QueueOverloadInterval,
+	}, {
+		name:       "overloaded",
+		overloaded: true,
+		wantMin:    CoDelTarget,
+		wantMax:    QueueOverloadInterval,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := newWaitQueue(CoDelQueuePolicy)
+			sem := newSemaphore(1, 0)
+
+			q.mux.Lock()
+			// Somebody holds the turn.
+			q.busy = true
+			if test.overloaded {
+				q.lastEmpty = time.Now().Add(-2 * QueueOverloadInterval)
+			}
+			q.mux.Unlock()
+
+			start := time.Now()
+			if q.acquire(context.Background(), sem) {
+				t.Fatal("acquire() = true, want false")
+			}
+			if got := time.Since(start); got < test.wantMin || got > test.wantMax {
+				t.Errorf("Dropped after %v, want between %v and %v", got, test.wantMin, test.wantMax)
+			}
+
+			q.mux.Lock()
+			defer q.mux.Unlock()
+			if got := q.waiters.Len(); got != 0 {
+				t.Errorf("Waiters = %d, want: 0", got)
+			}
+		})
+	}
+}
+
+// waitForPending waits until the breaker has the given number of pending
+// and in-flight requests.
+func waitForPending(t *testing.T, b *Breaker, pending, inFlight int) {
+	t.Helper()
+	if err := wait.PollImmediate(time.Millisecond, semAcquireTimeout, func() (bool, error) {
+		return b.Pending() == pending && b.InFlight() == inFlight, nil
+	}); err != nil {
+		t.Fatalf("Timed out waiting for %d pending and %d in flight requests, got %d and %d",
+			pending, inFlight, b.Pending(), b.InFlight())
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readiness
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/knative/serving/pkg/queue/health"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// pollInterval is the interval the container is probed at until it
+	// becomes ready, well below the granularity of the kubelet.
+	pollInterval = 50 * time.Millisecond
+
+	// The defaults of the kubelet for the fields left unset.
+	defaultTimeout          = time.Second
+	defaultSuccessThreshold = 1
+	defaultFailureThreshold = 3
+
+	localhost = "127.0.0.1"
+)
+
+// Probe runs the readiness probe of the user container from within the
+// queue-proxy. It's safe for concurrent use.
+type Probe struct {
+	*corev1.Probe
+
+	// pollTimeout is how long a single call of ProbeContainer keeps
+	// probing a container which isn't ready yet.
+	pollTimeout time.Duration
+	started     time.Time
+	logger      *zap.SugaredLogger
+	// socketPath is the Unix domain socket the container listens on, if it
+	// doesn't listen on TCP.
+	socketPath string
+	// grpcTransport and grpcTarget are set when the container is probed with
+	// the gRPC health checking protocol instead of the action of the probe.
+	grpcTransport http.RoundTripper
+	grpcTarget    string
+
+	mux       sync.Mutex
+	ready     bool
+	successes int
+	failures  int
+}
+
+// NewProbe creates a Probe running p, which must be an HTTP or a TCP probe.
+func NewProbe(p *corev1.Probe, pollTimeout time.Duration, logger *zap.SugaredLogger) *Probe {
+	return &Probe{
+		Probe:       p,
+		pollTimeout: pollTimeout,
+		started:     time.Now(),
+		logger:      logger,
+	}
+}
+
+// NewUnixSocketProbe creates a Probe running p against a container listening
+// on the Unix domain socket at socketPath. The host and port of p are ignored.
+func NewUnixSocketProbe(p *corev1.Probe, socketPath string, pollTimeout time.Duration, logger *zap.SugaredLogger) *Probe {
+	probe := NewProbe(p, pollTimeout, logger)
+	probe.socketPath = socketPath
+	return probe
+}
+
+// NewGRPCProbe creates a Probe calling the standard grpc.health.v1.Health/Check
+// method of the container at target through transport, which must support
+// h2c. Only the delays, thresholds and timeout of p are used, p may be nil.
+func NewGRPCProbe(p *corev1.Probe, transport http.RoundTripper, target string, pollTimeout time.Duration, logger *zap.SugaredLogger) *Probe {
+	if p == nil {
+		p = &corev1.Probe{}
+	}
+	probe := NewProbe(p, pollTimeout, logger)
+	probe.grpcTransport = transport
+	probe.grpcTarget = target
+	return probe
+}
+
+// EncodeProbe serializes the probe to pass it to the queue-proxy.
+func EncodeProbe(p *corev1.Probe) (string, error) {
+	if p == nil {
+		return "", errors.New("cannot encode nil probe")
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// DecodeProbe deserializes a probe encoded by EncodeProbe.
+func DecodeProbe(s string) (*corev1.Probe, error) {
+	p := &corev1.Probe{}
+	if err := json.Unmarshal([]byte(s), p); err != nil {
+		return nil, err
+	}
+	if p.HTTPGet == nil && p.TCPSocket == nil {
+		return nil, errors.New("probe must be an HTTP or a TCP probe")
+	}
+	return p, nil
+}
+
+// ProbeContainer returns whether the container is ready. Until it became
+// ready first, the container is probed repeatedly for up to the poll timeout
+// and needs to pass SuccessThreshold probes in a row. Once ready, it is probed
+// once per call and is considered unready after FailureThreshold failed
+// probes in a row.
+func (p *Probe) ProbeContainer() bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.ready {
+		if err := p.probe(); err != nil {
+			p.failures++
+			p.logger.Warnw("User-container failed its readiness probe.", zap.Error(err))
+			if p.failures >= threshold(p.FailureThreshold, defaultFailureThreshold) {
+				p.ready = false
+				p.successes = 0
+			}
+		} else {
+			p.failures = 0
+		}
+		return p.ready
+	}
+
+	var err error
+	wait.PollImmediate(pollInterval, p.pollTimeout, func() (bool, error) {
+		if time.Since(p.started) < time.Duration(p.InitialDelaySeconds)*time.Second {
+			return false, nil
+		}
+		if err = p.probe(); err != nil {
+			p.successes = 0
+			return false, nil
+		}
+		p.successes++
+		return p.successes >= threshold(p.SuccessThreshold, defaultSuccessThreshold), nil
+	})
+
+	if p.successes >= threshold(p.SuccessThreshold, defaultSuccessThreshold) {
+		p.logger.Info("User-container successfully probed.")
+		p.ready = true
+		p.failures = 0
+	} else {
+		p.logger.Errorw("User-container could not be probed successfully.", zap.Error(err))
+	}
+	return p.ready
+}
+
+// probe runs the probe once.
+func (p *Probe) probe() error {
+	timeout := defaultTimeout
+	if p.TimeoutSeconds > 0 {
+		timeout = time.Duration(p.TimeoutSeconds) * time.Second
+	}
+
+	switch {
+	case p.grpcTarget != "":
+		return health.GRPCProbe(p.grpcTransport, p.grpcTarget, timeout)
+	case p.HTTPGet != nil && p.socketPath != "":
+		return health.UnixHTTPProbe(p.socketPath, httpURL(p.HTTPGet), httpHeader(p.HTTPGet.HTTPHeaders), timeout)
+	case p.HTTPGet != nil:
+		return health.HTTPProbe(httpURL(p.HTTPGet), httpHeader(p.HTTPGet.HTTPHeaders), timeout)
+	case p.TCPSocket != nil && p.socketPath != "":
+		return health.UnixProbe(p.socketPath, timeout)
+	case p.TCPSocket != nil:
+		return health.TCPProbe(net.JoinHostPort(host(p.TCPSocket.Host), p.TCPSocket.Port.String()), timeout)
+	default:
+		return fmt.Errorf("unsupported probe: %v", p.Probe)
+	}
+}
+
+func httpURL(action *corev1.HTTPGetAction) string {
+	scheme := strings.ToLower(string(action.Scheme))
+	if scheme == "" {
+		scheme = "http"
+	}
+	path := action.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(host(action.Host), strconv.Itoa(action.Port.IntValue())), path)
+}
+
+func httpHeader(headers []corev1.HTTPHeader) http.Header {
+	h := make(http.Header, len(headers))
+	for _, header := range headers {
+		h.Add(header.Name, header.Value)
+	}
+	return h
+}
+
+func host(h string) string {
+	if h == "" {
+		return localhost
+	}
+	return h
+}
+
+func threshold(v int32, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return int(v)
+}
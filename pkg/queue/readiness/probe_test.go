@@ -0,0 +1,271 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readiness
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/serving/pkg/network"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	. "knative.dev/pkg/logging/testing"
+)
+
+func TestEncodeDecodeProbe(t *testing.T) {
+	want := &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: "/healthz",
+				Port: intstr.FromInt(8080),
+				HTTPHeaders: []corev1.HTTPHeader{{
+					Name:  "X-Probe",
+					Value: "yes",
+				}},
+			},
+		},
+		SuccessThreshold: 2,
+	}
+	encoded, err := EncodeProbe(want)
+	if err != nil {
+		t.Fatalf("EncodeProbe() = %v", err)
+	}
+	got, err := DecodeProbe(encoded)
+	if err != nil {
+		t.Fatalf("DecodeProbe() = %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Probe (-want, +got): %s", diff)
+	}
+
+	if _, err := EncodeProbe(nil); err == nil {
+		t.Error("EncodeProbe(nil) = nil, wanted an error")
+	}
+	for _, s := range []string{"{", `{"exec":{"command":["true"]}}`} {
+		if _, err := DecodeProbe(s); err == nil {
+			t.Errorf("DecodeProbe(%q) = nil, wanted an error", s)
+		}
+	}
+}
+
+// probeServer serves the given status codes one after the other, and the
+// last one from then on.
+func probeServer(t *testing.T, codes ...int) (*httptest.Server, *corev1.Probe) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ready" || r.Header.Get("X-Probe") != "yes" {
+			t.Errorf("Probe sent to %s with X-Probe = %q", r.URL.Path, r.Header.Get("X-Probe"))
+		}
+		i := int(atomic.AddInt32(&requests, 1)) - 1
+		if i >= len(codes) {
+			i = len(codes) - 1
+		}
+		w.WriteHeader(codes[i])
+	}))
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", server.URL, err)
+	}
+	port, _ := strconv.Atoi(u.Port())
+	return server, &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: "ready",
+				Port: intstr.FromInt(port),
+				HTTPHeaders: []corev1.HTTPHeader{{
+					Name:  "X-Probe",
+					Value: "yes",
+				}},
+			},
+		},
+	}
+}
+
+func TestProbeContainerHTTP(t *testing.T) {
+	server, p := probeServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK)
+	defer server.Close()
+
+	probe := NewProbe(p, time.Second, TestLogger(t))
+	start := time.Now()
+	if !probe.ProbeContainer() {
+		t.Fatal("ProbeContainer() = false, want: true")
+	}
+	// The container became ready after the second retry, well within a second.
+	if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+		t.Errorf("ProbeContainer() took %v, want: < 500ms", elapsed)
+	}
+}
+
+func TestProbeContainerSuccessThreshold(t *testing.T) {
+	server, p := probeServer(t, http.StatusOK, http.StatusServiceUnavailable, http.StatusOK)
+	defer server.Close()
+	p.SuccessThreshold = 2
+
+	probe := NewProbe(p, time.Second, TestLogger(t))
+	if !probe.ProbeContainer() {
+		t.Fatal("ProbeContainer() = false, want: true")
+	}
+	if got, want := probe.successes, 2; got != want {
+		t.Errorf("successes = %d, want: %d", got, want)
+	}
+}
+
+func TestProbeContainerFailureThreshold(t *testing.T) {
+	server, p := probeServer(t, http.StatusOK, http.StatusServiceUnavailable)
+	defer server.Close()
+	p.FailureThreshold = 2
+
+	probe := NewProbe(p, 100*time.Millisecond, TestLogger(t))
+	if !probe.ProbeContainer() {
+		t.Fatal("ProbeContainer() = false, want: true")
+	}
+	// A single failure doesn't make the container unready.
+	if !probe.ProbeContainer() {
+		t.Error("ProbeContainer() = false after one failure, want: true")
+	}
+	if probe.ProbeContainer() {
+		t.Error("ProbeContainer() = true after two failures, want: false")
+	}
+}
+
+func TestProbeContainerInitialDelay(t *testing.T) {
+	server, p := probeServer(t, http.StatusOK)
+	defer server.Close()
+	p.InitialDelaySeconds = 10
+
+	probe := NewProbe(p, 100*time.Millisecond, TestLogger(t))
+	if probe.ProbeContainer() {
+		t.Error("ProbeContainer() = true within the initial delay, want: false")
+	}
+}
+
+func TestProbeContainerTCP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+	p := &corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.FromInt(port),
+			},
+		},
+	}
+
+	probe := NewProbe(p, 100*time.Millisecond, TestLogger(t))
+	if !probe.ProbeContainer() {
+		t.Error("ProbeContainer() = false, want: true")
+	}
+
+	server.Close()
+	probe = NewProbe(p, 100*time.Millisecond, TestLogger(t))
+	if probe.ProbeContainer() {
+		t.Error("ProbeContainer() = true for a closed port, want: false")
+	}
+}
+
+func TestProbeContainerUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "readiness")
+	if err != nil {
+		t.Fatalf("TempDir() = %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "user.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ready" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	server.Listener = l
+	server.Start()
+
+	// The ports point nowhere, the probes must go to the socket.
+	probes := map[string]*corev1.Probe{
+		"http": {
+			Handler: corev1.Handler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Path: "/ready",
+					Port: intstr.FromInt(1),
+				},
+			},
+		},
+		"tcp": {
+			Handler: corev1.Handler{
+				TCPSocket: &corev1.TCPSocketAction{
+					Port: intstr.FromInt(1),
+				},
+			},
+		},
+	}
+	for name, p := range probes {
+		probe := NewUnixSocketProbe(p, path, 100*time.Millisecond, TestLogger(t))
+		if !probe.ProbeContainer() {
+			t.Errorf("%s: ProbeContainer() = false, want: true", name)
+		}
+	}
+
+	server.Close()
+	for name, p := range probes {
+		probe := NewUnixSocketProbe(p, path, 100*time.Millisecond, TestLogger(t))
+		if probe.ProbeContainer() {
+			t.Errorf("%s: ProbeContainer() = true for a closed socket, want: false", name)
+		}
+	}
+}
+
+func TestProbeContainerGRPC(t *testing.T) {
+	var serving int32 = 1
+	server := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "Grpc-Status")
+		// A framed HealthCheckResponse, either SERVING or NOT_SERVING.
+		if atomic.LoadInt32(&serving) == 1 {
+			w.Write([]byte{0, 0, 0, 0, 2, 8, 1})
+		} else {
+			w.Write([]byte{0, 0, 0, 0, 2, 8, 2})
+		}
+		w.Header().Set("Grpc-Status", "0")
+	}), &http2.Server{}))
+	defer server.Close()
+
+	// The probe of the container may be left unset.
+	probe := NewGRPCProbe(nil, network.NewAutoTransport(), server.URL, 100*time.Millisecond, TestLogger(t))
+	if !probe.ProbeContainer() {
+		t.Fatal("ProbeContainer() = false, want: true")
+	}
+
+	atomic.StoreInt32(&serving, 0)
+	probe = NewGRPCProbe(&corev1.Probe{TimeoutSeconds: 1}, network.NewAutoTransport(), server.URL, 100*time.Millisecond, TestLogger(t))
+	if probe.ProbeContainer() {
+		t.Error("ProbeContainer() = true for a NOT_SERVING container, want: false")
+	}
+}
@@ -40,6 +40,9 @@ const (
 	ProxiedIn
 	// ProxiedOut represents a finished proxied request.
 	ProxiedOut
+	// RateLimited represents a request rejected by the rate limiter. It
+	// is counted apart from the requests above.
+	RateLimited
 )
 
 // Channels is a structure for holding the channels for driving Stats.
@@ -70,6 +73,7 @@ func NewStats(podName string, channels Channels, startedAt time.Time) *Stats {
 		var (
 			requestCount       float64
 			proxiedCount       float64
+			rateLimitedCount   float64
 			concurrency        int32
 			proxiedConcurrency int32
 		)
@@ -98,6 +102,8 @@ func NewStats(podName string, channels Channels, startedAt time.Time) *Stats {
 				updateState(event.Time)
 
 				switch event.EventType {
+				case RateLimited:
+					rateLimitedCount++
 				case ProxiedIn:
 					proxiedConcurrency++
 					proxiedCount++
@@ -121,6 +127,7 @@ func NewStats(podName string, channels Channels, startedAt time.Time) *Stats {
 					AverageProxiedConcurrentRequests: weightedAverage(timeOnProxiedConcurrency),
 					RequestCount:                     requestCount,
 					ProxiedRequestCount:              proxiedCount,
+					RateLimitedRequestCount:          rateLimitedCount,
 				}
 				// Send the stat to another goroutine to transmit
 				// so we can continue bucketing stats.
@@ -131,6 +138,7 @@ func NewStats(podName string, channels Channels, startedAt time.Time) *Stats {
 				timeOnProxiedConcurrency = make(map[int32]time.Duration)
 				requestCount = 0
 				proxiedCount = 0
+				rateLimitedCount = 0
 			}
 		}
 	}()
@@ -0,0 +1,88 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"knative.dev/pkg/metrics"
+)
+
+// QueueTimeReporter reports the time requests spend queued in queue-proxy,
+// waiting for the user container to have capacity.
+type QueueTimeReporter struct {
+	ctx                  context.Context
+	responseCodeKey      tag.Key
+	responseCodeClassKey tag.Key
+	latencyMetric        *stats.Float64Measure
+}
+
+// NewQueueTimeReporter creates a reporter that collects and reports the
+// time requests spend queued in queue-proxy.
+func NewQueueTimeReporter(ns, service, config, rev string, latencyMetric *stats.Float64Measure) (*QueueTimeReporter, error) {
+	ctx, revisionTags, err := newRevisionContext(ns, service, config, rev)
+	if err != nil {
+		return nil, err
+	}
+	responseCodeTag, err := tag.NewKey("response_code")
+	if err != nil {
+		return nil, err
+	}
+	responseCodeClassTag, err := tag.NewKey("response_code_class")
+	if err != nil {
+		return nil, err
+	}
+
+	// Create view to see our measurements.
+	err = view.Register(&view.View{
+		Description: "The time requests spend queued in millisecond",
+		Measure:     latencyMetric,
+		Aggregation: defaultLatencyDistribution,
+		TagKeys:     append(revisionTags, responseCodeTag, responseCodeClassTag),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueueTimeReporter{
+		ctx:                  ctx,
+		responseCodeKey:      responseCodeTag,
+		responseCodeClassKey: responseCodeClassTag,
+		latencyMetric:        latencyMetric,
+	}, nil
+}
+
+// ReportQueueTime captures the time a request, answered with responseCode,
+// spent queued.
+func (r *QueueTimeReporter) ReportQueueTime(responseCode int, d time.Duration) error {
+	ctx, err := tag.New(
+		r.ctx,
+		tag.Insert(r.responseCodeKey, strconv.Itoa(responseCode)),
+		tag.Insert(r.responseCodeClassKey, responseCodeClass(responseCode)))
+	if err != nil {
+		return err
+	}
+
+	// convert time.Duration in nanoseconds to milliseconds
+	metrics.Record(ctx, r.latencyMetric.M(float64(d/time.Millisecond)))
+	return nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"knative.dev/pkg/metrics/metricskey"
+)
+
+var queueTimeMetric = stats.Float64(
+	"queue_wait_latencies",
+	"The time requests spend queued in millisecond",
+	stats.UnitMilliseconds)
+
+func TestNewQueueTimeReporter_negative(t *testing.T) {
+	if _, err := NewQueueTimeReporter("", testSvc, testConf, testRev, queueTimeMetric); err == nil {
+		t.Error("Expected namespace empty error")
+	}
+	if _, err := NewQueueTimeReporter(testNs, testSvc, "", testRev, queueTimeMetric); err == nil {
+		t.Error("Expected config empty error")
+	}
+	if _, err := NewQueueTimeReporter(testNs, testSvc, testConf, "", queueTimeMetric); err == nil {
+		t.Error("Expected revision empty error")
+	}
+}
+
+func TestQueueTimeReporter_Report(t *testing.T) {
+	r, err := NewQueueTimeReporter(testNs, testSvc, testConf, testRev, queueTimeMetric)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewQueueTimeReporter() = %v", err)
+	}
+	defer view.Unregister(view.Find("queue_wait_latencies"))
+
+	wantTags := map[string]string{
+		metricskey.LabelNamespaceName:     testNs,
+		metricskey.LabelServiceName:       testSvc,
+		metricskey.LabelConfigurationName: testConf,
+		metricskey.LabelRevisionName:      testRev,
+		"response_code":                   "503",
+		"response_code_class":             "5xx",
+	}
+
+	expectSuccess(t, "ReportQueueTime", func() error { return r.ReportQueueTime(503, 10*time.Millisecond) })
+	expectSuccess(t, "ReportQueueTime", func() error { return r.ReportQueueTime(503, 30*time.Millisecond) })
+	assertDistributionData(t, "queue_wait_latencies", wantTags, 2, 10, 30)
+}
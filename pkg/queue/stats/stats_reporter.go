@@ -134,6 +134,51 @@ func NewStatsReporter(ns, service, config, rev string, countMetric *stats.Int64M
 	}, nil
 }
 
+// newRevisionContext returns a context tagged with the given revision, and
+// the keys of these tags.
+func newRevisionContext(ns, service, config, rev string) (context.Context, []tag.Key, error) {
+	if ns == "" {
+		return nil, nil, errors.New("namespace must not be empty")
+	}
+	if config == "" {
+		return nil, nil, errors.New("config must not be empty")
+	}
+	if rev == "" {
+		return nil, nil, errors.New("revision must not be empty")
+	}
+
+	// Create the tag keys that will be used to add tags to our measurements.
+	nsTag, err := tag.NewKey(metricskey.LabelNamespaceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	svcTag, err := tag.NewKey(metricskey.LabelServiceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	configTag, err := tag.NewKey(metricskey.LabelConfigurationName)
+	if err != nil {
+		return nil, nil, err
+	}
+	revTag, err := tag.NewKey(metricskey.LabelRevisionName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Note that service name can be an empty string, so it needs a special treatment.
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(nsTag, ns),
+		tag.Insert(svcTag, valueOrUnknown(service)),
+		tag.Insert(configTag, config),
+		tag.Insert(revTag, rev),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ctx, []tag.Key{nsTag, svcTag, configTag, revTag}, nil
+}
+
 func valueOrUnknown(v string) string {
 	if v != "" {
 		return v
@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"context"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"knative.dev/pkg/metrics"
+)
+
+// TimeoutReporter reports the requests timed out by queue-proxy, by kind
+// of timeout.
+type TimeoutReporter struct {
+	ctx            context.Context
+	timeoutTypeKey tag.Key
+	countMetric    *stats.Int64Measure
+}
+
+// NewTimeoutReporter creates a reporter that collects and reports queue
+// proxy timeout metrics.
+func NewTimeoutReporter(ns, service, config, rev string, countMetric *stats.Int64Measure) (*TimeoutReporter, error) {
+	ctx, revisionTags, err := newRevisionContext(ns, service, config, rev)
+	if err != nil {
+		return nil, err
+	}
+	timeoutTypeTag, err := tag.NewKey("timeout_type")
+	if err != nil {
+		return nil, err
+	}
+
+	// Create view to see our measurements.
+	err = view.Register(&view.View{
+		Description: "The number of requests that timed out in queue-proxy",
+		Measure:     countMetric,
+		Aggregation: view.Sum(),
+		TagKeys:     append(revisionTags, timeoutTypeTag),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TimeoutReporter{
+		ctx:            ctx,
+		timeoutTypeKey: timeoutTypeTag,
+		countMetric:    countMetric,
+	}, nil
+}
+
+// ReportTimeout captures a request timed out with the given kind of
+// timeout.
+func (r *TimeoutReporter) ReportTimeout(kind string) error {
+	ctx, err := tag.New(r.ctx, tag.Insert(r.timeoutTypeKey, kind))
+	if err != nil {
+		return err
+	}
+
+	metrics.Record(ctx, r.countMetric.M(1))
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"knative.dev/pkg/metrics/metricskey"
+)
+
+var timeoutMetric = stats.Int64(
+	"request_timeouts",
+	"The number of requests that timed out in queue-proxy",
+	stats.UnitDimensionless)
+
+func TestNewTimeoutReporter_negative(t *testing.T) {
+	if _, err := NewTimeoutReporter("", testSvc, testConf, testRev, timeoutMetric); err == nil {
+		t.Error("Expected namespace empty error")
+	}
+	if _, err := NewTimeoutReporter(testNs, testSvc, "", testRev, timeoutMetric); err == nil {
+		t.Error("Expected config empty error")
+	}
+	if _, err := NewTimeoutReporter(testNs, testSvc, testConf, "", timeoutMetric); err == nil {
+		t.Error("Expected revision empty error")
+	}
+}
+
+func TestTimeoutReporter_Report(t *testing.T) {
+	r, err := NewTimeoutReporter(testNs, testSvc, testConf, testRev, timeoutMetric)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewTimeoutReporter() = %v", err)
+	}
+	defer view.Unregister(view.Find("request_timeouts"))
+
+	wantTags := map[string]string{
+		metricskey.LabelNamespaceName:     testNs,
+		metricskey.LabelServiceName:       testSvc,
+		metricskey.LabelConfigurationName: testConf,
+		metricskey.LabelRevisionName:      testRev,
+		"timeout_type":                    "idle",
+	}
+
+	expectSuccess(t, "ReportTimeout", func() error { return r.ReportTimeout("idle") })
+	expectSuccess(t, "ReportTimeout", func() error { return r.ReportTimeout("idle") })
+	assertSumData(t, "request_timeouts", wantTags, 2)
+}
@@ -258,6 +258,26 @@ func TestTwoRequestsOneProxied(t *testing.T) {
 }
 
 // Test type to hold the bi-directional time channels
+func TestRateLimitedRequest(t *testing.T) {
+	now := time.Now()
+	s := newTestStats(now)
+	s.requestStart(now)
+	s.rateLimited(now)
+	s.rateLimited(now)
+	now = now.Add(1 * time.Second)
+	got := s.report(now)
+	want := &autoscaler.Stat{
+		Time:                      &now,
+		PodName:                   podName,
+		AverageConcurrentRequests: 1.0,
+		RequestCount:              1,
+		RateLimitedRequestCount:   2,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected stat (-want +got): %v", diff)
+	}
+}
+
 type testStats struct {
 	Stats
 	reportBiChan chan time.Time
@@ -294,6 +314,10 @@ func (s *testStats) proxiedEnd(now time.Time) {
 	s.ch.ReqChan <- ReqEvent{Time: now, EventType: ProxiedOut}
 }
 
+func (s *testStats) rateLimited(now time.Time) {
+	s.ch.ReqChan <- ReqEvent{Time: now, EventType: RateLimited}
+}
+
 func (s *testStats) report(now time.Time) *autoscaler.Stat {
 	s.reportBiChan <- now
 	return <-s.ch.StatChan
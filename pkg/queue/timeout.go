@@ -28,7 +28,45 @@ import (
 	"knative.dev/pkg/websocket"
 )
 
-var defaultTimeoutBody = "<html><head><title>Timeout</title></head><body><h1>Timeout</h1></body></html>"
+var (
+	defaultTimeoutBody     = "<html><head><title>Timeout</title></head><body><h1>Timeout</h1></body></html>"
+	maxDurationTimeoutBody = "request duration exceeded"
+)
+
+// TimeoutKind identifies which of the limits enforced by TimeoutHandler
+// has been exceeded.
+type TimeoutKind string
+
+const (
+	// FirstByteTimeout is exceeded if no byte of the response has been
+	// written in time.
+	FirstByteTimeout TimeoutKind = "first_byte"
+	// MaxDurationTimeout is exceeded if the whole response has not been
+	// written in time.
+	MaxDurationTimeout TimeoutKind = "max_duration"
+	// IdleTimeout is exceeded if the response has started but no further
+	// write happened in time.
+	IdleTimeout TimeoutKind = "idle"
+)
+
+// TimeoutParams defines the limits enforced by TimeoutHandler.
+type TimeoutParams struct {
+	// FirstByte is the time in which the first byte of the response must
+	// be written.
+	FirstByte time.Duration
+	// MaxDuration is the time in which the whole response must be
+	// written. Zero disables the limit.
+	MaxDuration time.Duration
+	// Idle is the longest time allowed between two writes once the
+	// response has started. Zero disables the limit.
+	Idle time.Duration
+	// Message is the body of the first byte timeout response. If empty,
+	// a suitable default message will be sent.
+	Message string
+	// OnTimeout, if set, is called with the kind of timeout each time a
+	// request times out.
+	OnTimeout func(TimeoutKind)
+}
 
 // TimeToFirstByteTimeoutHandler returns a Handler that runs `h` with the
 // given time limit in which the first byte of the response must be written.
@@ -46,17 +84,41 @@ var defaultTimeoutBody = "<html><head><title>Timeout</title></head><body><h1>Tim
 //
 // The implementation is largely inspired by http.TimeoutHandler.
 func TimeToFirstByteTimeoutHandler(h http.Handler, dt time.Duration, msg string) http.Handler {
+	return TimeoutHandler(h, TimeoutParams{
+		FirstByte: dt,
+		Message:   msg,
+	})
+}
+
+// TimeoutHandler returns a Handler that runs `h` with the limits given
+// in p. The time to first byte is handled like in
+// TimeToFirstByteTimeoutHandler.
+//
+// If the max duration is exceeded before anything has been written,
+// the handler responds with a 504 Gateway Timeout error. If it is
+// exceeded after the response has started, or if the idle timeout is
+// exceeded, the response is aborted by panicking with
+// http.ErrAbortHandler, which cuts the connection (or stream) so that
+// the client can tell the response is incomplete. Hijacked connections
+// are only subject to the time to first byte.
+func TimeoutHandler(h http.Handler, p TimeoutParams) http.Handler {
 	return &timeoutHandler{
-		handler: h,
-		body:    msg,
-		dt:      dt,
+		handler:     h,
+		body:        p.Message,
+		dt:          p.FirstByte,
+		maxDuration: p.MaxDuration,
+		idle:        p.Idle,
+		onTimeout:   p.OnTimeout,
 	}
 }
 
 type timeoutHandler struct {
-	handler http.Handler
-	body    string
-	dt      time.Duration
+	handler     http.Handler
+	body        string
+	dt          time.Duration
+	maxDuration time.Duration
+	idle        time.Duration
+	onTimeout   func(TimeoutKind)
 }
 
 func (h *timeoutHandler) errorBody() string {
@@ -66,15 +128,21 @@ func (h *timeoutHandler) errorBody() string {
 	return defaultTimeoutBody
 }
 
+func (h *timeoutHandler) timedOut(kind TimeoutKind) {
+	if h.onTimeout != nil {
+		h.onTimeout(kind)
+	}
+}
+
 func (h *timeoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx, cancelCtx := context.WithCancel(r.Context())
 	defer cancelCtx()
 
 	done := make(chan struct{})
 	// The recovery value of a panic is written to this channel to be
-	// propagated (panicked with) again.
-	panicChan := make(chan interface{})
-	defer close(panicChan)
+	// propagated (panicked with) again. It is buffered so the handler
+	// never blocks (or panics) on it once we stopped listening.
+	panicChan := make(chan interface{}, 1)
 
 	tw := &timeoutWriter{w: w}
 	go func() {
@@ -91,6 +159,24 @@ func (h *timeoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	timeout := time.NewTimer(h.dt)
 	defer timeout.Stop()
+
+	var maxDuration <-chan time.Time
+	if h.maxDuration > 0 {
+		t := time.NewTimer(h.maxDuration)
+		defer t.Stop()
+		maxDuration = t.C
+	}
+
+	var (
+		idle      <-chan time.Time
+		idleTimer *time.Timer
+	)
+	if h.idle > 0 {
+		idleTimer = time.NewTimer(h.idle)
+		defer idleTimer.Stop()
+		idle = idleTimer.C
+	}
+
 	for {
 		select {
 		case p := <-panicChan:
@@ -99,8 +185,33 @@ func (h *timeoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		case <-timeout.C:
 			if tw.TimeoutAndWriteError(h.errorBody()) {
+				h.timedOut(FirstByteTimeout)
 				return
 			}
+		case <-maxDuration:
+			if tw.timeoutAndWriteError(http.StatusGatewayTimeout, maxDurationTimeoutBody) {
+				h.timedOut(MaxDurationTimeout)
+				return
+			}
+			if tw.abort() {
+				h.timedOut(MaxDurationTimeout)
+				panic(http.ErrAbortHandler)
+			}
+			// The connection has been hijacked, stop enforcing.
+			maxDuration = nil
+		case now := <-idle:
+			since, started := tw.sinceLastWrite(now)
+			if !started || since < h.idle {
+				// Not started yet (the time to first byte applies) or
+				// written to in the meantime.
+				idleTimer.Reset(h.idle - since)
+				continue
+			}
+			if tw.abort() {
+				h.timedOut(IdleTimeout)
+				panic(http.ErrAbortHandler)
+			}
+			idle = nil
 		}
 	}
 }
@@ -118,6 +229,8 @@ type timeoutWriter struct {
 	mu        sync.Mutex
 	timedOut  bool
 	wroteOnce bool
+	hijacked  bool
+	lastWrite time.Time
 }
 
 var _ http.Flusher = (*timeoutWriter)(nil)
@@ -125,6 +238,12 @@ var _ http.Flusher = (*timeoutWriter)(nil)
 var _ http.ResponseWriter = (*timeoutWriter)(nil)
 
 func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+
 	tw.w.(http.Flusher).Flush()
 }
 
@@ -132,7 +251,13 @@ func (tw *timeoutWriter) Flush() {
 // http.Hijacker interface, which is required for net/http/httputil/reverseproxy
 // to handle connection upgrade/switching protocol.  Otherwise returns an error.
 func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return websocket.HijackIfPossible(tw.w)
+	c, rw, err := websocket.HijackIfPossible(tw.w)
+	if err == nil {
+		tw.mu.Lock()
+		tw.hijacked = true
+		tw.mu.Unlock()
+	}
+	return c, rw, err
 }
 
 func (tw *timeoutWriter) Header() http.Header { return tw.w.Header() }
@@ -145,6 +270,7 @@ func (tw *timeoutWriter) Write(p []byte) (int, error) {
 	}
 
 	tw.wroteOnce = true
+	tw.lastWrite = time.Now()
 	return tw.w.Write(p)
 }
 
@@ -156,6 +282,7 @@ func (tw *timeoutWriter) WriteHeader(code int) {
 	}
 
 	tw.wroteOnce = true
+	tw.lastWrite = time.Now()
 	tw.w.WriteHeader(code)
 }
 
@@ -166,11 +293,15 @@ func (tw *timeoutWriter) WriteHeader(code int) {
 // If this writes an error, all subsequent calls to Write will
 // result in http.ErrHandlerTimeout.
 func (tw *timeoutWriter) TimeoutAndWriteError(msg string) bool {
+	return tw.timeoutAndWriteError(http.StatusServiceUnavailable, msg)
+}
+
+func (tw *timeoutWriter) timeoutAndWriteError(code int, msg string) bool {
 	tw.mu.Lock()
 	defer tw.mu.Unlock()
 
-	if !tw.wroteOnce {
-		tw.w.WriteHeader(http.StatusServiceUnavailable)
+	if !tw.wroteOnce && !tw.hijacked {
+		tw.w.WriteHeader(code)
 		io.WriteString(tw.w, msg)
 
 		tw.timedOut = true
@@ -179,3 +310,29 @@ func (tw *timeoutWriter) TimeoutAndWriteError(msg string) bool {
 
 	return false
 }
+
+// abort marks the writer as timed out regardless of what has been
+// written already. Returns false if the connection has been hijacked,
+// in which case the writer is left alone.
+func (tw *timeoutWriter) abort() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.hijacked {
+		return false
+	}
+	tw.timedOut = true
+	return true
+}
+
+// sinceLastWrite returns the time passed between the last write and now,
+// and whether the response has started at all.
+func (tw *timeoutWriter) sinceLastWrite(now time.Time) (time.Duration, bool) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if !tw.wroteOnce || tw.hijacked {
+		return 0, false
+	}
+	return now.Sub(tw.lastWrite), true
+}
@@ -136,3 +136,157 @@ func TestTimeToFirstByteTimeoutHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestTimeoutHandler(t *testing.T) {
+	const (
+		failingTimeout = 50 * time.Millisecond
+		sleepToFail    = 200 * time.Millisecond
+	)
+
+	tests := []struct {
+		name       string
+		params     TimeoutParams
+		handler    http.Handler
+		wantStatus int
+		wantBody   string
+		wantPanic  bool
+		wantKind   TimeoutKind
+	}{{
+		name: "all good",
+		params: TimeoutParams{
+			FirstByte:   10 * time.Second,
+			MaxDuration: 10 * time.Second,
+			Idle:        10 * time.Second,
+		},
+		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("hi"))
+		}),
+		wantStatus: http.StatusOK,
+		wantBody:   "hi",
+	}, {
+		name: "first byte timeout",
+		params: TimeoutParams{
+			FirstByte: failingTimeout,
+			Idle:      10 * time.Second,
+			Message:   "request timeout",
+		},
+		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(sleepToFail)
+			w.Write([]byte("hi"))
+		}),
+		wantStatus: http.StatusServiceUnavailable,
+		wantBody:   "request timeout",
+		wantKind:   FirstByteTimeout,
+	}, {
+		name: "max duration before first byte",
+		params: TimeoutParams{
+			FirstByte:   10 * time.Second,
+			MaxDuration: failingTimeout,
+		},
+		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(sleepToFail)
+			w.Write([]byte("hi"))
+		}),
+		wantStatus: http.StatusGatewayTimeout,
+		wantBody:   maxDurationTimeoutBody,
+		wantKind:   MaxDurationTimeout,
+	}, {
+		name: "max duration while streaming",
+		params: TimeoutParams{
+			FirstByte:   10 * time.Second,
+			MaxDuration: failingTimeout,
+		},
+		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for i := 0; i < 20; i++ {
+				if _, err := w.Write([]byte("hi")); err != nil {
+					return
+				}
+				time.Sleep(failingTimeout / 5)
+			}
+		}),
+		wantPanic: true,
+		wantKind:  MaxDurationTimeout,
+	}, {
+		name: "idle timeout",
+		params: TimeoutParams{
+			FirstByte: 10 * time.Second,
+			Idle:      failingTimeout,
+		},
+		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("hi"))
+			time.Sleep(sleepToFail)
+			w.Write([]byte("hi"))
+		}),
+		wantPanic: true,
+		wantKind:  IdleTimeout,
+	}, {
+		name: "idle timeout does not apply before first byte",
+		params: TimeoutParams{
+			FirstByte: 10 * time.Second,
+			Idle:      failingTimeout,
+		},
+		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(sleepToFail)
+			w.Write([]byte("hi"))
+		}),
+		wantStatus: http.StatusOK,
+		wantBody:   "hi",
+	}, {
+		name: "regular writes keep the response alive",
+		params: TimeoutParams{
+			FirstByte: 10 * time.Second,
+			Idle:      failingTimeout,
+		},
+		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for i := 0; i < 5; i++ {
+				w.Write([]byte("hi"))
+				time.Sleep(failingTimeout / 5)
+			}
+		}),
+		wantStatus: http.StatusOK,
+		wantBody:   "hihihihihi",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotKind TimeoutKind
+			params := test.params
+			params.OnTimeout = func(kind TimeoutKind) {
+				gotKind = kind
+			}
+
+			rr := httptest.NewRecorder()
+			handler := TimeoutHandler(test.handler, params)
+
+			func() {
+				defer func() {
+					recovered := recover()
+					if test.wantPanic && recovered != http.ErrAbortHandler {
+						t.Errorf("Expected the handler to panic with ErrAbortHandler, got %v", recovered)
+					} else if !test.wantPanic && recovered != nil {
+						t.Errorf("Unexpected panic: %v", recovered)
+					}
+				}()
+				handler.ServeHTTP(rr, req)
+			}()
+
+			if gotKind != test.wantKind {
+				t.Errorf("Timeout kind = %q, want %q", gotKind, test.wantKind)
+			}
+			if test.wantPanic {
+				return
+			}
+			if status := rr.Code; status != test.wantStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", status, test.wantStatus)
+			}
+			if rr.Body.String() != test.wantBody {
+				t.Errorf("Handler returned unexpected body: got %q want %q", rr.Body.String(), test.wantBody)
+			}
+		})
+	}
+}
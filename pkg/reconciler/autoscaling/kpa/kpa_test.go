@@ -366,6 +366,22 @@ func TestReconcile(t *testing.T) {
 			expectedDeploy,
 			makeSKSPrivateEndpoints(1, testNamespace, testRevision),
 		},
+	}, {
+		Name: "active revision accepting async invocations stays proxied",
+		Key:  key,
+		Objects: []runtime.Object{
+			kpa(testNamespace, testRevision, markActive, withAsyncInvocations, withMSvcStatus("a330-200"),
+				WithPAStatusService(testRevision)),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+			metricsSvc(testNamespace, testRevision, withSvcSelector(usualSelector),
+				withMSvcName("a330-200")),
+			expectedDeploy,
+			makeSKSPrivateEndpoints(1, testNamespace, testRevision),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady,
+				WithProxyMode),
+		}},
 	}, {
 		Name: "metric-service-mistmatch",
 		Key:  key,
@@ -1405,6 +1421,13 @@ func withMinScale(minScale int) PodAutoscalerOption {
 	}
 }
 
+func withAsyncInvocations(pa *asv1a1.PodAutoscaler) {
+	pa.Annotations = presources.UnionMaps(
+		pa.Annotations,
+		map[string]string{serving.AsyncInvocationsAnnotation: "true"},
+	)
+}
+
 type testConfigStore struct {
 	config *config.Config
 }
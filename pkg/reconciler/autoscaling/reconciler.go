@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strconv"
 
 	perrors "github.com/pkg/errors"
 
@@ -29,6 +30,7 @@ import (
 	pav1alpha1 "github.com/knative/serving/pkg/apis/autoscaling/v1alpha1"
 	"github.com/knative/serving/pkg/apis/networking"
 	nv1alpha1 "github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving"
 	listers "github.com/knative/serving/pkg/client/listers/autoscaling/v1alpha1"
 	nlisters "github.com/knative/serving/pkg/client/listers/networking/v1alpha1"
 	"github.com/knative/serving/pkg/reconciler"
@@ -61,7 +63,9 @@ func (c *Base) ReconcileSKS(ctx context.Context, pa *pav1alpha1.PodAutoscaler) (
 	logger := logging.FromContext(ctx)
 
 	mode := nv1alpha1.SKSOperationModeServe
-	if pa.Status.IsInactive() {
+	// The activator accepts the asynchronous invocations, so it stays in the
+	// request path of the revisions opting into them.
+	if pa.Status.IsInactive() || acceptsAsyncInvocations(pa) {
 		mode = nv1alpha1.SKSOperationModeProxy
 	}
 	sksName := anames.SKS(pa.Name)
@@ -94,6 +98,13 @@ func (c *Base) ReconcileSKS(ctx context.Context, pa *pav1alpha1.PodAutoscaler) (
 	return sks, nil
 }
 
+// acceptsAsyncInvocations returns whether the revision of the PA opted into
+// asynchronous invocations.
+func acceptsAsyncInvocations(pa *pav1alpha1.PodAutoscaler) bool {
+	enabled, _ := strconv.ParseBool(pa.Annotations[serving.AsyncInvocationsAnnotation])
+	return enabled
+}
+
 func (c *Base) metricService(pa *pav1alpha1.PodAutoscaler) (*corev1.Service, error) {
 	svcs, err := c.ServiceLister.Services(pa.Namespace).List(labels.SelectorFromSet(map[string]string{
 		autoscaling.KPALabelKey:   pa.Name,
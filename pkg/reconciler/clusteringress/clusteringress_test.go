@@ -27,7 +27,10 @@ import (
 	_ "knative.dev/pkg/client/injection/informers/istio/v1alpha3/gateway/fake"
 	_ "knative.dev/pkg/client/injection/informers/istio/v1alpha3/virtualservice/fake"
 	fakekubeclient "knative.dev/pkg/injection/clients/kubeclient/fake"
+	_ "knative.dev/pkg/injection/informers/kubeinformers/corev1/endpoints/fake"
+	_ "knative.dev/pkg/injection/informers/kubeinformers/corev1/namespace/fake"
 	_ "knative.dev/pkg/injection/informers/kubeinformers/corev1/secret/fake"
+	_ "knative.dev/pkg/injection/informers/kubeinformers/corev1/service/fake"
 	fakeservingclient "github.com/knative/serving/pkg/client/injection/client/fake"
 	_ "github.com/knative/serving/pkg/client/injection/informers/networking/v1alpha1/clusteringress/fake"
 
@@ -184,8 +187,8 @@ func TestReconcile(t *testing.T) {
 			ingress("no-virtualservice-yet", 1234),
 		},
 		WantCreates: []runtime.Object{
-			resources.MakeMeshVirtualService(ingress("no-virtualservice-yet", 1234)),
-			resources.MakeIngressVirtualService(ingress("no-virtualservice-yet", 1234),
+			resources.MakeMeshVirtualService(withProbe(ingress("no-virtualservice-yet", 1234))),
+			resources.MakeIngressVirtualService(withProbe(ingress("no-virtualservice-yet", 1234)),
 				[]string{"knative-test-gateway", "knative-ingress-gateway"}),
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
@@ -253,11 +256,11 @@ func TestReconcile(t *testing.T) {
 			},
 		},
 		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: resources.MakeIngressVirtualService(ingress("reconcile-virtualservice", 1234),
+			Object: resources.MakeIngressVirtualService(withProbe(ingress("reconcile-virtualservice", 1234)),
 				[]string{"knative-test-gateway", "knative-ingress-gateway"}),
 		}},
 		WantCreates: []runtime.Object{
-			resources.MakeMeshVirtualService(ingress("reconcile-virtualservice", 1234)),
+			resources.MakeMeshVirtualService(withProbe(ingress("reconcile-virtualservice", 1234))),
 		},
 		WantDeletes: []clientgotesting.DeleteActionImpl{{
 			ActionImpl: clientgotesting.ActionImpl{
@@ -308,9 +311,74 @@ func TestReconcile(t *testing.T) {
 				Base:                 reconciler.NewBase(ctx, controllerAgentName, cmw),
 				VirtualServiceLister: listers.GetVirtualServiceLister(),
 				GatewayLister:        listers.GetGatewayLister(),
+				NamespaceLister:      listers.GetNamespaceLister(),
 				ConfigStore: &testConfigStore{
 					config: ReconcilerTestConfig(),
 				},
+				StatusManager: &fakeStatusManager{ready: true},
+			},
+			clusterIngressLister: listers.GetClusterIngressLister(),
+		}
+	}))
+}
+
+func TestReconcile_GatewaysNotProbed(t *testing.T) {
+	table := TableTest{{
+		Name:                    "gateways don't serve the VirtualService yet",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			ingress("not-probed-yet", 1234),
+		},
+		WantCreates: []runtime.Object{
+			resources.MakeMeshVirtualService(withProbe(ingress("not-probed-yet", 1234))),
+			resources.MakeIngressVirtualService(withProbe(ingress("not-probed-yet", 1234)),
+				[]string{"knative-test-gateway", "knative-ingress-gateway"}),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingressWithStatus("not-probed-yet", 1234,
+				v1alpha1.IngressStatus{
+					Status: duckv1beta1.Status{
+						Conditions: duckv1beta1.Conditions{{
+							Type:     v1alpha1.IngressConditionLoadBalancerReady,
+							Status:   corev1.ConditionUnknown,
+							Severity: apis.ConditionSeverityError,
+							Reason:   "Uninitialized",
+							Message:  "Waiting for VirtualService to be ready",
+						}, {
+							Type:     v1alpha1.IngressConditionNetworkConfigured,
+							Status:   corev1.ConditionTrue,
+							Severity: apis.ConditionSeverityError,
+						}, {
+							Type:     v1alpha1.IngressConditionReady,
+							Status:   corev1.ConditionUnknown,
+							Severity: apis.ConditionSeverityError,
+							Reason:   "Uninitialized",
+							Message:  "Waiting for VirtualService to be ready",
+						}},
+					},
+				},
+			),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "not-probed-yet-mesh"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "not-probed-yet"),
+			Eventf(corev1.EventTypeNormal, "Updated", "Updated status for ClusterIngress %q", "not-probed-yet"),
+		},
+		Key: "not-probed-yet",
+	}}
+
+	defer logtesting.ClearAll()
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		return &Reconciler{
+			BaseIngressReconciler: &ing.BaseIngressReconciler{
+				Base:                 reconciler.NewBase(ctx, controllerAgentName, cmw),
+				VirtualServiceLister: listers.GetVirtualServiceLister(),
+				GatewayLister:        listers.GetGatewayLister(),
+				NamespaceLister:      listers.GetNamespaceLister(),
+				ConfigStore: &testConfigStore{
+					config: ReconcilerTestConfig(),
+				},
+				StatusManager: &fakeStatusManager{ready: false},
 			},
 			clusterIngressLister: listers.GetClusterIngressLister(),
 		}
@@ -331,8 +399,8 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 			// The creation of gateways are triggered when setting up the test.
 			gateway("knative-ingress-gateway", system.Namespace(), []v1alpha3.Server{irrelevantServer}),
 
-			resources.MakeMeshVirtualService(ingress("reconciling-clusteringress", 1234)),
-			resources.MakeIngressVirtualService(ingress("reconciling-clusteringress", 1234),
+			resources.MakeMeshVirtualService(withProbe(ingressWithTLS("reconciling-clusteringress", 1234, ingressTLS))),
+			resources.MakeIngressVirtualService(withProbe(ingressWithTLS("reconciling-clusteringress", 1234, ingressTLS)),
 				[]string{"knative-ingress-gateway"}),
 		},
 		WantUpdates: []clientgotesting.UpdateActionImpl{{
@@ -384,8 +452,8 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 			originSecret("istio-system", "secret0"),
 		},
 		WantCreates: []runtime.Object{
-			resources.MakeMeshVirtualService(ingress("reconciling-clusteringress", 1234)),
-			resources.MakeIngressVirtualService(ingress("reconciling-clusteringress", 1234),
+			resources.MakeMeshVirtualService(withProbe(ingressWithTLS("reconciling-clusteringress", 1234, ingressTLS))),
+			resources.MakeIngressVirtualService(withProbe(ingressWithTLS("reconciling-clusteringress", 1234, ingressTLS)),
 				[]string{"knative-ingress-gateway"}),
 		},
 		WantPatches: []clientgotesting.PatchActionImpl{
@@ -463,8 +531,8 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 			// The creation of gateways are triggered when setting up the test.
 			gateway("knative-ingress-gateway", system.Namespace(), []v1alpha3.Server{irrelevantServer}),
 
-			resources.MakeMeshVirtualService(ingress("reconciling-clusteringress", 1234)),
-			resources.MakeIngressVirtualService(ingress("reconciling-clusteringress", 1234),
+			resources.MakeMeshVirtualService(withProbe(ingressWithTLS("reconciling-clusteringress", 1234, ingressTLSWithSecretNamespace("knative-serving")))),
+			resources.MakeIngressVirtualService(withProbe(ingressWithTLS("reconciling-clusteringress", 1234, ingressTLSWithSecretNamespace("knative-serving"))),
 				[]string{"knative-ingress-gateway"}),
 
 			// The secret copy under istio-system.
@@ -543,8 +611,8 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 		WantCreates: []runtime.Object{
 			// The creation of gateways are triggered when setting up the test.
 			gateway("knative-ingress-gateway", system.Namespace(), []v1alpha3.Server{*withCredentialName(ingressTLSServer.DeepCopy(), targetSecretName), irrelevantServer}),
-			resources.MakeMeshVirtualService(ingress("reconciling-clusteringress", 1234)),
-			resources.MakeIngressVirtualService(ingress("reconciling-clusteringress", 1234),
+			resources.MakeMeshVirtualService(withProbe(ingressWithTLS("reconciling-clusteringress", 1234, ingressTLSWithSecretNamespace("knative-serving")))),
+			resources.MakeIngressVirtualService(withProbe(ingressWithTLS("reconciling-clusteringress", 1234, ingressTLSWithSecretNamespace("knative-serving"))),
 				[]string{"knative-ingress-gateway"}),
 		},
 		WantUpdates: []clientgotesting.UpdateActionImpl{{
@@ -612,7 +680,7 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 		WantCreates: []runtime.Object{
 			// The creation of gateways are triggered when setting up the test.
 			gateway("knative-ingress-gateway", system.Namespace(), []v1alpha3.Server{irrelevantServer}),
-			resources.MakeMeshVirtualService(ingress("reconciling-clusteringress", 1234)),
+			resources.MakeMeshVirtualService(withProbe(ingressWithTLSClusterLocal("reconciling-clusteringress", 1234, ingressTLS))),
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
 			Object: ingressWithTLSAndStatusClusterLocal("reconciling-clusteringress", 1234,
@@ -661,6 +729,7 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 				Base:                 reconciler.NewBase(ctx, controllerAgentName, cmw),
 				VirtualServiceLister: listers.GetVirtualServiceLister(),
 				GatewayLister:        listers.GetGatewayLister(),
+				NamespaceLister:      listers.GetNamespaceLister(),
 				SecretLister:         listers.GetSecretLister(),
 				Tracker:              &NullTracker{},
 				// Enable reconciling gateway.
@@ -678,6 +747,7 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 						},
 					},
 				},
+				StatusManager: &fakeStatusManager{ready: true},
 			},
 			clusterIngressLister: listers.GetClusterIngressLister(),
 		}
@@ -764,6 +834,29 @@ func (t *testConfigStore) ToContext(ctx context.Context) context.Context {
 
 var _ reconciler.ConfigStore = (*testConfigStore)(nil)
 
+// fakeStatusManager reports every ClusterIngress as ready or not ready.
+type fakeStatusManager struct {
+	ready bool
+}
+
+func (m *fakeStatusManager) IsReady(context.Context, v1alpha1.IngressAccessor, string, []string) (bool, error) {
+	return m.ready, nil
+}
+
+func (m *fakeStatusManager) CancelIngress(string, string) {}
+
+var _ ing.StatusManager = (*fakeStatusManager)(nil)
+
+// withProbe returns a copy of the ClusterIngress with the probe headers the
+// reconciler adds to the VirtualServices.
+func withProbe(ci *v1alpha1.ClusterIngress) *v1alpha1.ClusterIngress {
+	ci = ci.DeepCopy()
+	// The reconciler hashes the defaulted spec.
+	ci.SetDefaults(context.Background())
+	resources.InsertProbe(ci)
+	return ci
+}
+
 func ReconcilerTestConfig() *config.Config {
 	return &config.Config{
 		Istio: &config.Istio{
@@ -841,6 +934,7 @@ func newTestSetup(t *testing.T, configs ...*corev1.ConfigMap) (
 	ctx, informers := SetupFakeContext(t)
 	configMapWatcher := &configmap.ManualWatcher{Namespace: system.Namespace()}
 	controller := NewController(ctx, configMapWatcher)
+	controller.Reconciler.(*Reconciler).StatusManager = &fakeStatusManager{ready: true}
 
 	cms := append([]*corev1.ConfigMap{{
 		ObjectMeta: metav1.ObjectMeta{
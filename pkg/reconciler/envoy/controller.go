@@ -0,0 +1,107 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envoy
+
+import (
+	"context"
+
+	"k8s.io/client-go/tools/cache"
+
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
+
+	"github.com/knative/serving/pkg/apis/networking"
+	clusteringressinformer "github.com/knative/serving/pkg/client/injection/informers/networking/v1alpha1/clusteringress"
+	ingressinformer "github.com/knative/serving/pkg/client/injection/informers/networking/v1alpha1/ingress"
+	"github.com/knative/serving/pkg/envoy/xds"
+	"github.com/knative/serving/pkg/network"
+	"github.com/knative/serving/pkg/reconciler"
+)
+
+const (
+	controllerAgentName = "envoy-ingress-controller"
+	workQueueName       = "EnvoyIngresses"
+)
+
+// NewController returns a constructor for the Envoy ingress controller,
+// which reconciles both ClusterIngresses and Ingresses, and publishes the
+// resulting Envoy configuration to the given xDS cache.
+func NewController(xdsCache *xds.Cache) injection.ControllerConstructor {
+	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+		clusterIngressInformer := clusteringressinformer.Get(ctx)
+		ingressInformer := ingressinformer.Get(ctx)
+
+		c := &Reconciler{
+			Base:                 reconciler.NewBase(ctx, controllerAgentName, cmw),
+			clusterIngressLister: clusterIngressInformer.Lister(),
+			ingressLister:        ingressInformer.Lister(),
+			xdsCache:             xdsCache,
+		}
+		impl := controller.NewImpl(c, c.Logger, workQueueName)
+
+		c.Logger.Info("Setting up event handlers")
+		// Both kinds share the work queue: the key of a ClusterIngress has no
+		// namespace, which tells them apart.  The snapshot is rebuilt once per
+		// change, rather than for every ingress reconciled, so a change
+		// invalidates it before the ingress is enqueued.  An ingress leaving
+		// the class is deleted.
+		handler := cache.FilteringResourceEventHandler{
+			FilterFunc: reconciler.AnnotationFilterFunc(networking.IngressClassAnnotationKey, network.EnvoyIngressClassName, false),
+			Handler: cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					c.invalidateSnapshot()
+					impl.Enqueue(obj)
+				},
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					if affectsSnapshot(oldObj, newObj) {
+						c.invalidateSnapshot()
+					}
+					impl.Enqueue(newObj)
+				},
+				DeleteFunc: func(obj interface{}) {
+					c.invalidateSnapshot()
+					impl.Enqueue(obj)
+				},
+			},
+		}
+		clusterIngressInformer.Informer().AddEventHandler(handler)
+		ingressInformer.Informer().AddEventHandler(handler)
+
+		// Ingresses wait for Envoy to acknowledge the configuration before
+		// becoming ready, so reconcile them again whenever it does.
+		go func() {
+			for {
+				_, acked := xdsCache.Acked("")
+				select {
+				case <-ctx.Done():
+					return
+				case <-acked:
+				}
+				for _, informer := range []cache.SharedIndexInformer{clusterIngressInformer.Informer(), ingressInformer.Informer()} {
+					for _, obj := range informer.GetStore().List() {
+						if handler.FilterFunc(obj) {
+							impl.Enqueue(obj)
+						}
+					}
+				}
+			}
+		}()
+
+		return impl
+	}
+}
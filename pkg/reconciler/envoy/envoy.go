@@ -0,0 +1,279 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envoy
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+
+	"github.com/knative/serving/pkg/apis/networking"
+	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	listers "github.com/knative/serving/pkg/client/listers/networking/v1alpha1"
+	"github.com/knative/serving/pkg/envoy/xds"
+	"github.com/knative/serving/pkg/network"
+	"github.com/knative/serving/pkg/reconciler"
+	"github.com/knative/serving/pkg/reconciler/envoy/resources"
+)
+
+const (
+	// GatewayServiceName is the name of the K8s Service exposing the
+	// external listener of the Envoy pods programmed by this controller.
+	GatewayServiceName = "envoy-ingress"
+
+	// InternalGatewayServiceName is the name of the K8s Service exposing
+	// the internal listener, which serves every ingress to callers
+	// within the cluster.
+	InternalGatewayServiceName = "envoy-ingress-internal"
+)
+
+// snapshotCache is the part of the xds.Cache the Reconciler publishes the
+// Envoy configuration to.
+type snapshotCache interface {
+	Set(*xds.Snapshot)
+	Acked(version string) (bool, <-chan struct{})
+}
+
+// Reconciler implements controller.Reconciler for ClusterIngress and
+// Ingress resources of the Envoy ingress class.
+type Reconciler struct {
+	*reconciler.Base
+
+	clusterIngressLister listers.ClusterIngressLister
+	ingressLister        listers.IngressLister
+	xdsCache             snapshotCache
+
+	// generation is bumped, atomically, whenever an Envoy ingress changes
+	// in a way that may change the snapshot.
+	generation uint64
+	// snapshotMux guards snapshot and snapshotGeneration, the value of
+	// generation the snapshot was built at.
+	snapshotMux        sync.Mutex
+	snapshot           *xds.Snapshot
+	snapshotGeneration uint64
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile rebuilds the Envoy configuration from all of the Envoy
+// ingresses, publishes it, and then updates the Status block of the
+// ingress identified by key.  The key of a ClusterIngress has no namespace.
+//
+// Envoy is programmed with a single snapshot of the whole world, which is
+// only rebuilt once per change of the ingresses, whichever is reconciled.
+func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
+	// Convert the namespace/name string into a distinct namespace and name
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		c.Logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	logger := logging.FromContext(ctx)
+
+	snapshot, err := c.reconcileSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	original, err := c.getIngress(namespace, name)
+	if apierrs.IsNotFound(err) {
+		// The resource may no longer exist, in which case we stop processing.
+		logger.Infof("ingress %q in work queue no longer exists", key)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	// Don't modify the informers copy
+	ia := original.DeepCopyObject().(v1alpha1.IngressAccessor)
+	if ia.GetDeletionTimestamp() != nil {
+		return nil
+	}
+
+	ia.SetDefaults(ctx)
+	status := ia.GetStatus()
+	status.InitializeConditions()
+	if err := resources.ValidateBackends(ia); err != nil {
+		// The ingress was left out of the snapshot.
+		status.MarkInvalidBackends(err.Error())
+	} else {
+		status.MarkNetworkConfigured()
+		// Changes to other ingresses don't affect one that Envoy already
+		// serves, so only a new generation waits for Envoy to accept it.
+		lbReady := status.GetCondition(v1alpha1.IngressConditionLoadBalancerReady).IsTrue() &&
+			status.ObservedGeneration == ia.GetGeneration()
+		if acked, _ := c.xdsCache.Acked(snapshot.Version); acked || lbReady {
+			status.MarkLoadBalancerReady([]v1alpha1.LoadBalancerIngressStatus{{
+				DomainInternal: network.GetServiceHostname(InternalGatewayServiceName, system.Namespace()),
+			}})
+		} else {
+			// The ingress is enqueued again once Envoy acknowledges the
+			// configuration.
+			status.MarkLoadBalancerNotReady()
+		}
+	}
+	status.ObservedGeneration = ia.GetGeneration()
+
+	kind := ia.GetGroupVersionKind().Kind
+	if equality.Semantic.DeepEqual(original.GetStatus(), status) {
+		// If we didn't change anything then don't call updateStatus.
+		// This is important because the copy we loaded from the informer's
+		// cache may be stale and we don't want to overwrite a prior update
+		// to status with this stale state.
+		return nil
+	}
+	if err := c.updateStatus(ia); err != nil {
+		logger.Warnw("Failed to update "+kind+" status", zap.Error(err))
+		c.Recorder.Eventf(ia, corev1.EventTypeWarning, "UpdateFailed",
+			"Failed to update status for %s %q: %v", kind, ia.GetName(), err)
+		return err
+	}
+	logger.Infof("Updated status for %s %q", kind, ia.GetName())
+	return nil
+}
+
+func (c *Reconciler) getIngress(namespace, name string) (v1alpha1.IngressAccessor, error) {
+	if namespace == "" {
+		return c.clusterIngressLister.Get(name)
+	}
+	return c.ingressLister.Ingresses(namespace).Get(name)
+}
+
+// invalidateSnapshot makes the next Reconcile rebuild the snapshot.
+func (c *Reconciler) invalidateSnapshot() {
+	atomic.AddUint64(&c.generation, 1)
+}
+
+// affectsSnapshot returns whether the change of an ingress from oldObj to
+// newObj may change the snapshot, as opposed to a status update.
+func affectsSnapshot(oldObj, newObj interface{}) bool {
+	oldIA, ok := oldObj.(v1alpha1.IngressAccessor)
+	if !ok {
+		return true
+	}
+	newIA, ok := newObj.(v1alpha1.IngressAccessor)
+	if !ok {
+		return true
+	}
+	return !equality.Semantic.DeepEqual(oldIA.GetSpec(), newIA.GetSpec()) ||
+		(oldIA.GetDeletionTimestamp() == nil) != (newIA.GetDeletionTimestamp() == nil)
+}
+
+// reconcileSnapshot returns the snapshot of the current ingresses, which is
+// rebuilt and published when they changed since it last was.
+func (c *Reconciler) reconcileSnapshot(ctx context.Context) (*xds.Snapshot, error) {
+	c.snapshotMux.Lock()
+	defer c.snapshotMux.Unlock()
+	// The generation is read before the ingresses are listed, so that a
+	// change while they are leads to another rebuild.
+	generation := atomic.LoadUint64(&c.generation)
+	if c.snapshot != nil && c.snapshotGeneration == generation {
+		return c.snapshot, nil
+	}
+	snapshot, err := c.buildSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.snapshot, c.snapshotGeneration = snapshot, generation
+	return snapshot, nil
+}
+
+func (c *Reconciler) buildSnapshot(ctx context.Context) (*xds.Snapshot, error) {
+	logger := logging.FromContext(ctx)
+
+	cis, err := c.clusterIngressLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	ingresses, err := c.ingressLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	all := make([]v1alpha1.IngressAccessor, 0, len(cis)+len(ingresses))
+	for _, ci := range cis {
+		all = append(all, ci)
+	}
+	for _, ingress := range ingresses {
+		all = append(all, ingress)
+	}
+
+	isEnvoy := reconciler.AnnotationFilterFunc(networking.IngressClassAnnotationKey, network.EnvoyIngressClassName, false)
+	ias := make([]v1alpha1.IngressAccessor, 0, len(all))
+	for _, ia := range all {
+		if !isEnvoy(ia) || ia.GetDeletionTimestamp() != nil {
+			continue
+		}
+		// A single invalid ingress must not hold back the configuration
+		// of all the others; it's marked as failed when reconciled.
+		if err := resources.ValidateBackends(ia); err != nil {
+			logger.Warnf("Leaving %s %q out of the Envoy configuration: %v", ia.GetGroupVersionKind().Kind, resources.IngressKey(ia), err)
+			continue
+		}
+		ias = append(ias, ia)
+	}
+
+	listeners, err := resources.MakeListeners()
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := resources.MakeClusters(ias)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := xds.NewSnapshot(listeners, resources.MakeRouteConfigurations(ias), clusters)
+	if err != nil {
+		return nil, err
+	}
+	c.xdsCache.Set(snapshot)
+	logger.Infof("Published Envoy configuration version %s for %d ingresses", snapshot.Version, len(ias))
+	return snapshot, nil
+}
+
+// Update the Status of the ingress.  Caller is responsible for checking
+// for semantic differences before calling.
+func (c *Reconciler) updateStatus(desired v1alpha1.IngressAccessor) error {
+	ia, err := c.getIngress(desired.GetNamespace(), desired.GetName())
+	if err != nil {
+		return err
+	}
+	// If there's nothing to update, just return.
+	if reflect.DeepEqual(ia.GetStatus(), desired.GetStatus()) {
+		return nil
+	}
+	// Don't modify the informers copy
+	existing := ia.DeepCopyObject().(v1alpha1.IngressAccessor)
+	existing.SetStatus(*desired.GetStatus())
+	switch existing := existing.(type) {
+	case *v1alpha1.ClusterIngress:
+		_, err = c.ServingClientSet.NetworkingV1alpha1().ClusterIngresses().UpdateStatus(existing)
+	case *v1alpha1.Ingress:
+		_, err = c.ServingClientSet.NetworkingV1alpha1().Ingresses(existing.Namespace).UpdateStatus(existing)
+	}
+	return err
+}
@@ -0,0 +1,381 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envoy
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	// Inject our fakes
+	_ "github.com/knative/serving/pkg/client/injection/informers/networking/v1alpha1/clusteringress/fake"
+	_ "github.com/knative/serving/pkg/client/injection/informers/networking/v1alpha1/ingress/fake"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgotesting "k8s.io/client-go/testing"
+
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/system"
+	_ "knative.dev/pkg/system/testing"
+
+	"github.com/knative/serving/pkg/apis/networking"
+	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/envoy/api"
+	"github.com/knative/serving/pkg/envoy/xds"
+	"github.com/knative/serving/pkg/network"
+	"github.com/knative/serving/pkg/reconciler"
+
+	. "github.com/knative/serving/pkg/reconciler/testing/v1alpha1"
+	. "knative.dev/pkg/reconciler/testing"
+)
+
+const testNamespace = "test-ns"
+
+// fakeCache records the snapshots published, and reports every version as
+// acknowledged by Envoy, or none of them.
+type fakeCache struct {
+	acked bool
+
+	mux      sync.Mutex
+	snapshot *xds.Snapshot
+	sets     int
+}
+
+func (c *fakeCache) Set(s *xds.Snapshot) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.snapshot = s
+	c.sets++
+}
+
+func (c *fakeCache) Acked(version string) (bool, <-chan struct{}) {
+	return c.acked, make(chan struct{})
+}
+
+type ingressOption func(*v1alpha1.Ingress)
+
+func ingress(name string, opts ...ingressOption) *v1alpha1.Ingress {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Annotations: map[string]string{
+				networking.IngressClassAnnotationKey: network.EnvoyIngressClassName,
+			},
+			Generation: 1,
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts: []string{name + ".example.com"},
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: testNamespace,
+								ServiceName:      name,
+								ServicePort:      intstr.FromInt(80),
+							},
+						}},
+					}},
+				},
+			}},
+		},
+	}
+	// The reconciler defaults the ingresses it updates.
+	ing.SetDefaults(context.Background())
+	for _, opt := range opts {
+		opt(ing)
+	}
+	return ing
+}
+
+func withClass(class string) ingressOption {
+	return func(ing *v1alpha1.Ingress) {
+		ing.Annotations[networking.IngressClassAnnotationKey] = class
+	}
+}
+
+// withInvalidBackend makes the ingress route to a port no cluster can be
+// created for.
+func withInvalidBackend(ing *v1alpha1.Ingress) {
+	ing.Spec.Rules[0].HTTP.Paths[0].Splits[0].ServicePort = intstr.FromString("unknown")
+}
+
+func withGeneration(generation int64) ingressOption {
+	return func(ing *v1alpha1.Ingress) {
+		ing.Generation = generation
+	}
+}
+
+func withInitialConditions(ing *v1alpha1.Ingress) {
+	ing.Status.InitializeConditions()
+}
+
+func withReady(ing *v1alpha1.Ingress) {
+	ing.Status.InitializeConditions()
+	ing.Status.MarkNetworkConfigured()
+	ing.Status.MarkLoadBalancerReady([]v1alpha1.LoadBalancerIngressStatus{{
+		DomainInternal: network.GetServiceHostname(InternalGatewayServiceName, system.Namespace()),
+	}})
+	ing.Status.ObservedGeneration = ing.Generation
+}
+
+func withNotAcked(ing *v1alpha1.Ingress) {
+	ing.Status.InitializeConditions()
+	ing.Status.MarkNetworkConfigured()
+	ing.Status.MarkLoadBalancerNotReady()
+	ing.Status.ObservedGeneration = ing.Generation
+}
+
+func withInvalidBackendStatus(ing *v1alpha1.Ingress) {
+	ing.Status.InitializeConditions()
+	ing.Status.MarkInvalidBackends("backend " + testNamespace + "/" + ing.Name + ":unknown: unknown service port name \"unknown\"")
+	ing.Status.ObservedGeneration = ing.Generation
+}
+
+func newTestReconciler(ctx context.Context, listers *Listers, cmw configmap.Watcher, cache snapshotCache) *Reconciler {
+	return &Reconciler{
+		Base:                 reconciler.NewBase(ctx, controllerAgentName, cmw),
+		clusterIngressLister: listers.GetClusterIngressLister(),
+		ingressLister:        listers.GetIngressLister(),
+		xdsCache:             cache,
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	table := TableTest{{
+		Name: "bad workqueue key",
+		Key:  "too/many/parts",
+	}, {
+		Name: "key not found",
+		Key:  "foo/not-found",
+	}, {
+		Name: "ingress becomes ready once Envoy acknowledges the snapshot",
+		Objects: []runtime.Object{
+			ingress("acked"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingress("acked", withReady),
+		}},
+		Key: testNamespace + "/acked",
+	}, {
+		Name: "ready ingress stays ready",
+		Objects: []runtime.Object{
+			ingress("steady", withReady),
+		},
+		Key: testNamespace + "/steady",
+	}, {
+		Name: "ingress with invalid backends",
+		Objects: []runtime.Object{
+			ingress("invalid", withInvalidBackend),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingress("invalid", withInvalidBackend, withInvalidBackendStatus),
+		}},
+		Key: testNamespace + "/invalid",
+	}, {
+		Name: "valid ingress next to one with invalid backends",
+		Objects: []runtime.Object{
+			ingress("invalid", withInvalidBackend),
+			ingress("valid"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingress("valid", withReady),
+		}},
+		Key: testNamespace + "/valid",
+	}, {
+		Name:    "failure updating the status",
+		WantErr: true,
+		WithReactors: []clientgotesting.ReactionFunc{
+			InduceFailure("update", "ingresses"),
+		},
+		Objects: []runtime.Object{
+			ingress("update-fails"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingress("update-fails", withReady),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeWarning, "UpdateFailed", "Failed to update status for Ingress %q: %v",
+				"update-fails", "inducing failure for update ingresses"),
+		},
+		Key: testNamespace + "/update-fails",
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		return newTestReconciler(ctx, listers, cmw, &fakeCache{acked: true})
+	}))
+}
+
+func TestReconcile_NotAcked(t *testing.T) {
+	table := TableTest{{
+		Name: "ingress waits for Envoy to acknowledge the snapshot",
+		Objects: []runtime.Object{
+			ingress("not-acked"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingress("not-acked", withNotAcked),
+		}},
+		Key: testNamespace + "/not-acked",
+	}, {
+		Name: "ready ingress doesn't wait for changes to other ingresses",
+		Objects: []runtime.Object{
+			ingress("steady", withReady),
+		},
+		Key: testNamespace + "/steady",
+	}, {
+		Name: "new generation waits for Envoy to acknowledge the snapshot",
+		Objects: []runtime.Object{
+			ingress("changed", withReady, withGeneration(2)),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingress("changed", withReady, withGeneration(2), withNotAcked),
+		}},
+		Key: testNamespace + "/changed",
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		return newTestReconciler(ctx, listers, cmw, &fakeCache{acked: false})
+	}))
+}
+
+func TestReconcileSnapshot(t *testing.T) {
+	cache := &fakeCache{acked: true}
+	table := TableTest{{
+		Name: "snapshot holds the valid Envoy ingresses only",
+		Objects: []runtime.Object{
+			ingress("valid"),
+			ingress("invalid", withInvalidBackend, withInitialConditions),
+			ingress("other-class", withClass("other"), withInitialConditions),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingress("valid", withReady),
+		}},
+		Key: testNamespace + "/valid",
+	}}
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		return newTestReconciler(ctx, listers, cmw, cache)
+	}))
+
+	if cache.snapshot == nil {
+		t.Fatal("No snapshot was published")
+	}
+	var listeners, clusters, virtualHosts []string
+	for _, r := range cache.snapshot.Resources[api.ListenerType] {
+		listeners = append(listeners, r.(*api.Listener).Name)
+	}
+	for _, r := range cache.snapshot.Resources[api.ClusterType] {
+		clusters = append(clusters, r.(*api.Cluster).Name)
+	}
+	for _, r := range cache.snapshot.Resources[api.RouteType] {
+		for _, vh := range r.(*api.RouteConfiguration).VirtualHosts {
+			virtualHosts = append(virtualHosts, r.(*api.RouteConfiguration).Name+":"+vh.Name)
+		}
+	}
+	if want := []string{"knative-external", "knative-internal"}; !cmp.Equal(listeners, want) {
+		t.Errorf("Listeners (-want, +got): %s", cmp.Diff(want, listeners))
+	}
+	if want := []string{testNamespace + "/valid:80"}; !cmp.Equal(clusters, want) {
+		t.Errorf("Clusters (-want, +got): %s", cmp.Diff(want, clusters))
+	}
+	want := []string{
+		"knative-external:" + testNamespace + "/valid/0",
+		"knative-internal:" + testNamespace + "/valid/0",
+	}
+	if !cmp.Equal(virtualHosts, want) {
+		t.Errorf("Virtual hosts (-want, +got): %s", cmp.Diff(want, virtualHosts))
+	}
+}
+
+func TestReconcileSnapshotOncePerChange(t *testing.T) {
+	cache := &fakeCache{acked: true}
+	var c *Reconciler
+	factory := MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		c = newTestReconciler(ctx, listers, cmw, cache)
+		return c
+	})
+	factory(t, &TableRow{
+		Objects: []runtime.Object{
+			ingress("first", withReady),
+			ingress("second", withReady),
+		},
+	})
+
+	ctx := context.Background()
+	for _, key := range []string{testNamespace + "/first", testNamespace + "/second", testNamespace + "/first"} {
+		if err := c.Reconcile(ctx, key); err != nil {
+			t.Fatalf("Reconcile(%s) = %v", key, err)
+		}
+	}
+	if got, want := cache.sets, 1; got != want {
+		t.Errorf("Snapshots published without changes = %d, want: %d", got, want)
+	}
+
+	c.invalidateSnapshot()
+	if err := c.Reconcile(ctx, testNamespace+"/second"); err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+	if got, want := cache.sets, 2; got != want {
+		t.Errorf("Snapshots published after a change = %d, want: %d", got, want)
+	}
+}
+
+func TestAffectsSnapshot(t *testing.T) {
+	deleted := ingress("foo")
+	deleted.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	moved := ingress("foo")
+	moved.Spec.Rules[0].Hosts = []string{"bar.example.com"}
+
+	tests := []struct {
+		name     string
+		old, new interface{}
+		want     bool
+	}{{
+		name: "status update",
+		old:  ingress("foo"),
+		new:  ingress("foo", withReady),
+	}, {
+		name: "spec update",
+		old:  ingress("foo"),
+		new:  moved,
+		want: true,
+	}, {
+		name: "deletion",
+		old:  ingress("foo"),
+		new:  deleted,
+		want: true,
+	}, {
+		name: "tombstone",
+		old:  ingress("foo"),
+		new:  "foo",
+		want: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := affectsSnapshot(test.old, test.new); got != test.want {
+				t.Errorf("affectsSnapshot() = %v, want: %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/golang/protobuf/ptypes"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/knative/serving/pkg/apis/networking"
+	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/envoy/api"
+	"github.com/knative/serving/pkg/network"
+)
+
+// ClusterName returns the name of the Envoy cluster sending traffic to
+// the given backend.
+func ClusterName(backend v1alpha1.IngressBackend) string {
+	return fmt.Sprintf("%s/%s:%s", backend.ServiceNamespace, backend.ServiceName, backend.ServicePort.String())
+}
+
+// MakeClusters creates one Envoy cluster for each distinct backend of the
+// given ingresses.  Clusters resolve the backend's K8s Service
+// through DNS, so they don't need to change when its endpoints do.
+func MakeClusters(ias []v1alpha1.IngressAccessor) ([]*api.Cluster, error) {
+	byName := make(map[string]*api.Cluster)
+	for _, ia := range ias {
+		for _, rule := range ia.GetSpec().Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				for _, split := range path.Splits {
+					name := ClusterName(split.IngressBackend)
+					if _, ok := byName[name]; ok {
+						continue
+					}
+					c, err := makeCluster(name, split.IngressBackend)
+					if err != nil {
+						return nil, fmt.Errorf("%s %q: %v", ia.GetGroupVersionKind().Kind, IngressKey(ia), err)
+					}
+					byName[name] = c
+				}
+			}
+		}
+	}
+
+	clusters := make([]*api.Cluster, 0, len(byName))
+	for _, c := range byName {
+		clusters = append(clusters, c)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Name < clusters[j].Name
+	})
+	return clusters, nil
+}
+
+// ValidateBackends checks that clusters can be created for all of the
+// backends of the ingress.  MakeClusters fails on ingresses which don't
+// pass, so they must be left out of the snapshot.
+func ValidateBackends(ia v1alpha1.IngressAccessor) error {
+	for _, rule := range ia.GetSpec().Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			for _, split := range path.Splits {
+				if _, err := servicePort(split.ServicePort); err != nil {
+					return fmt.Errorf("backend %s: %v", ClusterName(split.IngressBackend), err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func makeCluster(name string, backend v1alpha1.IngressBackend) (*api.Cluster, error) {
+	port, err := servicePort(backend.ServicePort)
+	if err != nil {
+		return nil, err
+	}
+	c := &api.Cluster{
+		Name:           name,
+		Type:           api.Cluster_STRICT_DNS,
+		ConnectTimeout: ptypes.DurationProto(network.DefaultConnTimeout),
+		LbPolicy:       api.Cluster_ROUND_ROBIN,
+		LoadAssignment: &api.ClusterLoadAssignment{
+			ClusterName: name,
+			Endpoints: []*api.LocalityLbEndpoints{{
+				LbEndpoints: []*api.LbEndpoint{{
+					Endpoint: &api.Endpoint{
+						Address: &api.Address{
+							SocketAddress: &api.SocketAddress{
+								Address:   network.GetServiceHostname(backend.ServiceName, backend.ServiceNamespace),
+								PortValue: port,
+							},
+						},
+					},
+				}},
+			}},
+		},
+	}
+	if port == networking.ServiceHTTP2Port {
+		c.Http2ProtocolOptions = &api.Http2ProtocolOptions{}
+	}
+	return c, nil
+}
+
+// servicePort resolves the backend's port.  Named ports are resolved
+// through the names Knative gives to the ports of its K8s Services.
+func servicePort(port intstr.IntOrString) (uint32, error) {
+	if port.Type == intstr.Int {
+		return uint32(port.IntVal), nil
+	}
+	switch port.StrVal {
+	case networking.ServicePortNameHTTP1:
+		return networking.ServiceHTTPPort, nil
+	case networking.ServicePortNameH2C:
+		return networking.ServiceHTTP2Port, nil
+	}
+	return 0, fmt.Errorf("unknown service port name %q", port.StrVal)
+}
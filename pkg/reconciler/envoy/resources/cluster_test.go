@@ -0,0 +1,87 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+)
+
+func TestMakeClusters(t *testing.T) {
+	h2 := ingress("h2", v1alpha1.IngressVisibilityExternalIP, "h2.example.com")
+	h2.Spec.Rules[0].HTTP.Paths[0].Splits[0].ServicePort = intstr.FromString("http2")
+
+	clusters, err := MakeClusters([]v1alpha1.IngressAccessor{
+		ingress("foo", v1alpha1.IngressVisibilityExternalIP, "foo.example.com"),
+		// Same backend as above.
+		ingress("foo", v1alpha1.IngressVisibilityClusterLocal, "foo.default.svc.cluster.local"),
+		h2,
+	})
+	if err != nil {
+		t.Fatalf("MakeClusters() = %v", err)
+	}
+	if got, want := len(clusters), 2; got != want {
+		t.Fatalf("len(Clusters) = %d, want: %d", got, want)
+	}
+
+	foo, h2c := clusters[0], clusters[1]
+	if got, want := foo.Name, "default/foo:80"; got != want {
+		t.Errorf("Name = %s, want: %s", got, want)
+	}
+	if foo.Http2ProtocolOptions != nil {
+		t.Error("Http2ProtocolOptions is set for an HTTP/1 backend")
+	}
+	addr := foo.LoadAssignment.Endpoints[0].LbEndpoints[0].Endpoint.Address.SocketAddress
+	if got, want := addr.Address, "foo.default.svc.cluster.local"; got != want {
+		t.Errorf("Address = %s, want: %s", got, want)
+	}
+	if got, want := addr.PortValue, uint32(80); got != want {
+		t.Errorf("PortValue = %d, want: %d", got, want)
+	}
+
+	if got, want := h2c.Name, "default/h2:http2"; got != want {
+		t.Errorf("Name = %s, want: %s", got, want)
+	}
+	if h2c.Http2ProtocolOptions == nil {
+		t.Error("Http2ProtocolOptions is not set for an HTTP/2 backend")
+	}
+	addr = h2c.LoadAssignment.Endpoints[0].LbEndpoints[0].Endpoint.Address.SocketAddress
+	if got, want := addr.PortValue, uint32(81); got != want {
+		t.Errorf("PortValue = %d, want: %d", got, want)
+	}
+}
+
+func TestMakeClustersUnknownPortName(t *testing.T) {
+	ci := ingress("foo", v1alpha1.IngressVisibilityExternalIP, "foo.example.com")
+	ci.Spec.Rules[0].HTTP.Paths[0].Splits[0].ServicePort = intstr.FromString("grpc")
+	if _, err := MakeClusters([]v1alpha1.IngressAccessor{ci}); err == nil {
+		t.Error("MakeClusters() = nil, wanted an error")
+	}
+	if err := ValidateBackends(ci); err == nil {
+		t.Error("ValidateBackends() = nil, wanted an error")
+	}
+}
+
+func TestValidateBackends(t *testing.T) {
+	ci := ingress("foo", v1alpha1.IngressVisibilityExternalIP, "foo.example.com")
+	if err := ValidateBackends(ci); err != nil {
+		t.Errorf("ValidateBackends() = %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/knative/serving/pkg/envoy/api"
+)
+
+const (
+	// ExternalName is the name of the listener and route configuration
+	// serving ClusterIngresses visible outside of the cluster.
+	ExternalName = "knative-external"
+
+	// InternalName is the name of the listener and route configuration
+	// serving all ClusterIngresses to callers within the cluster.
+	InternalName = "knative-internal"
+
+	// ExternalPort is the port Envoy listens on for external traffic.
+	ExternalPort = 8080
+
+	// InternalPort is the port Envoy listens on for cluster-local traffic.
+	InternalPort = 8081
+)
+
+// MakeListeners creates the external and internal Envoy listeners.  Each
+// of them pulls its routes over RDS from the route configuration of the
+// same name.
+func MakeListeners() ([]*api.Listener, error) {
+	external, err := makeListener(ExternalName, ExternalPort)
+	if err != nil {
+		return nil, err
+	}
+	internal, err := makeListener(InternalName, InternalPort)
+	if err != nil {
+		return nil, err
+	}
+	return []*api.Listener{external, internal}, nil
+}
+
+func makeListener(name string, port uint32) (*api.Listener, error) {
+	hcm, err := ptypes.MarshalAny(&api.HttpConnectionManager{
+		CodecType:  api.HttpConnectionManager_AUTO,
+		StatPrefix: name,
+		Rds: &api.Rds{
+			ConfigSource:    &api.ConfigSource{Ads: &api.AggregatedConfigSource{}},
+			RouteConfigName: name,
+		},
+		HttpFilters: []*api.HttpFilter{{
+			Name: api.RouterFilter,
+		}},
+		UpgradeConfigs: []*api.UpgradeConfig{{
+			UpgradeType: "websocket",
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &api.Listener{
+		Name: name,
+		Address: &api.Address{
+			SocketAddress: &api.SocketAddress{
+				Address:   "0.0.0.0",
+				PortValue: port,
+			},
+		},
+		FilterChains: []*api.FilterChain{{
+			Filters: []*api.Filter{{
+				Name:        api.HTTPConnectionManagerFilter,
+				TypedConfig: hcm,
+			}},
+		}},
+	}, nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/knative/serving/pkg/envoy/api"
+)
+
+func TestMakeListeners(t *testing.T) {
+	listeners, err := MakeListeners()
+	if err != nil {
+		t.Fatalf("MakeListeners() = %v", err)
+	}
+	if got, want := len(listeners), 2; got != want {
+		t.Fatalf("len(Listeners) = %d, want: %d", got, want)
+	}
+
+	for i, want := range []struct {
+		name string
+		port uint32
+	}{{
+		name: ExternalName,
+		port: ExternalPort,
+	}, {
+		name: InternalName,
+		port: InternalPort,
+	}} {
+		l := listeners[i]
+		if got := l.Name; got != want.name {
+			t.Errorf("Name = %s, want: %s", got, want.name)
+		}
+		if got := l.Address.SocketAddress.PortValue; got != want.port {
+			t.Errorf("PortValue = %d, want: %d", got, want.port)
+		}
+
+		filter := l.FilterChains[0].Filters[0]
+		if got, want := filter.Name, api.HTTPConnectionManagerFilter; got != want {
+			t.Errorf("Filter = %s, want: %s", got, want)
+		}
+		hcm := &api.HttpConnectionManager{}
+		if err := ptypes.UnmarshalAny(filter.TypedConfig, hcm); err != nil {
+			t.Fatalf("UnmarshalAny() = %v", err)
+		}
+		// Each listener serves the routes of its own route configuration.
+		if got := hcm.Rds.RouteConfigName; got != want.name {
+			t.Errorf("RouteConfigName = %s, want: %s", got, want.name)
+		}
+		if hcm.Rds.ConfigSource.Ads == nil {
+			t.Error("Routes are not fetched over ADS")
+		}
+		if got, want := hcm.HttpFilters[0].Name, api.RouterFilter; got != want {
+			t.Errorf("HttpFilter = %s, want: %s", got, want)
+		}
+		if got, want := hcm.UpgradeConfigs[0].UpgradeType, "websocket"; got != want {
+			t.Errorf("UpgradeType = %s, want: %s", got, want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/envoy/api"
+	"github.com/knative/serving/pkg/network"
+)
+
+// retryOn lists the conditions under which Envoy retries a request,
+// matching what Istio does for a VirtualService with retries.
+const retryOn = "gateway-error,connect-failure,refused-stream"
+
+// MakeRouteConfigurations creates the external and internal route
+// configurations for the given ingresses.  The external one only
+// holds the ingresses that are publicly visible.
+//
+// Envoy rejects a route configuration in which two virtual hosts claim the
+// same domain, so when ingresses overlap the one whose key sorts first wins.
+func MakeRouteConfigurations(ias []v1alpha1.IngressAccessor) []*api.RouteConfiguration {
+	external := &api.RouteConfiguration{Name: ExternalName}
+	internal := &api.RouteConfiguration{Name: InternalName}
+	externalDomains, internalDomains := sets.NewString(), sets.NewString()
+
+	for _, ia := range sortedByKey(ias) {
+		for i, rule := range ia.GetSpec().Rules {
+			name := fmt.Sprintf("%s/%d", IngressKey(ia), i)
+			domains := domainsForHosts(rule.Hosts)
+			if vh := makeVirtualHost(name, claim(internalDomains, domains), rule); vh != nil {
+				internal.VirtualHosts = append(internal.VirtualHosts, vh)
+			}
+			if !ia.IsPublic() {
+				continue
+			}
+			if vh := makeVirtualHost(name, claim(externalDomains, domains), rule); vh != nil {
+				external.VirtualHosts = append(external.VirtualHosts, vh)
+			}
+		}
+	}
+	return []*api.RouteConfiguration{external, internal}
+}
+
+// IngressKey returns the name of a ClusterIngress, and the namespace/name
+// of an Ingress, i.e. the key the ingress is enqueued with.
+func IngressKey(ia v1alpha1.IngressAccessor) string {
+	if ia.GetNamespace() == "" {
+		return ia.GetName()
+	}
+	return ia.GetNamespace() + "/" + ia.GetName()
+}
+
+func sortedByKey(ias []v1alpha1.IngressAccessor) []v1alpha1.IngressAccessor {
+	sorted := make([]v1alpha1.IngressAccessor, len(ias))
+	copy(sorted, ias)
+	sort.Slice(sorted, func(i, j int) bool {
+		return IngressKey(sorted[i]) < IngressKey(sorted[j])
+	})
+	return sorted
+}
+
+// claim returns the domains that haven't been claimed yet, and marks them
+// as claimed.
+func claim(claimed sets.String, domains []string) []string {
+	unclaimed := []string{}
+	for _, d := range domains {
+		if !claimed.Has(d) {
+			claimed.Insert(d)
+			unclaimed = append(unclaimed, d)
+		}
+	}
+	return unclaimed
+}
+
+// domainsForHosts returns the domains Envoy should match for the given
+// hosts: each host along with its shorter cluster-local forms, with and
+// without a port.
+func domainsForHosts(hosts []string) []string {
+	suffixes := []string{
+		"",
+		"." + network.GetClusterDomainName(),
+		".svc." + network.GetClusterDomainName(),
+	}
+	expanded := sets.NewString()
+	for _, h := range hosts {
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(h, suffix) {
+				expanded.Insert(strings.TrimSuffix(h, suffix))
+			}
+		}
+	}
+	domains := []string{}
+	for _, h := range expanded.List() {
+		domains = append(domains, h, h+":*")
+	}
+	return domains
+}
+
+func makeVirtualHost(name string, domains []string, rule v1alpha1.IngressRule) *api.VirtualHost {
+	if len(domains) == 0 || rule.HTTP == nil {
+		return nil
+	}
+	vh := &api.VirtualHost{
+		Name:    name,
+		Domains: domains,
+	}
+	for i := range rule.HTTP.Paths {
+		vh.Routes = append(vh.Routes, makeRoute(&rule.HTTP.Paths[i]))
+	}
+	return vh
+}
+
+func makeRoute(path *v1alpha1.HTTPIngressPath) *api.Route {
+	match := &api.RouteMatch{Prefix: "/"}
+	if path.Path != "" {
+		match = &api.RouteMatch{Regex: path.Path}
+	}
+
+	weighted := &api.WeightedCluster{}
+	total := uint32(0)
+	for _, split := range path.Splits {
+		if split.Percent == 0 {
+			continue
+		}
+		total += uint32(split.Percent)
+		weighted.Clusters = append(weighted.Clusters, &api.WeightedCluster_ClusterWeight{
+			Name:                ClusterName(split.IngressBackend),
+			Weight:              &wrappers.UInt32Value{Value: uint32(split.Percent)},
+			RequestHeadersToAdd: makeHeaders(split.AppendHeaders),
+		})
+	}
+	if total == 0 {
+		// Envoy rejects a weighted cluster without any weight, so answer
+		// like a route without healthy backends instead.
+		return &api.Route{
+			Match:          match,
+			DirectResponse: &api.DirectResponseAction{Status: http.StatusServiceUnavailable},
+		}
+	}
+	weighted.TotalWeight = &wrappers.UInt32Value{Value: total}
+
+	action := &api.RouteAction{
+		WeightedClusters: weighted,
+		HostRewrite:      path.RewriteHost,
+	}
+	if path.Timeout != nil {
+		action.Timeout = ptypes.DurationProto(path.Timeout.Duration)
+	}
+	if path.Retries != nil && path.Retries.Attempts > 0 {
+		action.RetryPolicy = &api.RetryPolicy{
+			RetryOn:    retryOn,
+			NumRetries: &wrappers.UInt32Value{Value: uint32(path.Retries.Attempts)},
+		}
+		if path.Retries.PerTryTimeout != nil {
+			action.RetryPolicy.PerTryTimeout = ptypes.DurationProto(path.Retries.PerTryTimeout.Duration)
+		}
+	}
+
+	return &api.Route{
+		Match:               match,
+		Route:               action,
+		RequestHeadersToAdd: makeHeaders(path.AppendHeaders),
+	}
+}
+
+func makeHeaders(headers map[string]string) []*api.HeaderValueOption {
+	if len(headers) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	opts := make([]*api.HeaderValueOption, 0, len(keys))
+	for _, k := range keys {
+		opts = append(opts, &api.HeaderValueOption{
+			Header: &api.HeaderValue{Key: k, Value: headers[k]},
+			Append: &wrappers.BoolValue{Value: false},
+		})
+	}
+	return opts
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/envoy/api"
+)
+
+func ingress(name string, visibility v1alpha1.IngressVisibility, hosts ...string) *v1alpha1.ClusterIngress {
+	return &v1alpha1.ClusterIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1alpha1.IngressSpec{
+			Visibility: visibility,
+			Rules: []v1alpha1.IngressRule{{
+				Hosts: hosts,
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "default",
+								ServiceName:      name,
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent: 100,
+						}},
+					}},
+				},
+			}},
+		},
+	}
+}
+
+func TestMakeRouteConfigurationsVisibility(t *testing.T) {
+	rcs := MakeRouteConfigurations([]v1alpha1.IngressAccessor{
+		ingress("public", v1alpha1.IngressVisibilityExternalIP, "public.example.com"),
+		ingress("private", v1alpha1.IngressVisibilityClusterLocal, "private.default.svc.cluster.local"),
+	})
+	if got, want := len(rcs), 2; got != want {
+		t.Fatalf("len(RouteConfigurations) = %d, want: %d", got, want)
+	}
+	external, internal := rcs[0], rcs[1]
+	if external.Name != ExternalName || internal.Name != InternalName {
+		t.Errorf("Names = %s, %s, want: %s, %s", external.Name, internal.Name, ExternalName, InternalName)
+	}
+
+	if got, want := len(external.VirtualHosts), 1; got != want {
+		t.Fatalf("len(external.VirtualHosts) = %d, want: %d", got, want)
+	}
+	if got, want := external.VirtualHosts[0].Name, "public/0"; got != want {
+		t.Errorf("external VirtualHost = %s, want: %s", got, want)
+	}
+
+	if got, want := len(internal.VirtualHosts), 2; got != want {
+		t.Fatalf("len(internal.VirtualHosts) = %d, want: %d", got, want)
+	}
+	// Sorted by ingress key.
+	private := internal.VirtualHosts[0]
+	if got, want := private.Name, "private/0"; got != want {
+		t.Errorf("internal VirtualHost = %s, want: %s", got, want)
+	}
+	wantDomains := []string{
+		"private.default", "private.default:*",
+		"private.default.svc", "private.default.svc:*",
+		"private.default.svc.cluster.local", "private.default.svc.cluster.local:*",
+	}
+	if diff := cmp.Diff(wantDomains, private.Domains); diff != "" {
+		t.Errorf("Domains (-want, +got): %s", diff)
+	}
+}
+
+func TestMakeRouteConfigurationsConflictingHosts(t *testing.T) {
+	rcs := MakeRouteConfigurations([]v1alpha1.IngressAccessor{
+		ingress("b", v1alpha1.IngressVisibilityExternalIP, "foo.example.com", "bar.example.com"),
+		ingress("a", v1alpha1.IngressVisibilityExternalIP, "foo.example.com"),
+	})
+	external := rcs[0]
+	if got, want := len(external.VirtualHosts), 2; got != want {
+		t.Fatalf("len(VirtualHosts) = %d, want: %d", got, want)
+	}
+	if diff := cmp.Diff([]string{"foo.example.com", "foo.example.com:*"}, external.VirtualHosts[0].Domains); diff != "" {
+		t.Errorf("a's Domains (-want, +got): %s", diff)
+	}
+	if diff := cmp.Diff([]string{"bar.example.com", "bar.example.com:*"}, external.VirtualHosts[1].Domains); diff != "" {
+		t.Errorf("b's Domains (-want, +got): %s", diff)
+	}
+}
+
+func TestMakeRouteConfigurationsNamespacedIngress(t *testing.T) {
+	ci := ingress("foo", v1alpha1.IngressVisibilityExternalIP, "foo.example.com")
+	rcs := MakeRouteConfigurations([]v1alpha1.IngressAccessor{
+		&v1alpha1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+			Spec:       ci.Spec,
+		},
+	})
+	external := rcs[0]
+	if got, want := len(external.VirtualHosts), 1; got != want {
+		t.Fatalf("len(VirtualHosts) = %d, want: %d", got, want)
+	}
+	if got, want := external.VirtualHosts[0].Name, "default/foo/0"; got != want {
+		t.Errorf("VirtualHost = %s, want: %s", got, want)
+	}
+}
+
+func TestMakeRoute(t *testing.T) {
+	path := &v1alpha1.HTTPIngressPath{
+		Path: "^/foo.*",
+		Splits: []v1alpha1.IngressBackendSplit{{
+			IngressBackend: v1alpha1.IngressBackend{
+				ServiceNamespace: "ns",
+				ServiceName:      "v1",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent:       90,
+			AppendHeaders: map[string]string{"Knative-Serving-Revision": "v1"},
+		}, {
+			IngressBackend: v1alpha1.IngressBackend{
+				ServiceNamespace: "ns",
+				ServiceName:      "v2",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 10,
+		}, {
+			IngressBackend: v1alpha1.IngressBackend{
+				ServiceNamespace: "ns",
+				ServiceName:      "v3",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 0,
+		}},
+		AppendHeaders: map[string]string{"b": "2", "a": "1"},
+		Timeout:       &metav1.Duration{Duration: 10 * time.Second},
+		Retries: &v1alpha1.HTTPRetry{
+			Attempts:      3,
+			PerTryTimeout: &metav1.Duration{Duration: time.Second},
+		},
+	}
+	want := &api.Route{
+		Match: &api.RouteMatch{Regex: "^/foo.*"},
+		Route: &api.RouteAction{
+			WeightedClusters: &api.WeightedCluster{
+				Clusters: []*api.WeightedCluster_ClusterWeight{{
+					Name:   "ns/v1:80",
+					Weight: &wrappers.UInt32Value{Value: 90},
+					RequestHeadersToAdd: []*api.HeaderValueOption{{
+						Header: &api.HeaderValue{Key: "Knative-Serving-Revision", Value: "v1"},
+						Append: &wrappers.BoolValue{},
+					}},
+				}, {
+					Name:   "ns/v2:80",
+					Weight: &wrappers.UInt32Value{Value: 10},
+				}},
+				TotalWeight: &wrappers.UInt32Value{Value: 100},
+			},
+			Timeout: ptypes.DurationProto(10 * time.Second),
+			RetryPolicy: &api.RetryPolicy{
+				RetryOn:       retryOn,
+				NumRetries:    &wrappers.UInt32Value{Value: 3},
+				PerTryTimeout: ptypes.DurationProto(time.Second),
+			},
+		},
+		RequestHeadersToAdd: []*api.HeaderValueOption{{
+			Header: &api.HeaderValue{Key: "a", Value: "1"},
+			Append: &wrappers.BoolValue{},
+		}, {
+			Header: &api.HeaderValue{Key: "b", Value: "2"},
+			Append: &wrappers.BoolValue{},
+		}},
+	}
+	if got := makeRoute(path); !proto.Equal(got, want) {
+		t.Errorf("makeRoute() = %v, want: %v", got, want)
+	}
+}
+
+func TestMakeRouteWithoutWeight(t *testing.T) {
+	for name, splits := range map[string][]v1alpha1.IngressBackendSplit{
+		"no splits": nil,
+		"zero percent": {{
+			IngressBackend: v1alpha1.IngressBackend{
+				ServiceNamespace: "ns",
+				ServiceName:      "v1",
+				ServicePort:      intstr.FromInt(80),
+			},
+		}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := makeRoute(&v1alpha1.HTTPIngressPath{Splits: splits})
+			want := &api.Route{
+				Match:          &api.RouteMatch{Prefix: "/"},
+				DirectResponse: &api.DirectResponseAction{Status: http.StatusServiceUnavailable},
+			}
+			if !proto.Equal(got, want) {
+				t.Errorf("makeRoute() = %v, want: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMakeRouteMatchesAllPaths(t *testing.T) {
+	got := makeRoute(&v1alpha1.HTTPIngressPath{}).Match
+	if want := (&api.RouteMatch{Prefix: "/"}); !proto.Equal(got, want) {
+		t.Errorf("Match = %v, want: %v", got, want)
+	}
+}
+
+func TestMakeRouteRewriteHost(t *testing.T) {
+	got := makeRoute(&v1alpha1.HTTPIngressPath{
+		Splits: []v1alpha1.IngressBackendSplit{{
+			IngressBackend: v1alpha1.IngressBackend{
+				ServiceNamespace: "ns",
+				ServiceName:      "legacy",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent:  100,
+			External: true,
+		}},
+		RewriteHost: "legacy.example.com",
+	})
+	if got.Route == nil || got.Route.HostRewrite != "legacy.example.com" {
+		t.Errorf("Route = %v, want host rewritten to legacy.example.com", got.Route)
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/knative/serving/pkg/network"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
 )
 
@@ -118,6 +119,34 @@ func NewIstioFromConfigMap(configMap *corev1.ConfigMap) (*Istio, error) {
 	}, nil
 }
 
+// SelectGateways returns the gateways among the given ones whose names are
+// listed in the comma-separated selection, or all of them when the selection
+// is empty.  It fails when the selection names a gateway that isn't among
+// the given ones.
+func SelectGateways(gateways []Gateway, selection string) ([]Gateway, error) {
+	selected := sets.NewString()
+	for _, name := range strings.Split(selection, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			selected.Insert(name)
+		}
+	}
+	if selected.Len() == 0 {
+		return gateways, nil
+	}
+
+	gws := []Gateway{}
+	for _, g := range gateways {
+		if selected.Has(g.GatewayName) {
+			gws = append(gws, g)
+			selected.Delete(g.GatewayName)
+		}
+	}
+	if selected.Len() > 0 {
+		return nil, fmt.Errorf("unknown gateway(s): %s", strings.Join(selected.List(), ", "))
+	}
+	return gws, nil
+}
+
 func removeMeshGateway(gateways []Gateway) []Gateway {
 	gws := []Gateway{}
 	for _, g := range gateways {
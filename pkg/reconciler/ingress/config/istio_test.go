@@ -140,3 +140,52 @@ func TestGatewayConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectGateways(t *testing.T) {
+	gateways := []Gateway{{
+		GatewayName: "knative-ingress-gateway",
+		ServiceURL:  "istio-ingressgateway.istio-system.svc.cluster.local",
+	}, {
+		GatewayName: "team-a-gateway",
+		ServiceURL:  "team-a-gateway.istio-system.svc.cluster.local",
+	}, {
+		GatewayName: "team-b-gateway",
+		ServiceURL:  "team-b-gateway.istio-system.svc.cluster.local",
+	}}
+	tests := []struct {
+		name      string
+		selection string
+		want      []Gateway
+		wantErr   bool
+	}{{
+		name: "no selection",
+		want: gateways,
+	}, {
+		name:      "blank selection",
+		selection: " , ",
+		want:      gateways,
+	}, {
+		name:      "single gateway",
+		selection: "team-a-gateway",
+		want:      gateways[1:2],
+	}, {
+		name:      "configured order is kept",
+		selection: "team-b-gateway, team-a-gateway",
+		want:      gateways[1:],
+	}, {
+		name:      "unknown gateway",
+		selection: "team-a-gateway,team-c-gateway",
+		wantErr:   true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectGateways(gateways, tt.selection)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SelectGateways() = %v, wantErr: %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("SelectGateways() (-want +got): %s", diff)
+			}
+		})
+	}
+}
@@ -18,28 +18,43 @@ package ingress
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
-	ingressinformer "github.com/knative/serving/pkg/client/injection/informers/networking/v1alpha1/ingress"
-	listers "github.com/knative/serving/pkg/client/listers/networking/v1alpha1"
-	"github.com/knative/serving/pkg/reconciler/ingress/config"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"knative.dev/pkg/apis/istio/v1alpha3"
 	gatewayinformer "knative.dev/pkg/client/injection/informers/istio/v1alpha3/gateway"
 	virtualserviceinformer "knative.dev/pkg/client/injection/informers/istio/v1alpha3/virtualservice"
-	"knative.dev/pkg/logging"
-
 	istiolisters "knative.dev/pkg/client/listers/istio/v1alpha3"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
+	endpointsinformer "knative.dev/pkg/injection/informers/kubeinformers/corev1/endpoints"
+	namespaceinformer "knative.dev/pkg/injection/informers/kubeinformers/corev1/namespace"
 	secretinformer "knative.dev/pkg/injection/informers/kubeinformers/corev1/secret"
+	serviceinformer "knative.dev/pkg/injection/informers/kubeinformers/corev1/service"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
 	"knative.dev/pkg/tracker"
 
 	"github.com/knative/serving/pkg/apis/networking"
 	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving"
+	ingressinformer "github.com/knative/serving/pkg/client/injection/informers/networking/v1alpha1/ingress"
+	listers "github.com/knative/serving/pkg/client/listers/networking/v1alpha1"
 	"github.com/knative/serving/pkg/network"
 	"github.com/knative/serving/pkg/reconciler"
-
-	corev1 "k8s.io/api/core/v1"
-	corev1listers "k8s.io/client-go/listers/core/v1"
-	"k8s.io/client-go/tools/cache"
+	"github.com/knative/serving/pkg/reconciler/ingress/config"
+	"github.com/knative/serving/pkg/reconciler/ingress/resources"
 )
 
 const (
@@ -55,8 +70,9 @@ var (
 	ingressFinalizer = ingressResource.String()
 )
 
-// Check that our Reconciler implements controller.Reconciler
+// Check that our Reconciler implements controller.Reconciler and ReconcilerAccessor
 var _ controller.Reconciler = (*Reconciler)(nil)
+var _ ReconcilerAccessor = (*Reconciler)(nil)
 
 // Reconciler implements IngressReconciler for Ingress resources.
 type Reconciler struct {
@@ -72,9 +88,26 @@ type BaseIngressReconciler struct {
 	VirtualServiceLister istiolisters.VirtualServiceLister
 	GatewayLister        istiolisters.GatewayLister
 	SecretLister         corev1listers.SecretLister
+	NamespaceLister      corev1listers.NamespaceLister
 	ConfigStore          reconciler.ConfigStore
 
-	Tracker tracker.Interface
+	Tracker       tracker.Interface
+	StatusManager StatusManager
+}
+
+// ReconcilerAccessor gives the BaseIngressReconciler access to the
+// resources of the ingress type that a Reconciler is responsible for.
+type ReconcilerAccessor interface {
+	// GetIngress returns the ingress with the given namespace and name.
+	GetIngress(ns, name string) (v1alpha1.IngressAccessor, error)
+	// PatchIngress patches the given ingress.
+	PatchIngress(ns, name string, pt types.PatchType, data []byte) (v1alpha1.IngressAccessor, error)
+	// UpdateIngress updates the given ingress.
+	UpdateIngress(v1alpha1.IngressAccessor) (v1alpha1.IngressAccessor, error)
+	// UpdateIngressStatus updates the status of the given ingress.
+	UpdateIngressStatus(v1alpha1.IngressAccessor) (v1alpha1.IngressAccessor, error)
+	// GetFinalizer returns the name of the finalizer of the ingress type.
+	GetFinalizer() string
 }
 
 // NewBaseIngressReconciler creates a new BaseIngressReconciler
@@ -82,12 +115,14 @@ func NewBaseIngressReconciler(ctx context.Context, controllerAgentName string, c
 	virtualServiceInformer := virtualserviceinformer.Get(ctx)
 	gatewayInformer := gatewayinformer.Get(ctx)
 	secretInformer := secretinformer.Get(ctx)
+	namespaceInformer := namespaceinformer.Get(ctx)
 
 	base := &BaseIngressReconciler{
 		Base:                 reconciler.NewBase(ctx, controllerAgentName, cmw),
 		VirtualServiceLister: virtualServiceInformer.Lister(),
 		GatewayLister:        gatewayInformer.Lister(),
 		SecretLister:         secretInformer.Lister(),
+		NamespaceLister:      namespaceInformer.Lister(),
 	}
 	return base
 }
@@ -121,11 +156,30 @@ func (r *Reconciler) Init(ctx context.Context, cmw configmap.Watcher, impl *cont
 		Handler:    controller.HandleAll(impl.Enqueue),
 	}
 	ingressInformer.Informer().AddEventHandler(ingressHandler)
+	SetupNamespaceHandler(ctx, ingressInformer.Informer(), ingressHandler)
 
 	virtualServiceInformer := virtualserviceinformer.Get(ctx)
 	virtualServiceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
-		FilterFunc: myFilterFunc,
-		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+		FilterFunc: reconciler.ChainFilterFuncs(
+			myFilterFunc,
+			// VirtualServices of ClusterIngresses are owned by a cluster-scoped resource.
+			controller.Filter(v1alpha1.SchemeGroupVersion.WithKind("Ingress")),
+		),
+		Handler: controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
+	r.Logger.Info("Setting up StatusManager")
+	endpointsInformer := endpointsinformer.Get(ctx)
+	serviceInformer := serviceinformer.Get(ctx)
+	statusProber := NewStatusProber(r.Logger.Named("status-manager"),
+		endpointsInformer.Lister(), serviceInformer.Lister(),
+		func(ia v1alpha1.IngressAccessor) {
+			impl.Enqueue(ia)
+		})
+	r.StatusManager = statusProber
+	// Probing restarts when the gateway pods change.
+	endpointsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: statusProber.UpdateGatewayEndpoints,
 	})
 
 	r.Logger.Info("Setting up ConfigMap receivers")
@@ -142,6 +196,24 @@ func (r *Reconciler) Init(ctx context.Context, cmw configmap.Watcher, impl *cont
 
 }
 
+// SetupNamespaceHandler resyncs every ingress through the given handler
+// when the gateway annotations of a Namespace change, since they select the
+// gateways of the ingresses of its Routes.
+func SetupNamespaceHandler(ctx context.Context, ingressInformer cache.SharedIndexInformer, handler cache.ResourceEventHandler) {
+	namespaceInformer := namespaceinformer.Get(ctx)
+	namespaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNs, newNs := oldObj.(*corev1.Namespace), newObj.(*corev1.Namespace)
+			for _, key := range []string{networking.GatewaysAnnotationKey, networking.LocalGatewaysAnnotationKey} {
+				if oldNs.Annotations[key] != newNs.Annotations[key] {
+					controller.SendGlobalUpdates(ingressInformer, handler)
+					return
+				}
+			}
+		},
+	})
+}
+
 // SetupSecretTracker initializes Secret Tracker
 func SetupSecretTracker(ctx context.Context, cmw configmap.Watcher, init ReconcilerInitializer, impl *controller.Impl) {
 
@@ -166,6 +238,525 @@ func SetupSecretTracker(ctx context.Context, cmw configmap.Watcher, init Reconci
 // converge the two. It then updates the Status block of the Ingress resource
 // with the current status of the resource.
 func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
-	// TBD
+	return r.ReconcileIngress(r.ConfigStore.ToContext(ctx), r, key)
+}
+
+// GetIngress implements ReconcilerAccessor.
+func (r *Reconciler) GetIngress(ns, name string) (v1alpha1.IngressAccessor, error) {
+	return r.ingressLister.Ingresses(ns).Get(name)
+}
+
+// PatchIngress implements ReconcilerAccessor.
+func (r *Reconciler) PatchIngress(ns, name string, pt types.PatchType, data []byte) (v1alpha1.IngressAccessor, error) {
+	return r.ServingClientSet.NetworkingV1alpha1().Ingresses(ns).Patch(name, pt, data)
+}
+
+// UpdateIngress implements ReconcilerAccessor.
+func (r *Reconciler) UpdateIngress(ia v1alpha1.IngressAccessor) (v1alpha1.IngressAccessor, error) {
+	return r.ServingClientSet.NetworkingV1alpha1().Ingresses(ia.GetNamespace()).Update(ia.(*v1alpha1.Ingress))
+}
+
+// UpdateIngressStatus implements ReconcilerAccessor.
+func (r *Reconciler) UpdateIngressStatus(ia v1alpha1.IngressAccessor) (v1alpha1.IngressAccessor, error) {
+	return r.ServingClientSet.NetworkingV1alpha1().Ingresses(ia.GetNamespace()).UpdateStatus(ia.(*v1alpha1.Ingress))
+}
+
+// GetFinalizer implements ReconcilerAccessor.
+func (r *Reconciler) GetFinalizer() string {
+	return ingressFinalizer
+}
+
+// ReconcileIngress retrieves the ingress identified by key through the given
+// ReconcilerAccessor, reconciles it, and then updates its Status block
+// regardless of whether the reconciliation errored out.
+func (r *BaseIngressReconciler) ReconcileIngress(ctx context.Context, ra ReconcilerAccessor, key string) error {
+	// Convert the namespace/name string into a distinct namespace and name
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		r.Logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+	logger := logging.FromContext(ctx)
+
+	original, err := ra.GetIngress(ns, name)
+	if apierrs.IsNotFound(err) {
+		// The resource may no longer exist, in which case we stop processing.
+		logger.Errorf("ingress %q in work queue no longer exists", key)
+		r.StatusManager.CancelIngress(ns, name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	// Don't modify the informers copy
+	ia := original.DeepCopyObject().(v1alpha1.IngressAccessor)
+	kind := ia.GetGroupVersionKind().Kind
+
+	// Reconcile this copy of the ingress and then write back any status
+	// updates regardless of whether the reconciliation errored out.
+	reconcileErr := r.reconcileIngress(ctx, ra, ia)
+	if equality.Semantic.DeepEqual(original.GetStatus(), ia.GetStatus()) {
+		// If we didn't change anything then don't call updateStatus.
+		// This is important because the copy we loaded from the informer's
+		// cache may be stale and we don't want to overwrite a prior update
+		// to status with this stale state.
+	} else {
+		if _, err = r.updateStatus(ra, ia); err != nil {
+			logger.Warnw(fmt.Sprintf("Failed to update %s status", kind), zap.Error(err))
+			r.Recorder.Eventf(ia, corev1.EventTypeWarning, "UpdateFailed",
+				"Failed to update status for %s %q: %v", kind, ia.GetName(), err)
+			return err
+		}
+
+		logger.Infof("Updated status for %s %q", kind, ia.GetName())
+		r.Recorder.Eventf(ia, corev1.EventTypeNormal, "Updated",
+			"Updated status for %s %q", kind, ia.GetName())
+	}
+	if reconcileErr != nil {
+		r.Recorder.Event(ia, corev1.EventTypeWarning, "InternalError", reconcileErr.Error())
+	}
+	return reconcileErr
+}
+
+// Update the Status of the ingress.  Caller is responsible for checking
+// for semantic differences before calling.
+func (r *BaseIngressReconciler) updateStatus(ra ReconcilerAccessor, desired v1alpha1.IngressAccessor) (v1alpha1.IngressAccessor, error) {
+	ia, err := ra.GetIngress(desired.GetNamespace(), desired.GetName())
+	if err != nil {
+		return nil, err
+	}
+	// If there's nothing to update, just return.
+	if equality.Semantic.DeepEqual(ia.GetStatus(), desired.GetStatus()) {
+		return ia, nil
+	}
+	// Don't modify the informers copy
+	existing := ia.DeepCopyObject().(v1alpha1.IngressAccessor)
+	existing.SetStatus(*desired.GetStatus())
+	return ra.UpdateIngressStatus(existing)
+}
+
+func (r *BaseIngressReconciler) reconcileIngress(ctx context.Context, ra ReconcilerAccessor, ia v1alpha1.IngressAccessor) error {
+	logger := logging.FromContext(ctx)
+	if ia.GetDeletionTimestamp() != nil {
+		r.StatusManager.CancelIngress(ia.GetNamespace(), ia.GetName())
+		return r.reconcileDeletion(ctx, ra, ia)
+	}
+
+	// We may be reading a version of the object that was stored at an older version
+	// and may not have had all of the assumed defaults specified.  This won't result
+	// in this getting written back to the API Server, but lets downstream logic make
+	// assumptions about defaulting.
+	ia.SetDefaults(ctx)
+
+	ia.GetStatus().InitializeConditions()
+	logger.Infof("Reconciling %s: %#v", ia.GetGroupVersionKind().Kind, ia)
+
+	gateways, err := r.selectGateways(ctx, ia)
+	if err != nil {
+		ia.GetStatus().MarkInvalidGateways(err.Error())
+		return err
+	}
+	names := gatewayNames(gateways)
+
+	// Tag the requests routed by the gateways with the hash of the spec,
+	// so that we can tell when they serve this version of the ingress.
+	probed := ia.DeepCopyObject().(v1alpha1.IngressAccessor)
+	hash, err := resources.InsertProbe(probed)
+	if err != nil {
+		return err
+	}
+	vses := resources.MakeVirtualServices(probed, names)
+
+	// First, create the VirtualServices.
+	logger.Infof("Creating/Updating VirtualServices")
+	if err := r.reconcileVirtualServices(ctx, ia, vses); err != nil {
+		// TODO(lichuqiang): should we explicitly mark the ingress as unready
+		// when error reconciling VirtualService?
+		return err
+	}
+	ia.GetStatus().MarkNetworkConfigured()
+
+	// The VirtualServices are only propagated to the gateways asynchronously,
+	// so we only mark the ingress as ready once all of the gateway pods
+	// answer probes with the current hash.
+	ready, err := r.StatusManager.IsReady(ctx, ia, hash, gatewayServiceURLs(gateways))
+	if err != nil {
+		return err
+	}
+	if ready {
+		ia.GetStatus().MarkLoadBalancerReady(getLBStatus(gatewayServiceURL(gateways)))
+	} else {
+		ia.GetStatus().MarkLoadBalancerNotReady()
+	}
+	ia.GetStatus().ObservedGeneration = ia.GetGeneration()
+
+	if enablesAutoTLS(ctx) {
+		if !ia.IsPublic() {
+			logger.Infof("%s %s is not public. So no need to configure TLS.", ia.GetGroupVersionKind().Kind, ia.GetName())
+			return nil
+		}
+
+		// Add the finalizer before adding `Servers` into Gateway so that we can be sure
+		// the `Servers` get cleaned up from Gateway.
+		if err := r.ensureFinalizer(ra, ia); err != nil {
+			return err
+		}
+
+		originSecrets, err := resources.GetSecrets(ia, r.SecretLister)
+		if err != nil {
+			return err
+		}
+		targetSecrets := resources.MakeSecrets(ctx, originSecrets, ia)
+		if err := r.reconcileCertSecrets(ctx, ia, targetSecrets); err != nil {
+			return err
+		}
+
+		// Servers are also removed from the gateways which are no longer
+		// selected, so every configured gateway is reconciled.
+		selected := sets.NewString(names...)
+		for _, gatewayName := range gatewayNames(config.FromContext(ctx).Istio.IngressGateways) {
+			desired := []v1alpha3.Server{}
+			if selected.Has(gatewayName) {
+				ns, err := resources.GatewayServiceNamespace(config.FromContext(ctx).Istio.IngressGateways, gatewayName)
+				if err != nil {
+					return err
+				}
+				if desired, err = resources.MakeServers(ia, ns, originSecrets); err != nil {
+					return err
+				}
+			}
+			if err := r.reconcileGateway(ctx, ia, gatewayName, desired); err != nil {
+				return err
+			}
+		}
+	}
+
+	// TODO(zhiminx): Mark Route status to indicate that Gateway is configured.
+	logger.Infof("%s successfully synced", ia.GetGroupVersionKind().Kind)
+	return nil
+}
+
+func enablesAutoTLS(ctx context.Context) bool {
+	return config.FromContext(ctx).Network.AutoTLS
+}
+
+func getLBStatus(gatewayServiceURL string) []v1alpha1.LoadBalancerIngressStatus {
+	// The ingress isn't load-balanced by any particular
+	// Service, but through a Service mesh.
+	if gatewayServiceURL == "" {
+		return []v1alpha1.LoadBalancerIngressStatus{
+			{MeshOnly: true},
+		}
+	}
+	return []v1alpha1.LoadBalancerIngressStatus{
+		{DomainInternal: gatewayServiceURL},
+	}
+}
+
+// selectGateways returns the gateways the given ingress is exposed to: the
+// ones configured for its visibility, narrowed down by the gateways
+// annotation of the ingress or, failing that, of its namespace.
+func (r *BaseIngressReconciler) selectGateways(ctx context.Context, ia v1alpha1.IngressAccessor) ([]config.Gateway, error) {
+	cfg := config.FromContext(ctx).Istio
+	gateways, key := cfg.LocalGateways, networking.LocalGatewaysAnnotationKey
+	if ia.IsPublic() {
+		gateways, key = cfg.IngressGateways, networking.GatewaysAnnotationKey
+	}
+
+	selection, ok := ia.GetAnnotations()[key]
+	if !ok {
+		ns, err := r.getNamespace(ia)
+		if err != nil {
+			return nil, err
+		}
+		if ns != nil {
+			selection = ns.Annotations[key]
+		}
+	}
+	return config.SelectGateways(gateways, selection)
+}
+
+// getNamespace returns the namespace of the Route behind the given ingress,
+// or nil if it is unknown.
+func (r *BaseIngressReconciler) getNamespace(ia v1alpha1.IngressAccessor) (*corev1.Namespace, error) {
+	name := ia.GetNamespace()
+	if name == "" {
+		name = ia.GetLabels()[serving.RouteNamespaceLabelKey]
+	}
+	if name == "" {
+		return nil, nil
+	}
+	ns, err := r.NamespaceLister.Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil, nil
+	}
+	return ns, err
+}
+
+// gatewayServiceURL return an address of a load-balancer that the given
+// gateways are exposed through, or empty string if none.
+func gatewayServiceURL(gateways []config.Gateway) string {
+	if len(gateways) > 0 {
+		return gateways[0].ServiceURL
+	}
+	return ""
+}
+
+// gatewayServiceURLs returns the addresses of all of the given gateways.
+func gatewayServiceURLs(gateways []config.Gateway) []string {
+	urls := []string{}
+	for _, gw := range gateways {
+		if gw.ServiceURL != "" {
+			urls = append(urls, gw.ServiceURL)
+		}
+	}
+	return dedup(urls)
+}
+
+func gatewayNames(gateways []config.Gateway) []string {
+	names := []string{}
+	for _, gw := range gateways {
+		names = append(names, gw.GatewayName)
+	}
+	return dedup(names)
+}
+
+func dedup(strs []string) []string {
+	existed := sets.NewString()
+	unique := []string{}
+	// We can't just do `sets.NewString(str)`, since we need to preserve the order.
+	for _, s := range strs {
+		if !existed.Has(s) {
+			existed.Insert(s)
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
+
+func (r *BaseIngressReconciler) reconcileVirtualServices(ctx context.Context, ia v1alpha1.IngressAccessor,
+	desired []*v1alpha3.VirtualService) error {
+	logger := logging.FromContext(ctx)
+	// First, create all needed VirtualServices.
+	kept := sets.NewString()
+	for _, d := range desired {
+		if err := r.reconcileVirtualService(ctx, ia, d); err != nil {
+			return err
+		}
+		kept.Insert(d.Name)
+	}
+	// Now, remove the extra ones.
+	vses, err := r.VirtualServiceLister.VirtualServices(resources.VirtualServiceNamespace(ia)).List(
+		labels.Set(map[string]string{
+			serving.RouteLabelKey:          ia.GetLabels()[serving.RouteLabelKey],
+			serving.RouteNamespaceLabelKey: ia.GetLabels()[serving.RouteNamespaceLabelKey]}).AsSelector())
+	if err != nil {
+		logger.Errorw("Failed to get VirtualServices", zap.Error(err))
+		return err
+	}
+	for _, vs := range vses {
+		n, ns := vs.Name, vs.Namespace
+		if kept.Has(n) {
+			continue
+		}
+		if err = r.SharedClientSet.NetworkingV1alpha3().VirtualServices(ns).Delete(n, &metav1.DeleteOptions{}); err != nil {
+			logger.Errorw("Failed to delete VirtualService", zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *BaseIngressReconciler) reconcileVirtualService(ctx context.Context, ia v1alpha1.IngressAccessor,
+	desired *v1alpha3.VirtualService) error {
+	logger := logging.FromContext(ctx)
+	ns := desired.Namespace
+	name := desired.Name
+
+	vs, err := r.VirtualServiceLister.VirtualServices(ns).Get(name)
+	if apierrs.IsNotFound(err) {
+		_, err = r.SharedClientSet.NetworkingV1alpha3().VirtualServices(ns).Create(desired)
+		if err != nil {
+			logger.Errorw("Failed to create VirtualService", zap.Error(err))
+			r.Recorder.Eventf(ia, corev1.EventTypeWarning, "CreationFailed",
+				"Failed to create VirtualService %q/%q: %v", ns, name, err)
+			return err
+		}
+		r.Recorder.Eventf(ia, corev1.EventTypeNormal, "Created",
+			"Created VirtualService %q", desired.Name)
+	} else if err != nil {
+		return err
+	} else if !metav1.IsControlledBy(vs, ia) {
+		// Surface an error in the ingress's status, and return an error.
+		ia.GetStatus().MarkResourceNotOwned("VirtualService", name)
+		return fmt.Errorf("%s: %q does not own VirtualService: %q", ia.GetGroupVersionKind().Kind, ia.GetName(), name)
+	} else if !equality.Semantic.DeepEqual(vs.Spec, desired.Spec) {
+		// Don't modify the informers copy
+		existing := vs.DeepCopy()
+		existing.Spec = desired.Spec
+		_, err = r.SharedClientSet.NetworkingV1alpha3().VirtualServices(ns).Update(existing)
+		if err != nil {
+			logger.Errorw("Failed to update VirtualService", zap.Error(err))
+			return err
+		}
+		r.Recorder.Eventf(ia, corev1.EventTypeNormal, "Updated",
+			"Updated status for VirtualService %q/%q", ns, name)
+	}
+
+	return nil
+}
+
+func (r *BaseIngressReconciler) ensureFinalizer(ra ReconcilerAccessor, ia v1alpha1.IngressAccessor) error {
+	finalizers := sets.NewString(ia.GetFinalizers()...)
+	if finalizers.Has(ra.GetFinalizer()) {
+		return nil
+	}
+
+	mergePatch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"finalizers":      append(ia.GetFinalizers(), ra.GetFinalizer()),
+			"resourceVersion": ia.GetResourceVersion(),
+		},
+	}
+
+	patch, err := json.Marshal(mergePatch)
+	if err != nil {
+		return err
+	}
+
+	_, err = ra.PatchIngress(ia.GetNamespace(), ia.GetName(), types.MergePatchType, patch)
+	return err
+}
+
+func (r *BaseIngressReconciler) reconcileDeletion(ctx context.Context, ra ReconcilerAccessor, ia v1alpha1.IngressAccessor) error {
+	logger := logging.FromContext(ctx)
+
+	// If our Finalizer is first, delete the `Servers` from Gateway for this ingress,
+	// and remove the finalizer.
+	if len(ia.GetFinalizers()) == 0 || ia.GetFinalizers()[0] != ra.GetFinalizer() {
+		return nil
+	}
+
+	logger.Infof("Cleaning up Gateway Servers for %s %s", ia.GetGroupVersionKind().Kind, ia.GetName())
+	// No desired Servers means deleting all of the existing Servers associated with the ingress.
+	// The gateway selection may have changed since they were added, so they
+	// are looked up on every configured gateway.
+	for _, gatewayName := range gatewayNames(config.FromContext(ctx).Istio.IngressGateways) {
+		if err := r.reconcileGateway(ctx, ia, gatewayName, []v1alpha3.Server{}); err != nil {
+			return err
+		}
+	}
+
+	// The Secrets copied for a namespaced ingress can't be owned by it, so
+	// they are not garbage collected along with it.
+	if ia.GetNamespace() != "" {
+		if err := r.deleteCertSecrets(ctx, ia); err != nil {
+			return err
+		}
+	}
+
+	// Update the ingress to remove the Finalizer.
+	logger.Info("Removing Finalizer")
+	ia.SetFinalizers(ia.GetFinalizers()[1:])
+	_, err := ra.UpdateIngress(ia)
+	return err
+}
+
+func (r *BaseIngressReconciler) reconcileGateway(ctx context.Context, ia v1alpha1.IngressAccessor, gatewayName string, desired []v1alpha3.Server) error {
+	// TODO(zhiminx): Need to handle the scenario when deleting ClusterIngress. In this scenario,
+	// the Gateway servers of the ClusterIngress need also be removed from Gateway.
+	logger := logging.FromContext(ctx)
+	gateway, err := r.GatewayLister.Gateways(system.Namespace()).Get(gatewayName)
+	if err != nil {
+		// Not like VirtualService, A default gateway needs to be existed.
+		// It should be installed when installing Knative.
+		logger.Errorw("Failed to get Gateway.", zap.Error(err))
+		return err
+	}
+
+	existing := resources.GetServers(gateway, ia)
+	existingHTTPServer := resources.GetHTTPServer(gateway)
+	if existingHTTPServer != nil {
+		existing = append(existing, *existingHTTPServer)
+	}
+
+	desiredHTTPServer := resources.MakeHTTPServer(config.FromContext(ctx).Network.HTTPProtocol)
+	if desiredHTTPServer != nil {
+		desired = append(desired, *desiredHTTPServer)
+	}
+
+	if equality.Semantic.DeepEqual(existing, desired) {
+		return nil
+	}
+
+	copy := gateway.DeepCopy()
+	copy = resources.UpdateGateway(copy, desired, existing)
+	if _, err := r.SharedClientSet.NetworkingV1alpha3().Gateways(copy.Namespace).Update(copy); err != nil {
+		logger.Errorw("Failed to update Gateway", zap.Error(err))
+		return err
+	}
+	r.Recorder.Eventf(ia, corev1.EventTypeNormal, "Updated",
+		"Updated Gateway %q/%q", gateway.Namespace, gateway.Name)
+	return nil
+}
+
+func (r *BaseIngressReconciler) reconcileCertSecrets(ctx context.Context, ia v1alpha1.IngressAccessor, desiredSecrets []*corev1.Secret) error {
+	for _, certSecret := range desiredSecrets {
+		if err := r.reconcileCertSecret(ctx, ia, certSecret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *BaseIngressReconciler) reconcileCertSecret(ctx context.Context, ia v1alpha1.IngressAccessor, desired *corev1.Secret) error {
+	// We track the origin and desired secrets so that desired secrets could be synced accordingly when the origin TLS certificate
+	// secret is refreshed.
+	r.Tracker.Track(resources.SecretRef(desired.Namespace, desired.Name), ia)
+	r.Tracker.Track(resources.SecretRef(desired.Labels[networking.OriginSecretNamespaceLabelKey], desired.Labels[networking.OriginSecretNameLabelKey]), ia)
+
+	logger := logging.FromContext(ctx)
+	existing, err := r.SecretLister.Secrets(desired.Namespace).Get(desired.Name)
+	if apierrs.IsNotFound(err) {
+		_, err = r.KubeClientSet.CoreV1().Secrets(desired.Namespace).Create(desired)
+		if err != nil {
+			logger.Errorw("Failed to create Certificate Secret", zap.Error(err))
+			r.Recorder.Eventf(ia, corev1.EventTypeWarning, "CreationFailed",
+				"Failed to create Secret %s/%s: %v", desired.Namespace, desired.Name, err)
+			return err
+		}
+		r.Recorder.Eventf(ia, corev1.EventTypeNormal, "Created",
+			"Created Secret %s/%s", desired.Namespace, desired.Name)
+	} else if err != nil {
+		return err
+	} else if !equality.Semantic.DeepEqual(existing.Data, desired.Data) {
+		// Don't modify the informers copy
+		copy := existing.DeepCopy()
+		copy.Data = desired.Data
+		_, err = r.KubeClientSet.CoreV1().Secrets(copy.Namespace).Update(copy)
+		if err != nil {
+			logger.Errorw("Failed to update target secret", zap.Error(err))
+			r.Recorder.Eventf(ia, corev1.EventTypeWarning, "UpdateFailed",
+				"Failed to update Secret %s/%s: %v", desired.Namespace, desired.Name, err)
+			return err
+		}
+		r.Recorder.Eventf(ia, corev1.EventTypeNormal, "Updated",
+			"Updated Secret %s/%s", copy.Namespace, copy.Name)
+	}
+	return nil
+}
+
+// deleteCertSecrets deletes the Secrets copied for the given namespaced ingress.
+func (r *BaseIngressReconciler) deleteCertSecrets(ctx context.Context, ia v1alpha1.IngressAccessor) error {
+	logger := logging.FromContext(ctx)
+	secrets, err := r.SecretLister.List(resources.IngressSecretSelector(ia))
+	if err != nil {
+		return err
+	}
+	for _, secret := range secrets {
+		if err := r.KubeClientSet.CoreV1().Secrets(secret.Namespace).Delete(secret.Name, &metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			logger.Errorw("Failed to delete Certificate Secret", zap.Error(err))
+			return err
+		}
+	}
 	return nil
 }
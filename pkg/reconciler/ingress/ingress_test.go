@@ -0,0 +1,453 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	// Inject our fakes
+	_ "github.com/knative/serving/pkg/client/injection/informers/networking/v1alpha1/ingress/fake"
+	fakesharedclient "knative.dev/pkg/client/injection/client/fake"
+	_ "knative.dev/pkg/client/injection/informers/istio/v1alpha3/gateway/fake"
+	_ "knative.dev/pkg/client/injection/informers/istio/v1alpha3/virtualservice/fake"
+	_ "knative.dev/pkg/injection/informers/kubeinformers/corev1/endpoints/fake"
+	_ "knative.dev/pkg/injection/informers/kubeinformers/corev1/namespace/fake"
+	_ "knative.dev/pkg/injection/informers/kubeinformers/corev1/secret/fake"
+	_ "knative.dev/pkg/injection/informers/kubeinformers/corev1/service/fake"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgotesting "k8s.io/client-go/testing"
+
+	"knative.dev/pkg/apis"
+	duckv1beta1 "knative.dev/pkg/apis/duck/v1beta1"
+	"knative.dev/pkg/apis/istio/v1alpha3"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	logtesting "knative.dev/pkg/logging/testing"
+	"knative.dev/pkg/system"
+	_ "knative.dev/pkg/system/testing"
+
+	apiconfig "github.com/knative/serving/pkg/apis/config"
+	"github.com/knative/serving/pkg/apis/networking"
+	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/network"
+	"github.com/knative/serving/pkg/reconciler"
+	"github.com/knative/serving/pkg/reconciler/ingress/config"
+	"github.com/knative/serving/pkg/reconciler/ingress/resources"
+
+	. "github.com/knative/serving/pkg/reconciler/testing/v1alpha1"
+	. "knative.dev/pkg/reconciler/testing"
+)
+
+const testNamespace = "test-ns"
+
+var (
+	defaultMaxRevisionTimeout = time.Duration(apiconfig.DefaultMaxRevisionTimeoutSeconds) * time.Second
+	teamAGatewayURL           = network.GetServiceHostname("team-a-gateway", "istio-system")
+)
+
+var (
+	nsIngressRules = []v1alpha1.IngressRule{{
+		Hosts: []string{
+			"domain.com",
+			"test-route.test-ns.svc.cluster.local",
+		},
+		HTTP: &v1alpha1.HTTPIngressRuleValue{
+			Paths: []v1alpha1.HTTPIngressPath{{
+				Splits: []v1alpha1.IngressBackendSplit{{
+					IngressBackend: v1alpha1.IngressBackend{
+						ServiceNamespace: testNamespace,
+						ServiceName:      "test-service",
+						ServicePort:      intstr.FromInt(80),
+					},
+					Percent: 100,
+				}},
+				Timeout: &metav1.Duration{Duration: defaultMaxRevisionTimeout},
+				Retries: &v1alpha1.HTTPRetry{
+					PerTryTimeout: &metav1.Duration{Duration: defaultMaxRevisionTimeout},
+					Attempts:      networking.DefaultRetryCount,
+				},
+			}},
+		},
+	}}
+
+	nsIngressTLS = []v1alpha1.IngressTLS{{
+		Hosts:             []string{"host-tls.example.com"},
+		SecretName:        "secret0",
+		SecretNamespace:   "istio-system",
+		ServerCertificate: "tls.crt",
+		PrivateKey:        "tls.key",
+	}}
+
+	// The gateway server of nsIngressTLS, named after the namespace
+	// and name of the Ingress.
+	nsIngressTLSServer = v1alpha3.Server{
+		Hosts: []string{"host-tls.example.com"},
+		Port: v1alpha3.Port{
+			Name:     "test-ns/reconciling-ingress:0",
+			Number:   443,
+			Protocol: v1alpha3.ProtocolHTTPS,
+		},
+		TLS: &v1alpha3.TLSOptions{
+			Mode:              v1alpha3.TLSModeSimple,
+			ServerCertificate: "tls.crt",
+			PrivateKey:        "tls.key",
+			CredentialName:    "test-ns-reconciling-ingress-uid",
+		},
+	}
+
+	// A server of a ClusterIngress with the same name.
+	clusterIngressServer = v1alpha3.Server{
+		Hosts: []string{"other.example.com"},
+		Port: v1alpha3.Port{
+			Name:     "reconciling-ingress:0",
+			Number:   443,
+			Protocol: v1alpha3.ProtocolHTTPS,
+		},
+		TLS: &v1alpha3.TLSOptions{
+			Mode:              v1alpha3.TLSModeSimple,
+			ServerCertificate: "tls.crt",
+			PrivateKey:        "tls.key",
+			CredentialName:    "other-secret",
+		},
+	}
+)
+
+func TestReconcile(t *testing.T) {
+	table := TableTest{{
+		Name: "bad workqueue key",
+		Key:  "too/many/parts",
+	}, {
+		Name: "key not found",
+		Key:  "foo/not-found",
+	}, {
+		Name: "create VirtualServices matching Ingress",
+		Objects: []runtime.Object{
+			nsIngress("no-virtualservice-yet"),
+		},
+		WantCreates: []runtime.Object{
+			resources.MakeMeshVirtualService(withNsProbe(nsIngress("no-virtualservice-yet"))),
+			resources.MakeIngressVirtualService(withNsProbe(nsIngress("no-virtualservice-yet")),
+				[]string{"knative-ingress-gateway", "team-a-gateway"}),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: nsIngressWithStatus("no-virtualservice-yet", readyStatus()),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "no-virtualservice-yet-mesh"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "no-virtualservice-yet"),
+			Eventf(corev1.EventTypeNormal, "Updated", "Updated status for Ingress %q", "no-virtualservice-yet"),
+		},
+		Key: "test-ns/no-virtualservice-yet",
+	}, {
+		Name: "select gateways through the Ingress annotation",
+		Objects: []runtime.Object{
+			withGateways(nsIngress("team-a"), "team-a-gateway"),
+			namespace(testNamespace, "knative-ingress-gateway"),
+		},
+		WantCreates: []runtime.Object{
+			resources.MakeMeshVirtualService(withNsProbe(withGateways(nsIngress("team-a"), "team-a-gateway"))),
+			resources.MakeIngressVirtualService(withNsProbe(withGateways(nsIngress("team-a"), "team-a-gateway")),
+				[]string{"team-a-gateway"}),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withGateways(nsIngressWithStatus("team-a", readyStatusWithDomain(teamAGatewayURL)), "team-a-gateway"),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "team-a-mesh"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "team-a"),
+			Eventf(corev1.EventTypeNormal, "Updated", "Updated status for Ingress %q", "team-a"),
+		},
+		Key: "test-ns/team-a",
+	}, {
+		Name: "select gateways through the Namespace annotation",
+		Objects: []runtime.Object{
+			nsIngress("team-a"),
+			namespace(testNamespace, "team-a-gateway"),
+		},
+		WantCreates: []runtime.Object{
+			resources.MakeMeshVirtualService(withNsProbe(nsIngress("team-a"))),
+			resources.MakeIngressVirtualService(withNsProbe(nsIngress("team-a")),
+				[]string{"team-a-gateway"}),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: nsIngressWithStatus("team-a", readyStatusWithDomain(teamAGatewayURL)),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "team-a-mesh"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "team-a"),
+			Eventf(corev1.EventTypeNormal, "Updated", "Updated status for Ingress %q", "team-a"),
+		},
+		Key: "test-ns/team-a",
+	}, {
+		Name:    "reject unknown gateways",
+		WantErr: true,
+		Objects: []runtime.Object{
+			withGateways(nsIngress("team-c"), "team-a-gateway,team-c-gateway"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withGateways(nsIngressWithStatus("team-c", invalidGatewaysStatus("unknown gateway(s): team-c-gateway")),
+				"team-a-gateway,team-c-gateway"),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Updated", "Updated status for Ingress %q", "team-c"),
+			Eventf(corev1.EventTypeWarning, "InternalError", "unknown gateway(s): team-c-gateway"),
+		},
+		Key: "test-ns/team-c",
+	}, {
+		Name: "delete Ingress",
+		// The Gateway and the Secret live outside of the namespace of the Ingress.
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			nsIngressBeingDeleted("reconciling-ingress"),
+			gateway("knative-ingress-gateway", []v1alpha3.Server{clusterIngressServer, nsIngressTLSServer}),
+			gateway("team-a-gateway", []v1alpha3.Server{clusterIngressServer}),
+			copiedSecret("reconciling-ingress"),
+		},
+		WantCreates: []runtime.Object{
+			// The creation of gateways are triggered when setting up the test.
+			gateway("knative-ingress-gateway", []v1alpha3.Server{clusterIngressServer, nsIngressTLSServer}),
+			gateway("team-a-gateway", []v1alpha3.Server{clusterIngressServer}),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			// Only the server of the Ingress is removed.
+			Object: gateway("knative-ingress-gateway", []v1alpha3.Server{clusterIngressServer}),
+		}, {
+			// Finalizer should be removed.
+			Object: withFinalizers(nsIngressBeingDeleted("reconciling-ingress")),
+		}},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: "istio-system",
+				Verb:      "delete",
+			},
+			Name: "test-ns-reconciling-ingress-uid",
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Updated", "Updated Gateway %q/%q", system.Namespace(), "knative-ingress-gateway"),
+		},
+		Key: "test-ns/reconciling-ingress",
+	}}
+
+	defer logtesting.ClearAll()
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		// As the gateway lister is backed by the informer cache, the
+		// gateways also need to exist in the fake client.  They are
+		// created in a stable order, since the creations are recorded.
+		gateways := []*v1alpha3.Gateway{}
+		for _, obj := range listers.GetSharedObjects() {
+			if gw, ok := obj.(*v1alpha3.Gateway); ok {
+				gateways = append(gateways, gw)
+			}
+		}
+		sort.Slice(gateways, func(i, j int) bool {
+			return gateways[i].Name < gateways[j].Name
+		})
+		for _, gw := range gateways {
+			fakesharedclient.Get(ctx).NetworkingV1alpha3().Gateways(gw.Namespace).Create(gw)
+		}
+
+		return &Reconciler{
+			BaseIngressReconciler: &BaseIngressReconciler{
+				Base:                 reconciler.NewBase(ctx, controllerAgentName, cmw),
+				VirtualServiceLister: listers.GetVirtualServiceLister(),
+				GatewayLister:        listers.GetGatewayLister(),
+				SecretLister:         listers.GetSecretLister(),
+				NamespaceLister:      listers.GetNamespaceLister(),
+				Tracker:              &NullTracker{},
+				ConfigStore: &testConfigStore{
+					config: &config.Config{
+						Istio: &config.Istio{
+							IngressGateways: []config.Gateway{{
+								GatewayName: "knative-ingress-gateway",
+								ServiceURL:  network.GetServiceHostname("istio-ingressgateway", "istio-system"),
+							}, {
+								GatewayName: "team-a-gateway",
+								ServiceURL:  teamAGatewayURL,
+							}},
+						},
+						Network: &network.Config{
+							HTTPProtocol: network.HTTPDisabled,
+						},
+					},
+				},
+				StatusManager: &fakeStatusManager{ready: true},
+			},
+			ingressLister: listers.GetIngressLister(),
+		}
+	}))
+}
+
+func readyStatus() v1alpha1.IngressStatus {
+	return readyStatusWithDomain(network.GetServiceHostname("istio-ingressgateway", "istio-system"))
+}
+
+func readyStatusWithDomain(domain string) v1alpha1.IngressStatus {
+	return v1alpha1.IngressStatus{
+		LoadBalancer: &v1alpha1.LoadBalancerStatus{
+			Ingress: []v1alpha1.LoadBalancerIngressStatus{
+				{DomainInternal: domain},
+			},
+		},
+		Status: duckv1beta1.Status{
+			ObservedGeneration: 1,
+			Conditions: duckv1beta1.Conditions{{
+				Type:     v1alpha1.IngressConditionLoadBalancerReady,
+				Status:   corev1.ConditionTrue,
+				Severity: apis.ConditionSeverityError,
+			}, {
+				Type:     v1alpha1.IngressConditionNetworkConfigured,
+				Status:   corev1.ConditionTrue,
+				Severity: apis.ConditionSeverityError,
+			}, {
+				Type:     v1alpha1.IngressConditionReady,
+				Status:   corev1.ConditionTrue,
+				Severity: apis.ConditionSeverityError,
+			}},
+		},
+	}
+}
+
+func invalidGatewaysStatus(message string) v1alpha1.IngressStatus {
+	status := v1alpha1.IngressStatus{}
+	status.InitializeConditions()
+	status.MarkInvalidGateways(message)
+	return status
+}
+
+func nsIngressWithStatus(name string, status v1alpha1.IngressStatus) *v1alpha1.Ingress {
+	return &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				serving.RouteLabelKey:          "test-route",
+				serving.RouteNamespaceLabelKey: testNamespace,
+			},
+			Generation:      1,
+			ResourceVersion: "v1",
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: nsIngressRules,
+		},
+		Status: status,
+	}
+}
+
+func nsIngress(name string) *v1alpha1.Ingress {
+	return nsIngressWithStatus(name, v1alpha1.IngressStatus{})
+}
+
+func nsIngressBeingDeleted(name string) *v1alpha1.Ingress {
+	ing := nsIngress(name)
+	ing.Spec.TLS = nsIngressTLS
+	ing.Finalizers = []string{ingressFinalizer}
+	t := metav1.NewTime(time.Unix(1e9, 0))
+	ing.DeletionTimestamp = &t
+	return ing
+}
+
+func withGateways(ing *v1alpha1.Ingress, gateways string) *v1alpha1.Ingress {
+	ing.Annotations = map[string]string{networking.GatewaysAnnotationKey: gateways}
+	return ing
+}
+
+func namespace(name, gateways string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{networking.GatewaysAnnotationKey: gateways},
+		},
+	}
+}
+
+func withFinalizers(ing *v1alpha1.Ingress, finalizers ...string) *v1alpha1.Ingress {
+	ing.Finalizers = finalizers
+	return ing
+}
+
+// withNsProbe returns a copy of the Ingress with the probe headers the
+// reconciler adds to the VirtualServices.
+func withNsProbe(ing *v1alpha1.Ingress) *v1alpha1.Ingress {
+	ing = ing.DeepCopy()
+	// The reconciler hashes the defaulted spec.
+	ing.SetDefaults(context.Background())
+	resources.InsertProbe(ing)
+	return ing
+}
+
+func gateway(name string, servers []v1alpha3.Server) *v1alpha3.Gateway {
+	return &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: system.Namespace(),
+		},
+		Spec: v1alpha3.GatewaySpec{
+			Servers: servers,
+		},
+	}
+}
+
+// copiedSecret returns the copy of the origin TLS Secret made for the
+// Ingress with the given name.
+func copiedSecret(name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ns-" + name + "-uid",
+			Namespace: "istio-system",
+			Labels: map[string]string{
+				networking.OriginSecretNameLabelKey:      "secret0",
+				networking.OriginSecretNamespaceLabelKey: "istio-system",
+				networking.IngressLabelKey:               name,
+				networking.IngressNamespaceLabelKey:      testNamespace,
+			},
+		},
+		Data: map[string][]byte{
+			"test-secret": []byte("abcd"),
+		},
+	}
+}
+
+type testConfigStore struct {
+	config *config.Config
+}
+
+func (t *testConfigStore) ToContext(ctx context.Context) context.Context {
+	return config.ToContext(ctx, t.config)
+}
+
+var _ reconciler.ConfigStore = (*testConfigStore)(nil)
+
+// fakeStatusManager reports every ingress as ready or not ready.
+type fakeStatusManager struct {
+	ready bool
+}
+
+func (m *fakeStatusManager) IsReady(context.Context, v1alpha1.IngressAccessor, string, []string) (bool, error) {
+	return m.ready, nil
+}
+
+func (m *fakeStatusManager) CancelIngress(string, string) {}
+
+var _ StatusManager = (*fakeStatusManager)(nil)
@@ -44,11 +44,11 @@ var placeholderServer = v1alpha3.Server{
 	},
 }
 
-// GetServers gets the `Servers` from `Gateway` that belongs to the given ClusterIngress.
-func GetServers(gateway *v1alpha3.Gateway, ci *v1alpha1.ClusterIngress) []v1alpha3.Server {
+// GetServers gets the `Servers` from `Gateway` that belongs to the given ingress.
+func GetServers(gateway *v1alpha3.Gateway, ia v1alpha1.IngressAccessor) []v1alpha3.Server {
 	servers := []v1alpha3.Server{}
 	for i := range gateway.Spec.Servers {
-		if belongsToClusterIngress(&gateway.Spec.Servers[i], ci) {
+		if belongsToIngress(&gateway.Spec.Servers[i], ia) {
 			servers = append(servers, gateway.Spec.Servers[i])
 		}
 	}
@@ -65,14 +65,24 @@ func GetHTTPServer(gateway *v1alpha3.Gateway) *v1alpha3.Server {
 	return nil
 }
 
-func belongsToClusterIngress(server *v1alpha3.Server, ci *v1alpha1.ClusterIngress) bool {
-	// The format of the portName should be "<clusteringress-name>:<number>".
+func belongsToIngress(server *v1alpha3.Server, ia v1alpha1.IngressAccessor) bool {
+	// The format of the portName should be "<clusteringress-name>:<number>",
+	// or "<ingress-namespace>/<ingress-name>:<number>" for namespaced ingresses.
 	// For example, route-test:0.
 	portNameSplits := strings.Split(server.Port.Name, ":")
 	if len(portNameSplits) != 2 {
 		return false
 	}
-	return portNameSplits[0] == ci.Name
+	return portNameSplits[0] == serverPortPrefix(ia)
+}
+
+// serverPortPrefix returns the prefix of the port names of the `Servers`
+// that belong to the given ingress.
+func serverPortPrefix(ia v1alpha1.IngressAccessor) string {
+	if ia.GetNamespace() == "" {
+		return ia.GetName()
+	}
+	return ia.GetNamespace() + "/" + ia.GetName()
 }
 
 // SortServers sorts `Server` according to its port name.
@@ -84,12 +94,12 @@ func SortServers(servers []v1alpha3.Server) []v1alpha3.Server {
 }
 
 // MakeServers creates the expected Gateway `Servers` based on the given
-// ClusterIngress.
-func MakeServers(ci *v1alpha1.ClusterIngress, gatewayServiceNamespace string, originSecrets map[string]*corev1.Secret) ([]v1alpha3.Server, error) {
+// ingress.
+func MakeServers(ia v1alpha1.IngressAccessor, gatewayServiceNamespace string, originSecrets map[string]*corev1.Secret) ([]v1alpha3.Server, error) {
 	servers := []v1alpha3.Server{}
 	// TODO(zhiminx): for the hosts that does not included in the ClusterIngressTLS but listed in the ClusterIngressRule,
 	// do we consider them as hosts for HTTP?
-	for i, tls := range ci.Spec.TLS {
+	for i, tls := range ia.GetSpec().TLS {
 		credentialName := tls.SecretName
 		// If the origin secret is not in the target namespace, then it should have been
 		// copied into the target namespace. So we use the name of the copy.
@@ -98,12 +108,12 @@ func MakeServers(ci *v1alpha1.ClusterIngress, gatewayServiceNamespace string, or
 			if !ok {
 				return nil, fmt.Errorf("unable to get the original secret %s/%s", tls.SecretNamespace, tls.SecretName)
 			}
-			credentialName = targetSecret(originSecret, ci)
+			credentialName = targetSecret(originSecret, ia)
 		}
 		servers = append(servers, v1alpha3.Server{
 			Hosts: tls.Hosts,
 			Port: v1alpha3.Port{
-				Name:     fmt.Sprintf("%s:%d", ci.Name, i),
+				Name:     fmt.Sprintf("%s:%d", serverPortPrefix(ia), i),
 				Number:   443,
 				Protocol: v1alpha3.ProtocolHTTPS,
 			},
@@ -21,14 +21,14 @@ import (
 )
 
 // IngressVirtualService returns the name of the VirtualService child
-// resource for given ClusterIngress that programs traffic for Ingress
+// resource for given ingress that programs traffic for Ingress
 // Gateways.
 func IngressVirtualService(i kmeta.Accessor) string {
 	return i.GetName()
 }
 
 // MeshVirtualService returns the name of the VirtualService child
-// resource for given ClusterIngress that programs traffic for Service
+// resource for given ingress that programs traffic for Service
 // Mesh.
 func MeshVirtualService(i kmeta.Accessor) string {
 	return i.GetName() + "-mesh"
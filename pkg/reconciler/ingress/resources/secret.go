@@ -25,14 +25,15 @@ import (
 	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 )
 
-// GetSecrets gets the all of the secrets referenced by the given ClusterIngress, and
+// GetSecrets gets the all of the secrets referenced by the given ingress, and
 // returns a map whose key is the a secret namespace/name key and value is pointer of the secret.
-func GetSecrets(ci *v1alpha1.ClusterIngress, secretLister corev1listers.SecretLister) (map[string]*corev1.Secret, error) {
+func GetSecrets(ia v1alpha1.IngressAccessor, secretLister corev1listers.SecretLister) (map[string]*corev1.Secret, error) {
 	secrets := map[string]*corev1.Secret{}
-	for _, tls := range ci.Spec.TLS {
+	for _, tls := range ia.GetSpec().TLS {
 		ref := secretKey(tls)
 		if _, ok := secrets[ref]; ok {
 			continue
@@ -47,7 +48,7 @@ func GetSecrets(ci *v1alpha1.ClusterIngress, secretLister corev1listers.SecretLi
 }
 
 // MakeSecrets makes copies of the origin Secrets under the namespace of Istio gateway service.
-func MakeSecrets(ctx context.Context, originSecrets map[string]*corev1.Secret, ci *v1alpha1.ClusterIngress) []*corev1.Secret {
+func MakeSecrets(ctx context.Context, originSecrets map[string]*corev1.Secret, ia v1alpha1.IngressAccessor) []*corev1.Secret {
 	gatewaySvcNamespaces := getAllGatewaySvcNamespaces(ctx)
 	secrets := []*corev1.Secret{}
 	for _, originSecret := range originSecrets {
@@ -57,31 +58,51 @@ func MakeSecrets(ctx context.Context, originSecrets map[string]*corev1.Secret, c
 				// as the origin namespace
 				continue
 			}
-			secrets = append(secrets, makeSecret(originSecret, ns, ci))
+			secrets = append(secrets, makeSecret(originSecret, ns, ia))
 		}
 	}
 	return secrets
 }
 
-func makeSecret(originSecret *corev1.Secret, targetNamespace string, ci *v1alpha1.ClusterIngress) *corev1.Secret {
-	return &corev1.Secret{
+func makeSecret(originSecret *corev1.Secret, targetNamespace string, ia v1alpha1.IngressAccessor) *corev1.Secret {
+	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      targetSecret(originSecret, ci),
+			Name:      targetSecret(originSecret, ia),
 			Namespace: targetNamespace,
 			Labels: map[string]string{
 				networking.OriginSecretNameLabelKey:      originSecret.Name,
 				networking.OriginSecretNamespaceLabelKey: originSecret.Namespace,
 			},
-			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ci)},
 		},
 		Data: originSecret.Data,
 		Type: originSecret.Type,
 	}
+	if ia.GetNamespace() == "" {
+		secret.OwnerReferences = []metav1.OwnerReference{*kmeta.NewControllerRef(ia)}
+	} else {
+		// Owner references can't cross namespaces, so the copies of a
+		// namespaced ingress are labeled and cleaned up explicitly.
+		secret.Labels[networking.IngressLabelKey] = ia.GetName()
+		secret.Labels[networking.IngressNamespaceLabelKey] = ia.GetNamespace()
+	}
+	return secret
 }
 
 // targetSecret returns the name of the Secret that is copied from the origin Secret.
-func targetSecret(originSecret *corev1.Secret, ci *v1alpha1.ClusterIngress) string {
-	return fmt.Sprintf("%s-%s", ci.Name, originSecret.UID)
+func targetSecret(originSecret *corev1.Secret, ia v1alpha1.IngressAccessor) string {
+	if ia.GetNamespace() == "" {
+		return fmt.Sprintf("%s-%s", ia.GetName(), originSecret.UID)
+	}
+	return fmt.Sprintf("%s-%s-%s", ia.GetNamespace(), ia.GetName(), originSecret.UID)
+}
+
+// IngressSecretSelector returns the label selector of the Secrets copied
+// for the given namespaced ingress.
+func IngressSecretSelector(ia v1alpha1.IngressAccessor) labels.Selector {
+	return labels.Set(map[string]string{
+		networking.IngressLabelKey:          ia.GetName(),
+		networking.IngressNamespaceLabelKey: ia.GetNamespace(),
+	}).AsSelector()
 }
 
 // SecretRef returns the ObjectReference of a secret given the namespace and name of the secret.
@@ -29,6 +29,7 @@ import (
 	"github.com/knative/serving/pkg/reconciler/ingress/config"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	kubeinformers "k8s.io/client-go/informers"
 	fakek8s "k8s.io/client-go/kubernetes/fake"
 )
@@ -174,3 +175,55 @@ func TestMakeSecrets(t *testing.T) {
 		})
 	}
 }
+
+func TestMakeSecrets_NamespacedIngress(t *testing.T) {
+	ctx := TestContextWithLogger(t)
+	ctx = config.ToContext(ctx, &config.Config{
+		Istio: &config.Istio{
+			IngressGateways: []config.Gateway{{
+				GatewayName: "test-gateway",
+				ServiceURL:  "istio-ingressgateway.istio-system.svc.cluster.local",
+			}},
+		},
+	})
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "test-ns",
+		},
+	}
+	originSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-secret",
+			Namespace: "knative-serving",
+			UID:       "1234",
+		},
+		Data: map[string][]byte{
+			"test-data": []byte("abcd"),
+		},
+	}
+	// Owner references can't cross namespaces, so the Secret is
+	// labeled with the Ingress instead.
+	expected := []*corev1.Secret{{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ns-ingress-1234",
+			Namespace: "istio-system",
+			Labels: map[string]string{
+				networking.OriginSecretNameLabelKey:      "test-secret",
+				networking.OriginSecretNamespaceLabelKey: "knative-serving",
+				networking.IngressLabelKey:               "ingress",
+				networking.IngressNamespaceLabelKey:      "test-ns",
+			},
+		},
+		Data: map[string][]byte{
+			"test-data": []byte("abcd"),
+		},
+	}}
+	secrets := MakeSecrets(ctx, map[string]*corev1.Secret{"knative-serving/test-secret": originSecret}, ing)
+	if diff := cmp.Diff(expected, secrets); diff != "" {
+		t.Errorf("Unexpected secrets (-want, +got): %v", diff)
+	}
+	if !IngressSecretSelector(ing).Matches(labels.Set(secrets[0].Labels)) {
+		t.Error("IngressSecretSelector() doesn't match the Secret made for the Ingress")
+	}
+}
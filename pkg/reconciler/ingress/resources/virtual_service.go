@@ -17,6 +17,8 @@ limitations under the License.
 package resources
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
@@ -38,31 +40,58 @@ import (
 )
 
 // VirtualServiceNamespace gives the namespace of the child
-// VirtualServices for a given ClusterIngress.
-func VirtualServiceNamespace(_ *v1alpha1.ClusterIngress) string {
+// VirtualServices for a given ingress.  VirtualServices of a namespaced
+// Ingress live next to it, so that they can be owned by it.
+func VirtualServiceNamespace(ia v1alpha1.IngressAccessor) string {
+	if ia.GetNamespace() != "" {
+		return ia.GetNamespace()
+	}
 	return system.Namespace()
 }
 
+// ingressLabelKey returns the label key used to tie a child resource to the
+// given ingress.
+func ingressLabelKey(ia v1alpha1.IngressAccessor) string {
+	if ia.GetNamespace() != "" {
+		return networking.IngressLabelKey
+	}
+	return networking.ClusterIngressLabelKey
+}
+
+// qualifiedGateways returns the names of the given gateways, which live in
+// the system namespace, as seen from the namespace of the VirtualService.
+func qualifiedGateways(vsNamespace string, gateways []string) []string {
+	if vsNamespace == system.Namespace() {
+		return gateways
+	}
+	qualified := make([]string, 0, len(gateways))
+	for _, gw := range gateways {
+		qualified = append(qualified, system.Namespace()+"/"+gw)
+	}
+	return qualified
+}
+
 // MakeIngressVirtualService creates Istio VirtualService as network
 // programming for Istio Gateways other than 'mesh'.
-func MakeIngressVirtualService(ci *v1alpha1.ClusterIngress, gateways []string) *v1alpha3.VirtualService {
+func MakeIngressVirtualService(ia v1alpha1.IngressAccessor, gateways []string) *v1alpha3.VirtualService {
+	ns := VirtualServiceNamespace(ia)
 	vs := &v1alpha3.VirtualService{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:            names.IngressVirtualService(ci),
-			Namespace:       VirtualServiceNamespace(ci),
-			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ci)},
-			Annotations:     ci.ObjectMeta.Annotations,
+			Name:            names.IngressVirtualService(ia),
+			Namespace:       ns,
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ia)},
+			Annotations:     ia.GetAnnotations(),
 		},
-		Spec: *makeVirtualServiceSpec(ci, gateways, expandedHosts(getHosts(ci))),
+		Spec: *makeVirtualServiceSpec(ia, qualifiedGateways(ns, gateways), expandedHosts(getHosts(ia))),
 	}
 
-	// Populate the ClusterIngress labels.
+	// Populate the ingress labels.
 	if vs.Labels == nil {
 		vs.Labels = make(map[string]string)
 	}
-	vs.Labels[networking.ClusterIngressLabelKey] = ci.Name
+	vs.Labels[ingressLabelKey(ia)] = ia.GetName()
 
-	ingressLabels := ci.Labels
+	ingressLabels := ia.GetLabels()
 	vs.Labels[serving.RouteLabelKey] = ingressLabels[serving.RouteLabelKey]
 	vs.Labels[serving.RouteNamespaceLabelKey] = ingressLabels[serving.RouteNamespaceLabelKey]
 	return vs
@@ -70,22 +99,22 @@ func MakeIngressVirtualService(ci *v1alpha1.ClusterIngress, gateways []string) *
 
 // MakeMeshVirtualService creates Istio VirtualService as network
 // programming for Istio network mesh.
-func MakeMeshVirtualService(ci *v1alpha1.ClusterIngress) *v1alpha3.VirtualService {
+func MakeMeshVirtualService(ia v1alpha1.IngressAccessor) *v1alpha3.VirtualService {
 	vs := &v1alpha3.VirtualService{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:            names.MeshVirtualService(ci),
-			Namespace:       VirtualServiceNamespace(ci),
-			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ci)},
-			Annotations:     ci.ObjectMeta.Annotations,
+			Name:            names.MeshVirtualService(ia),
+			Namespace:       VirtualServiceNamespace(ia),
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ia)},
+			Annotations:     ia.GetAnnotations(),
 		},
-		Spec: *makeVirtualServiceSpec(ci, []string{"mesh"}, retainLocals(getHosts(ci))),
+		Spec: *makeVirtualServiceSpec(ia, []string{"mesh"}, retainLocals(getHosts(ia))),
 	}
-	// Populate the ClusterIngress labels.
+	// Populate the ingress labels.
 	vs.Labels = resources.UnionMaps(
-		resources.FilterMap(ci.Labels, func(k string) bool {
+		resources.FilterMap(ia.GetLabels(), func(k string) bool {
 			return k != serving.RouteLabelKey && k != serving.RouteNamespaceLabelKey
 		}),
-		map[string]string{networking.ClusterIngressLabelKey: ci.Name})
+		map[string]string{ingressLabelKey(ia): ia.GetName()})
 	return vs
 }
 
@@ -93,23 +122,58 @@ func MakeMeshVirtualService(ci *v1alpha1.ClusterIngress) *v1alpha3.VirtualServic
 //
 // These VirtualService specifies which Gateways and Hosts that it applies to,
 // as well as the routing rules.
-func MakeVirtualServices(ci *v1alpha1.ClusterIngress, gateways []string) []*v1alpha3.VirtualService {
-	vss := []*v1alpha3.VirtualService{MakeMeshVirtualService(ci)}
+func MakeVirtualServices(ia v1alpha1.IngressAccessor, gateways []string) []*v1alpha3.VirtualService {
+	vss := []*v1alpha3.VirtualService{MakeMeshVirtualService(ia)}
 	if len(gateways) > 0 {
-		vss = append(vss, MakeIngressVirtualService(ci, gateways))
+		vss = append(vss, MakeIngressVirtualService(ia, gateways))
 	}
 	return vss
 }
 
-func makeVirtualServiceSpec(ci *v1alpha1.ClusterIngress, gateways []string, hosts []string) *v1alpha3.VirtualServiceSpec {
+// InsertProbe adds an AppendHeaders rule to every path of the ingress,
+// so that any request going through a gateway is tagged with the hash of the
+// ingress spec currently programmed on that gateway.  It returns the
+// hash, which is computed before the headers are added.  The hash covers the
+// rules, the TLS configuration and the visibility, as a change to any of them
+// reprograms the gateways.
+func InsertProbe(ia v1alpha1.IngressAccessor) (string, error) {
+	spec := ia.GetSpec()
+	bytes, err := json.Marshal(v1alpha1.IngressSpec{
+		TLS:        spec.TLS,
+		Rules:      spec.Rules,
+		Visibility: spec.Visibility,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize ingress spec: %v", err)
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256(bytes))
+
+	for _, rule := range ia.GetSpec().Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for i := range rule.HTTP.Paths {
+			if rule.HTTP.Paths[i].AppendHeaders == nil {
+				rule.HTTP.Paths[i].AppendHeaders = make(map[string]string)
+			}
+			rule.HTTP.Paths[i].AppendHeaders[network.HashHeaderName] = hash
+		}
+	}
+	return hash, nil
+}
+
+func makeVirtualServiceSpec(ia v1alpha1.IngressAccessor, gateways []string, hosts []string) *v1alpha3.VirtualServiceSpec {
 	spec := v1alpha3.VirtualServiceSpec{
 		Gateways: gateways,
 		Hosts:    hosts,
 	}
-	for _, rule := range ci.Spec.Rules {
+	for _, rule := range ia.GetSpec().Rules {
 		for _, p := range rule.HTTP.Paths {
 			hosts := intersect(rule.Hosts, hosts)
 			if len(hosts) != 0 {
+				if probe := makeProbeRoute(hosts, &p); probe != nil {
+					spec.HTTP = append(spec.HTTP, *probe)
+				}
 				spec.HTTP = append(spec.HTTP, *makeVirtualServiceRoute(hosts, &p))
 			}
 		}
@@ -169,9 +233,17 @@ func makeVirtualServiceRoute(hosts []string, http *v1alpha1.HTTPIngressPath) *v1
 	// 	}
 	// }
 
+	var rewrite *v1alpha3.HTTPRewrite
+	if http.RewriteHost != "" {
+		rewrite = &v1alpha3.HTTPRewrite{
+			Authority: http.RewriteHost,
+		}
+	}
+
 	return &v1alpha3.HTTPRoute{
 		Match:   matches,
 		Route:   weights,
+		Rewrite: rewrite,
 		Timeout: http.Timeout.Duration.String(),
 		Retries: &v1alpha3.HTTPRetry{
 			Attempts:      http.Retries.Attempts,
@@ -186,6 +258,44 @@ func makeVirtualServiceRoute(hosts []string, http *v1alpha1.HTTPIngressPath) *v1
 	}
 }
 
+// makeProbeRoute returns a route sending the probes of the gateways to the
+// splits of the path within Knative only, since external backends don't
+// answer them.  It returns nil if the path needs no such route, because
+// all or none of its splits are within Knative.
+func makeProbeRoute(hosts []string, http *v1alpha1.HTTPIngressPath) *v1alpha3.HTTPRoute {
+	var splits []v1alpha1.IngressBackendSplit
+	total := 0
+	for _, split := range http.Splits {
+		if !split.External && split.Percent > 0 {
+			splits = append(splits, split)
+			total += split.Percent
+		}
+	}
+	if len(splits) == 0 || len(splits) == len(http.Splits) {
+		return nil
+	}
+
+	// Spread the traffic of the external splits over the others.
+	remaining := 100
+	for i := range splits {
+		splits[i].Percent = splits[i].Percent * 100 / total
+		remaining -= splits[i].Percent
+	}
+	splits[0].Percent += remaining
+
+	probe := *http
+	probe.Splits = splits
+	route := makeVirtualServiceRoute(hosts, &probe)
+	for i := range route.Match {
+		route.Match[i].Headers = map[string]istiov1alpha1.StringMatch{
+			network.ProbeHeaderName: {
+				Exact: network.ProbeHeaderValue,
+			},
+		}
+	}
+	return route
+}
+
 func dedup(hosts []string) []string {
 	return sets.NewString(hosts...).List()
 }
@@ -242,9 +352,9 @@ func hostRegExp(host string) string {
 	return fmt.Sprintf("^%s%s$", regexp.QuoteMeta(host), portMatch)
 }
 
-func getHosts(ci *v1alpha1.ClusterIngress) []string {
-	hosts := make([]string, 0, len(ci.Spec.Rules))
-	for _, rule := range ci.Spec.Rules {
+func getHosts(ia v1alpha1.IngressAccessor) []string {
+	hosts := make([]string, 0, len(ia.GetSpec().Rules))
+	for _, rule := range ia.GetSpec().Rules {
 		hosts = append(hosts, rule.Hosts...)
 	}
 	return dedup(hosts)
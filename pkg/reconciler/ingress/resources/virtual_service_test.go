@@ -30,6 +30,7 @@ import (
 	"github.com/knative/serving/pkg/apis/networking"
 	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
 	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/network"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -261,6 +262,36 @@ func TestMakeIngressVirtualServiceSpec_CorrectGateways(t *testing.T) {
 	}
 }
 
+func TestMakeIngressVirtualService_NamespacedIngress(t *testing.T) {
+	ing := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "test-ns",
+			Labels: map[string]string{
+				serving.RouteLabelKey:          "test-route",
+				serving.RouteNamespaceLabelKey: "test-ns",
+			},
+		},
+		Spec: v1alpha1.IngressSpec{},
+	}
+	vs := MakeIngressVirtualService(ing, []string{"gateway-one"})
+	if got, want := vs.Namespace, "test-ns"; got != want {
+		t.Errorf("Namespace = %s, want: %s", got, want)
+	}
+	// The gateways live in the system namespace.
+	if diff := cmp.Diff([]string{system.Namespace() + "/gateway-one"}, vs.Spec.Gateways); diff != "" {
+		t.Errorf("Unexpected gateways (-want +got): %v", diff)
+	}
+	wantLabels := map[string]string{
+		networking.IngressLabelKey:     "test-ingress",
+		serving.RouteLabelKey:          "test-route",
+		serving.RouteNamespaceLabelKey: "test-ns",
+	}
+	if diff := cmp.Diff(wantLabels, vs.Labels); diff != "" {
+		t.Errorf("Unexpected labels (-want +got): %v", diff)
+	}
+}
+
 func TestMakeIngressVirtualServiceSpec_CorrectRoutes(t *testing.T) {
 	ci := &v1alpha1.ClusterIngress{
 		ObjectMeta: metav1.ObjectMeta{
@@ -507,6 +538,154 @@ func TestMakeVirtualServiceRoute_TwoTargets(t *testing.T) {
 	}
 }
 
+func TestMakeVirtualServiceRoute_RewriteHost(t *testing.T) {
+	ingressPath := &v1alpha1.HTTPIngressPath{
+		Splits: []v1alpha1.IngressBackendSplit{{
+			IngressBackend: v1alpha1.IngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      "external-service",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent:  100,
+			External: true,
+		}},
+		RewriteHost: "legacy.example.com",
+		Timeout:     &metav1.Duration{Duration: defaultMaxRevisionTimeout},
+		Retries: &v1alpha1.HTTPRetry{
+			PerTryTimeout: &metav1.Duration{Duration: defaultMaxRevisionTimeout},
+			Attempts:      networking.DefaultRetryCount,
+		},
+	}
+	route := makeVirtualServiceRoute([]string{"test.org"}, ingressPath)
+	expected := v1alpha3.HTTPRoute{
+		Match: []v1alpha3.HTTPMatchRequest{{
+			Authority: &istiov1alpha1.StringMatch{Regex: `^test\.org(?::\d{1,5})?$`},
+		}},
+		Route: []v1alpha3.HTTPRouteDestination{{
+			Destination: v1alpha3.Destination{
+				Host: "external-service.test-ns.svc.cluster.local",
+				Port: v1alpha3.PortSelector{Number: 80},
+			},
+			Weight: 100,
+		}},
+		Rewrite: &v1alpha3.HTTPRewrite{
+			Authority: "legacy.example.com",
+		},
+		Timeout: defaultMaxRevisionTimeout.String(),
+		Retries: &v1alpha3.HTTPRetry{
+			Attempts:      networking.DefaultRetryCount,
+			PerTryTimeout: defaultMaxRevisionTimeout.String(),
+		},
+		WebsocketUpgrade: true,
+	}
+	if diff := cmp.Diff(&expected, route); diff != "" {
+		t.Errorf("Unexpected route  (-want +got): %v", diff)
+	}
+}
+
+func TestMakeProbeRoute(t *testing.T) {
+	ingressPath := &v1alpha1.HTTPIngressPath{
+		Splits: []v1alpha1.IngressBackendSplit{{
+			IngressBackend: v1alpha1.IngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      "revision-service",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 50,
+		}, {
+			IngressBackend: v1alpha1.IngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      "new-revision-service",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 20,
+		}, {
+			IngressBackend: v1alpha1.IngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      "external-service",
+				ServicePort:      intstr.FromInt(8080),
+			},
+			Percent:  30,
+			External: true,
+		}},
+		AppendHeaders: map[string]string{
+			network.HashHeaderName: "hash",
+		},
+		Timeout: &metav1.Duration{Duration: defaultMaxRevisionTimeout},
+		Retries: &v1alpha1.HTTPRetry{
+			PerTryTimeout: &metav1.Duration{Duration: defaultMaxRevisionTimeout},
+			Attempts:      networking.DefaultRetryCount,
+		},
+	}
+	route := makeProbeRoute([]string{"test.org"}, ingressPath)
+	expected := v1alpha3.HTTPRoute{
+		Match: []v1alpha3.HTTPMatchRequest{{
+			Authority: &istiov1alpha1.StringMatch{Regex: `^test\.org(?::\d{1,5})?$`},
+			Headers: map[string]istiov1alpha1.StringMatch{
+				network.ProbeHeaderName: {Exact: network.ProbeHeaderValue},
+			},
+		}},
+		// The probes only go to the Revisions, in the same proportions.
+		Route: []v1alpha3.HTTPRouteDestination{{
+			Destination: v1alpha3.Destination{
+				Host: "revision-service.test-ns.svc.cluster.local",
+				Port: v1alpha3.PortSelector{Number: 80},
+			},
+			Weight: 72,
+		}, {
+			Destination: v1alpha3.Destination{
+				Host: "new-revision-service.test-ns.svc.cluster.local",
+				Port: v1alpha3.PortSelector{Number: 80},
+			},
+			Weight: 28,
+		}},
+		Timeout: defaultMaxRevisionTimeout.String(),
+		Retries: &v1alpha3.HTTPRetry{
+			Attempts:      networking.DefaultRetryCount,
+			PerTryTimeout: defaultMaxRevisionTimeout.String(),
+		},
+		DeprecatedAppendHeaders: map[string]string{
+			network.HashHeaderName: "hash",
+		},
+		WebsocketUpgrade: true,
+	}
+	if diff := cmp.Diff(&expected, route); diff != "" {
+		t.Errorf("Unexpected route  (-want +got): %v", diff)
+	}
+	// The path itself is left untouched.
+	if got := ingressPath.Splits[0].Percent; got != 50 {
+		t.Errorf("Percent = %d, want: 50", got)
+	}
+}
+
+func TestMakeProbeRoute_NotNeeded(t *testing.T) {
+	revision := v1alpha1.IngressBackendSplit{
+		IngressBackend: v1alpha1.IngressBackend{
+			ServiceNamespace: "test-ns",
+			ServiceName:      "revision-service",
+			ServicePort:      intstr.FromInt(80),
+		},
+		Percent: 100,
+	}
+	external := v1alpha1.IngressBackendSplit{
+		IngressBackend: v1alpha1.IngressBackend{
+			ServiceNamespace: "test-ns",
+			ServiceName:      "external-service",
+			ServicePort:      intstr.FromInt(80),
+		},
+		Percent:  100,
+		External: true,
+	}
+	for _, split := range []v1alpha1.IngressBackendSplit{revision, external} {
+		ingressPath := &v1alpha1.HTTPIngressPath{
+			Splits: []v1alpha1.IngressBackendSplit{split},
+		}
+		if route := makeProbeRoute([]string{"test.org"}, ingressPath); route != nil {
+			t.Errorf("makeProbeRoute(%s) = %v, want: nil", split.ServiceName, route)
+		}
+	}
+}
+
 func TestGetHosts_Duplicate(t *testing.T) {
 	ci := &v1alpha1.ClusterIngress{
 		Spec: v1alpha1.IngressSpec{
@@ -586,3 +765,96 @@ func TestGetExpandedHosts(t *testing.T) {
 		})
 	}
 }
+
+func TestInsertProbe(t *testing.T) {
+	ci := &v1alpha1.ClusterIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-ingress",
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts: []string{"domain.com"},
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "test-service",
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent: 100,
+						}},
+						AppendHeaders: map[string]string{"foo": "bar"},
+					}, {
+						Path: "^/pets/(.*?)?",
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "pets-service",
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent: 100,
+						}},
+					}},
+				},
+			}},
+		},
+	}
+	original := ci.DeepCopy()
+
+	hash, err := InsertProbe(ci)
+	if err != nil {
+		t.Fatalf("InsertProbe() = %v", err)
+	}
+	if hash == "" {
+		t.Fatal("InsertProbe() returned an empty hash")
+	}
+	paths := ci.Spec.Rules[0].HTTP.Paths
+	if diff := cmp.Diff(map[string]string{"foo": "bar", network.HashHeaderName: hash}, paths[0].AppendHeaders); diff != "" {
+		t.Errorf("AppendHeaders (-want, +got): %s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{network.HashHeaderName: hash}, paths[1].AppendHeaders); diff != "" {
+		t.Errorf("AppendHeaders (-want, +got): %s", diff)
+	}
+
+	// The hash only depends on the spec it was computed from.
+	if got, err := InsertProbe(original.DeepCopy()); err != nil {
+		t.Errorf("InsertProbe() = %v", err)
+	} else if got != hash {
+		t.Errorf("InsertProbe() = %s, want: %s", got, hash)
+	}
+
+	for _, test := range []struct {
+		name   string
+		change func(*v1alpha1.IngressSpec)
+	}{{
+		name: "rules",
+		change: func(spec *v1alpha1.IngressSpec) {
+			spec.Rules[0].HTTP.Paths[0].Splits[0].Percent = 50
+		},
+	}, {
+		name: "tls",
+		change: func(spec *v1alpha1.IngressSpec) {
+			spec.TLS = []v1alpha1.IngressTLS{{
+				Hosts:           []string{"domain.com"},
+				SecretName:      "secret0",
+				SecretNamespace: "knative-serving",
+			}}
+		},
+	}, {
+		name: "visibility",
+		change: func(spec *v1alpha1.IngressSpec) {
+			spec.Visibility = v1alpha1.IngressVisibilityClusterLocal
+		},
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			changed := original.DeepCopy()
+			test.change(&changed.Spec)
+			if got, err := InsertProbe(changed); err != nil {
+				t.Errorf("InsertProbe() = %v", err)
+			} else if got == hash {
+				t.Error("InsertProbe() returned the same hash for a different spec")
+			}
+		})
+	}
+}
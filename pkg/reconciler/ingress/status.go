@@ -0,0 +1,512 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/network"
+	"github.com/knative/serving/pkg/network/prober"
+	"github.com/knative/serving/pkg/reconciler/ingress/config"
+)
+
+const (
+	// probePeriod is how often a gateway pod is probed until it
+	// serves the expected configuration.
+	probePeriod = time.Second
+	// probeTimeout is how long a gateway pod is probed before the
+	// ingress is reported as failed and probing starts over.
+	probeTimeout = 30 * time.Second
+)
+
+// StatusManager checks whether the gateways serve the latest configuration
+// of an ingress.
+type StatusManager interface {
+	// IsReady returns whether every pod of the given gateways answers probes
+	// with the given hash of the ingress spec. When that's not known
+	// yet, probing starts in the background and the ingress is
+	// re-enqueued once it completes.
+	IsReady(ctx context.Context, ia v1alpha1.IngressAccessor, hash string, gatewayServiceURLs []string) (bool, error)
+	// CancelIngress stops probing for the ingress with the given namespace
+	// and name. The namespace is empty for a ClusterIngress.
+	CancelIngress(ns, name string)
+}
+
+// ingressState tracks the probing of a single generation of an ingress.
+type ingressState struct {
+	ia   v1alpha1.IngressAccessor
+	key  types.NamespacedName
+	hash string
+	// id distinguishes the probes of this state from the ones of a previous
+	// state with the same hash, which may still be in flight.
+	id int
+	// gateways are the keys of the gateway Endpoints being probed.
+	gateways sets.String
+
+	// pending is the number of probes that haven't succeeded yet.
+	pending int
+	ready   bool
+	failed  bool
+
+	cancel context.CancelFunc
+}
+
+// probeItem is the opaque argument of a single probe.
+type probeItem struct {
+	state *ingressState
+	// pod is the address of the gateway pod the probe is sent to.
+	pod string
+	url string
+}
+
+// hostPath is a host and a path of an ingress to probe.
+type hostPath struct {
+	host   string
+	path   string
+	scheme string
+}
+
+// podAddressKey is the context key of the gateway pod address the
+// probes are sent to, whatever the host of their URL.
+type podAddressKey struct{}
+
+// StatusProber is a StatusManager that probes the gateway pods directly,
+// bypassing the gateway Services, so that every pod is known to be programmed.
+type StatusProber struct {
+	logger *zap.SugaredLogger
+
+	// mu guards ingressStates and lastID.
+	mu            sync.Mutex
+	ingressStates map[types.NamespacedName]*ingressState
+	lastID        int
+
+	prober          *prober.Manager
+	endpointsLister corev1listers.EndpointsLister
+	serviceLister   corev1listers.ServiceLister
+
+	readyCallback func(v1alpha1.IngressAccessor)
+}
+
+var _ StatusManager = (*StatusProber)(nil)
+
+// NewStatusProber creates a new instance of StatusProber. The readyCallback
+// is invoked when the probing of an ingress completes, successfully
+// or not.
+func NewStatusProber(logger *zap.SugaredLogger, endpointsLister corev1listers.EndpointsLister,
+	serviceLister corev1listers.ServiceLister, readyCallback func(v1alpha1.IngressAccessor)) *StatusProber {
+	m := &StatusProber{
+		logger:          logger,
+		ingressStates:   make(map[types.NamespacedName]*ingressState),
+		endpointsLister: endpointsLister,
+		serviceLister:   serviceLister,
+		readyCallback:   readyCallback,
+	}
+	m.prober = prober.New(m.onProbingDone, newProbeTransport)
+	return m
+}
+
+// newProbeTransport creates a transport which connects to the gateway pod
+// stored in the request context, so that the URL carries the host
+// of the ingress, which is then used for SNI.
+func newProbeTransport() http.RoundTripper {
+	dialer := &net.Dialer{Timeout: network.DefaultConnTimeout}
+	return &http.Transport{
+		DialContext: func(ctx context.Context, netw, addr string) (net.Conn, error) {
+			if pod, ok := ctx.Value(podAddressKey{}).(string); ok {
+				addr = pod
+			}
+			return dialer.DialContext(ctx, netw, addr)
+		},
+		// The gateways serve the certificates of the ingress hosts, which
+		// may not be issued yet.
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		DisableKeepAlives: true,
+	}
+}
+
+// IsReady implements StatusManager.
+func (m *StatusProber) IsReady(ctx context.Context, ia v1alpha1.IngressAccessor, hash string, gatewayServiceURLs []string) (bool, error) {
+	// Nothing to probe when the ingress is only served by the mesh.
+	if len(gatewayServiceURLs) == 0 {
+		return true, nil
+	}
+	key := types.NamespacedName{Namespace: ia.GetNamespace(), Name: ia.GetName()}
+
+	m.mu.Lock()
+	if state, ok := m.ingressStates[key]; ok {
+		if state.hash == hash && !state.failed {
+			m.mu.Unlock()
+			return state.ready, nil
+		}
+		// The spec changed, or probing failed: start over.
+		state.cancel()
+		delete(m.ingressStates, key)
+	}
+	m.mu.Unlock()
+
+	hostPaths := probeHostPaths(ia, servesHTTP(ctx, ia))
+	if len(hostPaths) == 0 {
+		return true, nil
+	}
+	targets, gateways, err := m.listTargets(gatewayServiceURLs)
+	if err != nil {
+		return false, err
+	}
+	pending := 0
+	for _, hp := range hostPaths {
+		if len(targets[hp.scheme]) == 0 {
+			return false, fmt.Errorf("no ready gateway pods serving %s behind %v", hp.scheme, gatewayServiceURLs)
+		}
+		pending += len(targets[hp.scheme])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastID++
+	state := &ingressState{
+		ia:       ia.DeepCopyObject().(v1alpha1.IngressAccessor),
+		key:      key,
+		hash:     hash,
+		id:       m.lastID,
+		gateways: gateways,
+		pending:  pending,
+		cancel:   cancel,
+	}
+	m.ingressStates[key] = state
+
+	for _, hp := range hostPaths {
+		u := (&url.URL{Scheme: hp.scheme, Host: hp.host, Path: hp.path}).String()
+		for _, pod := range targets[hp.scheme] {
+			item := &probeItem{state: state, pod: pod, url: u}
+			m.prober.OfferWithKey(context.WithValue(ctx, podAddressKey{}, pod),
+				fmt.Sprintf("%s/%d/%s/%s", key, state.id, pod, u), u, item,
+				probePeriod, probeTimeout,
+				prober.WithHost(hp.host),
+				prober.WithHeader(network.ProbeHeaderName, network.ProbeHeaderValue),
+				prober.ExpectsHeader(network.HashHeaderName, hash))
+		}
+	}
+	m.logger.Infof("Probing %d URLs on the gateway pods of %s %q", len(hostPaths),
+		ia.GetGroupVersionKind().Kind, key)
+	return false, nil
+}
+
+// CancelIngress implements StatusManager.
+func (m *StatusProber) CancelIngress(ns, name string) {
+	key := types.NamespacedName{Namespace: ns, Name: name}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if state, ok := m.ingressStates[key]; ok {
+		state.cancel()
+		delete(m.ingressStates, key)
+	}
+}
+
+// UpdateGatewayEndpoints restarts the probing in progress on the gateway
+// behind the given Endpoints when its pods change, so that new pods are
+// probed as well and removed ones don't fail the ingress.
+func (m *StatusProber) UpdateGatewayEndpoints(oldObj, newObj interface{}) {
+	oldEndpoints, ok := oldObj.(*corev1.Endpoints)
+	if !ok {
+		return
+	}
+	newEndpoints, ok := newObj.(*corev1.Endpoints)
+	if !ok || equality.Semantic.DeepEqual(oldEndpoints.Subsets, newEndpoints.Subsets) {
+		return
+	}
+	gateway := newEndpoints.Namespace + "/" + newEndpoints.Name
+
+	var restarted []v1alpha1.IngressAccessor
+	m.mu.Lock()
+	for key, state := range m.ingressStates {
+		if state.ready || state.failed || !state.gateways.Has(gateway) {
+			continue
+		}
+		state.cancel()
+		delete(m.ingressStates, key)
+		restarted = append(restarted, state.ia)
+	}
+	m.mu.Unlock()
+
+	for _, ia := range restarted {
+		m.readyCallback(ia)
+	}
+}
+
+func (m *StatusProber) onProbingDone(arg interface{}, success bool, err error) {
+	item := arg.(*probeItem)
+	state := item.state
+
+	m.mu.Lock()
+	if m.ingressStates[state.key] != state || state.failed || state.ready {
+		// Stale or already decided.
+		m.mu.Unlock()
+		return
+	}
+	if !success {
+		m.logger.Warnw(fmt.Sprintf("Gateway pod %s doesn't serve the latest configuration of %q for %s",
+			item.pod, state.key, item.url), zap.Error(err))
+		state.failed = true
+	} else if state.pending--; state.pending == 0 {
+		state.ready = true
+	}
+	done := state.failed || state.ready
+	m.mu.Unlock()
+
+	if done {
+		m.readyCallback(state.ia)
+	}
+}
+
+// listTargets returns the addresses of all the pods backing the given gateway
+// Services by URL scheme, along with the keys of the gateway Endpoints.
+func (m *StatusProber) listTargets(gatewayServiceURLs []string) (map[string][]string, sets.String, error) {
+	targets := map[string]sets.String{
+		"http":  sets.NewString(),
+		"https": sets.NewString(),
+	}
+	gateways := sets.NewString()
+	for _, gatewayServiceURL := range gatewayServiceURLs {
+		name, namespace, err := parseServiceURL(gatewayServiceURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		gateways.Insert(namespace + "/" + name)
+		service, err := m.serviceLister.Services(namespace).Get(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get gateway Service %s/%s: %v", namespace, name, err)
+		}
+		// The gateways follow the Istio port naming convention.
+		portNames := make(map[string]string, 2)
+		for _, port := range service.Spec.Ports {
+			if scheme := portScheme(port.Name); scheme != "" {
+				if _, ok := portNames[scheme]; !ok {
+					portNames[scheme] = port.Name
+				}
+			}
+		}
+		if _, ok := portNames["http"]; !ok {
+			return nil, nil, fmt.Errorf("gateway Service %s/%s has no http port", namespace, name)
+		}
+
+		endpoints, err := m.endpointsLister.Endpoints(namespace).Get(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get gateway Endpoints %s/%s: %v", namespace, name, err)
+		}
+		for _, subset := range endpoints.Subsets {
+			for scheme, portName := range portNames {
+				port, ok := endpointsPort(subset, portName)
+				if !ok {
+					continue
+				}
+				for _, addr := range subset.Addresses {
+					targets[scheme].Insert(net.JoinHostPort(addr.IP, strconv.Itoa(int(port))))
+				}
+			}
+		}
+	}
+	if targets["http"].Len() == 0 && targets["https"].Len() == 0 {
+		return nil, nil, fmt.Errorf("no ready gateway pods behind %v", gatewayServiceURLs)
+	}
+	return map[string][]string{
+		"http":  targets["http"].List(),
+		"https": targets["https"].List(),
+	}, gateways, nil
+}
+
+// portScheme returns the URL scheme served on the Service port with
+// the given name, or an empty string if it's neither HTTP nor HTTPS.
+func portScheme(name string) string {
+	switch {
+	case name == "http" || name == "http2" || strings.HasPrefix(name, "http-") || strings.HasPrefix(name, "http2-"):
+		return "http"
+	case name == "https" || strings.HasPrefix(name, "https-"):
+		return "https"
+	default:
+		return ""
+	}
+}
+
+// endpointsPort returns the port of the subset with the given name. Unnamed
+// Service ports yield unnamed Endpoints ports, so this works for both.
+func endpointsPort(subset corev1.EndpointSubset, name string) (int32, bool) {
+	for _, port := range subset.Ports {
+		if port.Name == name {
+			return port.Port, true
+		}
+	}
+	return 0, false
+}
+
+// parseServiceURL extracts the name and namespace of a Service from its
+// hostname, e.g. `istio-ingressgateway.istio-system.svc.cluster.local`.
+func parseServiceURL(serviceURL string) (string, string, error) {
+	parts := strings.SplitN(serviceURL, ".", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("unexpected gateway Service URL %q", serviceURL)
+	}
+	return parts[0], parts[1], nil
+}
+
+// servesHTTP returns whether the gateways serve the ingress over plain HTTP.
+// When auto TLS is on, the public gateways only do so if HTTP is enabled;
+// otherwise they redirect to HTTPS or don't listen at all.
+func servesHTTP(ctx context.Context, ia v1alpha1.IngressAccessor) bool {
+	return !ia.IsPublic() || !enablesAutoTLS(ctx) ||
+		config.FromContext(ctx).Network.HTTPProtocol == network.HTTPEnabled
+}
+
+// probeHostPaths returns every host of the ingress, with a path matched
+// by each of its routes. The hosts with a certificate are probed over
+// HTTPS when the ingress is public, since only then do the gateways
+// terminate TLS for them; the others are only probed if the gateways serve
+// plain HTTP. Rules only sending traffic outside of Knative are skipped,
+// as nothing there answers the probes.
+func probeHostPaths(ia v1alpha1.IngressAccessor, plainHTTP bool) []hostPath {
+	tlsHosts := sets.NewString()
+	if ia.IsPublic() {
+		for _, tls := range ia.GetSpec().TLS {
+			tlsHosts.Insert(tls.Hosts...)
+		}
+	}
+
+	seen := make(map[hostPath]bool)
+	var hostPaths []hostPath
+	for _, rule := range ia.GetSpec().Rules {
+		if !hasKnativeBackend(rule) {
+			continue
+		}
+		for _, host := range rule.Hosts {
+			scheme := "https"
+			if !tlsHosts.Has(host) {
+				if !plainHTTP {
+					continue
+				}
+				scheme = "http"
+			}
+			for _, path := range rule.HTTP.Paths {
+				if !isKnativePath(path) {
+					continue
+				}
+				hp := hostPath{host: host, path: samplePath(path.Path), scheme: scheme}
+				if !seen[hp] {
+					seen[hp] = true
+					hostPaths = append(hostPaths, hp)
+				}
+			}
+		}
+	}
+	return hostPaths
+}
+
+// samplePath returns a path matched by the given path regular expression,
+// or "/" if none is found.
+func samplePath(pattern string) string {
+	if pattern == "" {
+		return "/"
+	}
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "/"
+	}
+	var b strings.Builder
+	writeSample(&b, re.Simplify())
+	sample := b.String()
+	// The routes match the whole path.
+	if matched, err := regexp.MatchString("^(?:"+pattern+")$", sample); err != nil || !matched || !strings.HasPrefix(sample, "/") {
+		return "/"
+	}
+	return sample
+}
+
+// writeSample writes the shortest string matched by re, approximately.
+func writeSample(b *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		// Pick the first printable character of the class.
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1] && r < unicode.MaxASCII; r++ {
+				if r > ' ' {
+					b.WriteRune(r)
+					return
+				}
+			}
+		}
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteByte('a')
+	case syntax.OpCapture, syntax.OpPlus:
+		writeSample(b, re.Sub[0])
+	case syntax.OpRepeat:
+		for i := 0; i < re.Min; i++ {
+			writeSample(b, re.Sub[0])
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			writeSample(b, sub)
+		}
+	case syntax.OpAlternate:
+		writeSample(b, re.Sub[0])
+	}
+	// Anything else, e.g. `*`, `?` or anchors, matches the empty string.
+}
+
+// hasKnativeBackend returns whether any path of the rule sends traffic
+// within Knative.
+func hasKnativeBackend(rule v1alpha1.IngressRule) bool {
+	if rule.HTTP == nil {
+		return false
+	}
+	for _, path := range rule.HTTP.Paths {
+		if isKnativePath(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// isKnativePath returns whether the path sends traffic within Knative.
+func isKnativePath(path v1alpha1.HTTPIngressPath) bool {
+	for _, split := range path.Splits {
+		if !split.External {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,495 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/informers"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+
+	. "knative.dev/pkg/logging/testing"
+
+	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/network"
+	"github.com/knative/serving/pkg/reconciler/ingress/config"
+)
+
+const (
+	gatewayName      = "istio-ingressgateway"
+	gatewayNamespace = "istio-system"
+	testHash         = "deadbeef"
+)
+
+var testGatewayURL = network.GetServiceHostname(gatewayName, gatewayNamespace)
+
+func testIngress() *v1alpha1.ClusterIngress {
+	return &v1alpha1.ClusterIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "whatever",
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts: []string{"foo.bar.com"},
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Splits: []v1alpha1.IngressBackendSplit{{
+							IngressBackend: v1alpha1.IngressBackend{
+								ServiceNamespace: "default",
+								ServiceName:      "revision",
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent: 100,
+						}},
+					}},
+				},
+			}},
+		},
+	}
+}
+
+// newGateway returns a gateway pod which serves the given hash, and an
+// informer factory listing it as the only endpoint of the gateway Service.
+func newGateway(t *testing.T, hash string) (*httptest.Server, informers.SharedInformerFactory) {
+	ts := httptest.NewServer(gatewayHandler(hash))
+	return ts, gatewayInformers(t, ts, "http2")
+}
+
+// newTLSGateway is like newGateway, but the gateway pod serves HTTPS.
+func newTLSGateway(t *testing.T, hash string) (*httptest.Server, informers.SharedInformerFactory) {
+	ts := httptest.NewTLSServer(gatewayHandler(hash))
+	return ts, gatewayInformers(t, ts, "https")
+}
+
+func gatewayHandler(hash string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "foo.bar.com" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		r.Header.Set(network.HashHeaderName, hash)
+		network.NewProbeHandler(http.NotFoundHandler()).ServeHTTP(w, r)
+	})
+}
+
+// gatewayInformers returns an informer factory listing the given server
+// as the only endpoint of the gateway Service, on a port with the given name.
+func gatewayInformers(t *testing.T, ts *httptest.Server, portName string) informers.SharedInformerFactory {
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse URL %q: %v", ts.URL, err)
+	}
+	host, portStr, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		t.Fatalf("Failed to split host %q: %v", u.Host, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatalf("Failed to parse port %q: %v", portStr, err)
+	}
+
+	kubeClient := fakek8s.NewSimpleClientset(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gatewayName,
+			Namespace: gatewayNamespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{
+				Name: "http2",
+				Port: 80,
+			}, {
+				Name: "https",
+				Port: 443,
+			}},
+		},
+	}, &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gatewayName,
+			Namespace: gatewayNamespace,
+		},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: host}},
+			Ports: []corev1.EndpointPort{{
+				Name: portName,
+				Port: int32(port),
+			}},
+		}},
+	})
+	return informers.NewSharedInformerFactory(kubeClient, 0)
+}
+
+func testContext() context.Context {
+	return config.ToContext(context.Background(), &config.Config{
+		Istio: &config.Istio{},
+		Network: &network.Config{
+			AutoTLS:      true,
+			HTTPProtocol: network.HTTPEnabled,
+		},
+	})
+}
+
+func newTestProber(t *testing.T, factory informers.SharedInformerFactory, stopCh <-chan struct{},
+	ready chan v1alpha1.IngressAccessor) *StatusProber {
+	endpointsInformer := factory.Core().V1().Endpoints()
+	serviceInformer := factory.Core().V1().Services()
+	endpointsInformer.Informer()
+	serviceInformer.Informer()
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	return NewStatusProber(TestLogger(t), endpointsInformer.Lister(), serviceInformer.Lister(),
+		func(ia v1alpha1.IngressAccessor) {
+			ready <- ia
+		})
+}
+
+func TestIsReadyAfterProbing(t *testing.T) {
+	ts, factory := newGateway(t, testHash)
+	defer ts.Close()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	ready := make(chan v1alpha1.IngressAccessor)
+	prober := newTestProber(t, factory, stopCh, ready)
+
+	ci := testIngress()
+	if ok, err := prober.IsReady(testContext(), ci, testHash, []string{testGatewayURL}); err != nil {
+		t.Fatalf("IsReady() = %v", err)
+	} else if ok {
+		t.Fatal("IsReady() = true before probing")
+	}
+
+	select {
+	case got := <-ready:
+		if got.GetName() != ci.Name {
+			t.Errorf("Ready callback for %q, want: %q", got.GetName(), ci.Name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the ready callback")
+	}
+
+	if ok, err := prober.IsReady(testContext(), ci, testHash, []string{testGatewayURL}); err != nil {
+		t.Fatalf("IsReady() = %v", err)
+	} else if !ok {
+		t.Error("IsReady() = false after successful probing")
+	}
+}
+
+func TestIsReadyOverHTTPS(t *testing.T) {
+	ts, factory := newTLSGateway(t, testHash)
+	defer ts.Close()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	ready := make(chan v1alpha1.IngressAccessor)
+	prober := newTestProber(t, factory, stopCh, ready)
+
+	// The host has a certificate, so it's probed on the HTTPS port only.
+	ci := testIngress()
+	ci.Spec.TLS = []v1alpha1.IngressTLS{{Hosts: []string{"foo.bar.com"}}}
+	if ok, err := prober.IsReady(testContext(), ci, testHash, []string{testGatewayURL}); err != nil {
+		t.Fatalf("IsReady() = %v", err)
+	} else if ok {
+		t.Fatal("IsReady() = true before probing")
+	}
+
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the ready callback")
+	}
+	if ok, err := prober.IsReady(testContext(), ci, testHash, []string{testGatewayURL}); err != nil {
+		t.Fatalf("IsReady() = %v", err)
+	} else if !ok {
+		t.Error("IsReady() = false after successful probing")
+	}
+}
+
+func TestIsReadyWithStaleGateway(t *testing.T) {
+	ts, factory := newGateway(t, "stale")
+	defer ts.Close()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	ready := make(chan v1alpha1.IngressAccessor)
+	prober := newTestProber(t, factory, stopCh, ready)
+
+	ci := testIngress()
+	if ok, err := prober.IsReady(testContext(), ci, testHash, []string{testGatewayURL}); err != nil {
+		t.Fatalf("IsReady() = %v", err)
+	} else if ok {
+		t.Fatal("IsReady() = true before probing")
+	}
+
+	// Give the prober a few periods to see the stale hash.
+	select {
+	case <-ready:
+		t.Fatal("Ready callback invoked while the gateway serves a stale hash")
+	case <-time.After(3 * probePeriod):
+	}
+	if ok, err := prober.IsReady(testContext(), ci, testHash, []string{testGatewayURL}); err != nil {
+		t.Fatalf("IsReady() = %v", err)
+	} else if ok {
+		t.Error("IsReady() = true while the gateway serves a stale hash")
+	}
+	prober.CancelIngress(ci.Namespace, ci.Name)
+}
+
+func TestUpdateGatewayEndpointsRestartsProbing(t *testing.T) {
+	ts, factory := newGateway(t, "stale")
+	defer ts.Close()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	ready := make(chan v1alpha1.IngressAccessor, 1)
+	prober := newTestProber(t, factory, stopCh, ready)
+	defer prober.CancelIngress("", testIngress().Name)
+
+	ci := testIngress()
+	if ok, err := prober.IsReady(testContext(), ci, testHash, []string{testGatewayURL}); err != nil {
+		t.Fatalf("IsReady() = %v", err)
+	} else if ok {
+		t.Fatal("IsReady() = true before probing")
+	}
+
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gatewayName,
+			Namespace: gatewayNamespace,
+		},
+	}
+	// Unrelated Endpoints and unchanged pods leave the probing alone.
+	other := endpoints.DeepCopy()
+	other.Name = "other"
+	prober.UpdateGatewayEndpoints(other, &corev1.Endpoints{ObjectMeta: other.ObjectMeta,
+		Subsets: []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "1.2.3.4"}}}}})
+	prober.UpdateGatewayEndpoints(endpoints, endpoints.DeepCopy())
+	select {
+	case <-ready:
+		t.Fatal("Ready callback invoked without a change of the gateway pods")
+	case <-time.After(probePeriod):
+	}
+
+	changed := endpoints.DeepCopy()
+	changed.Subsets = []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "1.2.3.4"}}}}
+	prober.UpdateGatewayEndpoints(endpoints, changed)
+	select {
+	case got := <-ready:
+		if got.GetName() != ci.Name {
+			t.Errorf("Ready callback for %q, want: %q", got.GetName(), ci.Name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the callback restarting the probing")
+	}
+}
+
+func TestIsReadyWithoutGateways(t *testing.T) {
+	prober := NewStatusProber(TestLogger(t), nil, nil, func(v1alpha1.IngressAccessor) {
+		t.Error("Unexpected ready callback")
+	})
+	if ok, err := prober.IsReady(testContext(), testIngress(), testHash, nil); err != nil {
+		t.Fatalf("IsReady() = %v", err)
+	} else if !ok {
+		t.Error("IsReady() = false, want: true")
+	}
+}
+
+func TestIsReadyWithOnlyExternalBackends(t *testing.T) {
+	ts, factory := newGateway(t, "external")
+	defer ts.Close()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	prober := newTestProber(t, factory, stopCh, make(chan v1alpha1.IngressAccessor))
+
+	// Nothing outside of Knative answers the probes, so such rules aren't
+	// probed.
+	ci := testIngress()
+	ci.Spec.Rules[0].HTTP.Paths[0].Splits[0].External = true
+	if ok, err := prober.IsReady(testContext(), ci, testHash, []string{testGatewayURL}); err != nil {
+		t.Fatalf("IsReady() = %v", err)
+	} else if !ok {
+		t.Error("IsReady() = false, want: true")
+	}
+}
+
+func TestIsReadyWithoutEndpoints(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory := informers.NewSharedInformerFactory(fakek8s.NewSimpleClientset(), 0)
+	prober := newTestProber(t, factory, stopCh, make(chan v1alpha1.IngressAccessor))
+	if _, err := prober.IsReady(testContext(), testIngress(), testHash, []string{testGatewayURL}); err == nil {
+		t.Error("IsReady() = nil, wanted an error")
+	}
+}
+
+func TestParseServiceURL(t *testing.T) {
+	tests := []struct {
+		url       string
+		name      string
+		namespace string
+		wantErr   bool
+	}{{
+		url:       "istio-ingressgateway.istio-system.svc.cluster.local",
+		name:      "istio-ingressgateway",
+		namespace: "istio-system",
+	}, {
+		url:       "gateway.ns",
+		name:      "gateway",
+		namespace: "ns",
+	}, {
+		url:     "gateway",
+		wantErr: true,
+	}}
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			name, namespace, err := parseServiceURL(test.url)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseServiceURL() = %v, wantErr: %v", err, test.wantErr)
+			}
+			if name != test.name || namespace != test.namespace {
+				t.Errorf("parseServiceURL() = %s, %s, want: %s, %s", name, namespace, test.name, test.namespace)
+			}
+		})
+	}
+}
+
+func TestProbeHostPaths(t *testing.T) {
+	split := v1alpha1.IngressBackendSplit{
+		IngressBackend: v1alpha1.IngressBackend{
+			ServiceNamespace: "default",
+			ServiceName:      "revision",
+			ServicePort:      intstr.FromInt(80),
+		},
+		Percent: 100,
+	}
+	ci := &v1alpha1.ClusterIngress{
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts: []string{"foo.bar.com", "foo.default.svc.cluster.local"},
+				HTTP: &v1alpha1.HTTPIngressRuleValue{
+					Paths: []v1alpha1.HTTPIngressPath{{
+						Path:   "/api/v[0-9]+/.*",
+						Splits: []v1alpha1.IngressBackendSplit{split},
+					}, {
+						Splits: []v1alpha1.IngressBackendSplit{split},
+					}},
+				},
+			}},
+			TLS: []v1alpha1.IngressTLS{{
+				Hosts: []string{"foo.bar.com"},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		plainHTTP bool
+		want      []hostPath
+	}{{
+		name:      "http enabled",
+		plainHTTP: true,
+		want: []hostPath{
+			{host: "foo.bar.com", path: "/api/v0/", scheme: "https"},
+			{host: "foo.bar.com", path: "/", scheme: "https"},
+			{host: "foo.default.svc.cluster.local", path: "/api/v0/", scheme: "http"},
+			{host: "foo.default.svc.cluster.local", path: "/", scheme: "http"},
+		},
+	}, {
+		name: "http redirected",
+		want: []hostPath{
+			{host: "foo.bar.com", path: "/api/v0/", scheme: "https"},
+			{host: "foo.bar.com", path: "/", scheme: "https"},
+		},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := probeHostPaths(ci, test.plainHTTP)
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(hostPath{})); diff != "" {
+				t.Errorf("probeHostPaths() (-want, +got) = %v", diff)
+			}
+		})
+	}
+}
+
+func TestSamplePath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{{
+		pattern: "",
+		want:    "/",
+	}, {
+		pattern: "/",
+		want:    "/",
+	}, {
+		pattern: "/foo",
+		want:    "/foo",
+	}, {
+		pattern: "/api/.*",
+		want:    "/api/",
+	}, {
+		pattern: "/(users|groups)/[^/]+",
+		want:    "/users/!",
+	}, {
+		pattern: "/v[0-9]{2}/?",
+		want:    "/v00",
+	}, {
+		// Not a path.
+		pattern: "foo",
+		want:    "/",
+	}, {
+		// Not a valid regular expression.
+		pattern: "/(",
+		want:    "/",
+	}}
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			if got := samplePath(test.pattern); got != test.want {
+				t.Errorf("samplePath(%q) = %q, want: %q", test.pattern, got, test.want)
+			}
+		})
+	}
+}
+
+func TestPortScheme(t *testing.T) {
+	tests := map[string]string{
+		"http":        "http",
+		"http2":       "http",
+		"http-public": "http",
+		"https":       "https",
+		"https-443":   "https",
+		"tcp":         "",
+		"status-port": "",
+	}
+	for name, want := range tests {
+		if got := portScheme(name); got != want {
+			t.Errorf("portScheme(%q) = %q, want: %q", name, got, want)
+		}
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/knative/serving/pkg/reconciler/revision/resources"
 	presources "github.com/knative/serving/pkg/resources"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 )
 
@@ -104,6 +105,10 @@ func (c *Reconciler) createImageCache(ctx context.Context, rev *v1alpha1.Revisio
 	return c.CachingClientSet.CachingV1alpha1().Images(image.Namespace).Create(image)
 }
 
+func (c *Reconciler) createOutlierPeersService(ctx context.Context, service *corev1.Service) (*corev1.Service, error) {
+	return c.KubeClientSet.CoreV1().Services(service.Namespace).Create(service)
+}
+
 func (c *Reconciler) createKPA(ctx context.Context, rev *v1alpha1.Revision) (*kpav1alpha1.PodAutoscaler, error) {
 	kpa := resources.MakeKPA(rev)
 
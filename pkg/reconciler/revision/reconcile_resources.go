@@ -131,6 +131,29 @@ func (c *Reconciler) reconcileImageCache(ctx context.Context, rev *v1alpha1.Revi
 	return nil
 }
 
+func (c *Reconciler) reconcileOutlierPeers(ctx context.Context, rev *v1alpha1.Revision) error {
+	logger := logging.FromContext(ctx)
+
+	service := resources.MakeOutlierPeersService(rev)
+	if service == nil {
+		// The revision doesn't use outlier detection.
+		return nil
+	}
+	_, getServiceErr := c.serviceLister.Services(service.Namespace).Get(service.Name)
+	if apierrs.IsNotFound(getServiceErr) {
+		if _, err := c.createOutlierPeersService(ctx, service); err != nil {
+			logger.Errorf("Error creating outlier peers service %q: %v", service.Name, err)
+			return err
+		}
+		logger.Infof("Created outlier peers service %q", service.Name)
+	} else if getServiceErr != nil {
+		logger.Errorf("Error reconciling outlier peers service %q: %v", service.Name, getServiceErr)
+		return getServiceErr
+	}
+
+	return nil
+}
+
 func (c *Reconciler) reconcileKPA(ctx context.Context, rev *v1alpha1.Revision) error {
 	ns := rev.Namespace
 	kpaName := resourcenames.KPA(rev)
@@ -17,6 +17,7 @@ limitations under the License.
 package resources
 
 import (
+	"math"
 	"strconv"
 
 	"knative.dev/pkg/kmeta"
@@ -44,6 +45,10 @@ const (
 	varLogVolumePath   = "/var/log"
 	internalVolumeName = "knative-internal"
 	internalVolumePath = "/var/knative-internal"
+
+	userSocketVolumeName = "knative-user-socket"
+	userSocketVolumePath = "/var/run/knative"
+	userSocketPath       = userSocketVolumePath + "/user.sock"
 )
 
 var (
@@ -71,6 +76,18 @@ var (
 		MountPath: internalVolumePath,
 	}
 
+	userSocketVolume = corev1.Volume{
+		Name: userSocketVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	}
+
+	userSocketVolumeMount = corev1.VolumeMount{
+		Name:      userSocketVolumeName,
+		MountPath: userSocketVolumePath,
+	}
+
 	// This PreStop hook is actually calling an endpoint on the queue-proxy
 	// because of the way PreStop hooks are called by kubelet. We use this
 	// to block the user-container from exiting before the queue-proxy is ready
@@ -121,6 +138,13 @@ func makePodSpec(rev *v1alpha1.Revision, loggingConfig *logging.Config, observab
 	userContainer.Ports = buildContainerPorts(userPort)
 	userContainer.Env = append(userContainer.Env, buildUserPortEnv(userPortStr))
 	userContainer.Env = append(userContainer.Env, getKnativeEnvVar(rev)...)
+	if usesUnixSocket(rev) {
+		userContainer.VolumeMounts = append(userContainer.VolumeMounts, userSocketVolumeMount)
+		userContainer.Env = append(userContainer.Env, corev1.EnvVar{
+			Name:  "UNIX_SOCKET",
+			Value: userSocketPath,
+		})
+	}
 	// Explicitly disable stdin and tty allocation
 	userContainer.Stdin = false
 	userContainer.TTY = false
@@ -134,6 +158,11 @@ func makePodSpec(rev *v1alpha1.Revision, loggingConfig *logging.Config, observab
 		userContainer.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
 	}
 
+	// HTTP and TCP readiness probes are run by the queue-proxy, at a finer
+	// granularity than the kubelet's.
+	if isQueueProbe(userContainer.ReadinessProbe) {
+		userContainer.ReadinessProbe = nil
+	}
 	// If the client provides probes, we should fill in the port for them.
 	rewriteUserProbe(userContainer.ReadinessProbe, userPortInt)
 	rewriteUserProbe(userContainer.LivenessProbe, userPortInt)
@@ -145,17 +174,49 @@ func makePodSpec(rev *v1alpha1.Revision, loggingConfig *logging.Config, observab
 		},
 		Volumes:                       append([]corev1.Volume{varLogVolume}, rev.Spec.Volumes...),
 		ServiceAccountName:            rev.Spec.ServiceAccountName,
-		TerminationGracePeriodSeconds: rev.Spec.TimeoutSeconds,
+		TerminationGracePeriodSeconds: terminationGracePeriod(rev, deploymentConfig),
 	}
 
 	// Add the Knative internal volume only if /var/log collection is enabled
 	if observabilityConfig.EnableVarLogCollection {
 		podSpec.Volumes = append(podSpec.Volumes, internalVolume)
 	}
+	if usesUnixSocket(rev) {
+		podSpec.Volumes = append(podSpec.Volumes, userSocketVolume)
+	}
 
 	return podSpec
 }
 
+// terminationGracePeriod leaves the queue-proxy the time to drain, which
+// takes up to the drain delay plus the revision timeout (or the max
+// duration, if longer).
+func terminationGracePeriod(rev *v1alpha1.Revision, deploymentConfig *deployment.Config) *int64 {
+	if rev.Spec.TimeoutSeconds == nil {
+		return nil
+	}
+	timeout := *rev.Spec.TimeoutSeconds
+	if md := rev.Spec.MaxDurationSeconds; md != nil && *md > timeout {
+		timeout = *md
+	}
+	grace := timeout + int64(math.Ceil(deploymentConfig.QueueSidecarDrainDelay.Seconds()))
+	return &grace
+}
+
+// usesUnixSocket returns whether the queue-proxy reaches the user container
+// over a Unix domain socket rather than over TCP.
+func usesUnixSocket(rev *v1alpha1.Revision) bool {
+	b, _ := strconv.ParseBool(rev.Annotations[serving.QueueSideCarUnixSocketAnnotation])
+	return b
+}
+
+// usesGRPCHealthCheck returns whether the queue-proxy probes the user
+// container with the gRPC health checking protocol.
+func usesGRPCHealthCheck(rev *v1alpha1.Revision) bool {
+	b, _ := strconv.ParseBool(rev.Annotations[serving.QueueSideCarGRPCHealthCheckAnnotation])
+	return b
+}
+
 func getUserPort(rev *v1alpha1.Revision) int32 {
 	ports := rev.Spec.GetContainer().Ports
 
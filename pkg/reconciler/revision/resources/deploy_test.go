@@ -18,6 +18,7 @@ package resources
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -92,9 +93,38 @@ var (
 		}, {
 			Name:  "CONTAINER_CONCURRENCY",
 			Value: "0",
+		}, {
+			Name:  "CONTAINER_CONCURRENCY_MODE",
+			Value: "",
+		}, {
+			Name:  "CONTAINER_MIN_CONCURRENCY",
+			Value: "",
 		}, {
 			Name:  "REVISION_TIMEOUT_SECONDS",
 			Value: "45",
+		}, {
+			Name:  "REVISION_MAX_DURATION_SECONDS",
+			Value: "0",
+		}, {
+			Name:  "REVISION_IDLE_TIMEOUT_SECONDS",
+			Value: "0",
+		}, {
+			Name:  "QUEUE_DRAIN_DELAY",
+			Value: "0s",
+		}, {
+			Name: "QUEUE_RATE_LIMIT",
+		}, {
+			Name: "QUEUE_RATE_LIMIT_BURST",
+		}, {
+			Name: "QUEUE_POLICY",
+		}, {
+			Name: "QUEUE_OUTLIER_CONSECUTIVE_FAILURES",
+		}, {
+			Name: "QUEUE_OUTLIER_LATENCY_THRESHOLD",
+		}, {
+			Name: "QUEUE_OUTLIER_MAX_EJECTION_PERCENT",
+		}, {
+			Name: "QUEUE_OUTLIER_PEERS_HOST",
 		}, {
 			Name: "SERVING_POD",
 			ValueFrom: &corev1.EnvVarSource{
@@ -114,12 +144,27 @@ var (
 		}, {
 			Name:  "SERVING_REQUEST_LOG_TEMPLATE",
 			Value: "",
+		}, {
+			Name: "SERVING_REQUEST_LOG_FORMAT",
+		}, {
+			Name:  "SERVING_REQUEST_LOG_SAMPLE_RATE",
+			Value: "0",
+		}, {
+			Name: "SERVING_REQUEST_LOG_STATUS_CODES",
 		}, {
 			Name:  "SERVING_REQUEST_METRICS_BACKEND",
 			Value: "",
 		}, {
 			Name:  "USER_PORT",
 			Value: "8080",
+		}, {
+			Name: "USER_SOCKET",
+		}, {
+			Name:  "USER_GRPC_HEALTH_CHECK",
+			Value: "false",
+		}, {
+			Name:  "SERVING_READINESS_PROBE",
+			Value: "",
 		}, {
 			Name:  "SYSTEM_NAMESPACE",
 			Value: system.Namespace(),
@@ -272,17 +317,13 @@ func withHTTPReadinessProbe(port int) containerOption {
 	})
 }
 
-func withHTTPQPReadinessProbe(c *corev1.Container) {
-	withReadinessProbe(corev1.Handler{
-		HTTPGet: &corev1.HTTPGetAction{
-			Port: intstr.FromInt(networking.BackendHTTPPort),
-			Path: "/",
-			HTTPHeaders: []corev1.HTTPHeader{{
-				Name:  network.KubeletProbeHeaderName,
-				Value: "queue",
-			}},
+func withTCPReadinessProbe() containerOption {
+	return withReadinessProbe(corev1.Handler{
+		TCPSocket: &corev1.TCPSocketAction{
+			Host: "127.0.0.1",
+			Port: intstr.FromInt(12345),
 		},
-	})(c)
+	})
 }
 
 func withExecReadinessProbe(command []string) containerOption {
@@ -322,6 +363,12 @@ func withAppendedVolumes(volumes ...corev1.Volume) podSpecOption {
 	}
 }
 
+func withTerminationGracePeriod(seconds int64) podSpecOption {
+	return func(ps *corev1.PodSpec) {
+		ps.TerminationGracePeriodSeconds = refInt64(seconds)
+	}
+}
+
 func makeDeployment(opts ...deploymentOption) *appsv1.Deployment {
 	deploy := defaultDeployment.DeepCopy()
 	for _, option := range opts {
@@ -514,11 +561,63 @@ func TestMakePodSpec(t *testing.T) {
 		cc: &deployment.Config{},
 		want: podSpec(
 			[]corev1.Container{
-				userContainer(
-					withHTTPQPReadinessProbe,
+				userContainer(),
+				queueContainer(
+					withEnvVar("CONTAINER_CONCURRENCY", "0"),
+					withEnvVar("SERVING_READINESS_PROBE", `{"httpGet":{"path":"/","port":8080}}`),
+				),
+			}),
+	}, {
+		name: "with a drain delay",
+		rev:  revision(),
+		lc:   &logging.Config{},
+		oc:   &metrics.ObservabilityConfig{},
+		ac:   &autoscaler.Config{},
+		cc:   &deployment.Config{QueueSidecarDrainDelay: 10500 * time.Millisecond},
+		want: podSpec(
+			[]corev1.Container{
+				userContainer(),
+				queueContainer(
+					withEnvVar("CONTAINER_CONCURRENCY", "0"),
+					withEnvVar("QUEUE_DRAIN_DELAY", "10.5s"),
+				),
+			},
+			withTerminationGracePeriod(56)),
+	}, {
+		name: "with a max duration",
+		rev: revision(func(revision *v1alpha1.Revision) {
+			revision.Spec.MaxDurationSeconds = ptr.Int64(600)
+		}),
+		lc: &logging.Config{},
+		oc: &metrics.ObservabilityConfig{},
+		ac: &autoscaler.Config{},
+		cc: &deployment.Config{},
+		want: podSpec(
+			[]corev1.Container{
+				userContainer(),
+				queueContainer(
+					withEnvVar("CONTAINER_CONCURRENCY", "0"),
+					withEnvVar("REVISION_MAX_DURATION_SECONDS", "600"),
 				),
+			},
+			withTerminationGracePeriod(600)),
+	}, {
+		name: "with tcp readiness probe",
+		rev: revision(func(revision *v1alpha1.Revision) {
+			container(revision.Spec.GetContainer(),
+				withTCPReadinessProbe(),
+			)
+		}),
+		lc: &logging.Config{},
+		oc: &metrics.ObservabilityConfig{},
+		ac: &autoscaler.Config{},
+		cc: &deployment.Config{},
+		want: podSpec(
+			[]corev1.Container{
+				userContainer(),
 				queueContainer(
 					withEnvVar("CONTAINER_CONCURRENCY", "0"),
+					withEnvVar("SERVING_READINESS_PROBE", `{"tcpSocket":{"port":8080,"host":"127.0.0.1"}}`),
 				),
 			}),
 	}, {
@@ -626,6 +725,38 @@ func TestMakePodSpec(t *testing.T) {
 				podSpec.Volumes = append(podSpec.Volumes, internalVolume)
 			},
 		),
+	}, {
+		name: "with unix socket",
+		rev: revision(
+			withContainerConcurrency(1),
+			func(revision *v1alpha1.Revision) {
+				revision.Annotations = map[string]string{
+					serving.QueueSideCarUnixSocketAnnotation: "true",
+				}
+			},
+		),
+		lc: &logging.Config{},
+		oc: &metrics.ObservabilityConfig{},
+		ac: &autoscaler.Config{},
+		cc: &deployment.Config{},
+		want: podSpec(
+			[]corev1.Container{
+				userContainer(
+					withEnvVar("UNIX_SOCKET", "/var/run/knative/user.sock"),
+					func(container *corev1.Container) {
+						container.VolumeMounts = append(container.VolumeMounts, userSocketVolumeMount)
+					},
+				),
+				queueContainer(
+					withEnvVar("CONTAINER_CONCURRENCY", "1"),
+					withEnvVar("USER_SOCKET", "/var/run/knative/user.sock"),
+					func(container *corev1.Container) {
+						container.VolumeMounts = append(container.VolumeMounts, userSocketVolumeMount)
+					},
+				),
+			},
+			withAppendedVolumes(userSocketVolume),
+		),
 	}, {
 		name: "complex pod spec",
 		rev: revision(
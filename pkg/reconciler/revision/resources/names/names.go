@@ -34,3 +34,9 @@ func KPA(rev kmeta.Accessor) string {
 	// to simplify the transition to the KPA.
 	return rev.GetName()
 }
+
+// OutlierPeers returns the name of the headless Service through which the
+// pods of the revision find each other for outlier detection.
+func OutlierPeers(rev kmeta.Accessor) string {
+	return kmeta.ChildName(rev.GetName(), "-peers")
+}
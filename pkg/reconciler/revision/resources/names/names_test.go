@@ -95,6 +95,15 @@ func TestNamer(t *testing.T) {
 		},
 		f:    KPA,
 		want: "baz",
+	}, {
+		name: "OutlierPeers",
+		rev: &v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "foo",
+			},
+		},
+		f:    OutlierPeers,
+		want: "foo-peers",
 	}}
 
 	for _, test := range tests {
@@ -0,0 +1,68 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"knative.dev/pkg/kmeta"
+	"github.com/knative/serving/pkg/apis/networking"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/knative/serving/pkg/reconciler/revision/resources/names"
+)
+
+// usesOutlierDetection returns whether the queue-proxy ejects its pod
+// when the user container keeps failing requests.
+func usesOutlierDetection(rev *v1alpha1.Revision) bool {
+	failures, _ := strconv.Atoi(rev.Annotations[serving.QueueSideCarOutlierConsecutiveFailuresAnnotation])
+	return failures > 0
+}
+
+// MakeOutlierPeersService creates a headless Service publishing the
+// addresses of all of the pods of the revision, ready or not, so that a
+// queue-proxy can check how many of its peers are serving before ejecting
+// its own pod.  It returns nil if the revision doesn't use outlier
+// detection.
+func MakeOutlierPeersService(rev *v1alpha1.Revision) *corev1.Service {
+	if !usesOutlierDetection(rev) {
+		return nil
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            names.OutlierPeers(rev),
+			Namespace:       rev.Namespace,
+			Labels:          makeLabels(rev),
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(rev)},
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP:                corev1.ClusterIPNone,
+			PublishNotReadyAddresses: true,
+			Selector:                 makeSelector(rev).MatchLabels,
+			Ports: []corev1.ServicePort{{
+				Name:       v1alpha1.QueueAdminPortName,
+				Protocol:   corev1.ProtocolTCP,
+				Port:       int32(networking.QueueAdminPort),
+				TargetPort: intstr.FromInt(networking.QueueAdminPort),
+			}},
+		},
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"knative.dev/pkg/ptr"
+	"github.com/knative/serving/pkg/apis/networking"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+func TestMakeOutlierPeersService(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        *corev1.Service
+	}{{
+		name: "outlier detection disabled",
+	}, {
+		name: "outlier detection disabled explicitly",
+		annotations: map[string]string{
+			serving.QueueSideCarOutlierConsecutiveFailuresAnnotation: "0",
+		},
+	}, {
+		name: "outlier detection enabled",
+		annotations: map[string]string{
+			serving.QueueSideCarOutlierConsecutiveFailuresAnnotation: "5",
+		},
+		want: &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "foo",
+				Name:      "bar-peers",
+				Labels: map[string]string{
+					serving.RevisionLabelKey: "bar",
+					serving.RevisionUID:      "1234",
+					AppLabelKey:              "bar",
+				},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion:         v1alpha1.SchemeGroupVersion.String(),
+					Kind:               "Revision",
+					Name:               "bar",
+					UID:                "1234",
+					Controller:         ptr.Bool(true),
+					BlockOwnerDeletion: ptr.Bool(true),
+				}},
+			},
+			Spec: corev1.ServiceSpec{
+				ClusterIP:                corev1.ClusterIPNone,
+				PublishNotReadyAddresses: true,
+				Selector: map[string]string{
+					serving.RevisionUID: "1234",
+				},
+				Ports: []corev1.ServicePort{{
+					Name:       v1alpha1.QueueAdminPortName,
+					Protocol:   corev1.ProtocolTCP,
+					Port:       networking.QueueAdminPort,
+					TargetPort: intstr.FromInt(networking.QueueAdminPort),
+				}},
+			},
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rev := &v1alpha1.Revision{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "foo",
+					Name:        "bar",
+					UID:         "1234",
+					Annotations: test.annotations,
+				},
+			}
+			got := MakeOutlierPeersService(rev)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("MakeOutlierPeersService (-want, +got) = %v", diff)
+			}
+		})
+	}
+}
@@ -24,15 +24,20 @@ import (
 	pkgmetrics "knative.dev/pkg/metrics"
 	"knative.dev/pkg/ptr"
 	"knative.dev/pkg/system"
+	"github.com/knative/serving/pkg/apis/autoscaling"
 	"github.com/knative/serving/pkg/apis/networking"
 	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/knative/serving/pkg/autoscaler"
 	"github.com/knative/serving/pkg/deployment"
 	"github.com/knative/serving/pkg/metrics"
+	"github.com/knative/serving/pkg/network"
+	"github.com/knative/serving/pkg/queue/readiness"
+	"github.com/knative/serving/pkg/reconciler/revision/resources/names"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const requestQueueHTTPPortName = "queue-port"
@@ -156,6 +161,32 @@ func createResourcePercentageFromAnnotations(m map[string]string, k string) (boo
 	return true, float32(value / 100)
 }
 
+// userReadinessProbe returns the encoded readiness probe of the user
+// container for the queue-proxy to run, targeted at the user port. It's
+// empty if there is none, or if it's one the queue-proxy can't run.
+func userReadinessProbe(container *corev1.Container, userPort int32) string {
+	p := container.ReadinessProbe
+	if !isQueueProbe(p) {
+		return ""
+	}
+	p = p.DeepCopy()
+	switch {
+	case p.HTTPGet != nil:
+		p.HTTPGet.Port = intstr.FromInt(int(userPort))
+	case p.TCPSocket != nil:
+		p.TCPSocket.Port = intstr.FromInt(int(userPort))
+	}
+	// Encoding a non-nil probe cannot fail.
+	encoded, _ := readiness.EncodeProbe(p)
+	return encoded
+}
+
+// isQueueProbe returns whether the probe is run by the queue-proxy rather
+// than the kubelet.
+func isQueueProbe(p *corev1.Probe) bool {
+	return p != nil && (p.HTTPGet != nil || p.TCPSocket != nil)
+}
+
 // makeQueueContainer creates the container spec for the queue sidecar.
 func makeQueueContainer(rev *v1alpha1.Revision, loggingConfig *logging.Config, observabilityConfig *metrics.ObservabilityConfig,
 	autoscalerConfig *autoscaler.Config, deploymentConfig *deployment.Config) *corev1.Container {
@@ -176,6 +207,18 @@ func makeQueueContainer(rev *v1alpha1.Revision, loggingConfig *logging.Config, o
 	if rev.Spec.TimeoutSeconds != nil {
 		ts = *rev.Spec.TimeoutSeconds
 	}
+	mds := int64(0)
+	if rev.Spec.MaxDurationSeconds != nil {
+		mds = *rev.Spec.MaxDurationSeconds
+	}
+	its := int64(0)
+	if rev.Spec.IdleTimeoutSeconds != nil {
+		its = *rev.Spec.IdleTimeoutSeconds
+	}
+	outlierPeersHost := ""
+	if usesOutlierDetection(rev) {
+		outlierPeersHost = network.GetServiceHostname(names.OutlierPeers(rev), rev.Namespace)
+	}
 
 	// We need to configure only one serving port for the Queue proxy, since
 	// we know the protocol that is being used by this application.
@@ -190,6 +233,11 @@ func makeQueueContainer(rev *v1alpha1.Revision, loggingConfig *logging.Config, o
 	if observabilityConfig.EnableVarLogCollection {
 		volumeMounts = append(volumeMounts, internalVolumeMount)
 	}
+	var userSocket string
+	if usesUnixSocket(rev) {
+		volumeMounts = append(volumeMounts, userSocketVolumeMount)
+		userSocket = userSocketPath
+	}
 
 	return &corev1.Container{
 		Name:            QueueContainerName,
@@ -217,9 +265,45 @@ func makeQueueContainer(rev *v1alpha1.Revision, loggingConfig *logging.Config, o
 		}, {
 			Name:  "CONTAINER_CONCURRENCY",
 			Value: strconv.Itoa(int(rev.Spec.ContainerConcurrency)),
+		}, {
+			Name:  "CONTAINER_CONCURRENCY_MODE",
+			Value: rev.Annotations[autoscaling.ConcurrencyModeAnnotationKey],
+		}, {
+			Name:  "CONTAINER_MIN_CONCURRENCY",
+			Value: rev.Annotations[autoscaling.MinConcurrencyAnnotationKey],
 		}, {
 			Name:  "REVISION_TIMEOUT_SECONDS",
 			Value: strconv.Itoa(int(ts)),
+		}, {
+			Name:  "REVISION_MAX_DURATION_SECONDS",
+			Value: strconv.Itoa(int(mds)),
+		}, {
+			Name:  "REVISION_IDLE_TIMEOUT_SECONDS",
+			Value: strconv.Itoa(int(its)),
+		}, {
+			Name:  "QUEUE_DRAIN_DELAY",
+			Value: deploymentConfig.QueueSidecarDrainDelay.String(),
+		}, {
+			Name:  "QUEUE_RATE_LIMIT",
+			Value: rev.Annotations[serving.QueueSideCarPodRateLimitAnnotation],
+		}, {
+			Name:  "QUEUE_RATE_LIMIT_BURST",
+			Value: rev.Annotations[serving.QueueSideCarPodRateLimitBurstAnnotation],
+		}, {
+			Name:  "QUEUE_POLICY",
+			Value: rev.Annotations[serving.QueueSideCarQueuePolicyAnnotation],
+		}, {
+			Name:  "QUEUE_OUTLIER_CONSECUTIVE_FAILURES",
+			Value: rev.Annotations[serving.QueueSideCarOutlierConsecutiveFailuresAnnotation],
+		}, {
+			Name:  "QUEUE_OUTLIER_LATENCY_THRESHOLD",
+			Value: rev.Annotations[serving.QueueSideCarOutlierLatencyThresholdAnnotation],
+		}, {
+			Name:  "QUEUE_OUTLIER_MAX_EJECTION_PERCENT",
+			Value: rev.Annotations[serving.QueueSideCarOutlierMaxEjectionPercentAnnotation],
+		}, {
+			Name:  "QUEUE_OUTLIER_PEERS_HOST",
+			Value: outlierPeersHost,
 		}, {
 			Name: "SERVING_POD",
 			ValueFrom: &corev1.EnvVarSource{
@@ -243,12 +327,30 @@ func makeQueueContainer(rev *v1alpha1.Revision, loggingConfig *logging.Config, o
 		}, {
 			Name:  "SERVING_REQUEST_LOG_TEMPLATE",
 			Value: observabilityConfig.RequestLogTemplate,
+		}, {
+			Name:  "SERVING_REQUEST_LOG_FORMAT",
+			Value: observabilityConfig.RequestLogFormat,
+		}, {
+			Name:  "SERVING_REQUEST_LOG_SAMPLE_RATE",
+			Value: strconv.FormatFloat(observabilityConfig.RequestLogSampleRate, 'f', -1, 64),
+		}, {
+			Name:  "SERVING_REQUEST_LOG_STATUS_CODES",
+			Value: observabilityConfig.RequestLogStatusCodes,
 		}, {
 			Name:  "SERVING_REQUEST_METRICS_BACKEND",
 			Value: observabilityConfig.RequestMetricsBackend,
 		}, {
 			Name:  "USER_PORT",
 			Value: strconv.Itoa(int(userPort)),
+		}, {
+			Name:  "USER_SOCKET",
+			Value: userSocket,
+		}, {
+			Name:  "USER_GRPC_HEALTH_CHECK",
+			Value: strconv.FormatBool(usesGRPCHealthCheck(rev)),
+		}, {
+			Name:  "SERVING_READINESS_PROBE",
+			Value: userReadinessProbe(rev.Spec.GetContainer(), userPort),
 		}, {
 			Name:  system.NamespaceEnvKey,
 			Value: system.Namespace(),
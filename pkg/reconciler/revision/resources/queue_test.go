@@ -25,12 +25,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
-	"knative.dev/pkg/logging"
-	pkgmetrics "knative.dev/pkg/metrics"
-	_ "knative.dev/pkg/metrics/testing"
-	"knative.dev/pkg/ptr"
-	"knative.dev/pkg/system"
-	_ "knative.dev/pkg/system/testing"
+	"github.com/knative/serving/pkg/apis/autoscaling"
 	"github.com/knative/serving/pkg/apis/networking"
 	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
@@ -42,6 +37,12 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/logging"
+	pkgmetrics "knative.dev/pkg/metrics"
+	_ "knative.dev/pkg/metrics/testing"
+	"knative.dev/pkg/ptr"
+	"knative.dev/pkg/system"
+	_ "knative.dev/pkg/system/testing"
 )
 
 func TestMakeQueueContainer(t *testing.T) {
@@ -274,6 +275,188 @@ func TestMakeQueueContainer(t *testing.T) {
 				"CONTAINER_CONCURRENCY": "10",
 			}),
 		},
+	}, {
+		name: "adaptive container concurrency",
+		rev: &v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "foo",
+				Name:      "bar",
+				UID:       "1234",
+				Annotations: map[string]string{
+					autoscaling.ConcurrencyModeAnnotationKey: autoscaling.ConcurrencyModeAdaptive,
+					autoscaling.MinConcurrencyAnnotationKey:  "2",
+				},
+			},
+			Spec: v1alpha1.RevisionSpec{
+				RevisionSpec: v1beta1.RevisionSpec{
+					ContainerConcurrency: 10,
+					TimeoutSeconds:       ptr.Int64(45),
+				},
+			},
+		},
+		lc: &logging.Config{},
+		oc: &metrics.ObservabilityConfig{},
+		ac: &autoscaler.Config{},
+		cc: &deployment.Config{},
+		want: &corev1.Container{
+			// These are effectively constant
+			Name:            QueueContainerName,
+			Resources:       createQueueResources(make(map[string]string), &corev1.Container{}),
+			Ports:           append(queueNonServingPorts, queueHTTPPort),
+			ReadinessProbe:  queueReadinessProbe,
+			SecurityContext: queueSecurityContext,
+			// These changed based on the Revision and configs passed in.
+			Env: env(map[string]string{
+				"CONTAINER_CONCURRENCY":      "10",
+				"CONTAINER_CONCURRENCY_MODE": "adaptive",
+				"CONTAINER_MIN_CONCURRENCY":  "2",
+			}),
+		},
+	}, {
+		name: "max duration and idle timeout",
+		rev: &v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "foo",
+				Name:      "bar",
+				UID:       "1234",
+			},
+			Spec: v1alpha1.RevisionSpec{
+				RevisionSpec: v1beta1.RevisionSpec{
+					ContainerConcurrency: 1,
+					TimeoutSeconds:       ptr.Int64(45),
+					MaxDurationSeconds:   ptr.Int64(600),
+					IdleTimeoutSeconds:   ptr.Int64(30),
+				},
+			},
+		},
+		lc: &logging.Config{},
+		oc: &metrics.ObservabilityConfig{},
+		ac: &autoscaler.Config{},
+		cc: &deployment.Config{},
+		want: &corev1.Container{
+			// These are effectively constant
+			Name:            QueueContainerName,
+			Resources:       createQueueResources(make(map[string]string), &corev1.Container{}),
+			Ports:           append(queueNonServingPorts, queueHTTPPort),
+			ReadinessProbe:  queueReadinessProbe,
+			SecurityContext: queueSecurityContext,
+			// These changed based on the Revision and configs passed in.
+			Env: env(map[string]string{
+				"REVISION_MAX_DURATION_SECONDS": "600",
+				"REVISION_IDLE_TIMEOUT_SECONDS": "30",
+			}),
+		},
+	}, {
+		name: "rate limit and queue policy",
+		rev: &v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "foo",
+				Name:      "bar",
+				UID:       "1234",
+				Annotations: map[string]string{
+					serving.QueueSideCarPodRateLimitAnnotation:      "100",
+					serving.QueueSideCarPodRateLimitBurstAnnotation: "20",
+					serving.QueueSideCarQueuePolicyAnnotation:       "lifo",
+				},
+			},
+			Spec: v1alpha1.RevisionSpec{
+				RevisionSpec: v1beta1.RevisionSpec{
+					ContainerConcurrency: 1,
+					TimeoutSeconds:       ptr.Int64(45),
+				},
+			},
+		},
+		lc: &logging.Config{},
+		oc: &metrics.ObservabilityConfig{},
+		ac: &autoscaler.Config{},
+		cc: &deployment.Config{},
+		want: &corev1.Container{
+			// These are effectively constant
+			Name:            QueueContainerName,
+			Resources:       createQueueResources(make(map[string]string), &corev1.Container{}),
+			Ports:           append(queueNonServingPorts, queueHTTPPort),
+			ReadinessProbe:  queueReadinessProbe,
+			SecurityContext: queueSecurityContext,
+			// These changed based on the Revision and configs passed in.
+			Env: env(map[string]string{
+				"QUEUE_RATE_LIMIT":       "100",
+				"QUEUE_RATE_LIMIT_BURST": "20",
+				"QUEUE_POLICY":           "lifo",
+			}),
+		},
+	}, {
+		name: "outlier detection",
+		rev: &v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "foo",
+				Name:      "bar",
+				UID:       "1234",
+				Annotations: map[string]string{
+					serving.QueueSideCarOutlierConsecutiveFailuresAnnotation: "5",
+					serving.QueueSideCarOutlierLatencyThresholdAnnotation:    "2s",
+					serving.QueueSideCarOutlierMaxEjectionPercentAnnotation:  "20",
+				},
+			},
+			Spec: v1alpha1.RevisionSpec{
+				RevisionSpec: v1beta1.RevisionSpec{
+					ContainerConcurrency: 1,
+					TimeoutSeconds:       ptr.Int64(45),
+				},
+			},
+		},
+		lc: &logging.Config{},
+		oc: &metrics.ObservabilityConfig{},
+		ac: &autoscaler.Config{},
+		cc: &deployment.Config{},
+		want: &corev1.Container{
+			// These are effectively constant
+			Name:            QueueContainerName,
+			Resources:       createQueueResources(make(map[string]string), &corev1.Container{}),
+			Ports:           append(queueNonServingPorts, queueHTTPPort),
+			ReadinessProbe:  queueReadinessProbe,
+			SecurityContext: queueSecurityContext,
+			// These changed based on the Revision and configs passed in.
+			Env: env(map[string]string{
+				"QUEUE_OUTLIER_CONSECUTIVE_FAILURES": "5",
+				"QUEUE_OUTLIER_LATENCY_THRESHOLD":    "2s",
+				"QUEUE_OUTLIER_MAX_EJECTION_PERCENT": "20",
+				"QUEUE_OUTLIER_PEERS_HOST":           "bar-peers.foo.svc.cluster.local",
+			}),
+		},
+	}, {
+		name: "grpc health check",
+		rev: &v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "foo",
+				Name:      "bar",
+				UID:       "1234",
+				Annotations: map[string]string{
+					serving.QueueSideCarGRPCHealthCheckAnnotation: "true",
+				},
+			},
+			Spec: v1alpha1.RevisionSpec{
+				RevisionSpec: v1beta1.RevisionSpec{
+					ContainerConcurrency: 1,
+					TimeoutSeconds:       ptr.Int64(45),
+				},
+			},
+		},
+		lc: &logging.Config{},
+		oc: &metrics.ObservabilityConfig{},
+		ac: &autoscaler.Config{},
+		cc: &deployment.Config{},
+		want: &corev1.Container{
+			// These are effectively constant
+			Name:            QueueContainerName,
+			Resources:       createQueueResources(make(map[string]string), &corev1.Container{}),
+			Ports:           append(queueNonServingPorts, queueHTTPPort),
+			ReadinessProbe:  queueReadinessProbe,
+			SecurityContext: queueSecurityContext,
+			// These changed based on the Revision and configs passed in.
+			Env: env(map[string]string{
+				"USER_GRPC_HEALTH_CHECK": "true",
+			}),
+		},
 	}, {
 		name: "request log as env var",
 		rev: &v1alpha1.Revision{
@@ -306,6 +489,44 @@ func TestMakeQueueContainer(t *testing.T) {
 				"SERVING_REQUEST_LOG_TEMPLATE": "test template",
 			}),
 		},
+	}, {
+		name: "structured request log as env var",
+		rev: &v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "foo",
+				Name:      "bar",
+				UID:       "1234",
+			},
+			Spec: v1alpha1.RevisionSpec{
+				RevisionSpec: v1beta1.RevisionSpec{
+					ContainerConcurrency: 0,
+					TimeoutSeconds:       ptr.Int64(45),
+				},
+			},
+		},
+		lc: &logging.Config{},
+		oc: &metrics.ObservabilityConfig{
+			RequestLogFormat:      "json",
+			RequestLogSampleRate:  0.25,
+			RequestLogStatusCodes: "5xx",
+		},
+		ac: &autoscaler.Config{},
+		cc: &deployment.Config{},
+		want: &corev1.Container{
+			// These are effectively constant
+			Name:            QueueContainerName,
+			Resources:       createQueueResources(make(map[string]string), &corev1.Container{}),
+			Ports:           append(queueNonServingPorts, queueHTTPPort),
+			ReadinessProbe:  queueReadinessProbe,
+			SecurityContext: queueSecurityContext,
+			// These changed based on the Revision and configs passed in.
+			Env: env(map[string]string{
+				"CONTAINER_CONCURRENCY":            "0",
+				"SERVING_REQUEST_LOG_FORMAT":       "json",
+				"SERVING_REQUEST_LOG_SAMPLE_RATE":  "0.25",
+				"SERVING_REQUEST_LOG_STATUS_CODES": "5xx",
+			}),
+		},
 	}, {
 		name: "request metrics backend as env var",
 		rev: &v1alpha1.Revision{
@@ -618,24 +839,42 @@ func TestMakeQueueContainerWithPercentageAnnotation(t *testing.T) {
 }
 
 var defaultEnv = map[string]string{
-	"SERVING_NAMESPACE":               "foo",
-	"SERVING_SERVICE":                 "",
-	"SERVING_CONFIGURATION":           "",
-	"SERVING_REVISION":                "bar",
-	"CONTAINER_CONCURRENCY":           "1",
-	"REVISION_TIMEOUT_SECONDS":        "45",
-	"SERVING_LOGGING_CONFIG":          "",
-	"SERVING_LOGGING_LEVEL":           "",
-	"SERVING_REQUEST_LOG_TEMPLATE":    "",
-	"SERVING_REQUEST_METRICS_BACKEND": "",
-	"USER_PORT":                       strconv.Itoa(v1alpha1.DefaultUserPort),
-	"SYSTEM_NAMESPACE":                system.Namespace(),
-	"METRICS_DOMAIN":                  pkgmetrics.Domain(),
-	"QUEUE_SERVING_PORT":              "8012",
-	"USER_CONTAINER_NAME":             containerName,
-	"ENABLE_VAR_LOG_COLLECTION":       "false",
-	"VAR_LOG_VOLUME_NAME":             varLogVolumeName,
-	"INTERNAL_VOLUME_PATH":            internalVolumePath,
+	"SERVING_NAMESPACE":                  "foo",
+	"SERVING_SERVICE":                    "",
+	"SERVING_CONFIGURATION":              "",
+	"SERVING_REVISION":                   "bar",
+	"CONTAINER_CONCURRENCY":              "1",
+	"CONTAINER_CONCURRENCY_MODE":         "",
+	"CONTAINER_MIN_CONCURRENCY":          "",
+	"REVISION_TIMEOUT_SECONDS":           "45",
+	"REVISION_MAX_DURATION_SECONDS":      "0",
+	"REVISION_IDLE_TIMEOUT_SECONDS":      "0",
+	"QUEUE_DRAIN_DELAY":                  "0s",
+	"QUEUE_RATE_LIMIT":                   "",
+	"QUEUE_RATE_LIMIT_BURST":             "",
+	"QUEUE_POLICY":                       "",
+	"QUEUE_OUTLIER_CONSECUTIVE_FAILURES": "",
+	"QUEUE_OUTLIER_LATENCY_THRESHOLD":    "",
+	"QUEUE_OUTLIER_MAX_EJECTION_PERCENT": "",
+	"QUEUE_OUTLIER_PEERS_HOST":           "",
+	"SERVING_LOGGING_CONFIG":             "",
+	"SERVING_LOGGING_LEVEL":              "",
+	"SERVING_REQUEST_LOG_TEMPLATE":       "",
+	"SERVING_REQUEST_LOG_FORMAT":         "",
+	"SERVING_REQUEST_LOG_SAMPLE_RATE":    "0",
+	"SERVING_REQUEST_LOG_STATUS_CODES":   "",
+	"SERVING_REQUEST_METRICS_BACKEND":    "",
+	"USER_PORT":                          strconv.Itoa(v1alpha1.DefaultUserPort),
+	"USER_SOCKET":                        "",
+	"USER_GRPC_HEALTH_CHECK":             "false",
+	"SERVING_READINESS_PROBE":            "",
+	"SYSTEM_NAMESPACE":                   system.Namespace(),
+	"METRICS_DOMAIN":                     pkgmetrics.Domain(),
+	"QUEUE_SERVING_PORT":                 "8012",
+	"USER_CONTAINER_NAME":                containerName,
+	"ENABLE_VAR_LOG_COLLECTION":          "false",
+	"VAR_LOG_VOLUME_NAME":                varLogVolumeName,
+	"INTERNAL_VOLUME_PATH":               internalVolumePath,
 }
 
 func env(overrides map[string]string) []corev1.EnvVar {
@@ -186,6 +186,9 @@ func (c *Reconciler) reconcile(ctx context.Context, rev *v1alpha1.Revision) erro
 	}, {
 		name: "image cache",
 		f:    c.reconcileImageCache,
+	}, {
+		name: "outlier peers",
+		f:    c.reconcileOutlierPeers,
 	}, {
 		name: "KPA",
 		f:    c.reconcileKPA,
@@ -27,6 +27,7 @@ import (
 	logtesting "knative.dev/pkg/logging/testing"
 	autoscalingv1alpha1 "github.com/knative/serving/pkg/apis/autoscaling/v1alpha1"
 	"github.com/knative/serving/pkg/apis/networking"
+	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/knative/serving/pkg/apis/serving/v1beta1"
 	"github.com/knative/serving/pkg/autoscaler"
@@ -84,6 +85,24 @@ func TestReconcile(t *testing.T) {
 				WithLogURL, AllUnknownConditions, MarkDeploying("Deploying")),
 		}},
 		Key: "foo/first-reconcile",
+	}, {
+		Name: "first revision reconciliation with outlier detection",
+		// Revisions using outlier detection also get the headless Service
+		// through which their pods find each other.
+		Objects: []runtime.Object{
+			rev("foo", "first-reconcile", withOutlierDetection),
+		},
+		WantCreates: []runtime.Object{
+			resources.MakeKPA(rev("foo", "first-reconcile", withOutlierDetection)),
+			deploy("foo", "first-reconcile", RevisionOption(withOutlierDetection)),
+			resources.MakeOutlierPeersService(rev("foo", "first-reconcile", withOutlierDetection)),
+			resources.MakeImageCache(rev("foo", "first-reconcile", withOutlierDetection)),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: rev("foo", "first-reconcile", withOutlierDetection,
+				WithLogURL, AllUnknownConditions, MarkDeploying("Deploying")),
+		}},
+		Key: "foo/first-reconcile",
 	}, {
 		Name: "failure updating revision status",
 		// This starts from the first reconciliation case above and induces a failure
@@ -598,6 +617,13 @@ func withK8sServiceName(sn string) RevisionOption {
 	}
 }
 
+func withOutlierDetection(r *v1alpha1.Revision) {
+	if r.Annotations == nil {
+		r.Annotations = make(map[string]string, 1)
+	}
+	r.Annotations[serving.QueueSideCarOutlierConsecutiveFailuresAnnotation] = "5"
+}
+
 // TODO(mattmoor): Come up with a better name for this.
 func AllUnknownConditions(r *v1alpha1.Revision) {
 	WithInitRevConditions(r)
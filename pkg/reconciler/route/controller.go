@@ -22,6 +22,7 @@ import (
 	serviceinformer "knative.dev/pkg/injection/informers/kubeinformers/corev1/service"
 	certificateinformer "github.com/knative/serving/pkg/client/injection/informers/networking/v1alpha1/certificate"
 	clusteringressinformer "github.com/knative/serving/pkg/client/injection/informers/networking/v1alpha1/clusteringress"
+	ingressinformer "github.com/knative/serving/pkg/client/injection/informers/networking/v1alpha1/ingress"
 	configurationinformer "github.com/knative/serving/pkg/client/injection/informers/serving/v1alpha1/configuration"
 	revisioninformer "github.com/knative/serving/pkg/client/injection/informers/serving/v1alpha1/revision"
 	routeinformer "github.com/knative/serving/pkg/client/injection/informers/serving/v1alpha1/route"
@@ -62,6 +63,7 @@ func NewControllerWithClock(
 	configInformer := configurationinformer.Get(ctx)
 	revisionInformer := revisioninformer.Get(ctx)
 	clusterIngressInformer := clusteringressinformer.Get(ctx)
+	ingressInformer := ingressinformer.Get(ctx)
 	certificateInformer := certificateinformer.Get(ctx)
 
 	// No need to lock domainConfigMutex yet since the informers that can modify
@@ -73,6 +75,7 @@ func NewControllerWithClock(
 		revisionLister:       revisionInformer.Lister(),
 		serviceLister:        serviceInformer.Lister(),
 		clusterIngressLister: clusterIngressInformer.Lister(),
+		ingressLister:        ingressInformer.Lister(),
 		certificateLister:    certificateInformer.Lister(),
 		clock:                clock,
 	}
@@ -86,10 +89,17 @@ func NewControllerWithClock(
 		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
 	})
 
+	// ClusterIngresses are only watched to migrate the Routes that were
+	// reconciled by previous releases.
 	clusterIngressInformer.Informer().AddEventHandler(controller.HandleAll(
 		impl.EnqueueLabelOfNamespaceScopedResource(
 			serving.RouteNamespaceLabelKey, serving.RouteLabelKey)))
 
+	ingressInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.Filter(v1alpha1.SchemeGroupVersion.WithKind("Route")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
 	c.tracker = tracker.New(impl.EnqueueKey, controller.GetTrackerLease(ctx))
 
 	configInformer.Informer().AddEventHandler(controller.HandleAll(
@@ -17,7 +17,7 @@ limitations under the License.
 /*
 
 Package route implements a kubernetes controller which tracks Route resource
-and reconcile ClusterIngress as its child resource.
+and reconcile Ingress as its child resource.
 
 */
 package route
@@ -38,35 +38,13 @@ import (
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/knative/serving/pkg/reconciler/route/config"
 	"github.com/knative/serving/pkg/reconciler/route/resources"
-	resourcenames "github.com/knative/serving/pkg/reconciler/route/resources/names"
 	"github.com/knative/serving/pkg/reconciler/route/traffic"
 )
 
-func (c *Reconciler) getClusterIngressForRoute(route *v1alpha1.Route) (*netv1alpha1.ClusterIngress, error) {
-	// First, look up the fixed name.
-	ciName := resourcenames.ClusterIngress(route)
-	ci, err := c.clusterIngressLister.Get(ciName)
-	if err == nil {
-		return ci, nil
-	}
-
-	// If that isn't found, then fallback on the legacy selector-based approach.
-	selector := routeOwnerLabelSelector(route)
-	ingresses, err := c.clusterIngressLister.List(selector)
-	if err != nil {
-		return nil, err
-	}
-	if len(ingresses) == 0 {
-		return nil, apierrs.NewNotFound(
-			v1alpha1.Resource("clusteringress"), resourcenames.ClusterIngress(route))
-	}
-
-	if len(ingresses) > 1 {
-		// Return error as we expect only one ingress instance for a route.
-		return nil, fmt.Errorf("more than one ClusterIngress are found for route %s/%s: %v", route.Namespace, route.Name, ingresses)
-	}
-
-	return ingresses[0], nil
+func (c *Reconciler) getClusterIngressesForRoute(route *v1alpha1.Route) ([]*netv1alpha1.ClusterIngress, error) {
+	// The ClusterIngresses created by previous releases are labeled with the
+	// Route, whether they have a fixed or a generated name.
+	return c.clusterIngressLister.List(routeOwnerLabelSelector(route))
 }
 
 func routeOwnerLabelSelector(route *v1alpha1.Route) labels.Selector {
@@ -85,42 +63,96 @@ func (c *Reconciler) deleteClusterIngressesForRoute(route *v1alpha1.Route) error
 	)
 }
 
-func (c *Reconciler) reconcileClusterIngress(
-	ctx context.Context, r *v1alpha1.Route, desired *netv1alpha1.ClusterIngress) (*netv1alpha1.ClusterIngress, error) {
+// reconcileLegacyClusterIngress migrates the given Route from the
+// ClusterIngress created by a previous release to the given Ingress. It
+// returns the ingress that currently serves the Route: the ClusterIngress
+// until the Ingress is ready, at which point the ClusterIngress is deleted.
+// Meanwhile, both program the same hosts on the same gateways, so the
+// ClusterIngress is kept in sync with the Ingress for the Route's traffic
+// to follow its spec whichever of them the gateways pick.
+func (c *Reconciler) reconcileLegacyClusterIngress(
+	ctx context.Context, r *v1alpha1.Route, ingress *netv1alpha1.Ingress) (netv1alpha1.IngressAccessor, error) {
+	logger := logging.FromContext(ctx)
+	clusterIngresses, err := c.getClusterIngressesForRoute(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(clusterIngresses) == 0 {
+		return ingress, nil
+	}
+	if !ingress.Status.IsReady() {
+		if len(clusterIngresses) > 1 {
+			// Return error as we expect only one ingress instance for a route.
+			return nil, fmt.Errorf("more than one ClusterIngress are found for route %s/%s: %v",
+				r.Namespace, r.Name, clusterIngresses)
+		}
+		clusterIngress := clusterIngresses[0]
+		logger.Infof("Ingress %q is not ready yet, still serving through ClusterIngress %q",
+			ingress.Name, clusterIngress.Name)
+		if equality.Semantic.DeepEqual(clusterIngress.Spec, ingress.Spec) {
+			return clusterIngress, nil
+		}
+		// Don't modify the informers copy
+		origin := clusterIngress.DeepCopy()
+		origin.Spec = ingress.Spec
+		updated, err := c.ServingClientSet.NetworkingV1alpha1().ClusterIngresses().Update(origin)
+		if err != nil {
+			logger.Errorw("Failed to update ClusterIngress", zap.Error(err))
+			return nil, err
+		}
+		return updated, nil
+	}
+
+	logger.Info("Cleaning up ClusterIngress")
+	if err := c.deleteClusterIngressesForRoute(r); err != nil {
+		logger.Errorw("Failed to delete ClusterIngress", zap.Error(err))
+		return nil, err
+	}
+	c.Recorder.Eventf(r, corev1.EventTypeNormal, "Deleted",
+		"Deleted ClusterIngress %q", clusterIngresses[0].Name)
+	return ingress, nil
+}
+
+func (c *Reconciler) reconcileIngress(
+	ctx context.Context, r *v1alpha1.Route, desired *netv1alpha1.Ingress) (*netv1alpha1.Ingress, error) {
 	logger := logging.FromContext(ctx)
-	clusterIngress, err := c.getClusterIngressForRoute(r)
+	ingress, err := c.ingressLister.Ingresses(desired.Namespace).Get(desired.Name)
 	if apierrs.IsNotFound(err) {
-		clusterIngress, err = c.ServingClientSet.NetworkingV1alpha1().ClusterIngresses().Create(desired)
+		ingress, err = c.ServingClientSet.NetworkingV1alpha1().Ingresses(desired.Namespace).Create(desired)
 		if err != nil {
-			logger.Errorw("Failed to create ClusterIngress", zap.Error(err))
+			logger.Errorw("Failed to create Ingress", zap.Error(err))
 			c.Recorder.Eventf(r, corev1.EventTypeWarning, "CreationFailed",
-				"Failed to create ClusterIngress for route %s/%s: %v", r.Namespace, r.Name, err)
+				"Failed to create Ingress for route %s/%s: %v", r.Namespace, r.Name, err)
 			return nil, err
 		}
 		c.Recorder.Eventf(r, corev1.EventTypeNormal, "Created",
-			"Created ClusterIngress %q", clusterIngress.Name)
-		return clusterIngress, nil
+			"Created Ingress %q", ingress.Name)
+		return ingress, nil
 	} else if err != nil {
 		return nil, err
+	} else if !metav1.IsControlledBy(ingress, r) {
+		// Surface an error in the route's status, and return an error.
+		r.Status.MarkIngressNotOwned(desired.Name)
+		return nil, fmt.Errorf("route: %q does not own Ingress: %q", r.Name, desired.Name)
 	} else {
 		// It is notable that one reason for differences here may be defaulting.
 		// When that is the case, the Update will end up being a nop because the
 		// webhook will bring them into alignment and no new reconciliation will occur.
-		if !equality.Semantic.DeepEqual(clusterIngress.Spec, desired.Spec) {
+		if !equality.Semantic.DeepEqual(ingress.Spec, desired.Spec) {
 			// Don't modify the informers copy
-			origin := clusterIngress.DeepCopy()
+			origin := ingress.DeepCopy()
 			origin.Spec = desired.Spec
 
-			updated, err := c.ServingClientSet.NetworkingV1alpha1().ClusterIngresses().Update(origin)
+			updated, err := c.ServingClientSet.NetworkingV1alpha1().Ingresses(origin.Namespace).Update(origin)
 			if err != nil {
-				logger.Errorw("Failed to update ClusterIngress", zap.Error(err))
+				logger.Errorw("Failed to update Ingress", zap.Error(err))
 				return nil, err
 			}
 			return updated, nil
 		}
 	}
 
-	return clusterIngress, err
+	return ingress, err
 }
 
 func (c *Reconciler) deleteServices(namespace string, serviceNames sets.String) error {
@@ -194,6 +226,24 @@ func (c *Reconciler) reconcilePlaceholderServices(ctx context.Context, route *v1
 		delete(currentServices, desiredService.Name)
 	}
 
+	// External hosts are reached through ExternalName services, which
+	// aren't pointed at the load balancer like the placeholders above.
+	externals := make(map[string]*corev1.Service)
+	for _, tts := range targets {
+		for _, tt := range tts {
+			if tt.External != nil && tt.External.Host != "" {
+				desiredService := resources.MakeExternalService(route, tt.External)
+				externals[desiredService.Name] = desiredService
+			}
+		}
+	}
+	for _, name := range sets.StringKeySet(externals).List() {
+		if err := c.reconcileExternalService(ctx, route, externals[name]); err != nil {
+			return nil, err
+		}
+		delete(currentServices, name)
+	}
+
 	// Delete any current services that was no longer desired.
 	if err := c.deleteServices(ns, currentServices); err != nil {
 		return nil, err
@@ -204,7 +254,43 @@ func (c *Reconciler) reconcilePlaceholderServices(ctx context.Context, route *v1
 	return services, nil
 }
 
-func (c *Reconciler) updatePlaceholderServices(ctx context.Context, route *v1alpha1.Route, services []*corev1.Service, ingress *netv1alpha1.ClusterIngress) error {
+func (c *Reconciler) reconcileExternalService(ctx context.Context, route *v1alpha1.Route, desiredService *corev1.Service) error {
+	logger := logging.FromContext(ctx)
+	ns := route.Namespace
+
+	service, err := c.serviceLister.Services(ns).Get(desiredService.Name)
+	if apierrs.IsNotFound(err) {
+		// Doesn't exist, create it.
+		if _, err := c.KubeClientSet.CoreV1().Services(ns).Create(desiredService); err != nil {
+			logger.Errorw("Failed to create external service", zap.Error(err))
+			c.Recorder.Eventf(route, corev1.EventTypeWarning, "CreationFailed",
+				"Failed to create external service %q: %v", desiredService.Name, err)
+			return err
+		}
+		logger.Infof("Created service %s", desiredService.Name)
+		c.Recorder.Eventf(route, corev1.EventTypeNormal, "Created", "Created external service %q", desiredService.Name)
+		return nil
+	} else if err != nil {
+		return err
+	} else if !metav1.IsControlledBy(service, route) {
+		// Surface an error in the route's status, and return an error.
+		route.Status.MarkServiceNotOwned(desiredService.Name)
+		return fmt.Errorf("route: %q does not own Service: %q", route.Name, desiredService.Name)
+	}
+
+	// Make sure that the service has the proper specification.
+	if !equality.Semantic.DeepEqual(service.Spec, desiredService.Spec) {
+		// Don't modify the informers copy
+		existing := service.DeepCopy()
+		existing.Spec = desiredService.Spec
+		if _, err := c.KubeClientSet.CoreV1().Services(ns).Update(existing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Reconciler) updatePlaceholderServices(ctx context.Context, route *v1alpha1.Route, services []*corev1.Service, ingress netv1alpha1.IngressAccessor) error {
 	logger := logging.FromContext(ctx)
 	ns := route.Namespace
 
@@ -264,6 +350,10 @@ func (c *Reconciler) reconcileTargetRevisions(ctx context.Context, t *traffic.Co
 	for _, target := range t.Targets {
 		for _, rt := range target {
 			tt := rt.TrafficTarget
+			if tt.External != nil {
+				// There's no Revision to pin.
+				continue
+			}
 			eg.Go(func() error {
 				rev, err := c.revisionLister.Revisions(route.Namespace).Get(tt.RevisionName)
 				if apierrs.IsNotFound(err) {
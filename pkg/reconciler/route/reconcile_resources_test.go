@@ -31,7 +31,7 @@ import (
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/knative/serving/pkg/apis/serving/v1beta1"
 	fakecertinformer "github.com/knative/serving/pkg/client/injection/informers/networking/v1alpha1/certificate/fake"
-	fakeciinformer "github.com/knative/serving/pkg/client/injection/informers/networking/v1alpha1/clusteringress/fake"
+	fakeingressinformer "github.com/knative/serving/pkg/client/injection/informers/networking/v1alpha1/ingress/fake"
 	"github.com/knative/serving/pkg/gc"
 	"github.com/knative/serving/pkg/reconciler/route/config"
 	"github.com/knative/serving/pkg/reconciler/route/resources"
@@ -40,7 +40,7 @@ import (
 	. "knative.dev/pkg/logging/testing"
 )
 
-func TestReconcileClusterIngress_Insert(t *testing.T) {
+func TestReconcileIngress_Insert(t *testing.T) {
 	ctx, _, reconciler, _ := newTestReconciler(t)
 
 	r := &v1alpha1.Route{
@@ -49,8 +49,8 @@ func TestReconcileClusterIngress_Insert(t *testing.T) {
 			Namespace: "test-ns",
 		},
 	}
-	ci := newTestClusterIngress(t, r)
-	if _, err := reconciler.reconcileClusterIngress(TestContextWithLogger(t), r, ci); err != nil {
+	ci := newTestIngress(t, r)
+	if _, err := reconciler.reconcileIngress(TestContextWithLogger(t), r, ci); err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
 	created := getRouteIngressFromClient(t, ctx, r)
@@ -59,7 +59,7 @@ func TestReconcileClusterIngress_Insert(t *testing.T) {
 	}
 }
 
-func TestReconcileClusterIngress_Update(t *testing.T) {
+func TestReconcileIngress_Update(t *testing.T) {
 	ctx, _, reconciler, _ := newTestReconciler(t)
 
 	r := &v1alpha1.Route{
@@ -69,20 +69,20 @@ func TestReconcileClusterIngress_Update(t *testing.T) {
 		},
 	}
 
-	ci := newTestClusterIngress(t, r)
-	if _, err := reconciler.reconcileClusterIngress(TestContextWithLogger(t), r, ci); err != nil {
+	ci := newTestIngress(t, r)
+	if _, err := reconciler.reconcileIngress(TestContextWithLogger(t), r, ci); err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
 	updated := getRouteIngressFromClient(t, ctx, r)
-	fakeciinformer.Get(ctx).Informer().GetIndexer().Add(updated)
+	fakeingressinformer.Get(ctx).Informer().GetIndexer().Add(updated)
 
 	r.Status.URL = &apis.URL{
 		Scheme: "http",
 		Host:   "bar.com",
 	}
-	ci2 := newTestClusterIngress(t, r)
-	if _, err := reconciler.reconcileClusterIngress(TestContextWithLogger(t), r, ci2); err != nil {
+	ci2 := newTestIngress(t, r)
+	if _, err := reconciler.reconcileIngress(TestContextWithLogger(t), r, ci2); err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
@@ -152,7 +152,7 @@ func TestReconcileTargetRevisions(t *testing.T) {
 	}
 }
 
-func newTestClusterIngress(t *testing.T, r *v1alpha1.Route) *netv1alpha1.ClusterIngress {
+func newTestIngress(t *testing.T, r *v1alpha1.Route) *netv1alpha1.Ingress {
 	tc := &traffic.Config{Targets: map[string]traffic.RevisionTargets{
 		traffic.DefaultTarget: {{
 			TrafficTarget: v1beta1.TrafficTarget{
@@ -170,7 +170,7 @@ func newTestClusterIngress(t *testing.T, r *v1alpha1.Route) *netv1alpha1.Cluster
 			ServerCertificate: "tls.crt",
 		},
 	}
-	ingress, err := resources.MakeClusterIngress(getContext(), r, tc, tls, "foo-ingress")
+	ingress, err := resources.MakeIngress(getContext(), r, tc, tls, "foo-ingress")
 	if err != nil {
 		t.Errorf("Unexpected error %v", err)
 	}
@@ -23,6 +23,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"knative.dev/pkg/kmeta"
 
 	"github.com/knative/serving/pkg/activator"
 	"github.com/knative/serving/pkg/apis/networking"
@@ -50,18 +51,17 @@ func MakeIngressTLS(cert *v1alpha1.Certificate, hostNames []string) v1alpha1.Ing
 	}
 }
 
-// MakeClusterIngress creates ClusterIngress to set up routing rules. Such ClusterIngress specifies
+// MakeIngress creates Ingress to set up routing rules. Such Ingress specifies
 // which Hosts that it applies to, as well as the routing rules.
-func MakeClusterIngress(ctx context.Context, r *servingv1alpha1.Route, tc *traffic.Config, tls []v1alpha1.IngressTLS, ingressClass string) (*v1alpha1.ClusterIngress, error) {
+func MakeIngress(ctx context.Context, r *servingv1alpha1.Route, tc *traffic.Config, tls []v1alpha1.IngressTLS, ingressClass string) (*v1alpha1.Ingress, error) {
 	spec, err := makeIngressSpec(ctx, r, tls, tc.Targets)
 	if err != nil {
 		return nil, err
 	}
-	return &v1alpha1.ClusterIngress{
+	return &v1alpha1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			// As ClusterIngress resource is cluster-scoped,
-			// here we use GenerateName to avoid conflict.
-			Name: names.ClusterIngress(r),
+			Name:      names.Ingress(r),
+			Namespace: r.Namespace,
 			Labels: map[string]string{
 				serving.RouteLabelKey:          r.Name,
 				serving.RouteNamespaceLabelKey: r.Namespace,
@@ -69,6 +69,7 @@ func MakeClusterIngress(ctx context.Context, r *servingv1alpha1.Route, tc *traff
 			Annotations: resources.UnionMaps(map[string]string{
 				networking.IngressClassAnnotationKey: ingressClass,
 			}, r.ObjectMeta.Annotations),
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(r)},
 		},
 		Spec: spec,
 	}, nil
@@ -148,15 +149,20 @@ func makeIngressRule(domains []string, ns string, targets traffic.RevisionTarget
 			continue
 		}
 
+		// Port on the public service must match port on the activator.
+		// Otherwise, the serverless services can't guarantee seamless positive handoff.
+		port := intstr.FromInt(int(networking.ServicePort(t.Protocol)))
+		if t.External != nil {
+			port = intstr.FromInt(int(t.External.Port))
+		}
 		splits = append(splits, v1alpha1.IngressBackendSplit{
 			IngressBackend: v1alpha1.IngressBackend{
 				ServiceNamespace: ns,
 				ServiceName:      t.ServiceName,
-				// Port on the public service must match port on the activator.
-				// Otherwise, the serverless services can't guarantee seamless positive handoff.
-				ServicePort: intstr.FromInt(int(networking.ServicePort(t.Protocol))),
+				ServicePort:      port,
 			},
-			Percent: t.Percent,
+			Percent:  t.Percent,
+			External: t.External != nil,
 			// TODO(nghia): Append headers per-split.
 			// AppendHeaders: map[string]string{
 			// 	activator.RevisionHeaderName:      t.TrafficTarget.RevisionName,
@@ -165,21 +171,46 @@ func makeIngressRule(domains []string, ns string, targets traffic.RevisionTarget
 		})
 	}
 
+	// Targets sending all of their traffic outside of Knative have no
+	// Revision for the activator to look for.
+	var headers map[string]string
+	if revisionName := maxInactive(targets); revisionName != "" {
+		headers = map[string]string{
+			activator.RevisionHeaderName:      revisionName,
+			activator.RevisionHeaderNamespace: ns,
+		}
+	}
 	return &v1alpha1.IngressRule{
 		Hosts: domains,
 		HTTP: &v1alpha1.HTTPIngressRuleValue{
 			Paths: []v1alpha1.HTTPIngressPath{{
 				Splits: splits,
 				// TODO(lichuqiang): #2201, plumbing to config timeout and retries.
-				AppendHeaders: map[string]string{
-					activator.RevisionHeaderName:      maxInactive(targets),
-					activator.RevisionHeaderNamespace: ns,
-				},
+				AppendHeaders: headers,
+				RewriteHost:   externalHost(targets),
 			}},
 		},
 	}
 }
 
+// externalHost returns the external host receiving all of the traffic of
+// the targets, if any.  The Host header can only be rewritten for a whole
+// path, so an external host sharing traffic with other targets receives
+// the host of the Route.
+func externalHost(targets traffic.RevisionTargets) string {
+	host := ""
+	for _, t := range targets {
+		if t.Percent == 0 {
+			continue
+		}
+		if t.External == nil || t.External.Host == "" || host != "" {
+			return ""
+		}
+		host = t.External.Host
+	}
+	return host
+}
+
 // maxInactive constructs Splits for the inactive targets, and add into given IngressPath.
 func maxInactive(targets traffic.RevisionTargets) string {
 	revisionName, inactiveRevisionName := "", ""
@@ -187,7 +218,7 @@ func maxInactive(targets traffic.RevisionTargets) string {
 	maxInactiveTargetPercent := 0 // There must be a non-zero target if things add to 100
 
 	for _, t := range targets {
-		if t.Percent == 0 {
+		if t.Percent == 0 || t.External != nil {
 			continue
 		}
 		if t.Percent >= maxTargetPercent {
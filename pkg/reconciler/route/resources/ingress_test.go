@@ -24,6 +24,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"knative.dev/pkg/apis"
+	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/system"
 	_ "knative.dev/pkg/system/testing"
 	"github.com/knative/serving/pkg/apis/networking"
@@ -38,7 +39,7 @@ import (
 
 const ns = "test-ns"
 
-func TestMakeClusterIngress_CorrectMetadata(t *testing.T) {
+func TestMakeIngress_CorrectMetadata(t *testing.T) {
 	targets := map[string]traffic.RevisionTargets{}
 	ingressClass := "foo-ingress"
 	r := &v1alpha1.Route{
@@ -57,7 +58,8 @@ func TestMakeClusterIngress_CorrectMetadata(t *testing.T) {
 		},
 	}
 	expected := metav1.ObjectMeta{
-		Name: "route-1234-5678",
+		Name:      "test-route",
+		Namespace: "test-ns",
 		Labels: map[string]string{
 			serving.RouteLabelKey:          "test-route",
 			serving.RouteNamespaceLabelKey: "test-ns",
@@ -65,8 +67,9 @@ func TestMakeClusterIngress_CorrectMetadata(t *testing.T) {
 		Annotations: map[string]string{
 			networking.IngressClassAnnotationKey: ingressClass,
 		},
+		OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(r)},
 	}
-	ci, err := MakeClusterIngress(getContext(), r, &traffic.Config{Targets: targets}, nil, ingressClass)
+	ci, err := MakeIngress(getContext(), r, &traffic.Config{Targets: targets}, nil, ingressClass)
 	if err != nil {
 		t.Errorf("Unexpected error %v", err)
 	}
@@ -76,7 +79,7 @@ func TestMakeClusterIngress_CorrectMetadata(t *testing.T) {
 	}
 }
 
-func TestMakeClusterIngressSpec_CorrectRules(t *testing.T) {
+func TestMakeIngressSpec_CorrectRules(t *testing.T) {
 	targets := map[string]traffic.RevisionTargets{
 		traffic.DefaultTarget: {{
 			TrafficTarget: v1beta1.TrafficTarget{
@@ -168,7 +171,7 @@ func TestMakeClusterIngressSpec_CorrectRules(t *testing.T) {
 	}
 }
 
-func TestMakeClusterIngressSpec_CorrectVisibility(t *testing.T) {
+func TestMakeIngressSpec_CorrectVisibility(t *testing.T) {
 	cases := []struct {
 		name              string
 		route             v1alpha1.Route
@@ -309,7 +312,7 @@ func TestGetRouteDomains_NamedTarget(t *testing.T) {
 }
 
 // One active target.
-func TestMakeClusterIngressRule_Vanilla(t *testing.T) {
+func TestMakeIngressRule_Vanilla(t *testing.T) {
 	targets := []traffic.RevisionTarget{{
 		TrafficTarget: v1beta1.TrafficTarget{
 			ConfigurationName: "config",
@@ -350,7 +353,7 @@ func TestMakeClusterIngressRule_Vanilla(t *testing.T) {
 }
 
 // One active target and a target of zero percent.
-func TestMakeClusterIngressRule_ZeroPercentTarget(t *testing.T) {
+func TestMakeIngressRule_ZeroPercentTarget(t *testing.T) {
 	targets := []traffic.RevisionTarget{{
 		TrafficTarget: v1beta1.TrafficTarget{
 			ConfigurationName: "config",
@@ -396,7 +399,7 @@ func TestMakeClusterIngressRule_ZeroPercentTarget(t *testing.T) {
 }
 
 // Two active targets.
-func TestMakeClusterIngressRule_TwoTargets(t *testing.T) {
+func TestMakeIngressRule_TwoTargets(t *testing.T) {
 	targets := []traffic.RevisionTarget{{
 		TrafficTarget: v1beta1.TrafficTarget{
 			ConfigurationName: "config",
@@ -449,7 +452,7 @@ func TestMakeClusterIngressRule_TwoTargets(t *testing.T) {
 }
 
 // Inactive target.
-func TestMakeClusterIngressRule_InactiveTarget(t *testing.T) {
+func TestMakeIngressRule_InactiveTarget(t *testing.T) {
 	targets := []traffic.RevisionTarget{{
 		TrafficTarget: v1beta1.TrafficTarget{
 			ConfigurationName: "config",
@@ -489,7 +492,7 @@ func TestMakeClusterIngressRule_InactiveTarget(t *testing.T) {
 }
 
 // Two inactive targets.
-func TestMakeClusterIngressRule_TwoInactiveTargets(t *testing.T) {
+func TestMakeIngressRule_TwoInactiveTargets(t *testing.T) {
 	targets := []traffic.RevisionTarget{{
 		TrafficTarget: v1beta1.TrafficTarget{
 			ConfigurationName: "config",
@@ -543,7 +546,7 @@ func TestMakeClusterIngressRule_TwoInactiveTargets(t *testing.T) {
 	}
 }
 
-func TestMakeClusterIngressRule_ZeroPercentTargetInactive(t *testing.T) {
+func TestMakeIngressRule_ZeroPercentTargetInactive(t *testing.T) {
 	targets := []traffic.RevisionTarget{{
 		TrafficTarget: v1beta1.TrafficTarget{
 			ConfigurationName: "config",
@@ -588,7 +591,138 @@ func TestMakeClusterIngressRule_ZeroPercentTargetInactive(t *testing.T) {
 	}
 }
 
-func TestMakeClusterIngress_WithTLS(t *testing.T) {
+// A Revision and an external target split the traffic.
+func TestMakeIngressRule_ExternalTarget(t *testing.T) {
+	targets := []traffic.RevisionTarget{{
+		TrafficTarget: v1beta1.TrafficTarget{
+			ConfigurationName: "config",
+			RevisionName:      "revision",
+			Percent:           30,
+		},
+		ServiceName: "new-hotness",
+		Active:      true,
+	}, {
+		TrafficTarget: v1beta1.TrafficTarget{
+			External: &v1beta1.ExternalTarget{
+				Host: "legacy.example.com",
+				Port: 8080,
+			},
+			Percent: 70,
+		},
+		ServiceName: "test-route-external-legacy-example-com",
+		Active:      true,
+	}}
+	domains := []string{"test.org"}
+	rule := makeIngressRule(domains, ns, targets)
+	expected := netv1alpha1.IngressRule{
+		Hosts: []string{"test.org"},
+		HTTP: &netv1alpha1.HTTPIngressRuleValue{
+			Paths: []netv1alpha1.HTTPIngressPath{{
+				Splits: []netv1alpha1.IngressBackendSplit{{
+					IngressBackend: netv1alpha1.IngressBackend{
+						ServiceNamespace: "test-ns",
+						ServiceName:      "new-hotness",
+						ServicePort:      intstr.FromInt(80),
+					},
+					Percent: 30,
+				}, {
+					IngressBackend: netv1alpha1.IngressBackend{
+						ServiceNamespace: "test-ns",
+						ServiceName:      "test-route-external-legacy-example-com",
+						ServicePort:      intstr.FromInt(8080),
+					},
+					Percent:  70,
+					External: true,
+				}},
+				AppendHeaders: map[string]string{
+					"Knative-Serving-Revision":  "revision",
+					"Knative-Serving-Namespace": "test-ns",
+				},
+			}},
+		},
+	}
+
+	if !cmp.Equal(&expected, rule) {
+		t.Errorf("Unexpected rule (-want, +got): %s", cmp.Diff(&expected, rule))
+	}
+}
+
+// Only an external target, so there's no Revision to tell the activator about.
+func TestMakeIngressRule_OnlyExternalTarget(t *testing.T) {
+	targets := []traffic.RevisionTarget{{
+		TrafficTarget: v1beta1.TrafficTarget{
+			External: &v1beta1.ExternalTarget{
+				ServiceName: "legacy",
+				Port:        80,
+			},
+			Percent: 100,
+		},
+		ServiceName: "legacy",
+		Active:      true,
+	}}
+	domains := []string{"test.org"}
+	rule := makeIngressRule(domains, ns, targets)
+	expected := netv1alpha1.IngressRule{
+		Hosts: []string{"test.org"},
+		HTTP: &netv1alpha1.HTTPIngressRuleValue{
+			Paths: []netv1alpha1.HTTPIngressPath{{
+				Splits: []netv1alpha1.IngressBackendSplit{{
+					IngressBackend: netv1alpha1.IngressBackend{
+						ServiceNamespace: "test-ns",
+						ServiceName:      "legacy",
+						ServicePort:      intstr.FromInt(80),
+					},
+					Percent:  100,
+					External: true,
+				}},
+			}},
+		},
+	}
+
+	if !cmp.Equal(&expected, rule) {
+		t.Errorf("Unexpected rule (-want, +got): %s", cmp.Diff(&expected, rule))
+	}
+}
+
+// An external host receiving all of the traffic gets requests for its own name.
+func TestMakeIngressRule_OnlyExternalHost(t *testing.T) {
+	targets := []traffic.RevisionTarget{{
+		TrafficTarget: v1beta1.TrafficTarget{
+			External: &v1beta1.ExternalTarget{
+				Host: "legacy.example.com",
+				Port: 80,
+			},
+			Percent: 100,
+		},
+		ServiceName: "test-route-external-legacy-example-com",
+		Active:      true,
+	}}
+	domains := []string{"test.org"}
+	rule := makeIngressRule(domains, ns, targets)
+	expected := netv1alpha1.IngressRule{
+		Hosts: []string{"test.org"},
+		HTTP: &netv1alpha1.HTTPIngressRuleValue{
+			Paths: []netv1alpha1.HTTPIngressPath{{
+				Splits: []netv1alpha1.IngressBackendSplit{{
+					IngressBackend: netv1alpha1.IngressBackend{
+						ServiceNamespace: "test-ns",
+						ServiceName:      "test-route-external-legacy-example-com",
+						ServicePort:      intstr.FromInt(80),
+					},
+					Percent:  100,
+					External: true,
+				}},
+				RewriteHost: "legacy.example.com",
+			}},
+		},
+	}
+
+	if !cmp.Equal(&expected, rule) {
+		t.Errorf("Unexpected rule (-want, +got): %s", cmp.Diff(&expected, rule))
+	}
+}
+
+func TestMakeIngress_WithTLS(t *testing.T) {
 	targets := map[string]traffic.RevisionTargets{}
 	ingressClass := "foo-ingress"
 	r := &v1alpha1.Route{
@@ -613,9 +747,10 @@ func TestMakeClusterIngress_WithTLS(t *testing.T) {
 			ServerCertificate: "tls.crt",
 		},
 	}
-	expected := &netv1alpha1.ClusterIngress{
+	expected := &netv1alpha1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "route-1234-5678",
+			Name:      "test-route",
+			Namespace: "test-ns",
 			Annotations: map[string]string{
 				networking.IngressClassAnnotationKey: ingressClass,
 			},
@@ -623,6 +758,7 @@ func TestMakeClusterIngress_WithTLS(t *testing.T) {
 				serving.RouteLabelKey:          "test-route",
 				serving.RouteNamespaceLabelKey: "test-ns",
 			},
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(r)},
 		},
 		Spec: netv1alpha1.IngressSpec{
 			Rules:      []netv1alpha1.IngressRule{},
@@ -630,7 +766,7 @@ func TestMakeClusterIngress_WithTLS(t *testing.T) {
 			Visibility: netv1alpha1.IngressVisibilityExternalIP,
 		},
 	}
-	got, err := MakeClusterIngress(getContext(), r, &traffic.Config{Targets: targets}, tls, ingressClass)
+	got, err := MakeIngress(getContext(), r, &traffic.Config{Targets: targets}, tls, ingressClass)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -640,7 +776,7 @@ func TestMakeClusterIngress_WithTLS(t *testing.T) {
 	}
 }
 
-func TestMakeClusterIngressTLS(t *testing.T) {
+func TestMakeIngressTLS(t *testing.T) {
 	cert := &netv1alpha1.Certificate{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "route-1234",
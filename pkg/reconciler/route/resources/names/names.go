@@ -18,6 +18,7 @@ package names
 
 import (
 	"fmt"
+	"strings"
 
 	"knative.dev/pkg/kmeta"
 	"github.com/knative/serving/pkg/network"
@@ -31,10 +32,10 @@ func K8sServiceFullname(route kmeta.Accessor) string {
 	return network.GetServiceHostname(K8sService(route), route.GetNamespace())
 }
 
-// ClusterIngress returns the name for the ClusterIngress
+// Ingress returns the name for the Ingress
 // child resource for the given Route.
-func ClusterIngress(route kmeta.Accessor) string {
-	return fmt.Sprintf("route-%s", route.GetUID())
+func Ingress(route kmeta.Accessor) string {
+	return route.GetName()
 }
 
 // Certificate returns the name for the Certificate
@@ -42,3 +43,9 @@ func ClusterIngress(route kmeta.Accessor) string {
 func Certificate(route kmeta.Accessor) string {
 	return fmt.Sprintf("route-%s", route.GetUID())
 }
+
+// ExternalService returns the name for the ExternalName Service
+// child resource through which the given Route reaches an external host.
+func ExternalService(route kmeta.Accessor, host string) string {
+	return kmeta.ChildName(route.GetName()+"-external-"+strings.Replace(host, ".", "-", -1), "")
+}
@@ -52,7 +52,7 @@ func TestNamer(t *testing.T) {
 		f:    K8sServiceFullname,
 		want: "bar.default.svc.cluster.local",
 	}, {
-		name: "ClusterIngressPrefix",
+		name: "Ingress",
 		route: &v1alpha1.Route{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      "bar",
@@ -60,8 +60,8 @@ func TestNamer(t *testing.T) {
 				UID:       "1234-5678-910",
 			},
 		},
-		f:    ClusterIngress,
-		want: "route-1234-5678-910",
+		f:    Ingress,
+		want: "bar",
 	}, {
 		name: "Certificate",
 		route: &v1alpha1.Route{
@@ -84,3 +84,19 @@ func TestNamer(t *testing.T) {
 		})
 	}
 }
+
+func TestExternalService(t *testing.T) {
+	route := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "bar",
+			Namespace: "default",
+		},
+	}
+	if got, want := ExternalService(route, "legacy.example.com"), "bar-external-legacy-example-com"; got != want {
+		t.Errorf("ExternalService() = %v, wanted %v", got, want)
+	}
+	long := "a-very-long-hostname-that-does-not-fit.in-a-service-name.example.com"
+	if got := ExternalService(route, long); len(got) > 63 {
+		t.Errorf("len(ExternalService()) = %d, wanted <= 63", len(got))
+	}
+}
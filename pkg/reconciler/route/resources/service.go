@@ -30,7 +30,9 @@ import (
 	netv1alpha1 "github.com/knative/serving/pkg/apis/networking/v1alpha1"
 	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving/v1beta1"
 	"github.com/knative/serving/pkg/reconciler/route/domains"
+	"github.com/knative/serving/pkg/reconciler/route/resources/names"
 )
 
 var errLoadBalancerNotFound = errors.New("failed to fetch loadbalancer domain/IP from ingress status")
@@ -69,10 +71,37 @@ func MakeK8sPlaceholderService(ctx context.Context, route *v1alpha1.Route, targe
 	return service, nil
 }
 
+// MakeExternalService creates an ExternalName Service through which the given
+// Route reaches the external host of a traffic target.  It's owned by the
+// provided v1alpha1.Route.
+func MakeExternalService(route *v1alpha1.Route, external *v1beta1.ExternalTarget) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      names.ExternalService(route, external.Host),
+			Namespace: route.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				// This service is owned by the Route.
+				*kmeta.NewControllerRef(route),
+			},
+			Labels: map[string]string{
+				serving.RouteLabelKey: route.Name,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: external.Host,
+			Ports: []corev1.ServicePort{{
+				Name: networking.ServicePortNameHTTP1,
+				Port: external.Port,
+			}},
+		},
+	}
+}
+
 // MakeK8sService creates a Service that redirect to the loadbalancer specified
-// in ClusterIngress status. It's owned by the provided v1alpha1.Route.
+// in the ingress status. It's owned by the provided v1alpha1.Route.
 // The purpose of this service is to provide a domain name for Istio routing.
-func MakeK8sService(ctx context.Context, route *v1alpha1.Route, targetName string, ingress *netv1alpha1.ClusterIngress) (*corev1.Service, error) {
+func MakeK8sService(ctx context.Context, route *v1alpha1.Route, targetName string, ingress netv1alpha1.IngressAccessor) (*corev1.Service, error) {
 	svcSpec, err := makeServiceSpec(ingress)
 	if err != nil {
 		return nil, err
@@ -106,14 +135,15 @@ func makeK8sService(ctx context.Context, route *v1alpha1.Route, targetName strin
 	}, nil
 }
 
-func makeServiceSpec(ingress *netv1alpha1.ClusterIngress) (*corev1.ServiceSpec, error) {
-	ingressStatus := ingress.Status
+func makeServiceSpec(ingress netv1alpha1.IngressAccessor) (*corev1.ServiceSpec, error) {
+	ingressStatus := ingress.GetStatus()
 	if ingressStatus.LoadBalancer == nil || len(ingressStatus.LoadBalancer.Ingress) == 0 {
 		return nil, errLoadBalancerNotFound
 	}
 	if len(ingressStatus.LoadBalancer.Ingress) > 1 {
 		// Return error as we only support one LoadBalancer currently.
-		return nil, fmt.Errorf("more than one ingress are specified in status(LoadBalancer) of ClusterIngress %s", ingress.Name)
+		return nil, fmt.Errorf("more than one ingress are specified in status(LoadBalancer) of %s %s",
+			ingress.GetGroupVersionKind().Kind, ingress.GetName())
 	}
 	balancer := ingressStatus.LoadBalancer.Ingress[0]
 
@@ -60,7 +60,7 @@ func TestNewMakeK8SService(t *testing.T) {
 	scenarios := map[string]struct {
 		// Inputs
 		route        *v1alpha1.Route
-		ingress      *netv1alpha1.ClusterIngress
+		ingress      *netv1alpha1.Ingress
 		targetName   string
 		expectedSpec corev1.ServiceSpec
 		expectedMeta metav1.ObjectMeta
@@ -68,7 +68,7 @@ func TestNewMakeK8SService(t *testing.T) {
 	}{
 		"no-loadbalancer": {
 			route: r,
-			ingress: &netv1alpha1.ClusterIngress{
+			ingress: &netv1alpha1.Ingress{
 				Status: netv1alpha1.IngressStatus{},
 			},
 			expectedMeta: expectedMeta,
@@ -76,7 +76,7 @@ func TestNewMakeK8SService(t *testing.T) {
 		},
 		"empty-loadbalancer": {
 			route: r,
-			ingress: &netv1alpha1.ClusterIngress{
+			ingress: &netv1alpha1.Ingress{
 				Status: netv1alpha1.IngressStatus{
 					LoadBalancer: &netv1alpha1.LoadBalancerStatus{
 						Ingress: []netv1alpha1.LoadBalancerIngressStatus{{}},
@@ -88,7 +88,7 @@ func TestNewMakeK8SService(t *testing.T) {
 		},
 		"multi-loadbalancer": {
 			route: r,
-			ingress: &netv1alpha1.ClusterIngress{
+			ingress: &netv1alpha1.Ingress{
 				Status: netv1alpha1.IngressStatus{
 					LoadBalancer: &netv1alpha1.LoadBalancerStatus{
 						Ingress: []netv1alpha1.LoadBalancerIngressStatus{{
@@ -104,7 +104,7 @@ func TestNewMakeK8SService(t *testing.T) {
 		},
 		"ingress-with-domain": {
 			route: r,
-			ingress: &netv1alpha1.ClusterIngress{
+			ingress: &netv1alpha1.Ingress{
 				Status: netv1alpha1.IngressStatus{
 					LoadBalancer: &netv1alpha1.LoadBalancerStatus{
 						Ingress: []netv1alpha1.LoadBalancerIngressStatus{{Domain: "domain.com"}},
@@ -119,7 +119,7 @@ func TestNewMakeK8SService(t *testing.T) {
 		},
 		"ingress-with-domaininternal": {
 			route: r,
-			ingress: &netv1alpha1.ClusterIngress{
+			ingress: &netv1alpha1.Ingress{
 				Status: netv1alpha1.IngressStatus{
 					LoadBalancer: &netv1alpha1.LoadBalancerStatus{
 						Ingress: []netv1alpha1.LoadBalancerIngressStatus{{DomainInternal: "istio-ingressgateway.istio-system.svc.cluster.local"}},
@@ -135,7 +135,7 @@ func TestNewMakeK8SService(t *testing.T) {
 		},
 		"ingress-with-only-mesh": {
 			route: r,
-			ingress: &netv1alpha1.ClusterIngress{
+			ingress: &netv1alpha1.Ingress{
 				Status: netv1alpha1.IngressStatus{
 					LoadBalancer: &netv1alpha1.LoadBalancerStatus{
 						Ingress: []netv1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}},
@@ -154,7 +154,7 @@ func TestNewMakeK8SService(t *testing.T) {
 		"with-target-name-specified": {
 			route:      r,
 			targetName: "my-target-name",
-			ingress: &netv1alpha1.ClusterIngress{
+			ingress: &netv1alpha1.Ingress{
 				Status: netv1alpha1.IngressStatus{
 					LoadBalancer: &netv1alpha1.LoadBalancerStatus{
 						Ingress: []netv1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}},
@@ -245,6 +245,38 @@ func TestMakePlaceholderK8sService(t *testing.T) {
 	}
 }
 
+func TestMakeExternalService(t *testing.T) {
+	service := MakeExternalService(r, &v1beta1.ExternalTarget{
+		Host: "legacy.example.com",
+		Port: 8080,
+	})
+	expectedMeta := metav1.ObjectMeta{
+		Name:      "test-route-external-legacy-example-com",
+		Namespace: r.Namespace,
+		OwnerReferences: []metav1.OwnerReference{
+			*kmeta.NewControllerRef(r),
+		},
+		Labels: map[string]string{
+			serving.RouteLabelKey: r.Name,
+		},
+	}
+	expectedSpec := corev1.ServiceSpec{
+		Type:         corev1.ServiceTypeExternalName,
+		ExternalName: "legacy.example.com",
+		Ports: []corev1.ServicePort{{
+			Name: "http",
+			Port: 8080,
+		}},
+	}
+
+	if !cmp.Equal(expectedMeta, service.ObjectMeta) {
+		t.Errorf("Unexpected Metadata (-want +got): %s", cmp.Diff(expectedMeta, service.ObjectMeta))
+	}
+	if !cmp.Equal(expectedSpec, service.Spec) {
+		t.Errorf("Unexpected ServiceSpec (-want +got): %s", cmp.Diff(expectedSpec, service.Spec))
+	}
+}
+
 func TestSelectorFromRoute(t *testing.T) {
 	selector := SelectorFromRoute(r)
 	if !selector.Matches(labels.Set{serving.RouteLabelKey: r.Name}) {
@@ -71,6 +71,7 @@ type Reconciler struct {
 	revisionLister       listers.RevisionLister
 	serviceLister        corev1listers.ServiceLister
 	clusterIngressLister networkinglisters.ClusterIngressLister
+	ingressLister        networkinglisters.IngressLister
 	certificateLister    networkinglisters.CertificateLister
 	configStore          reconciler.ConfigStore
 	tracker              tracker.Interface
@@ -207,7 +208,8 @@ func (c *Reconciler) reconcile(ctx context.Context, r *v1alpha1.Route) error {
 		Hostname: "",
 	}
 
-	// Add the finalizer before creating the ClusterIngress so that we can be sure it gets cleaned up.
+	// Add the finalizer before reconciling the ingresses so that we can be sure
+	// the ClusterIngresses created by previous releases get cleaned up.
 	if err := c.ensureFinalizer(r); err != nil {
 		return err
 	}
@@ -223,19 +225,25 @@ func (c *Reconciler) reconcile(ctx context.Context, r *v1alpha1.Route) error {
 		return err
 	}
 
-	logger.Info("Creating ClusterIngress.")
-	desired, err := resources.MakeClusterIngress(ctx, r, traffic, tls, ingressClassForRoute(ctx, r))
+	logger.Info("Creating Ingress.")
+	desired, err := resources.MakeIngress(ctx, r, traffic, tls, ingressClassForRoute(ctx, r))
 	if err != nil {
 		return err
 	}
-	clusterIngress, err := c.reconcileClusterIngress(ctx, r, desired)
+	ingress, err := c.reconcileIngress(ctx, r, desired)
 	if err != nil {
 		return err
 	}
-	r.Status.PropagateClusterIngressStatus(clusterIngress.Status)
+	// Keep serving through the ClusterIngress of a previous release, if any,
+	// until the Ingress takes over.
+	current, err := c.reconcileLegacyClusterIngress(ctx, r, ingress)
+	if err != nil {
+		return err
+	}
+	r.Status.PropagateClusterIngressStatus(*current.GetStatus())
 
-	logger.Info("Updating placeholder k8s services with clusterIngress information")
-	if err := c.updatePlaceholderServices(ctx, r, services, clusterIngress); err != nil {
+	logger.Info("Updating placeholder k8s services with ingress information")
+	if err := c.updatePlaceholderServices(ctx, r, services, current); err != nil {
 		return err
 	}
 
@@ -291,8 +299,9 @@ func (c *Reconciler) tls(ctx context.Context, host string, r *v1alpha1.Route, tr
 func (c *Reconciler) reconcileDeletion(ctx context.Context, r *v1alpha1.Route) error {
 	logger := logging.FromContext(ctx)
 
-	// If our Finalizer is first, delete the ClusterIngress for this Route
-	// and remove the finalizer.
+	// If our Finalizer is first, delete the ClusterIngresses of previous
+	// releases for this Route and remove the finalizer. The Ingress is
+	// owned by the Route, so it is garbage collected along with it.
 	if len(r.Finalizers) == 0 || r.Finalizers[0] != routeFinalizer {
 		return nil
 	}
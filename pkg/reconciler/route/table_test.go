@@ -125,7 +125,7 @@ func TestReconcile(t *testing.T) {
 			rev("default", "config", 1, MarkRevisionReady, WithRevName("config-00001"), WithServiceName("mcd")),
 		},
 		WantCreates: []runtime.Object{
-			simpleClusterIngress(
+			simpleIngress(
 				route("default", "becomes-ready", WithConfigTarget("config"), WithURL,
 					WithRouteUID("12-34")),
 				&traffic.Config{
@@ -166,11 +166,82 @@ func TestReconcile(t *testing.T) {
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "Created", "Created placeholder service %q", "becomes-ready"),
-			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", "route-12-34"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "becomes-ready"),
 		},
 		Key: "default/becomes-ready",
 		// TODO(lichuqiang): config namespace validation in resource scope.
 		SkipNamespaceValidation: true,
+	}, {
+		Name: "route with an external host becomes ready, ingress unknown",
+		Objects: []runtime.Object{
+			route("default", "migrating", withExternalSplit, WithRouteUID("12-34")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated("config-00001"), WithLatestReady("config-00001")),
+			rev("default", "config", 1, MarkRevisionReady, WithRevName("config-00001"), WithServiceName("gump")),
+		},
+		WantCreates: []runtime.Object{
+			simpleIngress(
+				route("default", "migrating", withExternalSplit, WithURL, WithRouteUID("12-34")),
+				&traffic.Config{
+					Targets: map[string]traffic.RevisionTargets{
+						traffic.DefaultTarget: {{
+							TrafficTarget: v1beta1.TrafficTarget{
+								// Use the Revision name from the config.
+								RevisionName: "config-00001",
+								Percent:      90,
+							},
+							ServiceName: "gump",
+							Active:      true,
+						}, {
+							TrafficTarget: v1beta1.TrafficTarget{
+								External: legacyTarget,
+								Percent:  10,
+							},
+							ServiceName: "migrating-external-legacy-example-com",
+							Active:      true,
+						}},
+					},
+				},
+			),
+			simplePlaceholderK8sService(
+				getContext(),
+				route("default", "migrating", withExternalSplit, WithRouteUID("12-34")),
+				"",
+			),
+			resources.MakeExternalService(
+				route("default", "migrating", withExternalSplit, WithRouteUID("12-34")),
+				legacyTarget,
+			),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchFinalizers("default", "migrating"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "migrating", withExternalSplit,
+				WithRouteUID("12-34"),
+				// Populated by reconciliation when all traffic has been assigned.
+				WithURL, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressNotConfigured, WithStatusTraffic(v1alpha1.TrafficTarget{
+					TrafficTarget: v1beta1.TrafficTarget{
+						RevisionName:   "config-00001",
+						Percent:        90,
+						LatestRevision: ptr.Bool(true),
+					},
+				}, v1alpha1.TrafficTarget{
+					TrafficTarget: v1beta1.TrafficTarget{
+						External: legacyTarget,
+						Percent:  10,
+					},
+				})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created placeholder service %q", "migrating"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created external service %q", "migrating-external-legacy-example-com"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "migrating"),
+		},
+		Key: "default/migrating",
+		// TODO(lichuqiang): config namespace validation in resource scope.
+		SkipNamespaceValidation: true,
 	}, {
 		Name: "custom ingress route becomes ready, ingress unknown",
 		Objects: []runtime.Object{
@@ -223,7 +294,7 @@ func TestReconcile(t *testing.T) {
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "Created", "Created placeholder service %q", "becomes-ready"),
-			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", "route-12-34"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "becomes-ready"),
 		},
 		Key: "default/becomes-ready",
 		// TODO(lichuqiang): config namespace validation in resource scope.
@@ -239,7 +310,7 @@ func TestReconcile(t *testing.T) {
 			rev("default", "config", 1, MarkRevisionReady, WithRevName("config-00001"), WithServiceName("tb")),
 		},
 		WantCreates: []runtime.Object{
-			simpleClusterIngress(
+			simpleIngress(
 				route("default", "becomes-ready", WithConfigTarget("config"),
 					WithLocalDomain, WithRouteUID("65-23"),
 					WithRouteLabel("serving.knative.dev/visibility", "cluster-local")),
@@ -284,7 +355,7 @@ func TestReconcile(t *testing.T) {
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "Created", "Created placeholder service %q", "becomes-ready"),
-			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", "route-65-23"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "becomes-ready"),
 		},
 		Key: "default/becomes-ready",
 		// TODO(lichuqiang): config namespace validation in resource scope.
@@ -398,14 +469,14 @@ func TestReconcile(t *testing.T) {
 				WithGeneration(1), WithLatestCreated("config-00001"), WithLatestReady("config-00001")),
 			rev("default", "config", 1, MarkRevisionReady, WithRevName("config-00001"), WithServiceName("astrid")),
 		},
-		// We induce a failure creating the ClusterIngress.
+		// We induce a failure creating the Ingress.
 		WantErr: true,
 		WithReactors: []clientgotesting.ReactionFunc{
-			InduceFailure("create", "clusteringresses"),
+			InduceFailure("create", "ingresses"),
 		},
 		WantCreates: []runtime.Object{
 			//This is the Create we see for the cluter ingress, but we induce a failure.
-			simpleClusterIngress(
+			simpleIngress(
 				route("default", "ingress-create-failure", WithConfigTarget("config"),
 					WithURL),
 				&traffic.Config{
@@ -445,9 +516,9 @@ func TestReconcile(t *testing.T) {
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "Created", "Created placeholder service %q", "ingress-create-failure"),
-			Eventf(corev1.EventTypeWarning, "CreationFailed", "Failed to create ClusterIngress for route %s/%s: %v",
-				"default", "ingress-create-failure", "inducing failure for create clusteringresses"),
-			Eventf(corev1.EventTypeWarning, "InternalError", "inducing failure for create clusteringresses"),
+			Eventf(corev1.EventTypeWarning, "CreationFailed", "Failed to create Ingress for route %s/%s: %v",
+				"default", "ingress-create-failure", "inducing failure for create ingresses"),
+			Eventf(corev1.EventTypeWarning, "InternalError", "inducing failure for create ingresses"),
 		},
 		Key:                     "default/ingress-create-failure",
 		SkipNamespaceValidation: true,
@@ -726,7 +797,7 @@ func TestReconcile(t *testing.T) {
 		// Starting from the new latest ready, induce a failure updating the cluster ingress.
 		WantErr: true,
 		WithReactors: []clientgotesting.ReactionFunc{
-			InduceFailure("update", "clusteringresses"),
+			InduceFailure("update", "ingresses"),
 		},
 		Objects: []runtime.Object{
 			route("default", "update-ci-failure", WithConfigTarget("config"),
@@ -795,7 +866,7 @@ func TestReconcile(t *testing.T) {
 					})),
 		}},
 		WantEvents: []string{
-			Eventf(corev1.EventTypeWarning, "InternalError", "inducing failure for update clusteringresses"),
+			Eventf(corev1.EventTypeWarning, "InternalError", "inducing failure for update ingresses"),
 		},
 		Key:                     "default/update-ci-failure",
 		SkipNamespaceValidation: true,
@@ -1207,7 +1278,7 @@ func TestReconcile(t *testing.T) {
 			rev("default", "green", 1, MarkRevisionReady, WithRevName("green-00001"), WithServiceName("green-lake")),
 		},
 		WantCreates: []runtime.Object{
-			simpleClusterIngress(
+			simpleIngress(
 				route("default", "named-traffic-split", WithURL, WithSpecTraffic(
 					v1alpha1.TrafficTarget{
 						TrafficTarget: v1beta1.TrafficTarget{
@@ -1290,7 +1361,7 @@ func TestReconcile(t *testing.T) {
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "Created", "Created placeholder service %q", "named-traffic-split"),
-			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", "route-34-78"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "named-traffic-split"),
 		},
 		Key:                     "default/named-traffic-split",
 		SkipNamespaceValidation: true,
@@ -1316,7 +1387,7 @@ func TestReconcile(t *testing.T) {
 			rev("default", "gray", 1, MarkRevisionReady, WithRevName("gray-00001"), WithServiceName("shades")),
 		},
 		WantCreates: []runtime.Object{
-			simpleClusterIngress(
+			simpleIngress(
 				route("default", "same-revision-targets", WithURL, WithSpecTraffic(
 					v1alpha1.TrafficTarget{
 						TrafficTarget: v1beta1.TrafficTarget{
@@ -1463,7 +1534,7 @@ func TestReconcile(t *testing.T) {
 			Eventf(corev1.EventTypeNormal, "Created", "Created placeholder service %q", "same-revision-targets"),
 			Eventf(corev1.EventTypeNormal, "Created", "Created placeholder service %q", "also-gray-same-revision-targets"),
 			Eventf(corev1.EventTypeNormal, "Created", "Created placeholder service %q", "gray-same-revision-targets"),
-			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", "route-1-2"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "same-revision-targets"),
 		},
 		Key:                     "default/same-revision-targets",
 		SkipNamespaceValidation: true,
@@ -1661,9 +1732,9 @@ func TestReconcile(t *testing.T) {
 		},
 		Key: "default/stale-lastpinned",
 	}, {
-		Name: "check that we can find the cluster ingress with old naming",
+		Name: "check that the legacy cluster ingress serves until the ingress is ready",
 		Objects: []runtime.Object{
-			route("default", "old-naming", WithConfigTarget("config"), WithRouteFinalizer,
+			route("default", "legacy-ingress", WithConfigTarget("config"), WithRouteFinalizer,
 				WithURL, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
@@ -1677,12 +1748,11 @@ func TestReconcile(t *testing.T) {
 				WithGeneration(1),
 				WithLatestCreated("config-00001"), WithLatestReady("config-00001"),
 				// The Route controller attaches our label to this Configuration.
-				WithConfigLabel("serving.knative.dev/route", "old-naming"),
+				WithConfigLabel("serving.knative.dev/route", "legacy-ingress"),
 			),
 			rev("default", "config", 1, MarkRevisionReady, WithRevName("config-00001")),
-			// Make sure that we can find the ingress by labels if the name is different.
-			changeIngressName(simpleReadyIngress(
-				route("default", "old-naming", WithConfigTarget("config"), WithURL),
+			simpleIngress(
+				route("default", "legacy-ingress", WithConfigTarget("config"), WithURL),
 				&traffic.Config{
 					Targets: map[string]traffic.RevisionTargets{
 						traffic.DefaultTarget: {{
@@ -1695,10 +1765,165 @@ func TestReconcile(t *testing.T) {
 						}},
 					},
 				},
+			),
+			// The ClusterIngress created by a previous release.
+			legacyClusterIngress(simpleReadyIngress(
+				route("default", "legacy-ingress", WithConfigTarget("config"), WithURL),
+				&traffic.Config{
+					Targets: map[string]traffic.RevisionTargets{
+						traffic.DefaultTarget: {{
+							TrafficTarget: v1beta1.TrafficTarget{
+								RevisionName: "config-00001",
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
 			)),
-			simpleK8sService(route("default", "old-naming", WithConfigTarget("config"))),
+			simpleK8sService(route("default", "legacy-ingress", WithConfigTarget("config"))),
 		},
-		Key: "default/old-naming",
+		Key: "default/legacy-ingress",
+	}, {
+		Name: "check that the legacy cluster ingress follows the ingress until it is ready",
+		Objects: []runtime.Object{
+			route("default", "legacy-ingress", WithConfigTarget("config"), WithRouteFinalizer,
+				WithURL, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						TrafficTarget: v1beta1.TrafficTarget{
+							RevisionName:   "config-00001",
+							Percent:        100,
+							LatestRevision: ptr.Bool(true),
+						},
+					})),
+			cfg("default", "config",
+				WithGeneration(1),
+				WithLatestCreated("config-00001"), WithLatestReady("config-00001"),
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "legacy-ingress"),
+			),
+			rev("default", "config", 1, MarkRevisionReady, WithRevName("config-00001"), WithServiceName("mcd")),
+			simpleIngress(
+				route("default", "legacy-ingress", WithConfigTarget("config"), WithURL),
+				&traffic.Config{
+					Targets: map[string]traffic.RevisionTargets{
+						traffic.DefaultTarget: {{
+							TrafficTarget: v1beta1.TrafficTarget{
+								// Use the Revision name from the config.
+								RevisionName: "config-00001",
+								Percent:      100,
+							},
+							ServiceName: "mcd",
+							Active:      true,
+						}},
+					},
+				},
+			),
+			// The ClusterIngress created by a previous release, with
+			// a stale spec.
+			legacyClusterIngress(mutateIngress(simpleReadyIngress(
+				route("default", "legacy-ingress", WithConfigTarget("config"), WithURL),
+				&traffic.Config{
+					Targets: map[string]traffic.RevisionTargets{
+						traffic.DefaultTarget: {{
+							TrafficTarget: v1beta1.TrafficTarget{
+								RevisionName: "config-00001",
+								Percent:      100,
+							},
+							ServiceName: "mcd",
+							Active:      true,
+						}},
+					},
+				},
+			))),
+			simpleK8sService(route("default", "legacy-ingress", WithConfigTarget("config"))),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: legacyClusterIngress(simpleReadyIngress(
+				route("default", "legacy-ingress", WithConfigTarget("config"), WithURL),
+				&traffic.Config{
+					Targets: map[string]traffic.RevisionTargets{
+						traffic.DefaultTarget: {{
+							TrafficTarget: v1beta1.TrafficTarget{
+								RevisionName: "config-00001",
+								Percent:      100,
+							},
+							ServiceName: "mcd",
+							Active:      true,
+						}},
+					},
+				},
+			)),
+		}},
+		Key:                     "default/legacy-ingress",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "check that the legacy cluster ingress is deleted once the ingress is ready",
+		Objects: []runtime.Object{
+			route("default", "migrated-ingress", WithConfigTarget("config"), WithRouteFinalizer,
+				WithURL, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						TrafficTarget: v1beta1.TrafficTarget{
+							RevisionName:   "config-00001",
+							Percent:        100,
+							LatestRevision: ptr.Bool(true),
+						},
+					})),
+			cfg("default", "config",
+				WithGeneration(1),
+				WithLatestCreated("config-00001"), WithLatestReady("config-00001"),
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "migrated-ingress"),
+			),
+			rev("default", "config", 1, MarkRevisionReady, WithRevName("config-00001")),
+			simpleReadyIngress(
+				route("default", "migrated-ingress", WithConfigTarget("config"), WithURL),
+				&traffic.Config{
+					Targets: map[string]traffic.RevisionTargets{
+						traffic.DefaultTarget: {{
+							TrafficTarget: v1beta1.TrafficTarget{
+								// Use the Revision name from the config.
+								RevisionName: "config-00001",
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+			// The ClusterIngress created by a previous release.
+			legacyClusterIngress(simpleReadyIngress(
+				route("default", "migrated-ingress", WithConfigTarget("config"), WithURL),
+				&traffic.Config{
+					Targets: map[string]traffic.RevisionTargets{
+						traffic.DefaultTarget: {{
+							TrafficTarget: v1beta1.TrafficTarget{
+								RevisionName: "config-00001",
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			)),
+			simpleK8sService(route("default", "migrated-ingress", WithConfigTarget("config"))),
+		},
+		WantDeleteCollections: []clientgotesting.DeleteCollectionActionImpl{{
+			ListRestrictions: clientgotesting.ListRestrictions{
+				Labels: labels.Set(map[string]string{
+					serving.RouteLabelKey:          "migrated-ingress",
+					serving.RouteNamespaceLabelKey: "default",
+				}).AsSelector(),
+				Fields: fields.Nothing(),
+			},
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Deleted", "Deleted ClusterIngress %q", "route-migrated-ingress-uid"),
+		},
+		Key:                     "default/migrated-ingress",
+		SkipNamespaceValidation: true,
 	}, {
 		Name: "check that we do nothing with a deletion timestamp and no finalizers",
 		Objects: []runtime.Object{
@@ -1890,6 +2115,7 @@ func TestReconcile(t *testing.T) {
 			revisionLister:       listers.GetRevisionLister(),
 			serviceLister:        listers.GetK8sServiceLister(),
 			clusterIngressLister: listers.GetClusterIngressLister(),
+			ingressLister:        listers.GetIngressLister(),
 			tracker:              &NullTracker{},
 			configStore: &testConfigStore{
 				config: ReconcilerTestConfig(false),
@@ -1957,7 +2183,7 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "Created", "Created placeholder service %q", "becomes-ready"),
 			Eventf(corev1.EventTypeNormal, "Created", "Created Certificate %q/%q", "default", "route-12-34"),
-			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", "route-12-34"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "becomes-ready"),
 		},
 		Key:                     "default/becomes-ready",
 		SkipNamespaceValidation: true,
@@ -2038,7 +2264,7 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "Created", "Created placeholder service %q", "becomes-ready"),
 			Eventf(corev1.EventTypeNormal, "Updated", "Updated Spec for Certificate %s/%s", "default", "route-12-34"),
-			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", "route-12-34"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "becomes-ready"),
 		},
 		Key:                     "default/becomes-ready",
 		SkipNamespaceValidation: true,
@@ -2052,6 +2278,7 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 			revisionLister:       listers.GetRevisionLister(),
 			serviceLister:        listers.GetK8sServiceLister(),
 			clusterIngressLister: listers.GetClusterIngressLister(),
+			ingressLister:        listers.GetIngressLister(),
 			certificateLister:    listers.GetCertificateLister(),
 			tracker:              &NullTracker{},
 			configStore: &testConfigStore{
@@ -2062,6 +2289,26 @@ func TestReconcile_EnableAutoTLS(t *testing.T) {
 	}))
 }
 
+var legacyTarget = &v1beta1.ExternalTarget{
+	Host: "legacy.example.com",
+	Port: 80,
+}
+
+// withExternalSplit sends a tenth of the Route's traffic to legacyTarget.
+func withExternalSplit(r *v1alpha1.Route) {
+	WithSpecTraffic(v1alpha1.TrafficTarget{
+		TrafficTarget: v1beta1.TrafficTarget{
+			ConfigurationName: "config",
+			Percent:           90,
+		},
+	}, v1alpha1.TrafficTarget{
+		TrafficTarget: v1beta1.TrafficTarget{
+			External: legacyTarget,
+			Percent:  10,
+		},
+	})(r)
+}
+
 func route(namespace, name string, ro ...RouteOption) *v1alpha1.Route {
 	r := &v1alpha1.Route{
 		ObjectMeta: metav1.ObjectMeta{
@@ -2123,7 +2370,7 @@ func simpleK8sService(r *v1alpha1.Route, so ...K8sServiceOption) *corev1.Service
 
 	// omit the error here, as we are sure the loadbalancer info is porvided.
 	// return the service instance only, so that the result can be used in TableRow.
-	svc, _ := resources.MakeK8sService(ctx, r, "", &netv1alpha1.ClusterIngress{Status: readyIngressStatus()})
+	svc, _ := resources.MakeK8sService(ctx, r, "", &netv1alpha1.Ingress{Status: readyIngressStatus()})
 
 	for _, opt := range so {
 		opt(svc)
@@ -2132,12 +2379,12 @@ func simpleK8sService(r *v1alpha1.Route, so ...K8sServiceOption) *corev1.Service
 	return svc
 }
 
-func simpleClusterIngress(r *v1alpha1.Route, tc *traffic.Config, io ...ClusterIngressOption) *netv1alpha1.ClusterIngress {
+func simpleIngress(r *v1alpha1.Route, tc *traffic.Config, io ...IngressOption) *netv1alpha1.Ingress {
 	return ingressWithClass(r, tc, TestIngressClass, io...)
 }
 
-func ingressWithClass(r *v1alpha1.Route, tc *traffic.Config, class string, io ...ClusterIngressOption) *netv1alpha1.ClusterIngress {
-	ingress, _ := resources.MakeClusterIngress(getContext(), r, tc, nil, class)
+func ingressWithClass(r *v1alpha1.Route, tc *traffic.Config, class string, io ...IngressOption) *netv1alpha1.Ingress {
+	ingress, _ := resources.MakeIngress(getContext(), r, tc, nil, class)
 
 	for _, opt := range io {
 		opt(ingress)
@@ -2146,8 +2393,8 @@ func ingressWithClass(r *v1alpha1.Route, tc *traffic.Config, class string, io ..
 	return ingress
 }
 
-func ingressWithTLS(r *v1alpha1.Route, tc *traffic.Config, tls []netv1alpha1.IngressTLS, io ...ClusterIngressOption) *netv1alpha1.ClusterIngress {
-	ingress, _ := resources.MakeClusterIngress(getContext(), r, tc, tls, TestIngressClass)
+func ingressWithTLS(r *v1alpha1.Route, tc *traffic.Config, tls []netv1alpha1.IngressTLS, io ...IngressOption) *netv1alpha1.Ingress {
+	ingress, _ := resources.MakeIngress(getContext(), r, tc, tls, TestIngressClass)
 
 	for _, opt := range io {
 		opt(ingress)
@@ -2156,7 +2403,7 @@ func ingressWithTLS(r *v1alpha1.Route, tc *traffic.Config, tls []netv1alpha1.Ing
 	return ingress
 }
 
-func simpleReadyIngress(r *v1alpha1.Route, tc *traffic.Config, io ...ClusterIngressOption) *netv1alpha1.ClusterIngress {
+func simpleReadyIngress(r *v1alpha1.Route, tc *traffic.Config, io ...IngressOption) *netv1alpha1.Ingress {
 	ingress := ingressWithStatus(r, tc, readyIngressStatus())
 
 	for _, opt := range io {
@@ -2177,20 +2424,29 @@ func readyIngressStatus() netv1alpha1.IngressStatus {
 	return status
 }
 
-func ingressWithStatus(r *v1alpha1.Route, tc *traffic.Config, status netv1alpha1.IngressStatus) *netv1alpha1.ClusterIngress {
-	ci := simpleClusterIngress(r, tc)
+func ingressWithStatus(r *v1alpha1.Route, tc *traffic.Config, status netv1alpha1.IngressStatus) *netv1alpha1.Ingress {
+	ci := simpleIngress(r, tc)
 	ci.Name = r.Name
 	ci.Status = status
 
 	return ci
 }
 
-func changeIngressName(ci *netv1alpha1.ClusterIngress) *netv1alpha1.ClusterIngress {
-	ci.Name = "not-what-we-thought"
-	return ci
+// legacyClusterIngress returns the ClusterIngress that a previous release
+// created for the Route of the given Ingress.
+func legacyClusterIngress(ingress *netv1alpha1.Ingress) *netv1alpha1.ClusterIngress {
+	return &netv1alpha1.ClusterIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("route-%s-uid", ingress.Name),
+			Labels:      ingress.Labels,
+			Annotations: ingress.Annotations,
+		},
+		Spec:   ingress.Spec,
+		Status: ingress.Status,
+	}
 }
 
-func mutateIngress(ci *netv1alpha1.ClusterIngress) *netv1alpha1.ClusterIngress {
+func mutateIngress(ci *netv1alpha1.Ingress) *netv1alpha1.Ingress {
 	// Thor's Hammer
 	ci.Spec = netv1alpha1.IngressSpec{}
 	return ci
@@ -28,6 +28,7 @@ import (
 	"github.com/knative/serving/pkg/apis/serving/v1beta1"
 	listers "github.com/knative/serving/pkg/client/listers/serving/v1alpha1"
 	"github.com/knative/serving/pkg/reconciler/route/domains"
+	"github.com/knative/serving/pkg/reconciler/route/resources/names"
 )
 
 const (
@@ -36,7 +37,8 @@ const (
 )
 
 // A RevisionTarget adds the Active/Inactive state and the transport protocol of a
-// Revision to a flattened TrafficTarget.
+// Revision to a flattened TrafficTarget.  For an External target, which has no
+// Revision, ServiceName is the K8s Service the traffic is sent to.
 type RevisionTarget struct {
 	v1beta1.TrafficTarget
 	Active      bool
@@ -72,7 +74,7 @@ type Config struct {
 // In the case that some target is missing, an error of type TargetError will be returned.
 func BuildTrafficConfiguration(configLister listers.ConfigurationLister, revLister listers.RevisionLister,
 	u *v1alpha1.Route) (*Config, error) {
-	builder := newBuilder(configLister, revLister, u.Namespace, len(u.Spec.Traffic))
+	builder := newBuilder(configLister, revLister, u, len(u.Spec.Traffic))
 	builder.applySpecTraffic(u.Spec.Traffic)
 	return builder.build()
 }
@@ -98,6 +100,7 @@ func (t *Config) GetRevisionTrafficTargets(ctx context.Context, r *v1alpha1.Rout
 				RevisionName:   tt.RevisionName,
 				Percent:        tt.Percent,
 				LatestRevision: tt.LatestRevision,
+				External:       tt.External,
 			},
 		}
 		if tt.Tag != "" {
@@ -119,6 +122,7 @@ func (t *Config) GetRevisionTrafficTargets(ctx context.Context, r *v1alpha1.Rout
 type configBuilder struct {
 	configLister listers.ConfigurationLister
 	revLister    listers.RevisionLister
+	route        *v1alpha1.Route
 	namespace    string
 
 	// targets is a grouping of traffic targets serving the same origin.
@@ -138,11 +142,12 @@ type configBuilder struct {
 
 func newBuilder(
 	configLister listers.ConfigurationLister, revLister listers.RevisionLister,
-	namespace string, trafficSize int) *configBuilder {
+	route *v1alpha1.Route, trafficSize int) *configBuilder {
 	return &configBuilder{
 		configLister:    configLister,
 		revLister:       revLister,
-		namespace:       namespace,
+		route:           route,
+		namespace:       route.Namespace,
 		targets:         make(map[string]RevisionTargets),
 		revisionTargets: make(RevisionTargets, 0, trafficSize),
 
@@ -197,7 +202,9 @@ func (t *configBuilder) deferTargetError(err TargetError) {
 
 func (t *configBuilder) addTrafficTarget(tt *v1alpha1.TrafficTarget) error {
 	var err error
-	if tt.RevisionName != "" {
+	if tt.External != nil {
+		t.addExternalTarget(tt)
+	} else if tt.RevisionName != "" {
 		err = t.addRevisionTarget(tt)
 	} else if tt.ConfigurationName != "" {
 		err = t.addConfigurationTarget(tt)
@@ -263,6 +270,22 @@ func (t *configBuilder) addRevisionTarget(tt *v1alpha1.TrafficTarget) error {
 	return nil
 }
 
+// addExternalTarget adds a target sending traffic outside of Knative.  An
+// external host is reached through an ExternalName Service named after it.
+func (t *configBuilder) addExternalTarget(tt *v1alpha1.TrafficTarget) {
+	ntt := tt.TrafficTarget.DeepCopy()
+	serviceName := ntt.External.ServiceName
+	if serviceName == "" {
+		serviceName = names.ExternalService(t.route, ntt.External.Host)
+	}
+	t.addFlattenedTarget(RevisionTarget{
+		TrafficTarget: *ntt,
+		Active:        true,
+		Protocol:      net.ProtocolHTTP1,
+		ServiceName:   serviceName,
+	})
+}
+
 func (t *configBuilder) addFlattenedTarget(target RevisionTarget) {
 	name := target.TrafficTarget.Tag
 	t.revisionTargets = append(t.revisionTargets, target)
@@ -272,23 +295,38 @@ func (t *configBuilder) addFlattenedTarget(target RevisionTarget) {
 	}
 }
 
+// targetKey identifies the destination of a RevisionTarget: either a
+// Revision, or the Service and port of an External target.
+type targetKey struct {
+	revisionName string
+	serviceName  string
+	port         int32
+}
+
+func keyOf(tt RevisionTarget) targetKey {
+	if tt.External != nil {
+		return targetKey{serviceName: tt.ServiceName, port: tt.External.Port}
+	}
+	return targetKey{revisionName: tt.RevisionName}
+}
+
 func consolidate(targets RevisionTargets) RevisionTargets {
-	byName := make(map[string]RevisionTarget)
-	names := []string{}
+	byKey := make(map[targetKey]RevisionTarget)
+	keys := []targetKey{}
 	for _, tt := range targets {
-		name := tt.TrafficTarget.RevisionName
-		cur, ok := byName[name]
+		key := keyOf(tt)
+		cur, ok := byKey[key]
 		if !ok {
-			byName[name] = tt
-			names = append(names, name)
+			byKey[key] = tt
+			keys = append(keys, key)
 		} else {
 			cur.TrafficTarget.Percent += tt.TrafficTarget.Percent
-			byName[name] = cur
+			byKey[key] = cur
 		}
 	}
-	consolidated := make([]RevisionTarget, len(names))
-	for i, name := range names {
-		consolidated[i] = byName[name]
+	consolidated := make([]RevisionTarget, len(keys))
+	for i, key := range keys {
+		consolidated[i] = byKey[key]
 	}
 	if len(consolidated) == 1 {
 		consolidated[0].TrafficTarget.Percent = 100
@@ -165,6 +165,73 @@ func TestBuildTrafficConfiguration_Vanilla(t *testing.T) {
 	}
 }
 
+// External targets are split with Revisions, and consolidated by destination.
+func TestBuildTrafficConfiguration_External(t *testing.T) {
+	external := &v1beta1.ExternalTarget{
+		Host: "legacy.example.com",
+		Port: 80,
+	}
+	tts := []v1alpha1.TrafficTarget{{
+		TrafficTarget: v1beta1.TrafficTarget{
+			ConfigurationName: goodConfig.Name,
+			Percent:           80,
+		},
+	}, {
+		TrafficTarget: v1beta1.TrafficTarget{
+			External: external,
+			Percent:  10,
+		},
+	}, {
+		TrafficTarget: v1beta1.TrafficTarget{
+			Tag:      "legacy",
+			External: external,
+			Percent:  10,
+		},
+	}}
+
+	goodTarget := RevisionTarget{
+		TrafficTarget: v1beta1.TrafficTarget{
+			ConfigurationName: goodConfig.Name,
+			RevisionName:      goodNewRev.Name,
+			Percent:           80,
+		},
+		Active:   true,
+		Protocol: net.ProtocolH2C,
+	}
+	externalTarget := func(tag string, percent int) RevisionTarget {
+		return RevisionTarget{
+			TrafficTarget: v1beta1.TrafficTarget{
+				Tag:      tag,
+				External: external,
+				Percent:  percent,
+			},
+			Active:      true,
+			Protocol:    net.ProtocolHTTP1,
+			ServiceName: "test-route-external-legacy-example-com",
+		}
+	}
+	expected := &Config{
+		Targets: map[string]RevisionTargets{
+			DefaultTarget: {goodTarget, externalTarget("", 20)},
+			"legacy":      {externalTarget("legacy", 100)},
+		},
+		revisionTargets: []RevisionTarget{
+			goodTarget, externalTarget("", 10), externalTarget("legacy", 10),
+		},
+		Configurations: map[string]*v1alpha1.Configuration{
+			goodConfig.Name: goodConfig,
+		},
+		Revisions: map[string]*v1alpha1.Revision{
+			goodNewRev.Name: goodNewRev,
+		},
+	}
+	if tc, err := BuildTrafficConfiguration(configLister, revLister, testRouteWithTrafficTargets(tts)); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	} else if got, want := tc, expected; !cmp.Equal(want, got, cmpOpts...) {
+		t.Errorf("Unexpected traffic diff (-want +got): %v", cmp.Diff(want, got, cmpOpts...))
+	}
+}
+
 func TestBuildTrafficConfiguration_NoNameRevision(t *testing.T) {
 	tts := []v1alpha1.TrafficTarget{{
 		TrafficTarget: v1beta1.TrafficTarget{
@@ -143,6 +143,11 @@ func (l *Listers) GetClusterIngressLister() networkinglisters.ClusterIngressList
 	return networkinglisters.NewClusterIngressLister(l.IndexerFor(&networking.ClusterIngress{}))
 }
 
+// GetIngressLister get lister for Ingress resource.
+func (l *Listers) GetIngressLister() networkinglisters.IngressLister {
+	return networkinglisters.NewIngressLister(l.IndexerFor(&networking.Ingress{}))
+}
+
 // GetCertificateLister get lister for Certificate resource.
 func (l *Listers) GetCertificateLister() networkinglisters.CertificateLister {
 	return networkinglisters.NewCertificateLister(l.IndexerFor(&networking.Certificate{}))
@@ -187,6 +192,10 @@ func (l *Listers) GetSecretLister() corev1listers.SecretLister {
 	return corev1listers.NewSecretLister(l.IndexerFor(&corev1.Secret{}))
 }
 
+func (l *Listers) GetNamespaceLister() corev1listers.NamespaceLister {
+	return corev1listers.NewNamespaceLister(l.IndexerFor(&corev1.Namespace{}))
+}
+
 func (l *Listers) GetConfigMapLister() corev1listers.ConfigMapLister {
 	return corev1listers.NewConfigMapLister(l.IndexerFor(&corev1.ConfigMap{}))
 }
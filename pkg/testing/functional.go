@@ -289,12 +289,12 @@ func WithWaitingContainer(name, reason, message string) PodOption {
 	}
 }
 
-// ClusterIngressOption enables further configuration of the Cluster Ingress.
-type ClusterIngressOption func(*netv1alpha1.ClusterIngress)
+// IngressOption enables further configuration of the Ingress.
+type IngressOption func(*netv1alpha1.Ingress)
 
 // WithHosts sets the Hosts of the ingress rule specified index
-func WithHosts(index int, hosts ...string) ClusterIngressOption {
-	return func(ingress *netv1alpha1.ClusterIngress) {
+func WithHosts(index int, hosts ...string) IngressOption {
+	return func(ingress *netv1alpha1.Ingress) {
 		ingress.Spec.Rules[index].Hosts = hosts
 	}
 }